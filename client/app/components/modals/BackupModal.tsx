@@ -159,7 +159,7 @@ export default function BackupModal() {
             <div className="text-xs text-[var(--color-fg-tertiary)] space-y-1">
                 <p>• Full Backup includes your settings, themes, and complete listening history.</p>
                 <p>• Settings and themes are restored immediately.</p>
-                <p>• <strong>Listening history will be imported upon next application restart.</strong></p>
+                <p>• <strong>Listening history is imported in the background as an import job.</strong></p>
                 <p>• Legacy export is provided for compatibility with older import tools.</p>
             </div>
 
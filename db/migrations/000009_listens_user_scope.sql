@@ -0,0 +1,20 @@
+-- +goose Up
+-- Listens are keyed per user so that two users scrobbling the same track
+-- in the same second no longer collide
+ALTER TABLE listens DROP CONSTRAINT IF EXISTS listens_pkey;
+ALTER TABLE listens ADD CONSTRAINT listens_pkey PRIMARY KEY (user_id, track_id, listened_at);
+
+CREATE INDEX IF NOT EXISTS listens_user_id_listened_at_idx ON listens USING btree (user_id, listened_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS listens_user_id_listened_at_idx;
+
+-- Keep only one listen per (track_id, listened_at) so the old key can be restored
+DELETE FROM listens a
+    USING listens b
+    WHERE a.track_id = b.track_id
+      AND a.listened_at = b.listened_at
+      AND a.user_id > b.user_id;
+
+ALTER TABLE listens DROP CONSTRAINT IF EXISTS listens_pkey;
+ALTER TABLE listens ADD CONSTRAINT listens_pkey PRIMARY KEY (track_id, listened_at);
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Outbox of raw listen submissions. Rows are deleted once the listen has been
+-- saved; submissions that keep failing are kept with status 'dead'. They are
+-- spread across the ingest workers by partition_key, the lowercased artist
+-- name.
+CREATE TABLE listen_submissions (
+    id bigserial NOT NULL,
+    user_id integer NOT NULL,
+    payload jsonb NOT NULL,
+    partition_key text NOT NULL DEFAULT '',
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT listen_submissions_pkey PRIMARY KEY (id),
+    CONSTRAINT listen_submissions_status_check CHECK (status IN ('pending', 'processing', 'dead')),
+    CONSTRAINT listen_submissions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX listen_submissions_status_next_attempt_at_idx ON listen_submissions USING btree (status, next_attempt_at);
+
+CREATE TRIGGER update_listen_submissions_updated_at
+    BEFORE UPDATE ON listen_submissions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS update_listen_submissions_updated_at ON listen_submissions;
+DROP TABLE IF EXISTS listen_submissions;
+-- +goose StatementEnd
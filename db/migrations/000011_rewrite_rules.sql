@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Per user rules that rewrite submitted listens before they are associated
+-- with artists, albums and tracks. Rules are applied in position order.
+CREATE TABLE rewrite_rules (
+    id serial NOT NULL,
+    user_id integer NOT NULL,
+    position integer NOT NULL DEFAULT 0,
+    enabled boolean NOT NULL DEFAULT true,
+    field text NOT NULL,
+    pattern text NOT NULL,
+    action text NOT NULL,
+    target text,
+    replacement text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT rewrite_rules_pkey PRIMARY KEY (id),
+    CONSTRAINT rewrite_rules_field_check CHECK (field IN ('artist', 'title', 'album', 'client')),
+    CONSTRAINT rewrite_rules_target_check CHECK (target IN ('artist', 'title', 'album', 'client')),
+    CONSTRAINT rewrite_rules_action_check CHECK (action IN ('replace', 'split', 'drop', 'remap')),
+    CONSTRAINT rewrite_rules_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX rewrite_rules_user_id_position_idx ON rewrite_rules USING btree (user_id, position);
+
+CREATE TRIGGER update_rewrite_rules_updated_at
+    BEFORE UPDATE ON rewrite_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS update_rewrite_rules_updated_at ON rewrite_rules;
+DROP TABLE IF EXISTS rewrite_rules;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Details about how a listen was played and submitted, as sent by the client
+ALTER TABLE listens ADD COLUMN IF NOT EXISTS duration_ms INT;
+ALTER TABLE listens ADD COLUMN IF NOT EXISTS media_player TEXT;
+ALTER TABLE listens ADD COLUMN IF NOT EXISTS submission_client_version TEXT;
+ALTER TABLE listens ADD COLUMN IF NOT EXISTS tags TEXT[];
+ALTER TABLE listens ADD COLUMN IF NOT EXISTS origin_url TEXT;
+
+-- +goose Down
+ALTER TABLE listens DROP COLUMN IF EXISTS duration_ms;
+ALTER TABLE listens DROP COLUMN IF EXISTS media_player;
+ALTER TABLE listens DROP COLUMN IF EXISTS submission_client_version;
+ALTER TABLE listens DROP COLUMN IF EXISTS tags;
+ALTER TABLE listens DROP COLUMN IF EXISTS origin_url;
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Per user rules deciding which submitted listens are saved. A user without a
+-- policy accepts every listen.
+CREATE TABLE listen_policies (
+    user_id integer NOT NULL,
+    min_played_fraction double precision NOT NULL DEFAULT 0,
+    min_played_seconds integer NOT NULL DEFAULT 0,
+    duplicate_window_seconds integer NOT NULL DEFAULT 0,
+    flag_overlaps boolean NOT NULL DEFAULT false,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT listen_policies_pkey PRIMARY KEY (user_id),
+    CONSTRAINT listen_policies_min_played_fraction_check CHECK (min_played_fraction >= 0 AND min_played_fraction <= 1),
+    CONSTRAINT listen_policies_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_listen_policies_updated_at
+    BEFORE UPDATE ON listen_policies
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Listens turned away by the policy, kept so they can be reviewed and saved
+-- anyway. The payload holds everything needed to save the listen.
+CREATE TABLE rejected_listens (
+    id bigserial NOT NULL,
+    user_id integer NOT NULL,
+    track_id integer NOT NULL,
+    listened_at timestamptz NOT NULL,
+    reason text NOT NULL,
+    payload jsonb NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT rejected_listens_pkey PRIMARY KEY (id),
+    CONSTRAINT rejected_listens_reason_check CHECK (reason IN ('too_short', 'duplicate')),
+    CONSTRAINT rejected_listens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT rejected_listens_track_id_fkey FOREIGN KEY (track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX rejected_listens_user_id_listened_at_idx ON rejected_listens USING btree (user_id, listened_at);
+
+-- Set on listens that start before the previous listen could have finished
+ALTER TABLE listens ADD COLUMN IF NOT EXISTS overlap boolean NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listens DROP COLUMN IF EXISTS overlap;
+DROP TABLE IF EXISTS rejected_listens;
+DROP TRIGGER IF EXISTS update_listen_policies_updated_at ON listen_policies;
+DROP TABLE IF EXISTS listen_policies;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- How each listen was associated with its artists, album and track. Rows
+-- follow their listen when it is moved to another track.
+CREATE TABLE listen_matches (
+    user_id integer NOT NULL,
+    track_id integer NOT NULL,
+    listened_at timestamptz NOT NULL,
+    artist_method text NOT NULL,
+    artist_confidence double precision NOT NULL,
+    album_method text NOT NULL,
+    album_confidence double precision NOT NULL,
+    track_method text NOT NULL,
+    track_confidence double precision NOT NULL,
+    -- the lowest of the three confidences
+    confidence double precision NOT NULL,
+    reviewed boolean NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT listen_matches_pkey PRIMARY KEY (user_id, track_id, listened_at),
+    CONSTRAINT listen_matches_listen_fkey FOREIGN KEY (user_id, track_id, listened_at)
+        REFERENCES listens(user_id, track_id, listened_at) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX listen_matches_inbox_idx ON listen_matches USING btree (user_id, confidence) WHERE NOT reviewed;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listen_matches;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Pairs of artists, albums or tracks that look like duplicates. A pair is kept
+-- once dismissed so it is not suggested again.
+CREATE TABLE merge_suggestions (
+    id bigserial PRIMARY KEY,
+    kind text NOT NULL CHECK (kind IN ('artist', 'album', 'track')),
+    -- the entity that would be merged away
+    from_id integer NOT NULL,
+    -- the entity that would be kept
+    to_id integer NOT NULL,
+    score double precision NOT NULL,
+    reasons text[] NOT NULL DEFAULT '{}',
+    dismissed boolean NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX merge_suggestions_pair_idx ON merge_suggestions USING btree (kind, LEAST(from_id, to_id), GREATEST(from_id, to_id));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS merge_suggestions;
+-- +goose StatementEnd
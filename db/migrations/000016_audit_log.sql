@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Merges and deletes of artists, albums, tracks and listens, album and artist
+-- changes of tracks and reassociations of listens. Each entry keeps the rows
+-- the operation could change, as they were before it, so that it can be
+-- undone.
+CREATE TABLE audit_log (
+    id bigserial PRIMARY KEY,
+    action text NOT NULL CHECK (action IN (
+        'merge_artists', 'merge_albums', 'merge_tracks',
+        'delete_artist', 'delete_album', 'delete_track', 'delete_listen',
+        'reassociate_listens', 'edit_track')),
+    -- only set for operations on one user's listens: listen deletes, track
+    -- splits and reassociations. The catalog is shared by all users.
+    user_id integer,
+    inputs jsonb NOT NULL DEFAULT '{}',
+    snapshot jsonb NOT NULL,
+    -- the columns undoing the entry overwrites, as the operation left them. An
+    -- entry is not undone once they were changed by something else.
+    after_state jsonb,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    undone_at timestamptz,
+    CONSTRAINT audit_log_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- The images of the artists and albums, the albums of the tracks and the
+-- primary artist links recorded in the snapshot, as they are now.
+CREATE FUNCTION audit_undo_targets(snapshot jsonb)
+RETURNS jsonb AS $$
+    SELECT jsonb_build_object(
+        'artists', (SELECT COALESCE(jsonb_agg(jsonb_build_array(x.id, x.image, x.image_source) ORDER BY x.id), '[]'::jsonb)
+            FROM artists x
+            WHERE x.id IN (SELECT s.id FROM jsonb_populate_recordset(NULL::artists, snapshot -> 'artists') s)),
+        'releases', (SELECT COALESCE(jsonb_agg(jsonb_build_array(x.id, x.image, x.image_source) ORDER BY x.id), '[]'::jsonb)
+            FROM releases x
+            WHERE x.id IN (SELECT s.id FROM jsonb_populate_recordset(NULL::releases, snapshot -> 'releases') s)),
+        'tracks', (SELECT COALESCE(jsonb_agg(jsonb_build_array(x.id, x.release_id) ORDER BY x.id), '[]'::jsonb)
+            FROM tracks x
+            WHERE x.id IN (SELECT s.id FROM jsonb_populate_recordset(NULL::tracks, snapshot -> 'tracks') s)),
+        'artist_releases', (SELECT COALESCE(jsonb_agg(jsonb_build_array(x.artist_id, x.release_id, x.is_primary) ORDER BY x.artist_id, x.release_id), '[]'::jsonb)
+            FROM artist_releases x
+            WHERE (x.artist_id, x.release_id) IN (
+                SELECT s.artist_id, s.release_id FROM jsonb_populate_recordset(NULL::artist_releases, snapshot -> 'artist_releases') s)),
+        'artist_tracks', (SELECT COALESCE(jsonb_agg(jsonb_build_array(x.artist_id, x.track_id, x.is_primary) ORDER BY x.artist_id, x.track_id), '[]'::jsonb)
+            FROM artist_tracks x
+            WHERE (x.artist_id, x.track_id) IN (
+                SELECT s.artist_id, s.track_id FROM jsonb_populate_recordset(NULL::artist_tracks, snapshot -> 'artist_tracks') s))
+    );
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION set_audit_log_after_state() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    UPDATE audit_log SET after_state = audit_undo_targets(snapshot)
+    WHERE id = NEW.id;
+    RETURN NULL;
+END;
+$$;
+
+-- deferred until the transaction commits, once the operation is done
+CREATE CONSTRAINT TRIGGER set_audit_log_after_state
+    AFTER INSERT ON audit_log
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+    EXECUTE FUNCTION set_audit_log_after_state();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS set_audit_log_after_state ON audit_log;
+DROP FUNCTION IF EXISTS set_audit_log_after_state();
+DROP FUNCTION IF EXISTS audit_undo_targets(jsonb);
+DROP TABLE IF EXISTS audit_log;
+-- +goose StatementEnd
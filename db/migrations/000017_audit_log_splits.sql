@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check CHECK (action IN (
+    'merge_artists', 'merge_albums', 'merge_tracks',
+    'split_artist', 'split_album', 'split_track',
+    'delete_artist', 'delete_album', 'delete_track', 'delete_listen',
+    'reassociate_listens', 'edit_track'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM audit_log WHERE action IN ('split_artist', 'split_album', 'split_track');
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check CHECK (action IN (
+    'merge_artists', 'merge_albums', 'merge_tracks',
+    'delete_artist', 'delete_album', 'delete_track', 'delete_listen',
+    'reassociate_listens', 'edit_track'));
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tracks that were looked up with a MusicBrainz search, so that the backfill
+-- does not search for the same tracks again. score is that of the best
+-- result, whether or not it was attached.
+CREATE TABLE mbz_search_attempts (
+    track_id integer PRIMARY KEY,
+    score real NOT NULL,
+    searched_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT mbz_search_attempts_track_id_fkey FOREIGN KEY (track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mbz_search_attempts;
+-- +goose StatementEnd
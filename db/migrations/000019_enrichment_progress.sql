@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Where each task of the enrichment worker is in its current sweep, so that
+-- it resumes after a restart. last_id is the id of the last entity handled;
+-- finished_at is null while the sweep is in progress.
+CREATE TABLE enrichment_progress (
+    task text PRIMARY KEY,
+    last_id integer NOT NULL DEFAULT 0,
+    processed integer NOT NULL DEFAULT 0,
+    enriched integer NOT NULL DEFAULT 0,
+    started_at timestamptz NOT NULL DEFAULT now(),
+    finished_at timestamptz,
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Artists, albums and tracks an enrichment task was tried on without filling
+-- anything in, so that sweeps leave them out until MusicBrainz may have been
+-- filled in since. entity_id is that of an artist, release or track,
+-- depending on the task.
+CREATE TABLE enrichment_attempts (
+    task text NOT NULL,
+    entity_id integer NOT NULL,
+    attempted_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT enrichment_attempts_pkey PRIMARY KEY (task, entity_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS enrichment_attempts;
+DROP TABLE IF EXISTS enrichment_progress;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Responses of MusicBrainz lookups, so that the same artists, releases and
+-- recordings are not requested again until they expire. body is null when
+-- MusicBrainz answered that the entity does not exist.
+CREATE TABLE mbz_cache (
+    kind text NOT NULL,
+    musicbrainz_id uuid NOT NULL,
+    body jsonb,
+    fetched_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL,
+    PRIMARY KEY (kind, musicbrainz_id)
+);
+CREATE INDEX mbz_cache_expires_at_idx ON mbz_cache (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mbz_cache;
+-- +goose StatementEnd
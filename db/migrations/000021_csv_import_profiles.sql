@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Per user mappings of the columns of CSV files to the fields of a listen,
+-- used by the CSV importer. Optional columns are empty when not mapped.
+CREATE TABLE csv_import_profiles (
+    id serial NOT NULL,
+    user_id integer NOT NULL,
+    name text NOT NULL,
+    delimiter text NOT NULL DEFAULT ',',
+    artist_column text NOT NULL,
+    title_column text NOT NULL,
+    album_column text NOT NULL DEFAULT '',
+    timestamp_column text NOT NULL,
+    timestamp_format text NOT NULL,
+    timezone text NOT NULL DEFAULT 'UTC',
+    duration_column text NOT NULL DEFAULT '',
+    duration_unit text NOT NULL DEFAULT 's',
+    mbid_column text NOT NULL DEFAULT '',
+    filter_column text NOT NULL DEFAULT '',
+    filter_value text NOT NULL DEFAULT '',
+    played_column text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT csv_import_profiles_pkey PRIMARY KEY (id),
+    CONSTRAINT csv_import_profiles_user_id_name_key UNIQUE (user_id, name),
+    CONSTRAINT csv_import_profiles_duration_unit_check CHECK (duration_unit IN ('s', 'ms')),
+    CONSTRAINT csv_import_profiles_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_csv_import_profiles_updated_at
+    BEFORE UPDATE ON csv_import_profiles
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS update_csv_import_profiles_updated_at ON csv_import_profiles;
+DROP TABLE IF EXISTS csv_import_profiles;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Imports of uploaded files, run in the background one at a time. item_offset
+-- is how many items of the file were read, saved as the job runs so that it
+-- resumes from there after a restart. errors holds why the latest items that
+-- failed did; error why the job itself failed.
+CREATE TABLE import_jobs (
+    id bigserial NOT NULL,
+    user_id integer NOT NULL,
+    format text NOT NULL,
+    filename text NOT NULL,
+    options jsonb NOT NULL DEFAULT '{}',
+    status text NOT NULL DEFAULT 'queued',
+    size bigint NOT NULL DEFAULT 0,
+    bytes_read bigint NOT NULL DEFAULT 0,
+    item_offset integer NOT NULL DEFAULT 0,
+    imported integer NOT NULL DEFAULT 0,
+    skipped integer NOT NULL DEFAULT 0,
+    failed integer NOT NULL DEFAULT 0,
+    errors text[] NOT NULL DEFAULT '{}',
+    error text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    started_at timestamptz,
+    finished_at timestamptz,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT import_jobs_pkey PRIMARY KEY (id),
+    CONSTRAINT import_jobs_status_check CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'canceled')),
+    CONSTRAINT import_jobs_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX import_jobs_user_id_idx ON import_jobs USING btree (user_id, id DESC);
+
+CREATE INDEX import_jobs_unfinished_idx ON import_jobs USING btree (id) WHERE status IN ('queued', 'running');
+
+CREATE TRIGGER update_import_jobs_updated_at
+    BEFORE UPDATE ON import_jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS update_import_jobs_updated_at ON import_jobs;
+DROP TABLE IF EXISTS import_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,182 @@
+-- name: GetCatalogSnapshot :one
+WITH involved_releases AS (
+    SELECT r.id FROM releases r
+    WHERE r.id = ANY(sqlc.arg(release_ids)::int[])
+       OR r.id IN (SELECT ar.release_id FROM artist_releases ar WHERE ar.artist_id = ANY(sqlc.arg(artist_ids)::int[]))
+       OR r.id IN (SELECT t.release_id FROM tracks t WHERE t.id = ANY(sqlc.arg(track_ids)::int[]))
+), involved_tracks AS (
+    SELECT t.id FROM tracks t
+    WHERE t.id = ANY(sqlc.arg(track_ids)::int[])
+       OR t.release_id IN (SELECT id FROM involved_releases)
+       OR t.id IN (SELECT at.track_id FROM artist_tracks at WHERE at.artist_id = ANY(sqlc.arg(artist_ids)::int[]))
+), involved_artists AS (
+    SELECT a.id FROM artists a
+    WHERE a.id = ANY(sqlc.arg(artist_ids)::int[])
+       OR a.id IN (SELECT at.artist_id FROM artist_tracks at WHERE at.track_id IN (SELECT id FROM involved_tracks))
+       OR a.id IN (SELECT ar.artist_id FROM artist_releases ar WHERE ar.release_id IN (SELECT id FROM involved_releases))
+)
+SELECT jsonb_build_object(
+    'artists', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM artists x
+        WHERE x.id IN (SELECT id FROM involved_artists)),
+    'artist_aliases', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM artist_aliases x
+        WHERE x.artist_id IN (SELECT id FROM involved_artists)),
+    'releases', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM releases x
+        WHERE x.id IN (SELECT id FROM involved_releases)),
+    'release_aliases', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM release_aliases x
+        WHERE x.release_id IN (SELECT id FROM involved_releases)),
+    'tracks', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM tracks x
+        WHERE x.id IN (SELECT id FROM involved_tracks)),
+    'track_aliases', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM track_aliases x
+        WHERE x.track_id IN (SELECT id FROM involved_tracks)),
+    'artist_releases', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM artist_releases x
+        WHERE x.release_id IN (SELECT id FROM involved_releases)),
+    'artist_tracks', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM artist_tracks x
+        WHERE x.track_id IN (SELECT id FROM involved_tracks)),
+    'listens', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM listens x
+        WHERE x.track_id IN (SELECT id FROM involved_tracks)),
+    'listen_matches', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM listen_matches x
+        WHERE x.track_id IN (SELECT id FROM involved_tracks))
+)::jsonb AS snapshot;
+
+-- name: GetListenSnapshot :one
+SELECT jsonb_build_object(
+    'listens', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM listens x
+        WHERE x.user_id = $1 AND x.track_id = $2 AND x.listened_at = $3),
+    'listen_matches', (SELECT COALESCE(jsonb_agg(to_jsonb(x)), '[]'::jsonb) FROM listen_matches x
+        WHERE x.user_id = $1 AND x.track_id = $2 AND x.listened_at = $3)
+)::jsonb AS snapshot;
+
+-- name: InsertAuditEntry :exec
+INSERT INTO audit_log (action, user_id, inputs, snapshot)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetAuditLog :many
+SELECT
+    a.id,
+    a.action,
+    a.user_id,
+    a.inputs,
+    a.created_at,
+    a.undone_at,
+    COALESCE(jsonb_array_length(a.snapshot -> 'artists'), 0)::int AS artists,
+    COALESCE(jsonb_array_length(a.snapshot -> 'releases'), 0)::int AS albums,
+    COALESCE(jsonb_array_length(a.snapshot -> 'tracks'), 0)::int AS tracks,
+    COALESCE(jsonb_array_length(a.snapshot -> 'listens'), 0)::int AS listens,
+    (COALESCE(jsonb_array_length(a.snapshot -> 'artist_aliases'), 0)
+        + COALESCE(jsonb_array_length(a.snapshot -> 'release_aliases'), 0)
+        + COALESCE(jsonb_array_length(a.snapshot -> 'track_aliases'), 0))::int AS aliases,
+    (COALESCE(jsonb_array_length(a.snapshot -> 'artist_releases'), 0)
+        + COALESCE(jsonb_array_length(a.snapshot -> 'artist_tracks'), 0))::int AS artist_links,
+    (a.undone_at IS NULL AND NOT EXISTS (
+        SELECT 1 FROM audit_log b
+        WHERE b.id > a.id AND b.undone_at IS NULL AND (b.user_id IS NULL OR b.user_id = $1)
+    ))::boolean AS undoable
+FROM audit_log a
+WHERE a.user_id IS NULL OR a.user_id = $1
+ORDER BY a.id DESC
+LIMIT $2;
+
+-- name: GetAuditEntryForUpdate :one
+SELECT id, action, user_id, inputs, snapshot, after_state, created_at, undone_at
+FROM audit_log
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetLatestAuditEntryID :one
+SELECT COALESCE(MAX(id), 0)::bigint
+FROM audit_log
+WHERE undone_at IS NULL AND (user_id IS NULL OR user_id = $1);
+
+-- name: AuditEntryChangedSince :one
+SELECT (after_state IS NOT NULL AND after_state <> audit_undo_targets(snapshot))::boolean AS changed
+FROM audit_log
+WHERE id = $1;
+
+-- name: MarkAuditEntryUndone :exec
+UPDATE audit_log SET undone_at = NOW()
+WHERE id = $1;
+
+-- name: RestoreReleases :exec
+INSERT INTO releases OVERRIDING SYSTEM VALUE
+SELECT * FROM jsonb_populate_recordset(NULL::releases, sqlc.arg(snapshot)::jsonb -> 'releases')
+ON CONFLICT (id) DO UPDATE
+SET image = EXCLUDED.image, image_source = EXCLUDED.image_source;
+
+-- name: RestoreArtists :exec
+INSERT INTO artists OVERRIDING SYSTEM VALUE
+SELECT * FROM jsonb_populate_recordset(NULL::artists, sqlc.arg(snapshot)::jsonb -> 'artists')
+ON CONFLICT (id) DO UPDATE
+SET image = EXCLUDED.image, image_source = EXCLUDED.image_source;
+
+-- name: RestoreTracks :exec
+INSERT INTO tracks OVERRIDING SYSTEM VALUE
+SELECT * FROM jsonb_populate_recordset(NULL::tracks, sqlc.arg(snapshot)::jsonb -> 'tracks')
+ON CONFLICT (id) DO UPDATE
+SET release_id = EXCLUDED.release_id;
+
+-- name: RestoreArtistAliases :exec
+INSERT INTO artist_aliases
+SELECT * FROM jsonb_populate_recordset(NULL::artist_aliases, sqlc.arg(snapshot)::jsonb -> 'artist_aliases')
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreReleaseAliases :exec
+INSERT INTO release_aliases
+SELECT * FROM jsonb_populate_recordset(NULL::release_aliases, sqlc.arg(snapshot)::jsonb -> 'release_aliases')
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreTrackAliases :exec
+INSERT INTO track_aliases
+SELECT * FROM jsonb_populate_recordset(NULL::track_aliases, sqlc.arg(snapshot)::jsonb -> 'track_aliases')
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreArtistReleases :exec
+INSERT INTO artist_releases
+SELECT * FROM jsonb_populate_recordset(NULL::artist_releases, sqlc.arg(snapshot)::jsonb -> 'artist_releases')
+ON CONFLICT (artist_id, release_id) DO UPDATE
+SET is_primary = EXCLUDED.is_primary;
+
+-- name: RestoreArtistTracks :exec
+INSERT INTO artist_tracks
+SELECT * FROM jsonb_populate_recordset(NULL::artist_tracks, sqlc.arg(snapshot)::jsonb -> 'artist_tracks')
+ON CONFLICT (artist_id, track_id) DO UPDATE
+SET is_primary = EXCLUDED.is_primary;
+
+-- name: DeleteUnrecordedArtistReleases :exec
+DELETE FROM artist_releases ar
+WHERE ar.artist_id IN (SELECT x.id FROM jsonb_populate_recordset(NULL::artists, sqlc.arg(snapshot)::jsonb -> 'artists') x)
+  AND ar.release_id IN (SELECT x.id FROM jsonb_populate_recordset(NULL::releases, sqlc.arg(snapshot)::jsonb -> 'releases') x)
+  AND NOT EXISTS (
+    SELECT 1 FROM jsonb_populate_recordset(NULL::artist_releases, sqlc.arg(snapshot)::jsonb -> 'artist_releases') s
+    WHERE s.artist_id = ar.artist_id AND s.release_id = ar.release_id
+  );
+
+-- name: DeleteUnrecordedArtistTracks :exec
+DELETE FROM artist_tracks at
+WHERE at.artist_id IN (SELECT x.id FROM jsonb_populate_recordset(NULL::artists, sqlc.arg(snapshot)::jsonb -> 'artists') x)
+  AND at.track_id IN (SELECT x.id FROM jsonb_populate_recordset(NULL::tracks, sqlc.arg(snapshot)::jsonb -> 'tracks') x)
+  AND NOT EXISTS (
+    SELECT 1 FROM jsonb_populate_recordset(NULL::artist_tracks, sqlc.arg(snapshot)::jsonb -> 'artist_tracks') s
+    WHERE s.artist_id = at.artist_id AND s.track_id = at.track_id
+  );
+
+-- name: DeleteMovedListens :exec
+DELETE FROM listens l
+USING jsonb_populate_recordset(NULL::listens, sqlc.arg(snapshot)::jsonb -> 'listens') s
+WHERE l.user_id = s.user_id
+  AND l.listened_at = s.listened_at
+  AND l.track_id <> s.track_id
+  AND l.track_id IN (SELECT x.id FROM jsonb_populate_recordset(NULL::tracks, sqlc.arg(snapshot)::jsonb -> 'tracks') x)
+  AND NOT EXISTS (
+    SELECT 1 FROM jsonb_populate_recordset(NULL::listens, sqlc.arg(snapshot)::jsonb -> 'listens') k
+    WHERE k.user_id = l.user_id AND k.track_id = l.track_id AND k.listened_at = l.listened_at
+  );
+
+-- name: RestoreListens :exec
+INSERT INTO listens
+SELECT * FROM jsonb_populate_recordset(NULL::listens, sqlc.arg(snapshot)::jsonb -> 'listens')
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreListenMatches :exec
+INSERT INTO listen_matches
+SELECT * FROM jsonb_populate_recordset(NULL::listen_matches, sqlc.arg(snapshot)::jsonb -> 'listen_matches')
+ON CONFLICT DO NOTHING;
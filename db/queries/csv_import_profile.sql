@@ -0,0 +1,36 @@
+-- name: GetCsvImportProfile :one
+SELECT * FROM csv_import_profiles
+WHERE user_id = $1 AND name = $2
+LIMIT 1;
+
+-- name: GetCsvImportProfilesByUserID :many
+SELECT * FROM csv_import_profiles
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: SaveCsvImportProfile :one
+INSERT INTO csv_import_profiles (
+    user_id, name, delimiter, artist_column, title_column, album_column, timestamp_column,
+    timestamp_format, timezone, duration_column, duration_unit, mbid_column, filter_column, filter_value,
+    played_column
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+ON CONFLICT (user_id, name) DO UPDATE
+SET delimiter = EXCLUDED.delimiter,
+    artist_column = EXCLUDED.artist_column,
+    title_column = EXCLUDED.title_column,
+    album_column = EXCLUDED.album_column,
+    timestamp_column = EXCLUDED.timestamp_column,
+    timestamp_format = EXCLUDED.timestamp_format,
+    timezone = EXCLUDED.timezone,
+    duration_column = EXCLUDED.duration_column,
+    duration_unit = EXCLUDED.duration_unit,
+    mbid_column = EXCLUDED.mbid_column,
+    filter_column = EXCLUDED.filter_column,
+    filter_value = EXCLUDED.filter_value,
+    played_column = EXCLUDED.played_column
+RETURNING *;
+
+-- name: DeleteCsvImportProfile :execrows
+DELETE FROM csv_import_profiles
+WHERE user_id = $1 AND name = $2;
@@ -0,0 +1,53 @@
+-- name: UpdateTrackRelease :exec
+UPDATE tracks SET release_id = $2
+WHERE id = $1;
+
+-- name: GetTrackArtistIDs :many
+SELECT artist_id FROM artist_tracks
+WHERE track_id = $1
+ORDER BY artist_id;
+
+-- name: CountArtistsByIDs :one
+SELECT COUNT(*) FROM artists
+WHERE id = ANY(sqlc.arg(artist_ids)::int[]);
+
+-- name: AddArtistsToTrack :exec
+INSERT INTO artist_tracks (artist_id, track_id, is_primary)
+SELECT a.id, sqlc.arg(track_id)::int, false
+FROM artists a
+WHERE a.id = ANY(sqlc.arg(artist_ids)::int[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteTrackArtistsExcept :exec
+DELETE FROM artist_tracks
+WHERE track_id = sqlc.arg(track_id)
+  AND NOT (artist_id = ANY(sqlc.arg(artist_ids)::int[]));
+
+-- name: EnsureTrackPrimaryArtist :exec
+UPDATE artist_tracks SET is_primary = true
+WHERE track_id = sqlc.arg(track_id)
+  AND artist_id = sqlc.arg(artist_id)
+  AND NOT EXISTS (
+    SELECT 1 FROM artist_tracks o
+    WHERE o.track_id = sqlc.arg(track_id) AND o.is_primary
+  );
+
+-- name: DeleteUncreditedReleaseArtists :exec
+WITH gone AS (
+    SELECT ar.artist_id FROM artist_releases ar
+    WHERE ar.release_id = sqlc.arg(release_id)
+      AND ar.artist_id = ANY(sqlc.arg(artist_ids)::int[])
+      AND NOT EXISTS (
+        SELECT 1 FROM artist_tracks at
+        JOIN tracks t ON t.id = at.track_id
+        WHERE at.artist_id = ar.artist_id AND t.release_id = ar.release_id
+      )
+)
+DELETE FROM artist_releases ar
+WHERE ar.release_id = sqlc.arg(release_id)
+  AND ar.artist_id IN (SELECT artist_id FROM gone)
+  -- the album is deleted along with its last artist
+  AND EXISTS (
+    SELECT 1 FROM artist_releases o
+    WHERE o.release_id = sqlc.arg(release_id) AND o.artist_id NOT IN (SELECT artist_id FROM gone)
+  );
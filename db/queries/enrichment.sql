@@ -0,0 +1,79 @@
+-- name: GetEnrichmentProgress :many
+SELECT * FROM enrichment_progress
+ORDER BY task;
+
+-- name: SaveEnrichmentProgress :exec
+INSERT INTO enrichment_progress (task, last_id, processed, enriched, started_at, finished_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+ON CONFLICT (task) DO UPDATE SET
+  last_id = EXCLUDED.last_id,
+  processed = EXCLUDED.processed,
+  enriched = EXCLUDED.enriched,
+  started_at = EXCLUDED.started_at,
+  finished_at = EXCLUDED.finished_at,
+  updated_at = EXCLUDED.updated_at;
+
+-- name: SaveEnrichmentAttempt :exec
+INSERT INTO enrichment_attempts (task, entity_id)
+VALUES ($1, $2)
+ON CONFLICT (task, entity_id) DO UPDATE SET attempted_at = now();
+
+-- name: GetTracksWithoutDuration :many
+SELECT t.id FROM tracks t
+WHERE t.duration = 0
+  AND t.id > $1
+  AND (t.musicbrainz_id IS NOT NULL
+    OR NOT EXISTS (SELECT 1 FROM mbz_search_attempts s WHERE s.track_id = t.id))
+  AND NOT EXISTS (
+    SELECT 1 FROM enrichment_attempts e
+    WHERE e.task = 'track_durations' AND e.entity_id = t.id AND e.attempted_at > $3)
+ORDER BY t.id
+LIMIT $2;
+
+-- name: GetArtistsWithoutMbzID :many
+SELECT
+  a.id,
+  COALESCE((
+    SELECT t.id FROM artist_tracks at
+    JOIN tracks t ON t.id = at.track_id
+    WHERE at.artist_id = a.id
+      AND (t.musicbrainz_id IS NOT NULL
+        OR NOT EXISTS (SELECT 1 FROM mbz_search_attempts s WHERE s.track_id = t.id))
+    ORDER BY t.musicbrainz_id IS NULL, t.id
+    LIMIT 1
+  ), 0)::int AS track_id
+FROM artists a
+WHERE a.musicbrainz_id IS NULL
+  AND a.id > $1
+ORDER BY a.id
+LIMIT $2;
+
+-- name: GetArtistsWithoutGenres :many
+SELECT a.id, a.musicbrainz_id, a.name FROM artists_with_name a
+WHERE a.musicbrainz_id IS NOT NULL
+  AND (a.genres IS NULL OR cardinality(a.genres) = 0)
+  AND a.id > $1
+  AND NOT EXISTS (
+    SELECT 1 FROM enrichment_attempts e
+    WHERE e.task = 'artist_genres' AND e.entity_id = a.id AND e.attempted_at > $3)
+ORDER BY a.id
+LIMIT $2;
+
+-- name: GetReleasesWithoutGenres :many
+SELECT r.id, r.musicbrainz_id, r.title FROM releases_with_title r
+WHERE r.musicbrainz_id IS NOT NULL
+  AND (r.genres IS NULL OR cardinality(r.genres) = 0)
+  AND r.id > $1
+  AND NOT EXISTS (
+    SELECT 1 FROM enrichment_attempts e
+    WHERE e.task = 'album_genres' AND e.entity_id = r.id AND e.attempted_at > $3)
+ORDER BY r.id
+LIMIT $2;
+
+-- name: UpdateArtistGenres :exec
+UPDATE artists SET genres = $2
+WHERE id = $1;
+
+-- name: UpdateReleaseGenres :exec
+UPDATE releases SET genres = $2
+WHERE id = $1;
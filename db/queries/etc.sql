@@ -1,7 +1,8 @@
 -- name: CleanOrphanedEntries :exec
 DO $$
 BEGIN
-  DELETE FROM tracks WHERE id NOT IN (SELECT l.track_id FROM listens l);
+  DELETE FROM tracks WHERE id NOT IN (SELECT l.track_id FROM listens l)
+    AND id NOT IN (SELECT r.track_id FROM rejected_listens r);
   DELETE FROM releases WHERE id NOT IN (SELECT t.release_id FROM tracks t);
 --   DELETE FROM releases WHERE release_group_id NOT IN (SELECT t.release_group_id FROM tracks t);
 --   DELETE FROM releases WHERE release_group_id NOT IN (SELECT rg.id FROM release_groups rg);
@@ -0,0 +1,47 @@
+-- name: CreateImportJob :one
+INSERT INTO import_jobs (user_id, format, filename, options, size)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetImportJob :one
+SELECT * FROM import_jobs
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetImportJobsByUserID :many
+SELECT * FROM import_jobs
+WHERE user_id = $1
+ORDER BY id DESC
+LIMIT $2;
+
+-- name: GetNextImportJob :one
+SELECT * FROM import_jobs
+WHERE status IN ('queued', 'running')
+ORDER BY status = 'running' DESC, id
+LIMIT 1;
+
+-- name: StartImportJob :execrows
+UPDATE import_jobs
+SET status = 'running',
+    started_at = COALESCE(started_at, now())
+WHERE id = $1 AND status IN ('queued', 'running');
+
+-- name: SaveImportJobProgress :one
+UPDATE import_jobs
+SET bytes_read = $2,
+    item_offset = $3,
+    imported = $4,
+    skipped = $5,
+    failed = $6,
+    errors = $7,
+    error = $8,
+    status = CASE WHEN status = 'running' THEN sqlc.arg(status)::text ELSE status END,
+    finished_at = CASE WHEN status = 'running' THEN sqlc.narg(finished_at)::timestamptz ELSE finished_at END
+WHERE id = $1
+RETURNING status;
+
+-- name: CancelImportJob :execrows
+UPDATE import_jobs
+SET status = 'canceled',
+    finished_at = now()
+WHERE id = $1 AND user_id = $2 AND status IN ('queued', 'running');
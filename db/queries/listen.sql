@@ -1,6 +1,6 @@
 -- name: InsertListen :exec
-INSERT INTO listens (track_id, listened_at, user_id, client)
-VALUES ($1, $2, $3, $4)
+INSERT INTO listens (track_id, listened_at, user_id, client, duration_ms, media_player, submission_client_version, tags, origin_url, overlap)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 ON CONFLICT DO NOTHING;
 
 -- name: GetLastListensPaginated :many
@@ -15,6 +15,7 @@ FROM listens l
 JOIN tracks_with_title t ON l.track_id = t.id
 JOIN releases_with_title r ON t.release_id = r.id
 WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $5
 ORDER BY l.listened_at DESC
 LIMIT $3 OFFSET $4;
 
@@ -32,6 +33,7 @@ JOIN releases_with_title r ON t.release_id = r.id
 JOIN artist_tracks at ON t.id = at.track_id 
 WHERE at.artist_id = $5
   AND l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $6
 ORDER BY l.listened_at DESC
 LIMIT $3 OFFSET $4;
 
@@ -42,6 +44,7 @@ FROM listens l
 JOIN tracks_with_title t ON l.track_id = t.id
 JOIN artist_tracks at ON t.id = at.track_id 
 WHERE at.artist_id = $1
+  AND l.user_id = $2
 ORDER BY l.listened_at ASC
 LIMIT 1;
 
@@ -58,6 +61,7 @@ JOIN tracks_with_title t ON l.track_id = t.id
 JOIN releases_with_title r ON t.release_id = r.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND t.release_id = $5
+  AND l.user_id = $6
 ORDER BY l.listened_at DESC
 LIMIT $3 OFFSET $4;
 
@@ -67,6 +71,7 @@ SELECT
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE t.release_id = $1
+  AND l.user_id = $2
 ORDER BY l.listened_at ASC
 LIMIT 1;
 
@@ -83,6 +88,7 @@ JOIN tracks_with_title t ON l.track_id = t.id
 JOIN releases_with_title r ON t.release_id = r.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND t.id = $5
+  AND l.user_id = $6
 ORDER BY l.listened_at DESC
 LIMIT $3 OFFSET $4;
 
@@ -92,39 +98,45 @@ SELECT
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE t.id = $1
+  AND l.user_id = $2
 ORDER BY l.listened_at ASC
 LIMIT 1;
 
 -- name: CountListens :one
 SELECT COUNT(*) AS total_count
 FROM listens l
-WHERE l.listened_at BETWEEN $1 AND $2;
+WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $3;
 
 -- name: CountListensFromTrack :one
 SELECT COUNT(*) AS total_count
 FROM listens l
 WHERE l.listened_at BETWEEN $1 AND $2
-  AND l.track_id = $3;
+  AND l.track_id = $3
+  AND l.user_id = $4;
 
 -- name: CountListensFromArtist :one
 SELECT COUNT(*) AS total_count
 FROM listens l
 JOIN artist_tracks at ON l.track_id = at.track_id
 WHERE l.listened_at BETWEEN $1 AND $2
-  AND at.artist_id = $3;
+  AND at.artist_id = $3
+  AND l.user_id = $4;
 
 -- name: CountListensFromRelease :one
 SELECT COUNT(*) AS total_count
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
-  AND t.release_id = $3;
+  AND t.release_id = $3
+  AND l.user_id = $4;
 
 -- name: CountTimeListened :one
 SELECT COALESCE(SUM(t.duration), 0)::BIGINT AS seconds_listened
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
-WHERE l.listened_at BETWEEN $1 AND $2;
+WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $3;
 
 -- name: CountTimeListenedToArtist :one
 SELECT COALESCE(SUM(t.duration), 0)::BIGINT AS seconds_listened
@@ -132,21 +144,24 @@ FROM listens l
 JOIN tracks t ON l.track_id = t.id
 JOIN artist_tracks at ON t.id = at.track_id
 WHERE l.listened_at BETWEEN $1 AND $2
-  AND at.artist_id = $3;
+  AND at.artist_id = $3
+  AND l.user_id = $4;
 
 -- name: CountTimeListenedToRelease :one
 SELECT COALESCE(SUM(t.duration), 0)::BIGINT AS seconds_listened
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
-  AND t.release_id = $3;
+  AND t.release_id = $3
+  AND l.user_id = $4;
 
 -- name: CountTimeListenedToTrack :one
 SELECT COALESCE(SUM(t.duration), 0)::BIGINT AS seconds_listened
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
-  AND t.id = $3;
+  AND t.id = $3
+  AND l.user_id = $4;
 
 -- name: ListenActivity :many
 WITH buckets AS (
@@ -160,6 +175,7 @@ bucketed_listens AS (
   LEFT JOIN listens l
     ON l.listened_at >= b.bucket_start
     AND l.listened_at < b.bucket_start + $3::interval
+    AND l.user_id = $4
   GROUP BY b.bucket_start
   ORDER BY b.bucket_start
 )
@@ -174,6 +190,7 @@ filtered_listens AS (
   FROM listens l
   JOIN artist_tracks t ON l.track_id = t.track_id
   WHERE t.artist_id = $4
+    AND l.user_id = $5
 ),
 bucketed_listens AS (
   SELECT
@@ -197,6 +214,7 @@ filtered_listens AS (
   FROM listens l
   JOIN tracks t ON l.track_id = t.id
   WHERE t.release_id = $4
+    AND l.user_id = $5
 ),
 bucketed_listens AS (
   SELECT
@@ -220,6 +238,7 @@ filtered_listens AS (
   FROM listens l
   JOIN tracks t ON l.track_id = t.id
   WHERE t.id = $4
+    AND l.user_id = $5
 ),
 bucketed_listens AS (
   SELECT
@@ -239,13 +258,18 @@ UPDATE listens SET track_id = $2
 WHERE track_id = $1;
 
 -- name: DeleteListen :exec
-DELETE FROM listens WHERE track_id = $1 AND listened_at = $2;
+DELETE FROM listens WHERE track_id = $1 AND listened_at = $2 AND user_id = $3;
 
 -- name: GetListensExportPage :many
 SELECT
     l.listened_at,
     l.user_id,
     l.client,
+    l.duration_ms,
+    l.media_player,
+    l.submission_client_version,
+    l.tags,
+    l.origin_url,
 
     -- Track info
     t.id AS track_id,
@@ -307,3 +331,45 @@ WHERE l.user_id = @user_id::int
   AND (l.listened_at, l.track_id) > (@listened_at::timestamptz, @track_id::int)
 ORDER BY l.listened_at, l.track_id
 LIMIT $1;
+
+-- name: GetTrackListenCountsForReassociation :many
+SELECT l.track_id, COUNT(*) AS listen_count
+FROM listens l
+WHERE l.user_id = sqlc.arg(user_id)
+  AND l.listened_at BETWEEN sqlc.arg(period_start)::timestamptz AND sqlc.arg(period_end)::timestamptz
+  AND (sqlc.arg(client)::text = '' OR l.client = sqlc.arg(client)::text)
+  AND (sqlc.arg(artist_id)::int = 0 OR EXISTS (
+    SELECT 1 FROM artist_tracks at
+    WHERE at.track_id = l.track_id AND at.artist_id = sqlc.arg(artist_id)::int
+  ))
+GROUP BY l.track_id
+ORDER BY l.track_id;
+
+-- name: UpdateTrackIdForFilteredListens :execrows
+UPDATE listens l SET track_id = sqlc.arg(new_track_id)::int
+WHERE l.track_id = sqlc.arg(track_id)::int
+  AND l.user_id = sqlc.arg(user_id)
+  AND l.listened_at BETWEEN sqlc.arg(period_start)::timestamptz AND sqlc.arg(period_end)::timestamptz
+  AND (sqlc.arg(client)::text = '' OR l.client = sqlc.arg(client)::text)
+  AND NOT EXISTS (
+    SELECT 1 FROM listens d
+    WHERE d.user_id = l.user_id AND d.track_id = sqlc.arg(new_track_id)::int AND d.listened_at = l.listened_at
+  );
+
+-- name: ListenOfTrackExistsInRange :one
+SELECT EXISTS (
+  SELECT 1 FROM listens
+  WHERE user_id = @user_id::int
+    AND track_id = @track_id::int
+    AND listened_at BETWEEN @period_start::timestamptz AND @period_end::timestamptz
+    AND listened_at <> @listened_at::timestamptz
+);
+
+-- name: GetListenBefore :one
+SELECT l.listened_at, t.duration
+FROM listens l
+JOIN tracks t ON l.track_id = t.id
+WHERE l.user_id = $1
+  AND l.listened_at < $2
+ORDER BY l.listened_at DESC
+LIMIT 1;
@@ -0,0 +1,44 @@
+-- name: InsertListenMatch :exec
+INSERT INTO listen_matches (
+  user_id, track_id, listened_at,
+  artist_method, artist_confidence,
+  album_method, album_confidence,
+  track_method, track_confidence,
+  confidence
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT DO NOTHING;
+
+-- name: GetListenMatch :one
+SELECT * FROM listen_matches
+WHERE user_id = $1 AND track_id = $2 AND listened_at = $3
+LIMIT 1;
+
+-- name: GetListenMatchInboxPaginated :many
+SELECT
+  m.*,
+  l.client,
+  t.title AS track_title,
+  t.release_id AS release_id,
+  r.image AS release_image,
+  r.title AS release_title,
+  get_artists_for_track(t.id) AS artists
+FROM listen_matches m
+JOIN listens l ON l.user_id = m.user_id AND l.track_id = m.track_id AND l.listened_at = m.listened_at
+JOIN tracks_with_title t ON m.track_id = t.id
+JOIN releases_with_title r ON t.release_id = r.id
+WHERE m.user_id = $1
+  AND NOT m.reviewed
+  AND m.confidence < $2
+ORDER BY m.listened_at DESC
+LIMIT $3 OFFSET $4;
+
+-- name: CountListenMatchInbox :one
+SELECT COUNT(*) FROM listen_matches
+WHERE user_id = $1
+  AND NOT reviewed
+  AND confidence < $2;
+
+-- name: MarkListenMatchReviewed :execrows
+UPDATE listen_matches SET reviewed = true
+WHERE user_id = $1 AND track_id = $2 AND listened_at = $3;
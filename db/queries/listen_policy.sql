@@ -0,0 +1,57 @@
+-- name: GetListenPolicy :one
+SELECT * FROM listen_policies
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: UpsertListenPolicy :exec
+INSERT INTO listen_policies (user_id, min_played_fraction, min_played_seconds, duplicate_window_seconds, flag_overlaps)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE
+SET min_played_fraction = EXCLUDED.min_played_fraction,
+    min_played_seconds = EXCLUDED.min_played_seconds,
+    duplicate_window_seconds = EXCLUDED.duplicate_window_seconds,
+    flag_overlaps = EXCLUDED.flag_overlaps;
+
+-- name: InsertRejectedListen :exec
+INSERT INTO rejected_listens (user_id, track_id, listened_at, reason, payload)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetRejectedListen :one
+SELECT * FROM rejected_listens
+WHERE id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: GetRejectedListensPaginated :many
+SELECT
+  r.id,
+  r.track_id,
+  r.listened_at,
+  r.reason,
+  r.payload,
+  r.created_at,
+  t.title AS track_title,
+  get_artists_for_track(t.id) AS artists
+FROM rejected_listens r
+JOIN tracks_with_title t ON r.track_id = t.id
+WHERE r.user_id = $1
+ORDER BY r.listened_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountRejectedListens :one
+SELECT COUNT(*) FROM rejected_listens
+WHERE user_id = $1;
+
+-- name: DeleteRejectedListen :execrows
+DELETE FROM rejected_listens
+WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateTrackIdForRejectedListens :exec
+UPDATE rejected_listens SET track_id = $2
+WHERE track_id = $1;
+
+-- name: UpdateTrackIdForFilteredRejectedListens :exec
+UPDATE rejected_listens r SET track_id = sqlc.arg(new_track_id)::int
+WHERE r.track_id = sqlc.arg(track_id)::int
+  AND r.user_id = sqlc.arg(user_id)
+  AND r.listened_at BETWEEN sqlc.arg(period_start)::timestamptz AND sqlc.arg(period_end)::timestamptz
+  AND (sqlc.arg(client)::text = '' OR r.payload->>'Client' = sqlc.arg(client)::text);
@@ -0,0 +1,51 @@
+-- name: InsertListenSubmission :one
+INSERT INTO listen_submissions (user_id, payload, partition_key)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ClaimListenSubmission :one
+UPDATE listen_submissions
+SET status = 'processing', attempts = attempts + 1
+WHERE id = (
+    SELECT s.id FROM listen_submissions s
+    WHERE s.status = 'pending'
+      AND s.next_attempt_at <= now()
+      AND (hashtext(s.partition_key) & 2147483647) % sqlc.arg(workers)::int = sqlc.arg(worker)::int
+    ORDER BY s.next_attempt_at, s.id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: DeleteListenSubmission :exec
+DELETE FROM listen_submissions
+WHERE id = $1;
+
+-- name: FailListenSubmission :exec
+UPDATE listen_submissions
+SET status = $2, last_error = $3, next_attempt_at = $4
+WHERE id = $1;
+
+-- name: ResetProcessingListenSubmissions :execrows
+UPDATE listen_submissions
+SET status = 'pending'
+WHERE status = 'processing';
+
+-- name: GetDeadListenSubmissionsPaginated :many
+SELECT * FROM listen_submissions
+WHERE status = 'dead' AND user_id = $1
+ORDER BY updated_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountDeadListenSubmissions :one
+SELECT COUNT(*) FROM listen_submissions
+WHERE status = 'dead' AND user_id = $1;
+
+-- name: RequeueDeadListenSubmission :execrows
+UPDATE listen_submissions
+SET status = 'pending', attempts = 0, last_error = NULL, next_attempt_at = now()
+WHERE id = $1 AND user_id = $2 AND status = 'dead';
+
+-- name: DeleteDeadListenSubmission :execrows
+DELETE FROM listen_submissions
+WHERE id = $1 AND user_id = $2 AND status = 'dead';
@@ -0,0 +1,16 @@
+-- name: GetMbzCacheEntry :one
+SELECT * FROM mbz_cache
+WHERE kind = $1 AND musicbrainz_id = $2 AND expires_at > now();
+
+-- name: SaveMbzCacheEntry :exec
+INSERT INTO mbz_cache (kind, musicbrainz_id, body, fetched_at, expires_at)
+VALUES ($1, $2, $3, now(), $4)
+ON CONFLICT (kind, musicbrainz_id) DO UPDATE SET
+  body = EXCLUDED.body,
+  fetched_at = EXCLUDED.fetched_at,
+  expires_at = EXCLUDED.expires_at;
+
+-- name: DeleteMbzCacheEntries :execrows
+DELETE FROM mbz_cache
+WHERE (sqlc.narg(kind)::text IS NULL OR kind = sqlc.narg(kind)::text)
+  AND (NOT sqlc.arg(expired_only)::bool OR expires_at <= now());
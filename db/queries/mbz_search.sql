@@ -0,0 +1,11 @@
+-- name: SaveMbzSearchAttempt :exec
+INSERT INTO mbz_search_attempts (track_id, score)
+VALUES ($1, $2)
+ON CONFLICT (track_id) DO UPDATE SET score = EXCLUDED.score, searched_at = now();
+
+-- name: GetTracksToSearch :many
+SELECT t.id FROM tracks t
+WHERE t.musicbrainz_id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM mbz_search_attempts a WHERE a.track_id = t.id)
+ORDER BY t.id
+LIMIT $1;
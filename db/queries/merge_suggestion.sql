@@ -0,0 +1,158 @@
+-- name: GetArtistDuplicateCandidates :many
+WITH pairs AS (
+  SELECT a.artist_id AS id_a, b.artist_id AS id_b, similarity(a.alias, b.alias) AS similarity
+  FROM artist_aliases a
+  JOIN artist_aliases b ON a.artist_id < b.artist_id AND a.alias % b.alias
+  UNION ALL
+  SELECT a.artist_id, b.artist_id, similarity(a.alias, b.alias)
+  FROM artist_aliases a
+  JOIN artist_aliases b ON a.artist_id < b.artist_id
+    AND lower(regexp_replace(a.alias, '[^[:alnum:]]+', '', 'g')) = lower(regexp_replace(b.alias, '[^[:alnum:]]+', '', 'g'))
+  WHERE regexp_replace(a.alias, '[^[:alnum:]]+', '', 'g') <> ''
+)
+SELECT p.id_a::int AS id_a, p.id_b::int AS id_b, MAX(p.similarity)::float8 AS similarity
+FROM pairs p
+GROUP BY p.id_a, p.id_b;
+
+-- name: GetReleaseDuplicateCandidates :many
+WITH pairs AS (
+  SELECT a.release_id AS id_a, b.release_id AS id_b, similarity(a.alias, b.alias) AS similarity
+  FROM release_aliases a
+  JOIN release_aliases b ON a.release_id < b.release_id AND a.alias % b.alias
+  UNION ALL
+  SELECT a.release_id, b.release_id, similarity(a.alias, b.alias)
+  FROM release_aliases a
+  JOIN release_aliases b ON a.release_id < b.release_id
+    AND lower(regexp_replace(a.alias, '[^[:alnum:]]+', '', 'g')) = lower(regexp_replace(b.alias, '[^[:alnum:]]+', '', 'g'))
+  WHERE regexp_replace(a.alias, '[^[:alnum:]]+', '', 'g') <> ''
+)
+SELECT p.id_a::int AS id_a, p.id_b::int AS id_b, MAX(p.similarity)::float8 AS similarity
+FROM pairs p
+WHERE EXISTS (
+  SELECT 1 FROM artist_releases ara
+  JOIN artist_releases arb ON ara.artist_id = arb.artist_id
+  WHERE ara.release_id = p.id_a AND arb.release_id = p.id_b
+)
+GROUP BY p.id_a, p.id_b;
+
+-- name: GetTrackDuplicateCandidates :many
+WITH pairs AS (
+  SELECT a.track_id AS id_a, b.track_id AS id_b, similarity(a.alias, b.alias) AS similarity
+  FROM track_aliases a
+  JOIN track_aliases b ON a.track_id < b.track_id AND a.alias % b.alias
+  UNION ALL
+  SELECT a.track_id, b.track_id, similarity(a.alias, b.alias)
+  FROM track_aliases a
+  JOIN track_aliases b ON a.track_id < b.track_id
+    AND lower(regexp_replace(a.alias, '[^[:alnum:]]+', '', 'g')) = lower(regexp_replace(b.alias, '[^[:alnum:]]+', '', 'g'))
+  WHERE regexp_replace(a.alias, '[^[:alnum:]]+', '', 'g') <> ''
+)
+SELECT p.id_a::int AS id_a, p.id_b::int AS id_b, MAX(p.similarity)::float8 AS similarity
+FROM pairs p
+WHERE EXISTS (
+  SELECT 1 FROM artist_tracks ata
+  JOIN artist_tracks atb ON ata.artist_id = atb.artist_id
+  WHERE ata.track_id = p.id_a AND atb.track_id = p.id_b
+)
+GROUP BY p.id_a, p.id_b;
+
+-- name: GetArtistDuplicateDetails :one
+SELECT
+  a.id,
+  a.name,
+  a.musicbrainz_id,
+  (SELECT COUNT(*) FROM listens l JOIN artist_tracks at ON l.track_id = at.track_id WHERE at.artist_id = a.id) AS listen_count,
+  (SELECT COALESCE(array_agg(aa.alias), '{}') FROM artist_aliases aa WHERE aa.artist_id = a.id)::text[] AS aliases,
+  (SELECT COALESCE(array_agg(t.title), '{}') FROM tracks_with_title t JOIN artist_tracks at ON t.id = at.track_id WHERE at.artist_id = a.id)::text[] AS track_titles
+FROM artists_with_name a
+WHERE a.id = $1;
+
+-- name: GetReleaseDuplicateDetails :one
+SELECT
+  r.id,
+  r.title,
+  r.musicbrainz_id,
+  (SELECT COUNT(*) FROM listens l JOIN tracks t ON l.track_id = t.id WHERE t.release_id = r.id) AS listen_count,
+  (SELECT COALESCE(array_agg(ra.alias), '{}') FROM release_aliases ra WHERE ra.release_id = r.id)::text[] AS aliases,
+  (SELECT COALESCE(array_agg(t.title), '{}') FROM tracks_with_title t WHERE t.release_id = r.id)::text[] AS track_titles
+FROM releases_with_title r
+WHERE r.id = $1;
+
+-- name: GetTrackDuplicateDetails :one
+SELECT
+  t.id,
+  t.title,
+  t.musicbrainz_id,
+  t.duration,
+  (SELECT COUNT(*) FROM listens l WHERE l.track_id = t.id) AS listen_count,
+  (SELECT COALESCE(array_agg(ta.alias), '{}') FROM track_aliases ta WHERE ta.track_id = t.id)::text[] AS aliases
+FROM tracks_with_title t
+WHERE t.id = $1;
+
+-- name: UpsertMergeSuggestion :exec
+INSERT INTO merge_suggestions (kind, from_id, to_id, score, reasons, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (kind, LEAST(from_id, to_id), GREATEST(from_id, to_id)) DO UPDATE
+SET from_id = EXCLUDED.from_id,
+    to_id = EXCLUDED.to_id,
+    score = EXCLUDED.score,
+    reasons = EXCLUDED.reasons,
+    updated_at = EXCLUDED.updated_at
+WHERE NOT merge_suggestions.dismissed;
+
+-- name: DeleteOutdatedMergeSuggestions :exec
+DELETE FROM merge_suggestions
+WHERE kind = $1
+  AND NOT dismissed
+  AND updated_at < $2;
+
+-- name: DeleteOrphanedMergeSuggestions :exec
+DELETE FROM merge_suggestions s
+WHERE (s.kind = 'artist' AND (
+    NOT EXISTS (SELECT 1 FROM artists WHERE id = s.from_id)
+    OR NOT EXISTS (SELECT 1 FROM artists WHERE id = s.to_id)))
+  OR (s.kind = 'album' AND (
+    NOT EXISTS (SELECT 1 FROM releases WHERE id = s.from_id)
+    OR NOT EXISTS (SELECT 1 FROM releases WHERE id = s.to_id)))
+  OR (s.kind = 'track' AND (
+    NOT EXISTS (SELECT 1 FROM tracks WHERE id = s.from_id)
+    OR NOT EXISTS (SELECT 1 FROM tracks WHERE id = s.to_id)));
+
+-- name: GetMergeSuggestions :many
+SELECT
+  s.id,
+  s.kind,
+  s.from_id,
+  s.to_id,
+  s.score,
+  s.reasons,
+  s.dismissed,
+  s.created_at,
+  COALESCE(fa.name, fr.title, ft.title)::text AS from_name,
+  COALESCE(ta.name, tr.title, tt.title)::text AS to_name
+FROM merge_suggestions s
+LEFT JOIN artists_with_name fa ON s.kind = 'artist' AND fa.id = s.from_id
+LEFT JOIN artists_with_name ta ON s.kind = 'artist' AND ta.id = s.to_id
+LEFT JOIN releases_with_title fr ON s.kind = 'album' AND fr.id = s.from_id
+LEFT JOIN releases_with_title tr ON s.kind = 'album' AND tr.id = s.to_id
+LEFT JOIN tracks_with_title ft ON s.kind = 'track' AND ft.id = s.from_id
+LEFT JOIN tracks_with_title tt ON s.kind = 'track' AND tt.id = s.to_id
+WHERE s.dismissed = sqlc.arg(dismissed)
+  AND (sqlc.arg(kind)::text = '' OR s.kind = sqlc.arg(kind)::text)
+  AND COALESCE(fa.id, fr.id, ft.id) IS NOT NULL
+  AND COALESCE(ta.id, tr.id, tt.id) IS NOT NULL
+ORDER BY s.score DESC, s.id
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetMergeSuggestion :one
+SELECT * FROM merge_suggestions
+WHERE id = $1
+LIMIT 1;
+
+-- name: DismissMergeSuggestion :execrows
+UPDATE merge_suggestions SET dismissed = true
+WHERE id = $1;
+
+-- name: DeleteMergeSuggestion :exec
+DELETE FROM merge_suggestions
+WHERE id = $1;
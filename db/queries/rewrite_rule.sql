@@ -0,0 +1,28 @@
+-- name: InsertRewriteRule :one
+INSERT INTO rewrite_rules (user_id, position, enabled, field, pattern, action, target, replacement)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetRewriteRule :one
+SELECT * FROM rewrite_rules
+WHERE id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: GetRewriteRulesByUserID :many
+SELECT * FROM rewrite_rules
+WHERE user_id = $1
+ORDER BY position, id;
+
+-- name: GetEnabledRewriteRulesByUserID :many
+SELECT * FROM rewrite_rules
+WHERE user_id = $1 AND enabled
+ORDER BY position, id;
+
+-- name: UpdateRewriteRule :execrows
+UPDATE rewrite_rules
+SET position = $3, enabled = $4, field = $5, pattern = $6, action = $7, target = $8, replacement = $9
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteRewriteRule :execrows
+DELETE FROM rewrite_rules
+WHERE id = $1 AND user_id = $2;
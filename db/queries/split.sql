@@ -0,0 +1,96 @@
+-- name: MoveArtistAliases :execrows
+UPDATE artist_aliases SET artist_id = sqlc.arg(new_artist_id), is_primary = false
+WHERE artist_id = sqlc.arg(artist_id)
+  AND alias = ANY(sqlc.arg(aliases)::text[])
+  AND NOT is_primary;
+
+-- name: MoveReleaseAliases :execrows
+UPDATE release_aliases SET release_id = sqlc.arg(new_release_id), is_primary = false
+WHERE release_id = sqlc.arg(release_id)
+  AND alias = ANY(sqlc.arg(aliases)::text[])
+  AND NOT is_primary;
+
+-- name: MoveTrackAliases :execrows
+UPDATE track_aliases SET track_id = sqlc.arg(new_track_id), is_primary = false
+WHERE track_id = sqlc.arg(track_id)
+  AND alias = ANY(sqlc.arg(aliases)::text[])
+  AND NOT is_primary;
+
+-- name: MoveArtistTracks :execrows
+UPDATE artist_tracks SET artist_id = sqlc.arg(new_artist_id)
+WHERE artist_id = sqlc.arg(artist_id)
+  AND track_id = ANY(sqlc.arg(track_ids)::int[]);
+
+-- name: CopyArtistTracks :execrows
+INSERT INTO artist_tracks (artist_id, track_id, is_primary)
+SELECT sqlc.arg(new_artist_id)::int, at.track_id, false
+FROM artist_tracks at
+WHERE at.artist_id = sqlc.arg(artist_id)
+  AND at.track_id = ANY(sqlc.arg(track_ids)::int[])
+ON CONFLICT DO NOTHING;
+
+-- name: AssociateArtistToTrackReleases :many
+INSERT INTO artist_releases (artist_id, release_id, is_primary)
+SELECT DISTINCT sqlc.arg(artist_id)::int, t.release_id, false
+FROM tracks t
+WHERE t.id = ANY(sqlc.arg(track_ids)::int[])
+ON CONFLICT DO NOTHING
+RETURNING release_id;
+
+-- name: DeleteArtistReleasesWithoutTracks :exec
+DELETE FROM artist_releases ar
+WHERE ar.artist_id = sqlc.arg(artist_id)
+  AND ar.release_id IN (SELECT t.release_id FROM tracks t WHERE t.id = ANY(sqlc.arg(track_ids)::int[]))
+  AND NOT EXISTS (
+    SELECT 1 FROM artist_tracks at
+    JOIN tracks t ON t.id = at.track_id
+    WHERE at.artist_id = ar.artist_id AND t.release_id = ar.release_id
+  );
+
+-- name: MoveTracksToRelease :execrows
+UPDATE tracks SET release_id = sqlc.arg(new_release_id)
+WHERE release_id = sqlc.arg(release_id)
+  AND id = ANY(sqlc.arg(track_ids)::int[]);
+
+-- name: CountTracksOfRelease :one
+SELECT COUNT(*) FROM tracks
+WHERE release_id = $1;
+
+-- name: AssociateTrackArtistsToRelease :exec
+INSERT INTO artist_releases (artist_id, release_id, is_primary)
+SELECT DISTINCT at.artist_id, sqlc.arg(new_release_id)::int, COALESCE(ar.is_primary, false)
+FROM artist_tracks at
+LEFT JOIN artist_releases ar ON ar.artist_id = at.artist_id AND ar.release_id = sqlc.arg(release_id)::int
+WHERE at.track_id = ANY(sqlc.arg(track_ids)::int[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteReleaseArtistsWithoutTracks :exec
+WITH gone AS (
+    SELECT ar.artist_id FROM artist_releases ar
+    WHERE ar.release_id = sqlc.arg(release_id)
+      AND ar.artist_id IN (SELECT at.artist_id FROM artist_tracks at WHERE at.track_id = ANY(sqlc.arg(track_ids)::int[]))
+      AND NOT EXISTS (
+        SELECT 1 FROM artist_tracks at
+        JOIN tracks t ON t.id = at.track_id
+        WHERE at.artist_id = ar.artist_id AND t.release_id = ar.release_id
+      )
+)
+DELETE FROM artist_releases ar
+WHERE ar.release_id = sqlc.arg(release_id)
+  AND ar.artist_id IN (SELECT artist_id FROM gone)
+  -- the album is deleted along with its last artist
+  AND EXISTS (
+    SELECT 1 FROM artist_releases o
+    WHERE o.release_id = sqlc.arg(release_id) AND o.artist_id NOT IN (SELECT artist_id FROM gone)
+  );
+
+-- name: CopyTrackArtists :exec
+INSERT INTO artist_tracks (artist_id, track_id, is_primary)
+SELECT at.artist_id, sqlc.arg(new_track_id)::int, at.is_primary
+FROM artist_tracks at
+WHERE at.track_id = sqlc.arg(track_id)
+ON CONFLICT DO NOTHING;
+
+-- name: CountListensOfTracks :one
+SELECT COUNT(*) FROM listens
+WHERE track_id = ANY(sqlc.arg(track_ids)::int[]);
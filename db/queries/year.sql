@@ -203,7 +203,7 @@ SELECT
     c.gap_days
 FROM ranked c
 JOIN artists_with_name awn ON awn.id = c.artist_id
-WHERE r = 1;
+WHERE r = 1 AND c.user_id = @user_id::int;
 
 -- name: GetFirstListenInYear :one
 SELECT 
@@ -212,7 +212,8 @@ SELECT
     get_artists_for_track(t.id) as artists 
 FROM listens l 
 LEFT JOIN tracks_with_title t ON l.track_id = t.id 
-WHERE EXTRACT(YEAR FROM l.listened_at) = 2025 
+WHERE l.user_id = @user_id::int
+    AND EXTRACT(YEAR FROM l.listened_at) = @year::int
 ORDER BY l.listened_at ASC 
 LIMIT 1;
 
@@ -222,7 +223,8 @@ WITH monthly_plays AS (
         l.track_id,
         EXTRACT(MONTH FROM l.listened_at) AS month
     FROM listens l
-    WHERE EXTRACT(YEAR FROM l.listened_at) = @user_id::int
+    WHERE l.user_id = @user_id::int
+      AND EXTRACT(YEAR FROM l.listened_at) = @year::int
     GROUP BY l.track_id, EXTRACT(MONTH FROM l.listened_at)
 ),
 monthly_counts AS (
@@ -317,14 +319,15 @@ WITH first_artist_plays_in_year AS (
         MIN(l.listened_at) AS first_listen
     FROM listens l
     JOIN artist_tracks at ON at.track_id = l.track_id
-    WHERE EXTRACT(YEAR FROM l.listened_at) = 2024
+    WHERE l.user_id = @user_id::int
+      AND EXTRACT(YEAR FROM l.listened_at) = @year::int
       AND NOT EXISTS (
           SELECT 1
           FROM listens l2
           JOIN artist_tracks at2 ON at2.track_id = l2.track_id
           WHERE l2.user_id = l.user_id
             AND at2.artist_id = at.artist_id
-            AND l2.listened_at < DATE '2024-01-01'
+            AND l2.listened_at < @first_day_of_year::date
       )
     GROUP BY l.user_id, at.artist_id
 ) 
@@ -337,7 +340,7 @@ FROM first_artist_plays_in_year f
 JOIN listens l ON l.user_id = f.user_id
 JOIN artist_tracks at ON at.track_id = l.track_id JOIN artists_with_name a ON at.artist_id = a.id
 WHERE at.artist_id = f.artist_id
-  AND EXTRACT(YEAR FROM l.listened_at) = 2024
+  AND EXTRACT(YEAR FROM l.listened_at) = @year::int
 GROUP BY f.user_id, f.artist_id, f.first_listen, a.name HAVING COUNT(*) = 1;
 
 -- name: GetArtistCountInYear :one
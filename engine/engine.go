@@ -8,7 +8,6 @@ import (
 	"os"
 	"os/signal"
 	"path"
-	"strings"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -18,12 +17,18 @@ import (
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db/psql"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/dedupe"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/enrich"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/images"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importer"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importjob"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/ingest"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
 	mbz "github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mpd"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/SaturnX-Dev/Beat-Scrobble/queue"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog"
@@ -98,7 +103,11 @@ func Run(
 	l.Debug().Msg("Engine: Initializing MusicBrainz client")
 	var mbzC mbz.MusicBrainzCaller
 	if !cfg.MusicBrainzDisabled() {
-		mbzC = mbz.NewMusicBrainzClient()
+		client := mbz.NewMusicBrainzClient()
+		if cfg.MusicBrainzCacheTTL() > 0 {
+			client.UseCache(store, cfg.MusicBrainzCacheTTL())
+		}
+		mbzC = client
 		l.Info().Msg("Engine: MusicBrainz client initialized")
 	} else {
 		mbzC = &mbz.MbzErrorCaller{}
@@ -161,6 +170,45 @@ func Run(
 		l.Warn().Msg("You have enabled ListenBrainz relay, but either the URL or token is missing. Double check your configuration to make sure it is correct!")
 	}
 
+	l.Debug().Msg("Engine: Starting ingest workers")
+	ingestPool := ingest.Start(logger.NewContext(l), store, mbzC, cfg.IngestWorkers())
+
+	l.Debug().Msg("Engine: Starting import job worker")
+	importWorker := importjob.Start(logger.NewContext(l), store, mbzC)
+
+	var mpdWatcher *mpd.Watcher
+	if cfg.MpdAddress() != "" {
+		l.Debug().Msg("Engine: Starting MPD watcher")
+		u, err := store.GetUserByUsername(ctx, cfg.MpdUser())
+		if err != nil {
+			l.Err(err).Msg("Engine: Failed to get user for MPD watcher")
+		} else if u == nil {
+			l.Error().Msgf("Engine: User '%s' for MPD watcher does not exist", cfg.MpdUser())
+		} else {
+			mpdWatcher = mpd.Start(logger.NewContext(l), store, mbzC, mpd.Options{
+				Address:  cfg.MpdAddress(),
+				Password: cfg.MpdPassword(),
+				UserID:   u.ID,
+			})
+		}
+	}
+
+	var analyzer *dedupe.Analyzer
+	if cfg.DuplicateScanInterval() > 0 {
+		l.Debug().Msg("Engine: Starting duplicate analyzer")
+		analyzer = dedupe.Start(logger.NewContext(l), store, cfg.DuplicateScanInterval())
+	}
+
+	var enricher *enrich.Worker
+	if cfg.EnrichmentInterval() > 0 {
+		l.Debug().Msg("Engine: Starting enrichment worker")
+		var enrichMbz mbz.MusicBrainzCaller
+		if !cfg.MusicBrainzDisabled() {
+			enrichMbz = mbzC
+		}
+		enricher = enrich.Start(logger.NewContext(l), store, enrichMbz, cfg.EnrichmentInterval())
+	}
+
 	l.Debug().Msg("Engine: Setting up HTTP server")
 	var ready atomic.Bool
 	mux := chi.NewRouter()
@@ -169,12 +217,15 @@ func Run(
 	mux.Use(chimiddleware.Recoverer)
 	mux.Use(chimiddleware.RealIP)
 	mux.Use(middleware.AllowedHosts)
-	bindRoutes(mux, &ready, store, mbzC)
+	shutdown, endStreams := context.WithCancel(context.Background())
+	defer endStreams()
+	bindRoutes(mux, &ready, store, mbzC, shutdown)
 
 	httpServer := &http.Server{
 		Addr:    cfg.ListenAddr(),
 		Handler: mux,
 	}
+	httpServer.RegisterOnShutdown(endStreams)
 
 	go func() {
 		ready.Store(true)
@@ -202,6 +253,16 @@ func Run(
 	l.Info().Msg("Engine: Pruning orphaned images")
 	go catalog.PruneOrphanedImages(logger.NewContext(l), store)
 
+	l.Debug().Msg("Engine: Pruning expired MusicBrainz responses")
+	go func() {
+		n, err := store.PurgeMbzCache(ctx, db.PurgeMbzCacheOpts{ExpiredOnly: true})
+		if err != nil {
+			l.Err(err).Msg("Engine: Failed to prune expired MusicBrainz responses")
+		} else if n > 0 {
+			l.Info().Msgf("Engine: Pruned %d expired MusicBrainz responses", n)
+		}
+	}()
+
 	l.Info().Msg("Engine: Initialization finished")
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -212,9 +273,24 @@ func Run(
 	defer cancel()
 	l.Info().Msg("Engine: Waiting for all processes to finish")
 	mbzC.Shutdown()
-	if err := httpServer.Shutdown(ctx); err != nil {
-		l.Fatal().Err(err).Msg("Engine: Error during server shutdown")
-		return err
+	// workers are stopped even when requests are still open
+	shutdownErr := httpServer.Shutdown(ctx)
+	if shutdownErr != nil {
+		l.Err(shutdownErr).Msg("Engine: Error during server shutdown")
+	}
+	if mpdWatcher != nil {
+		mpdWatcher.Stop()
+	}
+	if analyzer != nil {
+		analyzer.Stop()
+	}
+	if enricher != nil {
+		enricher.Stop()
+	}
+	importWorker.Stop()
+	ingestPool.Stop()
+	if shutdownErr != nil {
+		return shutdownErr
 	}
 	l.Info().Msg("Engine: Shutdown successful")
 	return nil
@@ -236,42 +312,45 @@ func RunImporter(l *zerolog.Logger, store db.DB, mbzc mbz.MusicBrainzCaller) {
 			l.Error().Interface("recover", r).Msg("Panic when importing files")
 		}
 	}()
+	// imports can take many lookups, which should not hold up scrobbles
+	ctx := queue.WithPriority(logger.NewContext(l), queue.PriorityBackground)
 	for _, file := range files {
 		if file.IsDir() {
 			continue
 		}
-		if strings.Contains(file.Name(), "Streaming_History_Audio") {
+		format, ok := importer.DetectFormat(file.Name())
+		if !ok {
+			l.Warn().Msgf("File %s not recognized as a valid import file; make sure it is valid and named correctly", file.Name())
+			continue
+		}
+		switch format {
+		case models.ImportSpotify:
 			l.Info().Msgf("Import file %s detecting as being Spotify export", file.Name())
-			err := importer.ImportSpotifyFile(logger.NewContext(l), store, file.Name())
-			if err != nil {
-				l.Err(err).Msgf("Failed to import file: %s", file.Name())
-			}
-		} else if strings.Contains(file.Name(), "maloja") {
+			err = importer.ImportSpotifyFile(ctx, store, file.Name())
+		case models.ImportMaloja:
 			l.Info().Msgf("Import file %s detecting as being Maloja export", file.Name())
-			err := importer.ImportMalojaFile(logger.NewContext(l), store, file.Name())
-			if err != nil {
-				l.Err(err).Msgf("Failed to import file: %s", file.Name())
-			}
-		} else if strings.Contains(file.Name(), "recenttracks") {
+			err = importer.ImportMalojaFile(ctx, store, file.Name())
+		case models.ImportLastFM:
 			l.Info().Msgf("Import file %s detecting as being ghan.nl LastFM export", file.Name())
-			err := importer.ImportLastFMFile(logger.NewContext(l), store, mbzc, file.Name())
-			if err != nil {
-				l.Err(err).Msgf("Failed to import file: %s", file.Name())
-			}
-		} else if strings.Contains(file.Name(), "listenbrainz") {
+			err = importer.ImportLastFMFile(ctx, store, mbzc, file.Name())
+		case models.ImportListenBrainz:
 			l.Info().Msgf("Import file %s detecting as being ListenBrainz export", file.Name())
-			err := importer.ImportListenBrainzExport(logger.NewContext(l), store, mbzc, file.Name())
-			if err != nil {
-				l.Err(err).Msgf("Failed to import file: %s", file.Name())
-			}
-		} else if strings.Contains(file.Name(), "beat_scrobble") || strings.Contains(file.Name(), "beat-scrobble") || strings.Contains(file.Name(), "koito") {
+			err = importer.ImportListenBrainzExport(ctx, store, mbzc, file.Name())
+		case models.ImportYouTubeMusic:
+			l.Info().Msgf("Import file %s detecting as being YouTube Music history", file.Name())
+			err = importer.ImportYouTubeMusicFile(ctx, store, mbzc, file.Name())
+		case models.ImportScrobblerLog:
+			l.Info().Msgf("Import file %s detecting as being Audioscrobbler log", file.Name())
+			err = importer.ImportScrobblerLogFile(ctx, store, mbzc, file.Name())
+		case models.ImportCsv:
+			l.Info().Msgf("Import file %s detecting as being CSV file", file.Name())
+			err = importer.ImportCsvFile(ctx, store, mbzc, file.Name())
+		case models.ImportBeatScrobble:
 			l.Info().Msgf("Import file %s detecting as being Beat Scrobble/Koito export", file.Name())
-			err := importer.ImportBeatScrobbleFile(logger.NewContext(l), store, file.Name())
-			if err != nil {
-				l.Err(err).Msgf("Failed to import file: %s", file.Name())
-			}
-		} else {
-			l.Warn().Msgf("File %s not recognized as a valid import file; make sure it is valid and named correctly", file.Name())
+			err = importer.ImportBeatScrobbleFile(ctx, store, file.Name())
+		}
+		if err != nil {
+			l.Err(err).Msgf("Failed to import file: %s", file.Name())
 		}
 	}
 }
@@ -147,11 +147,13 @@ func GenerateAIPlaylistHandler(store db.DB) http.HandlerFunc {
 			Limit:  10,
 			Page:   1,
 			Period: db.PeriodMonth,
+			UserID: user.ID,
 		})
 		topTracksResp, _ := store.GetTopTracksPaginated(ctx, db.GetItemsOpts{
 			Limit:  10,
 			Page:   1,
 			Period: db.PeriodMonth,
+			UserID: user.ID,
 		})
 
 		artistNames := make([]string, 0)
@@ -127,15 +127,16 @@ func GetAIProfileCritiqueHandler(store db.DB) http.HandlerFunc {
 		}
 
 		// 4. Fetch Stats & Top Artists
-		listens, _ := store.CountListens(ctx, period)
-		artistCount, _ := store.CountArtists(ctx, period)
-		albumCount, _ := store.CountAlbums(ctx, period)
-		trackCount, _ := store.CountTracks(ctx, period)
+		listens, _ := store.CountListens(ctx, period, user.ID)
+		artistCount, _ := store.CountArtists(ctx, period, user.ID)
+		albumCount, _ := store.CountAlbums(ctx, period, user.ID)
+		trackCount, _ := store.CountTracks(ctx, period, user.ID)
 
 		topArtistsResp, err := store.GetTopArtistsPaginated(ctx, db.GetItemsOpts{
 			Limit:  5,
 			Page:   1,
 			Period: period,
+			UserID: user.ID,
 		})
 
 		var topArtistsNames []string
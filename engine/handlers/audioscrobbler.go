@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/ingest"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/memkv"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/google/uuid"
+)
+
+// plain text replies defined by the Audioscrobbler 1.2 protocol
+const (
+	asReplyOK         = "OK"
+	asReplyBadAuth    = "BADAUTH"
+	asReplyBadTime    = "BADTIME"
+	asReplyBadSession = "BADSESSION"
+	asReplyFailed     = "FAILED"
+)
+
+const (
+	asSessionKeyPrefix = "as_session_"
+	// sessions are refreshed on every use, clients handshake again once expired
+	asSessionExpiry = 24 * time.Hour
+	// handshakes with a timestamp further than this from the server time are rejected
+	asMaxClockSkew             = time.Hour
+	maxAsSubmissionsPerRequest = 50
+)
+
+type asSession struct {
+	UserID        int32
+	Client        string
+	ClientVersion string
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeAsReply(w http.ResponseWriter, lines ...string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(strings.Join(lines, "\n") + "\n"))
+}
+
+// AudioscrobblerHandshakeHandler authenticates a 1.2 client and hands out a
+// session id along with the now playing and submission urls. Since passwords
+// are only stored hashed, the auth token must be md5(md5(api_key) + timestamp)
+// using one of the user's API keys. The 1.2.1 token scheme is accepted as well,
+// with an API key in place of the Last.fm session key.
+func AudioscrobblerHandshakeHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		q := r.URL.Query()
+		username := q.Get("u")
+		timestamp := q.Get("t")
+		auth := strings.ToLower(q.Get("a"))
+		client := q.Get("c")
+		clientVersion := q.Get("v")
+
+		l.Debug().Msgf("AudioscrobblerHandshakeHandler: Received handshake from client '%s' for user '%s'", client, username)
+
+		if q.Get("hs") != "true" || username == "" || timestamp == "" || auth == "" {
+			l.Debug().Msg("AudioscrobblerHandshakeHandler: Missing handshake parameters")
+			writeAsReply(w, asReplyFailed+" Missing handshake parameters")
+			return
+		}
+
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			writeAsReply(w, asReplyBadTime)
+			return
+		}
+		if skew := time.Since(time.Unix(unix, 0)); skew > asMaxClockSkew || skew < -asMaxClockSkew {
+			l.Debug().Msgf("AudioscrobblerHandshakeHandler: Handshake timestamp is off by %s", skew)
+			writeAsReply(w, asReplyBadTime)
+			return
+		}
+
+		var u *models.User
+		if sk := q.Get("sk"); sk != "" {
+			u, err = store.GetUserByApiKey(ctx, sk)
+			if err != nil {
+				l.Err(err).Msg("AudioscrobblerHandshakeHandler: Failed to get user by api key")
+				writeAsReply(w, asReplyFailed+" Internal server error")
+				return
+			}
+			if u == nil || !strings.EqualFold(u.Username, username) {
+				u = nil
+			} else if secret := cfg.LastFMApiSecret(); secret != "" && auth != md5Hex(secret+timestamp) {
+				u = nil
+			}
+		} else {
+			u, err = store.GetUserByUsername(ctx, username)
+			if err != nil {
+				l.Err(err).Msg("AudioscrobblerHandshakeHandler: Failed to get user by username")
+				writeAsReply(w, asReplyFailed+" Internal server error")
+				return
+			}
+			if u != nil {
+				keys, err := store.GetApiKeysByUserID(ctx, u.ID)
+				if err != nil {
+					l.Err(err).Msg("AudioscrobblerHandshakeHandler: Failed to get api keys for user")
+					writeAsReply(w, asReplyFailed+" Internal server error")
+					return
+				}
+				matched := false
+				for _, k := range keys {
+					if auth == md5Hex(md5Hex(k.Key)+timestamp) {
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					u = nil
+				}
+			}
+		}
+		if u == nil {
+			l.Debug().Msg("AudioscrobblerHandshakeHandler: Invalid credentials")
+			writeAsReply(w, asReplyBadAuth)
+			return
+		}
+
+		sid, err := utils.GenerateRandomString(32)
+		if err != nil {
+			l.Err(err).Msg("AudioscrobblerHandshakeHandler: Failed to generate session id")
+			writeAsReply(w, asReplyFailed+" Internal server error")
+			return
+		}
+		memkv.Store.Set(asSessionKeyPrefix+sid, asSession{UserID: u.ID, Client: client, ClientVersion: clientVersion}, asSessionExpiry)
+
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base := fmt.Sprintf("%s://%s%s", scheme, r.Host, strings.TrimSuffix(r.URL.Path, "/"))
+
+		l.Debug().Msgf("AudioscrobblerHandshakeHandler: Created session for user '%s'", u.Username)
+		writeAsReply(w, asReplyOK, sid, base+"/nowplaying", base+"/submissions")
+	}
+}
+
+// asSessionFromRequest returns the session named by the s parameter, writing a
+// BADSESSION reply when it does not exist.
+func asSessionFromRequest(w http.ResponseWriter, r *http.Request) (asSession, bool) {
+	sid := r.FormValue("s")
+	if sid == "" {
+		writeAsReply(w, asReplyBadSession)
+		return asSession{}, false
+	}
+	v, ok := memkv.Store.Get(asSessionKeyPrefix + sid)
+	if !ok {
+		writeAsReply(w, asReplyBadSession)
+		return asSession{}, false
+	}
+	sess, ok := v.(asSession)
+	if !ok {
+		writeAsReply(w, asReplyBadSession)
+		return asSession{}, false
+	}
+	memkv.Store.Set(asSessionKeyPrefix+sid, sess, asSessionExpiry)
+	return sess, true
+}
+
+// asParam reads an indexed submission field such as a[0], or the plain field
+// used by now playing requests when i is negative.
+func asParam(form url.Values, name string, i int) string {
+	if i < 0 {
+		return strings.TrimSpace(form.Get(name))
+	}
+	return strings.TrimSpace(form.Get(fmt.Sprintf("%s[%d]", name, i)))
+}
+
+func asSubmitOpts(form url.Values, i int, mbzc mbz.MusicBrainzCaller, sess asSession) catalog.SubmitListenOpts {
+	opts := catalog.SubmitListenOpts{
+		MbzCaller:    mbzc,
+		Artist:       asParam(form, "a", i),
+		TrackTitle:   asParam(form, "t", i),
+		ReleaseTitle: asParam(form, "b", i),
+		UserID:       sess.UserID,
+		Client:       sess.Client,
+
+		SubmissionClientVersion: sess.ClientVersion,
+	}
+	if d, err := strconv.Atoi(asParam(form, "l", i)); err == nil && d > 0 {
+		opts.Duration = int32(d)
+	}
+	if id, err := uuid.Parse(asParam(form, "m", i)); err == nil {
+		opts.RecordingMbzID = id
+	}
+	return opts
+}
+
+func AudioscrobblerNowPlayingHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("AudioscrobblerNowPlayingHandler: Failed to parse form")
+			writeAsReply(w, asReplyFailed+" Invalid request")
+			return
+		}
+		sess, ok := asSessionFromRequest(w, r)
+		if !ok {
+			l.Debug().Msg("AudioscrobblerNowPlayingHandler: Invalid session")
+			return
+		}
+
+		opts := asSubmitOpts(r.Form, -1, mbzc, sess)
+		if opts.Artist == "" || opts.TrackTitle == "" {
+			l.Debug().Msg("AudioscrobblerNowPlayingHandler: Artist name or track name are missing")
+			writeAsReply(w, asReplyFailed+" Artist name or track name are missing")
+			return
+		}
+		opts.Time = time.Now()
+		opts.IsNowPlaying = true
+		opts.SkipSaveListen = true
+
+		if err := catalog.SubmitListen(r.Context(), store, opts); err != nil {
+			l.Err(err).Msg("AudioscrobblerNowPlayingHandler: Failed to submit now playing")
+			writeAsReply(w, asReplyFailed+" Internal server error")
+			return
+		}
+		writeAsReply(w, asReplyOK)
+	}
+}
+
+// AudioscrobblerSubmissionHandler queues a batch of up to 50 listens. Entries
+// rated as skipped or banned, and entries missing required fields, are
+// dropped rather than failing the whole batch, since clients resend a failed
+// batch verbatim.
+func AudioscrobblerSubmissionHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("AudioscrobblerSubmissionHandler: Failed to parse form")
+			writeAsReply(w, asReplyFailed+" Invalid request")
+			return
+		}
+		sess, ok := asSessionFromRequest(w, r)
+		if !ok {
+			l.Debug().Msg("AudioscrobblerSubmissionHandler: Invalid session")
+			return
+		}
+
+		var submitted []catalog.SubmitListenOpts
+		for i := 0; i < maxAsSubmissionsPerRequest; i++ {
+			if _, ok := r.Form[fmt.Sprintf("a[%d]", i)]; !ok {
+				break
+			}
+			if rating := strings.ToUpper(asParam(r.Form, "r", i)); rating == "S" || rating == "B" {
+				l.Debug().Msgf("AudioscrobblerSubmissionHandler: Skipping submission %d with rating '%s'", i, rating)
+				continue
+			}
+			opts := asSubmitOpts(r.Form, i, mbzc, sess)
+			unix, err := strconv.ParseInt(asParam(r.Form, "i", i), 10, 64)
+			if opts.Artist == "" || opts.TrackTitle == "" || err != nil || unix <= 0 {
+				l.Debug().Msgf("AudioscrobblerSubmissionHandler: Skipping invalid submission %d", i)
+				continue
+			}
+			opts.Time = time.Unix(unix, 0)
+			submitted = append(submitted, opts)
+		}
+
+		// the batch is queued as a whole, so that a client resending it after
+		// an error does not submit any of it twice
+		if len(submitted) > 0 {
+			if err := ingest.EnqueueAll(r.Context(), store, submitted); err != nil {
+				l.Err(err).Msg("AudioscrobblerSubmissionHandler: Failed to queue listens")
+				writeAsReply(w, asReplyFailed+" Internal server error")
+				return
+			}
+		}
+
+		l.Debug().Msgf("AudioscrobblerSubmissionHandler: Queued %d listens", len(submitted))
+		writeAsReply(w, asReplyOK)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// writeAuditedError writes the error an operation recorded in the audit log
+// failed with, or msg when it failed for another reason.
+func writeAuditedError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, db.ErrAuditSnapshotTooLarge) {
+		utils.WriteError(w, db.ErrAuditSnapshotTooLarge.Error(), http.StatusConflict)
+		return
+	}
+	utils.WriteError(w, msg, http.StatusInternalServerError)
+}
+
+// GetAuditLogHandler lists merges, splits and deletes, newest first. Entries
+// on the listens of other users are left out.
+func GetAuditLogHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetAuditLogHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("GetAuditLogHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		limit := 0
+		if s := r.URL.Query().Get("limit"); s != "" {
+			var err error
+			limit, err = strconv.Atoi(s)
+			if err != nil || limit < 1 {
+				l.Debug().Msg("GetAuditLogHandler: Invalid limit parameter")
+				utils.WriteError(w, "limit is invalid", http.StatusBadRequest)
+				return
+			}
+		}
+
+		entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{
+			UserID: user.ID,
+			Limit:  limit,
+		})
+		if err != nil {
+			l.Err(err).Msg("GetAuditLogHandler: Failed to get audit log")
+			utils.WriteError(w, "failed to get audit log", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetAuditLogHandler: Retrieved %d audit entries", len(entries))
+		utils.WriteJSON(w, http.StatusOK, entries)
+	}
+}
+
+// UndoAuditEntryHandler restores what the operation changed. Only the latest
+// operation that was not undone yet can be undone.
+func UndoAuditEntryHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("UndoAuditEntryHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("UndoAuditEntryHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("UndoAuditEntryHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.UndoAuditEntry(ctx, id, user.ID)
+		if errors.Is(err, db.ErrNotUndoable) {
+			l.Debug().AnErr("error", err).Msgf("UndoAuditEntryHandler: Audit entry %d cannot be undone", id)
+			utils.WriteError(w, db.ErrNotUndoable.Error(), http.StatusConflict)
+			return
+		} else if errors.Is(err, db.ErrUndoConflict) {
+			l.Debug().AnErr("error", err).Msgf("UndoAuditEntryHandler: Audit entry %d conflicts with later changes", id)
+			utils.WriteError(w, db.ErrUndoConflict.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Err(err).Msg("UndoAuditEntryHandler: Failed to undo")
+			utils.WriteError(w, "failed to undo: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msgf("UndoAuditEntryHandler: Audit entry %d not found", id)
+			utils.WriteError(w, "audit entry not found", http.StatusNotFound)
+			return
+		}
+
+		l.Debug().Msgf("UndoAuditEntryHandler: Undid audit entry %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
@@ -36,7 +37,7 @@ func DeleteTrackHandler(store db.DB) http.HandlerFunc {
 		err = store.DeleteTrack(ctx, int32(trackID))
 		if err != nil {
 			l.Err(err).Msg("DeleteTrackHandler: Failed to delete track")
-			utils.WriteError(w, "failed to delete track", http.StatusInternalServerError)
+			writeAuditedError(w, err, "failed to delete track")
 			return
 		}
 
@@ -50,6 +51,13 @@ func DeleteListenHandler(store db.DB) http.HandlerFunc {
 		ctx := r.Context()
 		l := logger.FromContext(ctx)
 
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("DeleteListenHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
 		l.Debug().Msg("DeleteListenHandler: Received request to delete listen record")
 
 		trackIDStr := r.URL.Query().Get("track_id")
@@ -82,7 +90,7 @@ func DeleteListenHandler(store db.DB) http.HandlerFunc {
 
 		l.Debug().Msgf("DeleteListenHandler: Deleting listen record for track ID %d at timestamp %d", trackID, unix)
 
-		err = store.DeleteListen(ctx, int32(trackID), time.Unix(unix, 0))
+		err = store.DeleteListen(ctx, int32(trackID), time.Unix(unix, 0), user.ID)
 		if err != nil {
 			l.Err(err).Msg("DeleteListenHandler: Failed to delete listen record")
 			utils.WriteError(w, "failed to delete listen", http.StatusInternalServerError)
@@ -120,7 +128,7 @@ func DeleteArtistHandler(store db.DB) http.HandlerFunc {
 		err = store.DeleteArtist(ctx, int32(artistID))
 		if err != nil {
 			l.Err(err).Msg("DeleteArtistHandler: Failed to delete artist")
-			utils.WriteError(w, "failed to delete artist", http.StatusInternalServerError)
+			writeAuditedError(w, err, "failed to delete artist")
 			return
 		}
 
@@ -155,7 +163,7 @@ func DeleteAlbumHandler(store db.DB) http.HandlerFunc {
 		err = store.DeleteAlbum(ctx, int32(albumID))
 		if err != nil {
 			l.Err(err).Msg("DeleteAlbumHandler: Failed to delete album")
-			utils.WriteError(w, "failed to delete album", http.StatusInternalServerError)
+			writeAuditedError(w, err, "failed to delete album")
 			return
 		}
 
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/google/uuid"
+)
+
+// mbzIDFromForm reads the musicbrainz_id parameter, which is uuid.Nil when
+// not present.
+func mbzIDFromForm(r *http.Request) (uuid.UUID, error) {
+	s := r.FormValue("musicbrainz_id")
+	if s == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil || id == uuid.Nil {
+		return uuid.Nil, errors.New("musicbrainz_id must be a valid MusicBrainz ID")
+	}
+	return id, nil
+}
+
+// writeEditError writes the response for an error from an update.
+func writeEditError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, db.ErrMbzIDInUse):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, db.ErrInvalidEdit):
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeAuditedError(w, err, "failed to save changes")
+	}
+}
+
+// UpdateTrackHandler changes only the fields of the track that are present
+// in the request: album_id moves the track to another album, artist_id can be
+// repeated and replaces the credited artists, musicbrainz_id and duration are
+// set as given. When refetch_aliases is true, the title of the recording is
+// fetched from MusicBrainz and saved as an alias after the update.
+func UpdateTrackHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("UpdateTrackHandler: Received request")
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateTrackHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.Atoi(r.FormValue("id"))
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateTrackHandler: Invalid id parameter")
+			utils.WriteError(w, "id is invalid", http.StatusBadRequest)
+			return
+		}
+		opts := db.UpdateTrackOpts{ID: int32(id)}
+
+		if s := r.FormValue("album_id"); s != "" {
+			albumID, err := strconv.Atoi(s)
+			if err != nil || albumID <= 0 {
+				l.Debug().AnErr("error", err).Msg("UpdateTrackHandler: Invalid album_id parameter")
+				utils.WriteError(w, "album_id is invalid", http.StatusBadRequest)
+				return
+			}
+			opts.AlbumID = int32(albumID)
+		}
+		for _, s := range r.Form["artist_id"] {
+			artistID, err := strconv.Atoi(s)
+			if err != nil || artistID <= 0 {
+				l.Debug().AnErr("error", err).Msg("UpdateTrackHandler: Invalid artist_id parameter")
+				utils.WriteError(w, "artist_id is invalid", http.StatusBadRequest)
+				return
+			}
+			opts.ArtistIDs = append(opts.ArtistIDs, int32(artistID))
+		}
+		if s := r.FormValue("duration"); s != "" {
+			duration, err := strconv.Atoi(s)
+			if err != nil || duration <= 0 {
+				l.Debug().AnErr("error", err).Msg("UpdateTrackHandler: Invalid duration parameter")
+				utils.WriteError(w, "duration must be a positive number of seconds", http.StatusBadRequest)
+				return
+			}
+			opts.Duration = int32(duration)
+		}
+		opts.MusicBrainzID, err = mbzIDFromForm(r)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateTrackHandler: Invalid musicbrainz_id parameter")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		refetch := r.FormValue("refetch_aliases") == "true"
+		if refetch && opts.MusicBrainzID == uuid.Nil {
+			utils.WriteError(w, "refetch_aliases requires musicbrainz_id", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := store.GetTrack(ctx, db.GetTrackOpts{ID: opts.ID}); err != nil {
+			l.Debug().AnErr("error", err).Msgf("UpdateTrackHandler: Track %d not found", opts.ID)
+			utils.WriteError(w, "track not found", http.StatusNotFound)
+			return
+		}
+
+		if err := store.UpdateTrack(ctx, opts); err != nil {
+			l.Err(err).Msg("UpdateTrackHandler: Failed to update track")
+			writeEditError(w, err)
+			return
+		}
+
+		if refetch {
+			if err := catalog.RefetchTrackAliases(ctx, store, mbzc, opts.ID, opts.MusicBrainzID); err != nil {
+				l.Err(err).Msg("UpdateTrackHandler: Failed to refetch aliases")
+				utils.WriteError(w, "track was updated, but aliases could not be fetched from MusicBrainz", http.StatusBadGateway)
+				return
+			}
+		}
+
+		l.Debug().Msgf("UpdateTrackHandler: Successfully updated track %d", opts.ID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// UpdateArtistHandler sets the MusicBrainz ID of the artist. When
+// refetch_aliases is true, the name and primary aliases of the artist are
+// fetched from MusicBrainz and saved as aliases after the update.
+func UpdateArtistHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("UpdateArtistHandler: Received request")
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateArtistHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.Atoi(r.FormValue("id"))
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateArtistHandler: Invalid id parameter")
+			utils.WriteError(w, "id is invalid", http.StatusBadRequest)
+			return
+		}
+		mbzID, err := mbzIDFromForm(r)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateArtistHandler: Invalid musicbrainz_id parameter")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if mbzID == uuid.Nil {
+			utils.WriteError(w, "musicbrainz_id is required", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := store.GetArtist(ctx, db.GetArtistOpts{ID: int32(id)}); err != nil {
+			l.Debug().AnErr("error", err).Msgf("UpdateArtistHandler: Artist %d not found", id)
+			utils.WriteError(w, "artist not found", http.StatusNotFound)
+			return
+		}
+
+		err = store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: int32(id), MusicBrainzID: mbzID})
+		if err != nil {
+			l.Err(err).Msg("UpdateArtistHandler: Failed to update artist")
+			writeEditError(w, err)
+			return
+		}
+
+		if r.FormValue("refetch_aliases") == "true" {
+			if err := catalog.RefetchArtistAliases(ctx, store, mbzc, int32(id), mbzID); err != nil {
+				l.Err(err).Msg("UpdateArtistHandler: Failed to refetch aliases")
+				utils.WriteError(w, "artist was updated, but aliases could not be fetched from MusicBrainz", http.StatusBadGateway)
+				return
+			}
+		}
+
+		l.Debug().Msgf("UpdateArtistHandler: Successfully updated artist %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
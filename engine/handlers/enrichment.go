@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/enrich"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+type enrichmentStatusResponse struct {
+	Running   bool                         `json:"running"`
+	WorkingOn *enrich.Current              `json:"working_on"`
+	Tasks     []*models.EnrichmentProgress `json:"tasks"`
+}
+
+// EnrichmentStatusHandler returns what the enrichment worker is working on,
+// and how far each of its tasks got in its latest sweep.
+func EnrichmentStatusHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("EnrichmentStatusHandler: Received request")
+
+		progress, err := store.GetEnrichmentProgress(ctx)
+		if err != nil {
+			l.Err(err).Msg("EnrichmentStatusHandler: Failed to get enrichment progress")
+			utils.WriteError(w, "failed to get enrichment progress", http.StatusInternalServerError)
+			return
+		}
+		if progress == nil {
+			progress = []*models.EnrichmentProgress{}
+		}
+
+		utils.WriteJSON(w, http.StatusOK, enrichmentStatusResponse{
+			Running:   enrich.Running(),
+			WorkingOn: enrich.Working(),
+			Tasks:     progress,
+		})
+	}
+}
+
+// RunEnrichmentHandler makes the enrichment worker sweep every task now
+// without waiting for it to finish.
+func RunEnrichmentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		l.Debug().Msg("RunEnrichmentHandler: Received request")
+		if !enrich.Running() {
+			l.Debug().Msg("RunEnrichmentHandler: Enrichment worker is disabled")
+			utils.WriteError(w, "enrichment is disabled", http.StatusConflict)
+			return
+		}
+		enrich.Wake()
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
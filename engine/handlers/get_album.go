@@ -32,7 +32,7 @@ func GetAlbumHandler(store db.DB) func(w http.ResponseWriter, r *http.Request) {
 
 		l.Debug().Msgf("GetAlbumHandler: Retrieving album with ID %d", id)
 
-		album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: int32(id)})
+		album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: int32(id), UserID: UserIDFromRequest(r)})
 		if err != nil {
 			l.Err(err).Msgf("GetAlbumHandler: Failed to retrieve album with ID %d", id)
 			utils.WriteError(w, "album with specified id could not be found", http.StatusNotFound)
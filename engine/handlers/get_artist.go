@@ -32,7 +32,7 @@ func GetArtistHandler(store db.DB) func(w http.ResponseWriter, r *http.Request)
 
 		l.Debug().Msgf("GetArtistHandler: Retrieving artist with ID %d", id)
 
-		artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: int32(id)})
+		artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: int32(id), UserID: UserIDFromRequest(r)})
 		if err != nil {
 			l.Err(err).Msgf("GetArtistHandler: Failed to retrieve artist with ID %d", id)
 			utils.WriteError(w, "artist with specified id could not be found", http.StatusNotFound)
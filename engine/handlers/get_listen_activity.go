@@ -88,6 +88,7 @@ func GetListenActivityHandler(store db.DB) func(w http.ResponseWriter, r *http.R
 			AlbumID:  int32(albumId),
 			ArtistID: int32(artistId),
 			TrackID:  int32(trackId),
+			UserID:   UserIDFromRequest(r),
 		}
 
 		l.Debug().Msgf("GetListenActivityHandler: Retrieving listen activity with options: %+v", opts)
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
 )
@@ -13,6 +14,28 @@ import (
 const defaultLimitSize = 100
 const maximumLimit = 500
 
+// the user created on first start, whose stats are shown when no other user
+// can be determined from the request
+const defaultUserID int32 = 1
+
+// UserIDFromRequest determines whose listens a request should be scoped to.
+// The authenticated user takes precedence, so that users cannot read each
+// other's listens. Unauthenticated requests, which are only let through when
+// the login gate is off, can pick a user with the 'user_id' query parameter,
+// and are otherwise scoped to the default user.
+func UserIDFromRequest(r *http.Request) int32 {
+	if u := middleware.GetUserFromContext(r.Context()); u != nil {
+		return u.ID
+	}
+	if userIdStr := r.URL.Query().Get("user_id"); userIdStr != "" {
+		userId, err := strconv.Atoi(userIdStr)
+		if err == nil && userId > 0 {
+			return int32(userId)
+		}
+	}
+	return defaultUserID
+}
+
 func OptsFromRequest(r *http.Request) db.GetItemsOpts {
 	l := logger.FromContext(r.Context())
 
@@ -71,8 +94,10 @@ func OptsFromRequest(r *http.Request) db.GetItemsOpts {
 		period = db.PeriodDay
 	}
 
-	l.Debug().Msgf("OptsFromRequest: Parsed options: limit=%d, page=%d, week=%d, month=%d, year=%d, from=%d, to=%d, artist_id=%d, album_id=%d, track_id=%d, period=%s",
-		limit, page, week, month, year, from, to, artistId, albumId, trackId, period)
+	userId := UserIDFromRequest(r)
+
+	l.Debug().Msgf("OptsFromRequest: Parsed options: limit=%d, page=%d, week=%d, month=%d, year=%d, from=%d, to=%d, artist_id=%d, album_id=%d, track_id=%d, user_id=%d, period=%s",
+		limit, page, week, month, year, from, to, artistId, albumId, trackId, userId, period)
 
 	return db.GetItemsOpts{
 		Limit:    limit,
@@ -86,5 +111,6 @@ func OptsFromRequest(r *http.Request) db.GetItemsOpts {
 		ArtistID: artistId,
 		AlbumID:  albumId,
 		TrackID:  trackId,
+		UserID:   userId,
 	}
 }
@@ -1,19 +1,20 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path"
 	"strconv"
 	"time"
 
 	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
-	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importjob"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
 )
 
@@ -98,23 +99,28 @@ func ImportHandler(store db.DB) http.HandlerFunc {
 		}
 
 		// Queue Listens for import (v1 and v2)
-		// Write to import directory so the importer picks it up on next restart
+		var jobId int64
 		if len(importData.Listens) > 0 {
 			listensPending = len(importData.Listens)
 			l.Info().Msgf("ImportHandler: Found %d listens to import", listensPending)
 
-			// Write the body to import directory for processing
-			timestamp := time.Now().UnixMilli()
-			filename := fmt.Sprintf("web_import_%d_koito.json", timestamp)
-			importPath := path.Join(cfg.ConfigDir(), "import", filename)
-
-			err := os.WriteFile(importPath, body, 0644)
-			if err != nil {
-				l.Error().Err(err).Msg("ImportHandler: Failed to write import file")
+			job, err := importjob.Create(ctx, store, importjob.CreateOpts{
+				UserID:   user.ID,
+				Format:   models.ImportBeatScrobble,
+				Filename: fmt.Sprintf("web_import_%d_beat_scrobble.json", time.Now().UnixMilli()),
+				File:     bytes.NewReader(body),
+			})
+			if errors.Is(err, importjob.ErrInvalidFile) {
+				l.Debug().AnErr("error", err).Msg("ImportHandler: Invalid listens")
+				utils.WriteError(w, "invalid import file format", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				l.Error().Err(err).Msg("ImportHandler: Failed to create import job")
 				utils.WriteError(w, "failed to queue import file", http.StatusInternalServerError)
 				return
 			}
-			l.Info().Msgf("ImportHandler: Listens queued for import in file %s", filename)
+			jobId = job.ID
+			l.Info().Msgf("ImportHandler: Listens queued for import in job %d", jobId)
 		}
 
 		// Build response based on what was restored
@@ -122,7 +128,7 @@ func ImportHandler(store db.DB) http.HandlerFunc {
 		if importData.Version == "1" {
 			if listensPending > 0 {
 				message = "Legacy export (v1) received! " +
-					strconv.Itoa(listensPending) + " listens will be imported on next restart."
+					strconv.Itoa(listensPending) + " listens are being imported."
 			} else {
 				message = "Legacy export (v1) detected but no listens were found."
 			}
@@ -136,7 +142,7 @@ func ImportHandler(store db.DB) http.HandlerFunc {
 				parts = append(parts, "theme")
 			}
 			if listensPending > 0 {
-				parts = append(parts, strconv.Itoa(listensPending)+" listens (pending restart)")
+				parts = append(parts, strconv.Itoa(listensPending)+" listens (importing)")
 			}
 
 			if len(parts) > 0 {
@@ -153,6 +159,7 @@ func ImportHandler(store db.DB) http.HandlerFunc {
 			"prefsRestored":  prefsRestored,
 			"themeRestored":  themeRestored,
 			"listensPending": listensPending,
+			"jobId":          jobId,
 		})
 	}
 }
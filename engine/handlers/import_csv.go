@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importer"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// GetCsvImportProfilesHandler returns the CSV import profiles saved by the
+// user, followed by the built-in ones.
+func GetCsvImportProfilesHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetCsvImportProfilesHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("GetCsvImportProfilesHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		profiles, err := importer.CsvProfiles(ctx, store, user.ID)
+		if err != nil {
+			l.Err(err).Msg("GetCsvImportProfilesHandler: Failed to get CSV import profiles")
+			utils.WriteError(w, "failed to get csv import profiles", http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, profiles)
+	}
+}
+
+// SaveCsvImportProfileHandler creates a CSV import profile, or replaces the
+// user's profile with the same name.
+func SaveCsvImportProfileHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("SaveCsvImportProfileHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("SaveCsvImportProfileHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("SaveCsvImportProfileHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		profile := models.CsvImportProfile{
+			Name:            strings.TrimSpace(r.FormValue("name")),
+			Delimiter:       r.FormValue("delimiter"),
+			ArtistColumn:    r.FormValue("artist_column"),
+			TitleColumn:     r.FormValue("title_column"),
+			AlbumColumn:     r.FormValue("album_column"),
+			TimestampColumn: r.FormValue("timestamp_column"),
+			TimestampFormat: r.FormValue("timestamp_format"),
+			Timezone:        r.FormValue("timezone"),
+			DurationColumn:  r.FormValue("duration_column"),
+			DurationUnit:    models.CsvDurationUnit(strings.ToLower(r.FormValue("duration_unit"))),
+			MbzIDColumn:     r.FormValue("mbid_column"),
+			FilterColumn:    r.FormValue("filter_column"),
+			FilterValue:     r.FormValue("filter_value"),
+			PlayedColumn:    r.FormValue("played_column"),
+		}
+		if profile.Delimiter == "" {
+			profile.Delimiter = ","
+		}
+		if profile.Timezone == "" {
+			profile.Timezone = "UTC"
+		}
+		if profile.DurationUnit == "" {
+			profile.DurationUnit = models.CsvDurationSeconds
+		}
+		if importer.IsBuiltinCsvProfile(profile.Name) {
+			utils.WriteError(w, "name is taken by a built-in profile", http.StatusConflict)
+			return
+		}
+		if err := importer.ValidateCsvProfile(profile); err != nil {
+			l.Debug().AnErr("error", err).Msg("SaveCsvImportProfileHandler: Invalid CSV import profile")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		saved, err := store.SaveCsvImportProfile(ctx, user.ID, &profile)
+		if err != nil {
+			l.Err(err).Msg("SaveCsvImportProfileHandler: Failed to save CSV import profile")
+			utils.WriteError(w, "failed to save csv import profile", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("SaveCsvImportProfileHandler: Saved CSV import profile '%s'", saved.Name)
+		utils.WriteJSON(w, http.StatusOK, saved)
+	}
+}
+
+func DeleteCsvImportProfileHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("DeleteCsvImportProfileHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("DeleteCsvImportProfileHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			utils.WriteError(w, "name is required", http.StatusBadRequest)
+			return
+		}
+
+		ok, err := store.DeleteCsvImportProfile(ctx, user.ID, name)
+		if err != nil {
+			l.Err(err).Msg("DeleteCsvImportProfileHandler: Failed to delete CSV import profile")
+			utils.WriteError(w, "failed to delete csv import profile", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			utils.WriteError(w, "csv import profile not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importer"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importjob"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// the largest file an import job can be created with
+const maxImportJobSize = 1 << 30
+
+// CreateImportJobHandler queues an import of the file that is the body of the
+// request. The format is detected from filename unless given with format.
+// The CSV import profile can be given with profile, and the timezone of
+// scrobbler.log files that do not know theirs with tz.
+func CreateImportJobHandler(store db.DB) http.HandlerFunc {
+	return importJobHandler(store, "CreateImportJobHandler", "")
+}
+
+// ImportScrobblerLogHandler queues an import of the .scrobbler.log that is the
+// body of the request, like CreateImportJobHandler with format scrobbler_log.
+func ImportScrobblerLogHandler(store db.DB) http.HandlerFunc {
+	return importJobHandler(store, "ImportScrobblerLogHandler", models.ImportScrobblerLog)
+}
+
+// ImportCsvHandler queues an import of the CSV file that is the body of the
+// request, like CreateImportJobHandler with format csv.
+func ImportCsvHandler(store db.DB) http.HandlerFunc {
+	return importJobHandler(store, "ImportCsvHandler", models.ImportCsv)
+}
+
+// importJobHandler queues an import of the file that is the body of the
+// request as fixed, or as the format given with format or detected from
+// filename when fixed is empty.
+func importJobHandler(store db.DB, handler string, fixed models.ImportFormat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msgf("%s: Received request", handler)
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msgf("%s: Invalid user context", handler)
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		format := fixed
+		if format == "" {
+			format = models.ImportFormat(query.Get("format"))
+		}
+		switch format {
+		case "", models.ImportSpotify, models.ImportMaloja, models.ImportLastFM, models.ImportListenBrainz,
+			models.ImportYouTubeMusic, models.ImportScrobblerLog, models.ImportCsv, models.ImportBeatScrobble:
+		default:
+			l.Debug().Msgf("%s: Invalid format '%s'", handler, format)
+			utils.WriteError(w, "format is invalid", http.StatusBadRequest)
+			return
+		}
+		filename := query.Get("filename")
+		if filename == "" && format == "" {
+			utils.WriteError(w, "filename or format is required", http.StatusBadRequest)
+			return
+		}
+		if tz := query.Get("tz"); tz != "" {
+			if _, err := time.LoadLocation(tz); err != nil {
+				l.Debug().AnErr("error", err).Msgf("%s: Invalid tz parameter", handler)
+				utils.WriteError(w, "tz is not a valid timezone", http.StatusBadRequest)
+				return
+			}
+		}
+
+		job, err := importjob.Create(ctx, store, importjob.CreateOpts{
+			UserID:   user.ID,
+			Format:   format,
+			Filename: filename,
+			Options: models.ImportJobOptions{
+				CsvProfile: query.Get("profile"),
+				Timezone:   query.Get("tz"),
+			},
+			File: http.MaxBytesReader(w, r.Body, maxImportJobSize),
+		})
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case err == nil:
+		case errors.As(err, &maxBytesErr):
+			utils.WriteError(w, "file is too large", http.StatusRequestEntityTooLarge)
+			return
+		case errors.Is(err, importjob.ErrUnknownFormat):
+			utils.WriteError(w, "the format of the file could not be detected from its name, set format", http.StatusBadRequest)
+			return
+		case errors.Is(err, importjob.ErrCsvProfileNotFound):
+			utils.WriteError(w, "csv import profile not found", http.StatusNotFound)
+			return
+		case errors.Is(err, importer.ErrNoCsvProfile):
+			utils.WriteError(w, "the columns of the file do not match any csv import profile", http.StatusBadRequest)
+			return
+		case errors.Is(err, importjob.ErrInvalidFile):
+			l.Debug().AnErr("error", err).Msgf("%s: Invalid import file", handler)
+			utils.WriteError(w, "the file is not a valid import file of its format", http.StatusBadRequest)
+			return
+		default:
+			l.Err(err).Msgf("%s: Failed to create import job", handler)
+			utils.WriteError(w, "failed to create import job", http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusAccepted, job)
+	}
+}
+
+// GetImportJobsHandler lists the user's import jobs, newest first.
+func GetImportJobsHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetImportJobsHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("GetImportJobsHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		limit := 20
+		if s := r.URL.Query().Get("limit"); s != "" {
+			var err error
+			limit, err = strconv.Atoi(s)
+			if err != nil || limit < 1 || limit > 100 {
+				l.Debug().Msg("GetImportJobsHandler: Invalid limit parameter")
+				utils.WriteError(w, "limit must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+		}
+
+		jobs, err := store.GetImportJobsByUserID(ctx, user.ID, limit)
+		if err != nil {
+			l.Err(err).Msg("GetImportJobsHandler: Failed to get import jobs")
+			utils.WriteError(w, "failed to get import jobs", http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, jobs)
+	}
+}
+
+// importJobFromRequest returns the user's import job with the id given with
+// id, writing the error response and returning nil when there is none.
+func importJobFromRequest(w http.ResponseWriter, r *http.Request, store db.DB, handler string) *models.ImportJob {
+	ctx := r.Context()
+	l := logger.FromContext(ctx)
+
+	user := middleware.GetUserFromContext(ctx)
+	if user == nil {
+		l.Debug().Msgf("%s: Invalid user context", handler)
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return nil
+	}
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		l.Debug().AnErr("error", err).Msgf("%s: Invalid id parameter", handler)
+		utils.WriteError(w, "id is required", http.StatusBadRequest)
+		return nil
+	}
+	job, err := store.GetImportJob(ctx, id)
+	if err != nil {
+		l.Err(err).Msgf("%s: Failed to get import job", handler)
+		utils.WriteError(w, "failed to get import job", http.StatusInternalServerError)
+		return nil
+	}
+	if job == nil || job.UserID != user.ID {
+		utils.WriteError(w, "import job not found", http.StatusNotFound)
+		return nil
+	}
+	return job
+}
+
+// GetImportJobHandler returns the import job with the id given with id.
+func GetImportJobHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		l.Debug().Msg("GetImportJobHandler: Received request")
+
+		job := importJobFromRequest(w, r, store, "GetImportJobHandler")
+		if job == nil {
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, job)
+	}
+}
+
+// ImportJobStreamHandler streams the progress of the import job with the id
+// given with id as server-sent events, until the job is done.
+func ImportJobStreamHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("ImportJobStreamHandler: Received request")
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			utils.WriteError(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		job := importJobFromRequest(w, r, store, "ImportJobStreamHandler")
+		if job == nil {
+			return
+		}
+		// get the job again once watching, so that no progress is missed in
+		// between
+		updates, stop := importjob.Watch(job.ID)
+		defer stop()
+		job, err := store.GetImportJob(ctx, job.ID)
+		if err != nil || job == nil {
+			l.Err(err).Msg("ImportJobStreamHandler: Failed to get import job")
+			utils.WriteError(w, "failed to get import job", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		sendProgress := func(job models.ImportJob) {
+			jsonData, err := json.Marshal(job)
+			if err != nil {
+				l.Err(err).Msg("ImportJobStreamHandler: Failed to marshal import job")
+				return
+			}
+			fmt.Fprintf(w, "event: progress\ndata: %s\n\n", jsonData)
+			flusher.Flush()
+		}
+
+		sendProgress(*job)
+		if job.Status.Done() {
+			return
+		}
+
+		keepAlive := time.NewTicker(streamKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				l.Debug().Msgf("ImportJobStreamHandler: Client for import job %d disconnected", job.ID)
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				flusher.Flush()
+			case update := <-updates:
+				sendProgress(update)
+				if update.Status.Done() {
+					return
+				}
+			}
+		}
+	}
+}
+
+// CancelImportJobHandler cancels the import job with the id given with id. A
+// running job stops after the item it is on.
+func CancelImportJobHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("CancelImportJobHandler: Received request")
+
+		job := importJobFromRequest(w, r, store, "CancelImportJobHandler")
+		if job == nil {
+			return
+		}
+
+		ok, err := importjob.Cancel(ctx, store, job.UserID, job.ID)
+		if err != nil {
+			l.Err(err).Msg("CancelImportJobHandler: Failed to cancel import job")
+			utils.WriteError(w, "failed to cancel import job", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			utils.WriteError(w, "import job has already finished", http.StatusConflict)
+			return
+		}
+
+		l.Info().Msgf("CancelImportJobHandler: Canceled import job %d", job.ID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/ingest"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// GetDeadLettersHandler lists the user's submissions that failed to be
+// processed too many times.
+func GetDeadLettersHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetDeadLettersHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("GetDeadLettersHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		opts := OptsFromRequest(r)
+		opts.UserID = user.ID
+
+		resp, err := store.GetDeadListenSubmissionsPaginated(ctx, opts)
+		if err != nil {
+			l.Err(err).Msg("GetDeadLettersHandler: Failed to get dead letters")
+			utils.WriteError(w, "failed to get dead letters", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetDeadLettersHandler: Retrieved %d dead letters", len(resp.Items))
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// RetryDeadLetterHandler puts a dead submission back into the ingest queue.
+func RetryDeadLetterHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("RetryDeadLetterHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("RetryDeadLetterHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("RetryDeadLetterHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.RequeueDeadListenSubmission(ctx, id, user.ID)
+		if err != nil {
+			l.Err(err).Msg("RetryDeadLetterHandler: Failed to requeue dead letter")
+			utils.WriteError(w, "failed to retry dead letter", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msgf("RetryDeadLetterHandler: Dead letter %d not found", id)
+			utils.WriteError(w, "dead letter not found", http.StatusNotFound)
+			return
+		}
+		ingest.Wake()
+
+		l.Debug().Msgf("RetryDeadLetterHandler: Requeued dead letter %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func DeleteDeadLetterHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("DeleteDeadLetterHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("DeleteDeadLetterHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("DeleteDeadLetterHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.DeleteDeadListenSubmission(ctx, id, user.ID)
+		if err != nil {
+			l.Err(err).Msg("DeleteDeadLetterHandler: Failed to delete dead letter")
+			utils.WriteError(w, "failed to delete dead letter", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msgf("DeleteDeadLetterHandler: Dead letter %d not found", id)
+			utils.WriteError(w, "dead letter not found", http.StatusNotFound)
+			return
+		}
+
+		l.Debug().Msgf("DeleteDeadLetterHandler: Deleted dead letter %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/ingest"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/go-chi/httprate"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// error codes defined by the Last.fm API
+const (
+	lfmErrInvalidMethod    = 3
+	lfmErrAuthFailed       = 4
+	lfmErrInvalidParams    = 6
+	lfmErrInvalidSession   = 9
+	lfmErrInvalidApiKey    = 10
+	lfmErrInvalidSignature = 13
+	lfmErrTemporary        = 16
+	lfmErrRateLimited      = 29
+)
+
+// codes used in the ignoredMessage of a scrobble
+const (
+	lfmIgnoredNone         = "0"
+	lfmIgnoredArtist       = "1"
+	lfmIgnoredTrack        = "2"
+	lfmIgnoredTimestampOld = "3"
+	lfmIgnoredTimestampNew = "4"
+)
+
+const (
+	maxLfmScrobblesPerRequest = 50
+	// scrobbles further in the future than this are ignored
+	lfmMaxClockSkew = 10 * time.Minute
+)
+
+type LfmError struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Code    int      `json:"error" xml:"code,attr"`
+	Message string   `json:"message" xml:",chardata"`
+}
+
+type LfmSession struct {
+	XMLName    xml.Name `json:"-" xml:"session"`
+	Name       string   `json:"name" xml:"name"`
+	Key        string   `json:"key" xml:"key"`
+	Subscriber int      `json:"subscriber" xml:"subscriber"`
+}
+
+type LfmCorrectable struct {
+	Corrected string `json:"corrected" xml:"corrected,attr"`
+	Text      string `json:"#text" xml:",chardata"`
+}
+
+type LfmIgnoredMessage struct {
+	Code string `json:"code" xml:"code,attr"`
+	Text string `json:"#text" xml:",chardata"`
+}
+
+type LfmNowPlaying struct {
+	XMLName        xml.Name          `json:"-" xml:"nowplaying"`
+	Track          LfmCorrectable    `json:"track" xml:"track"`
+	Artist         LfmCorrectable    `json:"artist" xml:"artist"`
+	Album          LfmCorrectable    `json:"album" xml:"album"`
+	AlbumArtist    LfmCorrectable    `json:"albumArtist" xml:"albumArtist"`
+	IgnoredMessage LfmIgnoredMessage `json:"ignoredMessage" xml:"ignoredMessage"`
+}
+
+type LfmScrobble struct {
+	Track          LfmCorrectable    `json:"track" xml:"track"`
+	Artist         LfmCorrectable    `json:"artist" xml:"artist"`
+	Album          LfmCorrectable    `json:"album" xml:"album"`
+	AlbumArtist    LfmCorrectable    `json:"albumArtist" xml:"albumArtist"`
+	Timestamp      string            `json:"timestamp" xml:"timestamp"`
+	IgnoredMessage LfmIgnoredMessage `json:"ignoredMessage" xml:"ignoredMessage"`
+}
+
+type LfmScrobblesAttr struct {
+	Accepted int `json:"accepted"`
+	Ignored  int `json:"ignored"`
+}
+
+// LfmScrobbles carries the accepted and ignored counts twice because Last.fm
+// reports them as attributes in XML but under "@attr" in JSON.
+type LfmScrobbles struct {
+	XMLName  xml.Name         `json:"-" xml:"scrobbles"`
+	Accepted int              `json:"-" xml:"accepted,attr"`
+	Ignored  int              `json:"-" xml:"ignored,attr"`
+	Scrobble []LfmScrobble    `json:"scrobble" xml:"scrobble"`
+	Attr     LfmScrobblesAttr `json:"@attr" xml:"-"`
+}
+
+type lfmXMLResponse struct {
+	XMLName xml.Name `xml:"lfm"`
+	Status  string   `xml:"status,attr"`
+	Body    any
+}
+
+// writeLfmResponse writes body in the format requested by the client. Last.fm
+// defaults to XML, and wraps JSON responses in an object keyed by name.
+func writeLfmResponse(w http.ResponseWriter, format string, status int, name string, body any) {
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if name == "" {
+			json.NewEncoder(w).Encode(body)
+		} else {
+			json.NewEncoder(w).Encode(map[string]any{name: body})
+		}
+		return
+	}
+	resp := lfmXMLResponse{Status: "ok", Body: body}
+	if status != http.StatusOK {
+		resp.Status = "failed"
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(resp)
+}
+
+func writeLfmError(w http.ResponseWriter, format string, code int, message string) {
+	status := http.StatusBadRequest
+	switch code {
+	case lfmErrAuthFailed, lfmErrInvalidSession, lfmErrInvalidApiKey:
+		status = http.StatusForbidden
+	case lfmErrTemporary:
+		status = http.StatusServiceUnavailable
+	}
+	writeLfmResponse(w, format, status, "", LfmError{Code: code, Message: message})
+}
+
+// LastFMSignature computes the api_sig of a request as defined by the Last.fm
+// API: the md5 of every parameter name and value ordered by name, followed by
+// the shared secret. The format, callback and api_sig parameters are not signed.
+func LastFMSignature(params url.Values, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k == "format" || k == "callback" || k == "api_sig" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString(params.Get(k))
+	}
+	b.WriteString(secret)
+	return md5Hex(b.String())
+}
+
+// LastFMHandler implements the scrobbling subset of the Last.fm 2.0 API so that
+// Last.fm-only clients can submit to Beat Scrobble. Session keys are the user's
+// API keys. Signatures are only verified when a shared secret is configured,
+// since most clients ship with their own Last.fm credentials. Passwords are
+// only checked as often as loginLimiter allows, unless it is nil.
+func LastFMHandler(store db.DB, mbzc mbz.MusicBrainzCaller, loginLimiter *httprate.RateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("LastFMHandler: Failed to parse form")
+			writeLfmError(w, "", lfmErrInvalidParams, "Invalid parameters")
+			return
+		}
+		format := strings.ToLower(r.Form.Get("format"))
+		method := strings.ToLower(r.Form.Get("method"))
+
+		l.Debug().Msgf("LastFMHandler: Received request for method '%s'", method)
+
+		if r.Form.Get("api_key") == "" {
+			l.Debug().Msg("LastFMHandler: Request is missing api_key")
+			writeLfmError(w, format, lfmErrInvalidApiKey, "Invalid API key - You must be granted a valid key by last.fm")
+			return
+		}
+
+		if secret := cfg.LastFMApiSecret(); secret != "" {
+			if !strings.EqualFold(r.Form.Get("api_sig"), LastFMSignature(r.Form, secret)) {
+				l.Debug().Msg("LastFMHandler: Invalid method signature")
+				writeLfmError(w, format, lfmErrInvalidSignature, "Invalid method signature supplied")
+				return
+			}
+		}
+
+		switch method {
+		case "auth.getmobilesession":
+			lfmGetMobileSession(w, r, store, format, loginLimiter)
+		case "track.updatenowplaying":
+			lfmUpdateNowPlaying(w, r, store, mbzc, format)
+		case "track.scrobble":
+			lfmScrobble(w, r, store, mbzc, format)
+		default:
+			l.Debug().Msgf("LastFMHandler: Unsupported method '%s'", method)
+			writeLfmError(w, format, lfmErrInvalidMethod, "Invalid Method - No method with that name in this package")
+		}
+	}
+}
+
+// lfmGetMobileSession exchanges a username and password for a session key. The
+// password may either be one of the user's API keys, which is then used as the
+// session key, or the account password, in which case the user's first API key
+// is returned.
+func lfmGetMobileSession(w http.ResponseWriter, r *http.Request, store db.DB, format string, loginLimiter *httprate.RateLimiter) {
+	ctx := r.Context()
+	l := logger.FromContext(ctx)
+
+	// counted against the same key as /login, whose limiter keys every
+	// request alike
+	if loginLimiter != nil && loginLimiter.OnLimit(w, r, "*") {
+		l.Debug().Msg("lfmGetMobileSession: Too many attempts")
+		writeLfmError(w, format, lfmErrRateLimited, "Rate Limit Exceeded - Your IP has made too many requests in a short period")
+		return
+	}
+
+	username := r.Form.Get("username")
+	password := r.Form.Get("password")
+	if username == "" || password == "" {
+		l.Debug().Msg("lfmGetMobileSession: Missing credentials")
+		writeLfmError(w, format, lfmErrInvalidParams, "Invalid parameters - username and password are required")
+		return
+	}
+
+	u, err := store.GetUserByApiKey(ctx, password)
+	if err != nil {
+		l.Err(err).Msg("lfmGetMobileSession: Failed to get user by api key")
+		writeLfmError(w, format, lfmErrTemporary, "There was a temporary error processing your request. Please try again")
+		return
+	}
+	if u != nil && strings.EqualFold(u.Username, username) {
+		l.Debug().Msgf("lfmGetMobileSession: Authenticated user '%s' with api key", u.Username)
+		writeLfmResponse(w, format, http.StatusOK, "session", LfmSession{Name: u.Username, Key: password})
+		return
+	}
+
+	u, err = store.GetUserByUsername(ctx, username)
+	if err != nil {
+		l.Err(err).Msg("lfmGetMobileSession: Failed to get user by username")
+		writeLfmError(w, format, lfmErrTemporary, "There was a temporary error processing your request. Please try again")
+		return
+	}
+	if u == nil || bcrypt.CompareHashAndPassword(u.Password, []byte(password)) != nil {
+		l.Debug().Msg("lfmGetMobileSession: Invalid credentials")
+		writeLfmError(w, format, lfmErrAuthFailed, "Authentication Failed - You do not have permissions to access the service")
+		return
+	}
+
+	keys, err := store.GetApiKeysByUserID(ctx, u.ID)
+	if err != nil {
+		l.Err(err).Msg("lfmGetMobileSession: Failed to get api keys for user")
+		writeLfmError(w, format, lfmErrTemporary, "There was a temporary error processing your request. Please try again")
+		return
+	}
+	if len(keys) < 1 {
+		l.Debug().Msgf("lfmGetMobileSession: User '%s' has no api keys", u.Username)
+		writeLfmError(w, format, lfmErrAuthFailed, "Authentication Failed - Generate an API key before signing in")
+		return
+	}
+
+	l.Debug().Msgf("lfmGetMobileSession: Authenticated user '%s' with password", u.Username)
+	writeLfmResponse(w, format, http.StatusOK, "session", LfmSession{Name: u.Username, Key: keys[0].Key})
+}
+
+// lfmUserFromSession returns the user owning the session key of the request,
+// writing an error response when it is invalid.
+func lfmUserFromSession(w http.ResponseWriter, r *http.Request, store db.DB, format string) *models.User {
+	l := logger.FromContext(r.Context())
+
+	sk := r.Form.Get("sk")
+	if sk == "" {
+		writeLfmError(w, format, lfmErrInvalidSession, "Invalid session key - Please re-authenticate")
+		return nil
+	}
+	u, err := store.GetUserByApiKey(r.Context(), sk)
+	if err != nil {
+		l.Err(err).Msg("lfmUserFromSession: Failed to get user by api key")
+		writeLfmError(w, format, lfmErrTemporary, "There was a temporary error processing your request. Please try again")
+		return nil
+	}
+	if u == nil {
+		l.Debug().Msg("lfmUserFromSession: Session key does not exist")
+		writeLfmError(w, format, lfmErrInvalidSession, "Invalid session key - Please re-authenticate")
+		return nil
+	}
+	return u
+}
+
+// lfmParam reads an indexed parameter such as artist[3] from a batch request,
+// or the plain parameter when i is negative.
+func lfmParam(form url.Values, name string, i int) string {
+	if i < 0 {
+		return strings.TrimSpace(form.Get(name))
+	}
+	return strings.TrimSpace(form.Get(fmt.Sprintf("%s[%d]", name, i)))
+}
+
+type lfmTrack struct {
+	Artist         string
+	Track          string
+	Album          string
+	AlbumArtist    string
+	Timestamp      string
+	Duration       int32
+	RecordingMbzID uuid.UUID
+}
+
+func lfmTrackFromForm(form url.Values, i int) lfmTrack {
+	t := lfmTrack{
+		Artist:      lfmParam(form, "artist", i),
+		Track:       lfmParam(form, "track", i),
+		Album:       lfmParam(form, "album", i),
+		AlbumArtist: lfmParam(form, "albumArtist", i),
+		Timestamp:   lfmParam(form, "timestamp", i),
+	}
+	if d, err := strconv.Atoi(lfmParam(form, "duration", i)); err == nil && d > 0 {
+		t.Duration = int32(d)
+	}
+	if id, err := uuid.Parse(lfmParam(form, "mbid", i)); err == nil {
+		t.RecordingMbzID = id
+	}
+	return t
+}
+
+func (t lfmTrack) submitOpts(mbzc mbz.MusicBrainzCaller, userId int32) catalog.SubmitListenOpts {
+	return catalog.SubmitListenOpts{
+		MbzCaller:      mbzc,
+		Artist:         t.Artist,
+		TrackTitle:     t.Track,
+		RecordingMbzID: t.RecordingMbzID,
+		ReleaseTitle:   t.Album,
+		Duration:       t.Duration,
+		UserID:         userId,
+	}
+}
+
+func lfmUpdateNowPlaying(w http.ResponseWriter, r *http.Request, store db.DB, mbzc mbz.MusicBrainzCaller, format string) {
+	l := logger.FromContext(r.Context())
+
+	u := lfmUserFromSession(w, r, store, format)
+	if u == nil {
+		return
+	}
+
+	t := lfmTrackFromForm(r.Form, -1)
+	if t.Artist == "" || t.Track == "" {
+		l.Debug().Msg("lfmUpdateNowPlaying: Artist name or track name are missing")
+		writeLfmError(w, format, lfmErrInvalidParams, "Invalid parameters - artist and track are required")
+		return
+	}
+
+	opts := t.submitOpts(mbzc, u.ID)
+	opts.Time = time.Now()
+	opts.IsNowPlaying = true
+	opts.SkipSaveListen = true
+	if err := catalog.SubmitListen(r.Context(), store, opts); err != nil {
+		l.Err(err).Msg("lfmUpdateNowPlaying: Failed to submit now playing")
+		writeLfmError(w, format, lfmErrTemporary, "There was a temporary error processing your request. Please try again")
+		return
+	}
+
+	writeLfmResponse(w, format, http.StatusOK, "nowplaying", LfmNowPlaying{
+		Track:          LfmCorrectable{Corrected: "0", Text: t.Track},
+		Artist:         LfmCorrectable{Corrected: "0", Text: t.Artist},
+		Album:          LfmCorrectable{Corrected: "0", Text: t.Album},
+		AlbumArtist:    LfmCorrectable{Corrected: "0", Text: t.AlbumArtist},
+		IgnoredMessage: LfmIgnoredMessage{Code: lfmIgnoredNone},
+	})
+}
+
+func lfmScrobble(w http.ResponseWriter, r *http.Request, store db.DB, mbzc mbz.MusicBrainzCaller, format string) {
+	l := logger.FromContext(r.Context())
+
+	u := lfmUserFromSession(w, r, store, format)
+	if u == nil {
+		return
+	}
+
+	var tracks []lfmTrack
+	for i := 0; i < maxLfmScrobblesPerRequest; i++ {
+		if _, ok := r.Form[fmt.Sprintf("artist[%d]", i)]; !ok {
+			break
+		}
+		tracks = append(tracks, lfmTrackFromForm(r.Form, i))
+	}
+	if len(tracks) == 0 && r.Form.Has("artist") {
+		tracks = append(tracks, lfmTrackFromForm(r.Form, -1))
+	}
+	if len(tracks) == 0 {
+		l.Debug().Msg("lfmScrobble: Request contains no scrobbles")
+		writeLfmError(w, format, lfmErrInvalidParams, "Invalid parameters - no scrobbles were supplied")
+		return
+	}
+
+	resp := LfmScrobbles{Scrobble: make([]LfmScrobble, 0, len(tracks))}
+	var accepted []catalog.SubmitListenOpts
+	for _, t := range tracks {
+		result := LfmScrobble{
+			Track:          LfmCorrectable{Corrected: "0", Text: t.Track},
+			Artist:         LfmCorrectable{Corrected: "0", Text: t.Artist},
+			Album:          LfmCorrectable{Corrected: "0", Text: t.Album},
+			AlbumArtist:    LfmCorrectable{Corrected: "0", Text: t.AlbumArtist},
+			Timestamp:      t.Timestamp,
+			IgnoredMessage: LfmIgnoredMessage{Code: lfmIgnoredNone},
+		}
+
+		unix, err := strconv.ParseInt(t.Timestamp, 10, 64)
+		switch {
+		case t.Artist == "":
+			result.IgnoredMessage = LfmIgnoredMessage{Code: lfmIgnoredArtist, Text: "Artist was ignored"}
+		case t.Track == "":
+			result.IgnoredMessage = LfmIgnoredMessage{Code: lfmIgnoredTrack, Text: "Track was ignored"}
+		case err != nil || unix <= 0:
+			result.IgnoredMessage = LfmIgnoredMessage{Code: lfmIgnoredTimestampOld, Text: "Timestamp is invalid"}
+		case time.Unix(unix, 0).After(time.Now().Add(lfmMaxClockSkew)):
+			result.IgnoredMessage = LfmIgnoredMessage{Code: lfmIgnoredTimestampNew, Text: "Timestamp was too new"}
+		}
+		if result.IgnoredMessage.Code != lfmIgnoredNone {
+			l.Debug().Msgf("lfmScrobble: Ignoring scrobble: %s", result.IgnoredMessage.Text)
+			resp.Ignored++
+			resp.Scrobble = append(resp.Scrobble, result)
+			continue
+		}
+
+		opts := t.submitOpts(mbzc, u.ID)
+		opts.Time = time.Unix(unix, 0)
+		accepted = append(accepted, opts)
+		resp.Accepted++
+		resp.Scrobble = append(resp.Scrobble, result)
+	}
+
+	// the batch is queued as a whole, so that a client retrying after an
+	// error does not submit any of it twice
+	if len(accepted) > 0 {
+		if err := ingest.EnqueueAll(r.Context(), store, accepted); err != nil {
+			l.Err(err).Msg("lfmScrobble: Failed to queue listens")
+			writeLfmError(w, format, lfmErrTemporary, "There was a temporary error processing your request. Please try again")
+			return
+		}
+	}
+	resp.Attr = LfmScrobblesAttr{Accepted: resp.Accepted, Ignored: resp.Ignored}
+
+	l.Debug().Msgf("lfmScrobble: Accepted %d scrobbles, ignored %d", resp.Accepted, resp.Ignored)
+	writeLfmResponse(w, format, http.StatusOK, "scrobbles", resp)
+}
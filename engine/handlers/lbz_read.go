@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// number of items returned when the request does not specify a count, same as ListenBrainz
+const lbzDefaultItemCount = 25
+
+type LbzErrorResponse struct {
+	Code  int    `json:"code"`
+	Error string `json:"error"`
+}
+
+type LbzListen struct {
+	ListenedAt int64              `json:"listened_at,omitempty"`
+	PlayingNow bool               `json:"playing_now,omitempty"`
+	UserName   string             `json:"user_name"`
+	TrackMeta  LbzListenTrackMeta `json:"track_metadata"`
+}
+
+type LbzListenTrackMeta struct {
+	ArtistName     string            `json:"artist_name"`
+	TrackName      string            `json:"track_name"`
+	ReleaseName    string            `json:"release_name,omitempty"`
+	AdditionalInfo LbzAdditionalInfo `json:"additional_info"`
+}
+
+type LbzListensPayload struct {
+	Count          int         `json:"count"`
+	LatestListenTs int64       `json:"latest_listen_ts"`
+	Listens        []LbzListen `json:"listens"`
+	PlayingNow     bool        `json:"playing_now,omitempty"`
+	UserID         string      `json:"user_id"`
+}
+
+type LbzListensResponse struct {
+	Payload LbzListensPayload `json:"payload"`
+}
+
+type LbzStatsArtist struct {
+	ArtistMBID  *string  `json:"artist_mbid"`
+	ArtistMBIDs []string `json:"artist_mbids"`
+	ArtistName  string   `json:"artist_name"`
+	ListenCount int64    `json:"listen_count"`
+}
+
+type LbzStatsRelease struct {
+	ArtistMBIDs []string `json:"artist_mbids"`
+	ArtistName  string   `json:"artist_name"`
+	ListenCount int64    `json:"listen_count"`
+	ReleaseMBID *string  `json:"release_mbid"`
+	ReleaseName string   `json:"release_name"`
+}
+
+type LbzStatsRecording struct {
+	ArtistMBIDs   []string `json:"artist_mbids"`
+	ArtistName    string   `json:"artist_name"`
+	ListenCount   int64    `json:"listen_count"`
+	RecordingMBID *string  `json:"recording_mbid"`
+	ReleaseMBID   *string  `json:"release_mbid"`
+	ReleaseName   string   `json:"release_name,omitempty"`
+	TrackName     string   `json:"track_name"`
+}
+
+type LbzStatsPayload struct {
+	Count       int    `json:"count"`
+	Offset      int    `json:"offset"`
+	Range       string `json:"range"`
+	FromTs      int64  `json:"from_ts"`
+	ToTs        int64  `json:"to_ts"`
+	LastUpdated int64  `json:"last_updated"`
+	UserID      string `json:"user_id"`
+}
+
+type LbzArtistStatsPayload struct {
+	Artists          []LbzStatsArtist `json:"artists"`
+	TotalArtistCount int64            `json:"total_artist_count"`
+	LbzStatsPayload
+}
+
+type LbzReleaseStatsPayload struct {
+	Releases          []LbzStatsRelease `json:"releases"`
+	TotalReleaseCount int64             `json:"total_release_count"`
+	LbzStatsPayload
+}
+
+type LbzRecordingStatsPayload struct {
+	Recordings          []LbzStatsRecording `json:"recordings"`
+	TotalRecordingCount int64               `json:"total_recording_count"`
+	LbzStatsPayload
+}
+
+type LbzStatsResponse[T any] struct {
+	Payload T `json:"payload"`
+}
+
+func writeLbzError(w http.ResponseWriter, message string, code int) {
+	utils.WriteJSON(w, code, LbzErrorResponse{Code: code, Error: message})
+}
+
+// lbzUserFromRequest looks up the user named in the url, writing a ListenBrainz
+// style error when it does not exist, or when the request was made with the API
+// key of another user.
+func lbzUserFromRequest(w http.ResponseWriter, r *http.Request, store db.DB) *models.User {
+	l := logger.FromContext(r.Context())
+
+	username := chi.URLParam(r, "username")
+	u, err := store.GetUserByUsername(r.Context(), username)
+	if err != nil {
+		l.Err(err).Msg("lbzUserFromRequest: Failed to get user by username")
+		writeLbzError(w, "internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	if u == nil {
+		writeLbzError(w, "Cannot find user: "+username, http.StatusNotFound)
+		return nil
+	}
+	// with the login gate on, users can only read their own listens
+	if keyUser := middleware.GetUserFromContext(r.Context()); keyUser != nil && keyUser.ID != u.ID {
+		l.Debug().Msgf("lbzUserFromRequest: User %d is not allowed to read listens of user %d", keyUser.ID, u.ID)
+		writeLbzError(w, "You are not allowed to access the listens of "+username, http.StatusForbidden)
+		return nil
+	}
+	return u
+}
+
+// lbzCountFromRequest parses the count parameter, capped to the same maximum
+// ListenBrainz accepts for submissions.
+func lbzCountFromRequest(r *http.Request) (int, bool) {
+	countStr := r.URL.Query().Get("count")
+	if countStr == "" {
+		return lbzDefaultItemCount, true
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 {
+		return 0, false
+	}
+	if count > maxListensPerRequest {
+		count = maxListensPerRequest
+	}
+	return count, true
+}
+
+func lbzArtistName(artists []models.SimpleArtist) string {
+	return strings.Join(utils.FlattenSimpleArtistNames(artists), ", ")
+}
+
+func lbzMbid(id *uuid.UUID) *string {
+	if id == nil || *id == uuid.Nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// LbzGetListensHandler returns the most recent listens of a user. min_ts and
+// max_ts restrict the listens to those strictly after or before the given
+// timestamps.
+func LbzGetListensHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		u := lbzUserFromRequest(w, r, store)
+		if u == nil {
+			return
+		}
+
+		l.Debug().Msgf("LbzGetListensHandler: Received request for listens of user '%s'", u.Username)
+
+		count, ok := lbzCountFromRequest(r)
+		if !ok {
+			writeLbzError(w, "Invalid count parameter", http.StatusBadRequest)
+			return
+		}
+		minTs, err := strconv.Atoi(r.URL.Query().Get("min_ts"))
+		if err != nil && r.URL.Query().Has("min_ts") {
+			writeLbzError(w, "Invalid min_ts parameter", http.StatusBadRequest)
+			return
+		}
+		maxTs, err := strconv.Atoi(r.URL.Query().Get("max_ts"))
+		if err != nil && r.URL.Query().Has("max_ts") {
+			writeLbzError(w, "Invalid max_ts parameter", http.StatusBadRequest)
+			return
+		}
+		if minTs > 0 && maxTs > 0 {
+			writeLbzError(w, "You may only specify max_ts or min_ts, not both.", http.StatusBadRequest)
+			return
+		}
+
+		opts := db.GetItemsOpts{
+			Limit:  count,
+			Page:   1,
+			Period: db.PeriodAllTime,
+			UserID: u.ID,
+		}
+		if minTs > 0 {
+			opts.From = minTs + 1
+			opts.To = int(time.Now().Unix())
+		} else if maxTs > 0 {
+			// From must be non-zero for the range to be used
+			opts.From = 1
+			opts.To = maxTs - 1
+		}
+
+		resp := LbzListensResponse{Payload: LbzListensPayload{
+			Listens: []LbzListen{},
+			UserID:  u.Username,
+		}}
+
+		if count > 0 {
+			listens, err := store.GetListensPaginated(ctx, opts)
+			if err != nil {
+				l.Err(err).Msg("LbzGetListensHandler: Failed to get listens")
+				writeLbzError(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			for _, listen := range listens.Items {
+				resp.Payload.Listens = append(resp.Payload.Listens, lbzListenFromModel(listen, u.Username))
+			}
+		}
+		resp.Payload.Count = len(resp.Payload.Listens)
+
+		latest, err := store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 1, Page: 1, Period: db.PeriodAllTime, UserID: u.ID})
+		if err != nil {
+			l.Err(err).Msg("LbzGetListensHandler: Failed to get latest listen")
+			writeLbzError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if len(latest.Items) > 0 {
+			resp.Payload.LatestListenTs = latest.Items[0].Time.Unix()
+		}
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func lbzListenFromModel(listen *models.Listen, username string) LbzListen {
+	meta := LbzListenTrackMeta{
+		ArtistName: lbzArtistName(listen.Track.Artists),
+		TrackName:  listen.Track.Title,
+		AdditionalInfo: LbzAdditionalInfo{
+			ArtistNames:             utils.FlattenSimpleArtistNames(listen.Track.Artists),
+			MediaPlayer:             listen.MediaPlayer,
+			SubmissionClientVersion: listen.SubmissionClientVersion,
+			DurationMs:              listen.DurationMs,
+			Tags:                    listen.Tags,
+			OriginURL:               listen.OriginURL,
+		},
+	}
+	if listen.Track.Album != nil {
+		meta.ReleaseName = *listen.Track.Album
+	}
+	return LbzListen{
+		ListenedAt: listen.Time.Unix(),
+		UserName:   username,
+		TrackMeta:  meta,
+	}
+}
+
+func LbzPlayingNowHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		u := lbzUserFromRequest(w, r, store)
+		if u == nil {
+			return
+		}
+
+		l.Debug().Msgf("LbzPlayingNowHandler: Received request for user '%s'", u.Username)
+
+		resp := LbzListensResponse{Payload: LbzListensPayload{
+			Listens: []LbzListen{},
+			UserID:  u.Username,
+		}}
+
+		np, ok := catalog.GetNowPlaying(u.ID)
+		if ok {
+			track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: np.TrackID, UserID: u.ID})
+			if err != nil {
+				l.Err(err).Msg("LbzPlayingNowHandler: Failed to get track")
+				writeLbzError(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			info := LbzAdditionalInfo{
+				ArtistNames: utils.FlattenSimpleArtistNames(track.Artists),
+				DurationMs:  track.Duration * 1000,
+			}
+			if mbid := lbzMbid(track.MbzID); mbid != nil {
+				info.RecordingMBID = *mbid
+			}
+			meta := LbzListenTrackMeta{
+				ArtistName:     lbzArtistName(track.Artists),
+				TrackName:      track.Title,
+				AdditionalInfo: info,
+			}
+			if album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: track.AlbumID, UserID: u.ID}); err == nil {
+				meta.ReleaseName = album.Title
+			}
+			resp.Payload.Listens = append(resp.Payload.Listens, LbzListen{
+				PlayingNow: true,
+				UserName:   u.Username,
+				TrackMeta:  meta,
+			})
+			resp.Payload.PlayingNow = true
+		}
+		resp.Payload.Count = len(resp.Payload.Listens)
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// lbzStatsRange maps a ListenBrainz stats range onto a period. The calendar
+// ranges (this_week etc.) are approximated by the rolling period of the same
+// length.
+func lbzStatsRange(s string) (db.Period, bool) {
+	switch s {
+	case "", "all_time":
+		return db.PeriodAllTime, true
+	case "week", "this_week":
+		return db.PeriodWeek, true
+	case "month", "this_month":
+		return db.PeriodMonth, true
+	case "year", "this_year":
+		return db.PeriodYear, true
+	default:
+		return "", false
+	}
+}
+
+type lbzStatsEntity string
+
+const (
+	lbzStatsArtists    lbzStatsEntity = "artists"
+	lbzStatsReleases   lbzStatsEntity = "releases"
+	lbzStatsRecordings lbzStatsEntity = "recordings"
+)
+
+func LbzUserArtistStatsHandler(store db.DB) http.HandlerFunc {
+	return lbzUserStatsHandler(store, lbzStatsArtists)
+}
+
+func LbzUserReleaseStatsHandler(store db.DB) http.HandlerFunc {
+	return lbzUserStatsHandler(store, lbzStatsReleases)
+}
+
+func LbzUserRecordingStatsHandler(store db.DB) http.HandlerFunc {
+	return lbzUserStatsHandler(store, lbzStatsRecordings)
+}
+
+// lbzUserStatsHandler serves the top artists, releases or recordings of a user.
+// ListenBrainz pages by offset, so the offset is rounded down to a multiple of
+// count to match the page based top item queries.
+func lbzUserStatsHandler(store db.DB, entity lbzStatsEntity) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		u := lbzUserFromRequest(w, r, store)
+		if u == nil {
+			return
+		}
+
+		l.Debug().Msgf("lbzUserStatsHandler: Received request for %s stats of user '%s'", entity, u.Username)
+
+		count, ok := lbzCountFromRequest(r)
+		if !ok || count == 0 {
+			writeLbzError(w, "Invalid count parameter", http.StatusBadRequest)
+			return
+		}
+		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+		if (err != nil && r.URL.Query().Has("offset")) || offset < 0 {
+			writeLbzError(w, "Invalid offset parameter", http.StatusBadRequest)
+			return
+		}
+		rangeStr := r.URL.Query().Get("range")
+		period, ok := lbzStatsRange(rangeStr)
+		if !ok {
+			writeLbzError(w, "Invalid range: "+rangeStr, http.StatusBadRequest)
+			return
+		}
+		if rangeStr == "" {
+			rangeStr = "all_time"
+		}
+
+		page := offset/count + 1
+		opts := db.GetItemsOpts{
+			Limit:  count,
+			Page:   page,
+			Period: period,
+			UserID: u.ID,
+		}
+
+		now := time.Now()
+		payload := LbzStatsPayload{
+			Offset:      (page - 1) * count,
+			Range:       rangeStr,
+			FromTs:      db.StartTimeFromPeriod(period).Unix(),
+			ToTs:        now.Unix(),
+			LastUpdated: now.Unix(),
+			UserID:      u.Username,
+		}
+		if period == db.PeriodAllTime {
+			payload.FromTs = 0
+		}
+
+		resp, err := lbzStats(ctx, store, entity, opts, payload)
+		if err != nil {
+			l.Err(err).Msgf("lbzUserStatsHandler: Failed to get top %s", entity)
+			writeLbzError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func lbzStats(ctx context.Context, store db.DB, entity lbzStatsEntity, opts db.GetItemsOpts, base LbzStatsPayload) (any, error) {
+	switch entity {
+	case lbzStatsReleases:
+		albums, err := store.GetTopAlbumsPaginated(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("lbzStats: %w", err)
+		}
+		payload := LbzReleaseStatsPayload{
+			Releases:          make([]LbzStatsRelease, 0, len(albums.Items)),
+			TotalReleaseCount: albums.TotalCount,
+			LbzStatsPayload:   base,
+		}
+		for _, a := range albums.Items {
+			payload.Releases = append(payload.Releases, LbzStatsRelease{
+				ArtistMBIDs: []string{},
+				ArtistName:  lbzArtistName(a.Artists),
+				ListenCount: a.ListenCount,
+				ReleaseMBID: lbzMbid(a.MbzID),
+				ReleaseName: a.Title,
+			})
+		}
+		payload.Count = len(payload.Releases)
+		return LbzStatsResponse[LbzReleaseStatsPayload]{Payload: payload}, nil
+	case lbzStatsRecordings:
+		tracks, err := store.GetTopTracksPaginated(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("lbzStats: %w", err)
+		}
+		payload := LbzRecordingStatsPayload{
+			Recordings:          make([]LbzStatsRecording, 0, len(tracks.Items)),
+			TotalRecordingCount: tracks.TotalCount,
+			LbzStatsPayload:     base,
+		}
+		for _, t := range tracks.Items {
+			item := LbzStatsRecording{
+				ArtistMBIDs:   []string{},
+				ArtistName:    lbzArtistName(t.Artists),
+				ListenCount:   t.ListenCount,
+				RecordingMBID: lbzMbid(t.MbzID),
+				TrackName:     t.Title,
+			}
+			if t.Album != nil {
+				item.ReleaseName = *t.Album
+			}
+			payload.Recordings = append(payload.Recordings, item)
+		}
+		payload.Count = len(payload.Recordings)
+		return LbzStatsResponse[LbzRecordingStatsPayload]{Payload: payload}, nil
+	default:
+		artists, err := store.GetTopArtistsPaginated(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("lbzStats: %w", err)
+		}
+		payload := LbzArtistStatsPayload{
+			Artists:          make([]LbzStatsArtist, 0, len(artists.Items)),
+			TotalArtistCount: artists.TotalCount,
+			LbzStatsPayload:  base,
+		}
+		for _, a := range artists.Items {
+			item := LbzStatsArtist{
+				ArtistMBID:  lbzMbid(a.MbzID),
+				ArtistMBIDs: []string{},
+				ArtistName:  a.Name,
+				ListenCount: a.ListenCount,
+			}
+			if item.ArtistMBID != nil {
+				item.ArtistMBIDs = append(item.ArtistMBIDs, *item.ArtistMBID)
+			}
+			payload.Artists = append(payload.Artists, item)
+		}
+		payload.Count = len(payload.Artists)
+		return LbzStatsResponse[LbzArtistStatsPayload]{Payload: payload}, nil
+	}
+}
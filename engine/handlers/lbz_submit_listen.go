@@ -16,8 +16,10 @@ import (
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/ingest"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -37,43 +39,14 @@ type LbzSubmitListenRequest struct {
 	Payload    []LbzSubmitListenPayload `json:"payload,omitempty"`
 }
 
-type LbzSubmitListenPayload struct {
-	ListenedAt int64        `json:"listened_at,omitempty"`
-	TrackMeta  LbzTrackMeta `json:"track_metadata"`
-}
-
-type LbzTrackMeta struct {
-	ArtistName     string            `json:"artist_name"` // required
-	TrackName      string            `json:"track_name"`  // required
-	ReleaseName    string            `json:"release_name,omitempty"`
-	MBIDMapping    LbzMBIDMapping    `json:"mbid_mapping"`
-	AdditionalInfo LbzAdditionalInfo `json:"additional_info,omitempty"`
-}
-type LbzArtist struct {
-	ArtistMBID string `json:"artist_mbid"`
-	ArtistName string `json:"artist_credit_name"`
-}
-type LbzMBIDMapping struct {
-	ReleaseMBID   string      `json:"release_mbid"`
-	RecordingMBID string      `json:"recording_mbid"`
-	ArtistMBIDs   []string    `json:"artist_mbids"`
-	Artists       []LbzArtist `json:"artists"`
-}
-
-type LbzAdditionalInfo struct {
-	MediaPlayer             string   `json:"media_player,omitempty"`
-	SubmissionClient        string   `json:"submission_client,omitempty"`
-	SubmissionClientVersion string   `json:"submission_client_version,omitempty"`
-	ReleaseMBID             string   `json:"release_mbid,omitempty"`
-	ReleaseGroupMBID        string   `json:"release_group_mbid,omitempty"`
-	ArtistMBIDs             []string `json:"artist_mbids,omitempty"`
-	ArtistNames             []string `json:"artist_names,omitempty"`
-	RecordingMBID           string   `json:"recording_mbid,omitempty"`
-	DurationMs              int32    `json:"duration_ms,omitempty"`
-	Duration                int32    `json:"duration,omitempty"`
-	Tags                    []string `json:"tags,omitempty"`
-	AlbumArtist             string   `json:"albumartist,omitempty"`
-}
+// the payload is shared with the ListenBrainz export importer
+type (
+	LbzSubmitListenPayload = models.LbzSubmitListenPayload
+	LbzTrackMeta           = models.LbzTrackMeta
+	LbzArtist              = models.LbzArtist
+	LbzMBIDMapping         = models.LbzMBIDMapping
+	LbzAdditionalInfo      = models.LbzAdditionalInfo
+)
 
 const (
 	maxListensPerRequest = 1000
@@ -127,6 +100,9 @@ func LbzSubmitListenHandler(store db.DB, mbzc mbz.MusicBrainzCaller) func(w http
 			return
 		}
 
+		// imports are queued as a whole once every listen is read, so that a
+		// client retrying after an error does not submit any of them twice
+		var imported []catalog.SubmitListenOpts
 		for _, payload := range req.Payload {
 			if payload.TrackMeta.ArtistName == "" || payload.TrackMeta.TrackName == "" {
 				l.Debug().Msg("LbzSubmitListenHandler: Artist name or track name are missing")
@@ -217,10 +193,26 @@ func LbzSubmitListenHandler(store db.DB, mbzc mbz.MusicBrainzCaller) func(w http
 				Client:             client,
 				IsNowPlaying:       req.ListenType == ListenTypePlayingNow,
 				SkipSaveListen:     req.ListenType == ListenTypePlayingNow,
+
+				DurationMs:              payload.TrackMeta.AdditionalInfo.DurationMs,
+				MediaPlayer:             payload.TrackMeta.AdditionalInfo.MediaPlayer,
+				SubmissionClientVersion: payload.TrackMeta.AdditionalInfo.SubmissionClientVersion,
+				Tags:                    payload.TrackMeta.AdditionalInfo.Tags,
+				OriginURL:               payload.TrackMeta.AdditionalInfo.OriginURL,
+			}
+
+			if req.ListenType == ListenTypeImport {
+				imported = append(imported, opts)
+				continue
 			}
 
-			_, err, shared := sfGroup.Do(buildCaolescingKey(payload), func() (interface{}, error) {
-				return 0, catalog.SubmitListen(r.Context(), store, opts)
+			// now playing is handled right away so it shows up immediately, while
+			// listens are queued and associated by the ingest workers
+			_, err, shared := sfGroup.Do(buildCaolescingKey(u.ID, payload), func() (interface{}, error) {
+				if opts.IsNowPlaying {
+					return 0, catalog.SubmitListen(r.Context(), store, opts)
+				}
+				return 0, ingest.Enqueue(r.Context(), store, opts)
 			})
 			if shared {
 				l.Info().Msg("LbzSubmitListenHandler: Duplicate requests detected; results were coalesced")
@@ -234,6 +226,16 @@ func LbzSubmitListenHandler(store db.DB, mbzc mbz.MusicBrainzCaller) func(w http
 			}
 		}
 
+		if len(imported) > 0 {
+			if err := ingest.EnqueueAll(r.Context(), store, imported); err != nil {
+				l.Err(err).Msg("LbzSubmitListenHandler: Failed to queue imported listens")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte("{\"status\": \"internal server error\"}"))
+				return
+			}
+		}
+
 		l.Debug().Msg("LbzSubmitListenHandler: Successfully processed listens")
 		w.WriteHeader(http.StatusOK)
 		w.Header().Set("Content-Type", "application/json")
@@ -311,7 +313,7 @@ func doLbzRelay(requestBytes []byte, l *zerolog.Logger) {
 	}
 }
 
-func buildCaolescingKey(p LbzSubmitListenPayload) string {
+func buildCaolescingKey(userId int32, p LbzSubmitListenPayload) string {
 	// the key not including the listen_type introduces the very rare possibility of a playing_now
 	// request taking precedence over a single, meaning that a listen will not be logged when it
 	// should, however that would require a playing_now request to fire a few seconds before a 'single'
@@ -319,5 +321,8 @@ func buildCaolescingKey(p LbzSubmitListenPayload) string {
 	//
 	// this could be fixed by restructuring the database inserts for idempotency, which would
 	// eliminate the need to coalesce responses, however i'm not gonna do that right now
-	return fmt.Sprintf("%s:%s:%s", p.TrackMeta.ArtistName, p.TrackMeta.TrackName, p.TrackMeta.ReleaseName)
+	//
+	// the user is part of the key, so that users submitting the same track at the same time each get
+	// their listen
+	return fmt.Sprintf("%d:%s:%s:%s", userId, p.TrackMeta.ArtistName, p.TrackMeta.TrackName, p.TrackMeta.ReleaseName)
 }
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// listenPolicyFromForm overwrites the fields of the policy that are present in
+// the parsed form.
+func listenPolicyFromForm(r *http.Request, policy *models.ListenPolicy) error {
+	if _, ok := r.Form["min_played_fraction"]; ok {
+		fraction, err := strconv.ParseFloat(r.FormValue("min_played_fraction"), 64)
+		if err != nil || fraction < 0 || fraction > 1 {
+			return errors.New("min_played_fraction must be between 0 and 1")
+		}
+		policy.MinPlayedFraction = fraction
+	}
+	if _, ok := r.Form["min_played_seconds"]; ok {
+		seconds, err := strconv.Atoi(r.FormValue("min_played_seconds"))
+		if err != nil || seconds < 0 {
+			return errors.New("invalid min_played_seconds")
+		}
+		policy.MinPlayedSeconds = int32(seconds)
+	}
+	if _, ok := r.Form["duplicate_window_seconds"]; ok {
+		seconds, err := strconv.Atoi(r.FormValue("duplicate_window_seconds"))
+		if err != nil || seconds < 0 {
+			return errors.New("invalid duplicate_window_seconds")
+		}
+		policy.DuplicateWindowSeconds = int32(seconds)
+	}
+	if _, ok := r.Form["flag_overlaps"]; ok {
+		flag, err := strconv.ParseBool(r.FormValue("flag_overlaps"))
+		if err != nil {
+			return errors.New("invalid flag_overlaps value")
+		}
+		policy.FlagOverlaps = flag
+	}
+	return nil
+}
+
+func GetListenPolicyHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetListenPolicyHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("GetListenPolicyHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		policy, err := store.GetListenPolicy(ctx, user.ID)
+		if err != nil {
+			l.Err(err).Msg("GetListenPolicyHandler: Failed to get listen policy")
+			utils.WriteError(w, "failed to get listen policy", http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, policy)
+	}
+}
+
+// UpdateListenPolicyHandler changes only the fields that are present in the
+// request.
+func UpdateListenPolicyHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("UpdateListenPolicyHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("UpdateListenPolicyHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateListenPolicyHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		policy, err := store.GetListenPolicy(ctx, user.ID)
+		if err != nil {
+			l.Err(err).Msg("UpdateListenPolicyHandler: Failed to get listen policy")
+			utils.WriteError(w, "failed to get listen policy", http.StatusInternalServerError)
+			return
+		}
+
+		if err := listenPolicyFromForm(r, policy); err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateListenPolicyHandler: Invalid listen policy")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = store.SaveListenPolicy(ctx, db.SaveListenPolicyOpts{
+			UserID:                 user.ID,
+			MinPlayedFraction:      policy.MinPlayedFraction,
+			MinPlayedSeconds:       policy.MinPlayedSeconds,
+			DuplicateWindowSeconds: policy.DuplicateWindowSeconds,
+			FlagOverlaps:           policy.FlagOverlaps,
+		})
+		if err != nil {
+			l.Err(err).Msg("UpdateListenPolicyHandler: Failed to save listen policy")
+			utils.WriteError(w, "failed to save listen policy", http.StatusInternalServerError)
+			return
+		}
+
+		policy, err = store.GetListenPolicy(ctx, user.ID)
+		if err != nil {
+			l.Err(err).Msg("UpdateListenPolicyHandler: Failed to get listen policy")
+			utils.WriteError(w, "failed to get listen policy", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msg("UpdateListenPolicyHandler: Updated listen policy")
+		utils.WriteJSON(w, http.StatusOK, policy)
+	}
+}
+
+func GetRejectedListensHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetRejectedListensHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("GetRejectedListensHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		opts := OptsFromRequest(r)
+		opts.UserID = user.ID
+
+		resp, err := store.GetRejectedListensPaginated(ctx, opts)
+		if err != nil {
+			l.Err(err).Msg("GetRejectedListensHandler: Failed to get rejected listens")
+			utils.WriteError(w, "failed to get rejected listens", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetRejectedListensHandler: Retrieved %d rejected listens", len(resp.Items))
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// AcceptRejectedListenHandler saves a rejected listen as if the policy had
+// let it through.
+func AcceptRejectedListenHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("AcceptRejectedListenHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("AcceptRejectedListenHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("AcceptRejectedListenHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.AcceptRejectedListen(ctx, id, user.ID)
+		if err != nil {
+			l.Err(err).Msg("AcceptRejectedListenHandler: Failed to accept rejected listen")
+			utils.WriteError(w, "failed to accept rejected listen", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msgf("AcceptRejectedListenHandler: Rejected listen %d not found", id)
+			utils.WriteError(w, "rejected listen not found", http.StatusNotFound)
+			return
+		}
+
+		l.Debug().Msgf("AcceptRejectedListenHandler: Accepted rejected listen %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func DeleteRejectedListenHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("DeleteRejectedListenHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("DeleteRejectedListenHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("DeleteRejectedListenHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.DeleteRejectedListen(ctx, id, user.ID)
+		if err != nil {
+			l.Err(err).Msg("DeleteRejectedListenHandler: Failed to delete rejected listen")
+			utils.WriteError(w, "failed to delete rejected listen", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msgf("DeleteRejectedListenHandler: Rejected listen %d not found", id)
+			utils.WriteError(w, "rejected listen not found", http.StatusNotFound)
+			return
+		}
+
+		l.Debug().Msgf("DeleteRejectedListenHandler: Deleted rejected listen %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// matchInboxListenFromRequest reads the listen to act on from the track_id and
+// unix query parameters, the same way listens are deleted.
+func matchInboxListenFromRequest(r *http.Request, userID int32) (catalog.MatchInboxListen, error) {
+	trackID, err := strconv.Atoi(r.URL.Query().Get("track_id"))
+	if err != nil {
+		return catalog.MatchInboxListen{}, errors.New("track_id must be provided")
+	}
+	unix, err := strconv.ParseInt(r.URL.Query().Get("unix"), 10, 64)
+	if err != nil {
+		return catalog.MatchInboxListen{}, errors.New("unix timestamp must be provided")
+	}
+	return catalog.MatchInboxListen{
+		UserID:  userID,
+		TrackID: int32(trackID),
+		Time:    time.Unix(unix, 0),
+	}, nil
+}
+
+// GetMatchInboxHandler lists listens that created new artists, albums or
+// tracks or matched them weakly, and have not been reviewed yet.
+func GetMatchInboxHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetMatchInboxHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("GetMatchInboxHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		below := catalog.DefaultMatchInboxConfidence
+		if s := r.URL.Query().Get("confidence"); s != "" {
+			c, err := strconv.ParseFloat(s, 64)
+			if err != nil || c < 0 || c > 1 {
+				l.Debug().Msg("GetMatchInboxHandler: Invalid confidence parameter")
+				utils.WriteError(w, "confidence must be between 0 and 1", http.StatusBadRequest)
+				return
+			}
+			below = c
+		}
+
+		opts := OptsFromRequest(r)
+		resp, err := store.GetMatchInboxPaginated(ctx, db.GetMatchInboxOpts{
+			UserID: user.ID,
+			Below:  below,
+			Limit:  opts.Limit,
+			Page:   opts.Page,
+		})
+		if err != nil {
+			l.Err(err).Msg("GetMatchInboxHandler: Failed to get match inbox")
+			utils.WriteError(w, "failed to get match inbox", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetMatchInboxHandler: Retrieved %d listens", len(resp.Items))
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// AcceptMatchHandler keeps the listen as it was matched and takes it out of
+// the inbox.
+func AcceptMatchHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("AcceptMatchHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("AcceptMatchHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		listen, err := matchInboxListenFromRequest(r, user.ID)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("AcceptMatchHandler: Invalid listen")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.ReviewListenMatch(ctx, listen.UserID, listen.TrackID, listen.Time)
+		if err != nil {
+			l.Err(err).Msg("AcceptMatchHandler: Failed to accept match")
+			utils.WriteError(w, "failed to accept match", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msg("AcceptMatchHandler: Listen not found")
+			utils.WriteError(w, "listen not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ReassignMatchHandler moves the listen to an existing track.
+func ReassignMatchHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("ReassignMatchHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("ReassignMatchHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		listen, err := matchInboxListenFromRequest(r, user.ID)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("ReassignMatchHandler: Invalid listen")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		toID, err := strconv.Atoi(r.URL.Query().Get("to_id"))
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("ReassignMatchHandler: Invalid to_id parameter")
+			utils.WriteError(w, "to_id is invalid", http.StatusBadRequest)
+			return
+		}
+		if _, err := store.GetTrack(ctx, db.GetTrackOpts{ID: int32(toID)}); err != nil {
+			l.Debug().AnErr("error", err).Msgf("ReassignMatchHandler: Track %d not found", toID)
+			utils.WriteError(w, "track not found", http.StatusNotFound)
+			return
+		}
+
+		found, err := catalog.ReassignMatch(ctx, store, listen, int32(toID))
+		if err != nil {
+			l.Err(err).Msg("ReassignMatchHandler: Failed to reassign listen")
+			utils.WriteError(w, "failed to reassign listen", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msg("ReassignMatchHandler: Listen not found")
+			utils.WriteError(w, "listen not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// MergeMatchHandler merges the artist, album or track the listen was matched
+// to into an existing one.
+func MergeMatchHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("MergeMatchHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("MergeMatchHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		listen, err := matchInboxListenFromRequest(r, user.ID)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("MergeMatchHandler: Invalid listen")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		toID, err := strconv.Atoi(r.URL.Query().Get("to_id"))
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("MergeMatchHandler: Invalid to_id parameter")
+			utils.WriteError(w, "to_id is invalid", http.StatusBadRequest)
+			return
+		}
+		var fromID int
+		if s := r.URL.Query().Get("from_id"); s != "" {
+			fromID, err = strconv.Atoi(s)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("MergeMatchHandler: Invalid from_id parameter")
+				utils.WriteError(w, "from_id is invalid", http.StatusBadRequest)
+				return
+			}
+		}
+
+		found, err := catalog.MergeMatch(ctx, store, catalog.MergeMatchOpts{
+			Listen:       listen,
+			Kind:         catalog.MergeMatchKind(strings.ToLower(r.URL.Query().Get("type"))),
+			FromID:       int32(fromID),
+			ToID:         int32(toID),
+			ReplaceImage: strings.ToLower(r.URL.Query().Get("replace_image")) == "true",
+		})
+		if errors.Is(err, catalog.ErrInvalidMatchMerge) {
+			l.Debug().AnErr("error", err).Msg("MergeMatchHandler: Invalid merge")
+			utils.WriteError(w, "type must be artist, album or track, and from_id one of the track's artists when it has several", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Err(err).Msg("MergeMatchHandler: Failed to merge")
+			utils.WriteError(w, "failed to merge: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msg("MergeMatchHandler: Listen not found")
+			utils.WriteError(w, "listen not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
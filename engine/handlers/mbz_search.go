@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+const (
+	defaultMbzBackfillLimit = 100
+	maxMbzBackfillLimit     = 1000
+)
+
+// BackfillMbzIDsHandler starts searching MusicBrainz for up to limit tracks
+// without MusicBrainz IDs in the background.
+func BackfillMbzIDsHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("BackfillMbzIDsHandler: Received request")
+
+		if !catalog.MbzSearchEnabled(mbzc) {
+			l.Debug().Msg("BackfillMbzIDsHandler: MusicBrainz search is disabled")
+			utils.WriteError(w, "musicbrainz search is disabled", http.StatusConflict)
+			return
+		}
+
+		limit := defaultMbzBackfillLimit
+		if s := r.URL.Query().Get("limit"); s != "" {
+			var err error
+			limit, err = strconv.Atoi(s)
+			if err != nil || limit < 1 || limit > maxMbzBackfillLimit {
+				l.Debug().Msg("BackfillMbzIDsHandler: Invalid limit parameter")
+				utils.WriteError(w, "limit must be between 1 and "+strconv.Itoa(maxMbzBackfillLimit), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// the backfill outlives the request
+		if !catalog.StartMbzBackfill(context.WithoutCancel(ctx), store, mbzc, limit) {
+			l.Debug().Msg("BackfillMbzIDsHandler: Backfill already running")
+			utils.WriteError(w, "a backfill is already running", http.StatusConflict)
+			return
+		}
+
+		l.Debug().Msgf("BackfillMbzIDsHandler: Started backfill of up to %d tracks", limit)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// PurgeMbzCacheHandler deletes cached MusicBrainz responses, only those of
+// the kind when set, and only expired ones when expired_only is true.
+func PurgeMbzCacheHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("PurgeMbzCacheHandler: Received request")
+
+		kind := r.URL.Query().Get("kind")
+		if kind != "" && !slices.Contains(mbz.CacheKinds, kind) {
+			l.Debug().Msgf("PurgeMbzCacheHandler: Invalid kind '%s'", kind)
+			utils.WriteError(w, "kind must be one of "+strings.Join(mbz.CacheKinds, ", "), http.StatusBadRequest)
+			return
+		}
+
+		n, err := store.PurgeMbzCache(ctx, db.PurgeMbzCacheOpts{
+			Kind:        kind,
+			ExpiredOnly: strings.ToLower(r.URL.Query().Get("expired_only")) == "true",
+		})
+		if err != nil {
+			l.Err(err).Msg("PurgeMbzCacheHandler: Failed to purge MusicBrainz cache")
+			utils.WriteError(w, "failed to purge cache", http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("PurgeMbzCacheHandler: Purged %d cached MusicBrainz responses", n)
+		utils.WriteJSON(w, http.StatusOK, map[string]int64{"deleted": n})
+	}
+}
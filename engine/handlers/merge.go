@@ -37,7 +37,7 @@ func MergeTracksHandler(store db.DB) http.HandlerFunc {
 		err = store.MergeTracks(r.Context(), int32(fromId), int32(toId))
 		if err != nil {
 			l.Err(err).Msg("MergeTracksHandler: Failed to merge tracks")
-			utils.WriteError(w, "Failed to merge tracks: "+err.Error(), http.StatusInternalServerError)
+			writeAuditedError(w, err, "Failed to merge tracks: "+err.Error())
 			return
 		}
 
@@ -80,7 +80,7 @@ func MergeReleaseGroupsHandler(store db.DB) http.HandlerFunc {
 		err = store.MergeAlbums(r.Context(), int32(fromId), int32(toId), replaceImage)
 		if err != nil {
 			l.Err(err).Msg("MergeReleaseGroupsHandler: Failed to merge release groups")
-			utils.WriteError(w, "Failed to merge release groups: "+err.Error(), http.StatusInternalServerError)
+			writeAuditedError(w, err, "Failed to merge release groups: "+err.Error())
 			return
 		}
 
@@ -123,7 +123,7 @@ func MergeArtistsHandler(store db.DB) http.HandlerFunc {
 		err = store.MergeArtists(r.Context(), int32(fromId), int32(toId), replaceImage)
 		if err != nil {
 			l.Err(err).Msg("MergeArtistsHandler: Failed to merge artists")
-			utils.WriteError(w, "Failed to merge artists: "+err.Error(), http.StatusInternalServerError)
+			writeAuditedError(w, err, "Failed to merge artists: "+err.Error())
 			return
 		}
 
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/dedupe"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// GetMergeSuggestionsHandler lists likely duplicates, optionally of one type
+// only. Dismissed suggestions are listed instead when dismissed=true.
+func GetMergeSuggestionsHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetMergeSuggestionsHandler: Received request")
+
+		kind := models.MergeSuggestionKind(strings.ToLower(r.URL.Query().Get("type")))
+		switch kind {
+		case "", models.MergeSuggestionArtist, models.MergeSuggestionAlbum, models.MergeSuggestionTrack:
+		default:
+			l.Debug().Msgf("GetMergeSuggestionsHandler: Invalid type '%s'", kind)
+			utils.WriteError(w, "type must be artist, album or track", http.StatusBadRequest)
+			return
+		}
+
+		limit := 0
+		if s := r.URL.Query().Get("limit"); s != "" {
+			var err error
+			limit, err = strconv.Atoi(s)
+			if err != nil || limit < 1 {
+				l.Debug().Msg("GetMergeSuggestionsHandler: Invalid limit parameter")
+				utils.WriteError(w, "limit is invalid", http.StatusBadRequest)
+				return
+			}
+		}
+
+		suggestions, err := store.GetMergeSuggestions(ctx, db.GetMergeSuggestionsOpts{
+			Kind:      kind,
+			Dismissed: strings.ToLower(r.URL.Query().Get("dismissed")) == "true",
+			Limit:     limit,
+		})
+		if err != nil {
+			l.Err(err).Msg("GetMergeSuggestionsHandler: Failed to get merge suggestions")
+			utils.WriteError(w, "failed to get merge suggestions", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetMergeSuggestionsHandler: Retrieved %d merge suggestions", len(suggestions))
+		utils.WriteJSON(w, http.StatusOK, suggestions)
+	}
+}
+
+// AcceptMergeSuggestionHandler merges the pair of the suggestion, keeping the
+// suggested one unless another is given with keep.
+func AcceptMergeSuggestionHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("AcceptMergeSuggestionHandler: Received request")
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("AcceptMergeSuggestionHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		var keep int
+		if s := r.URL.Query().Get("keep"); s != "" {
+			keep, err = strconv.Atoi(s)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("AcceptMergeSuggestionHandler: Invalid keep parameter")
+				utils.WriteError(w, "keep is invalid", http.StatusBadRequest)
+				return
+			}
+		}
+
+		found, err := dedupe.Accept(ctx, store, dedupe.AcceptOpts{
+			ID:           id,
+			Keep:         int32(keep),
+			ReplaceImage: strings.ToLower(r.URL.Query().Get("replace_image")) == "true",
+		})
+		if errors.Is(err, dedupe.ErrInvalidKeep) {
+			l.Debug().AnErr("error", err).Msg("AcceptMergeSuggestionHandler: Invalid keep parameter")
+			utils.WriteError(w, "keep must be the id of one of the pair", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Err(err).Msg("AcceptMergeSuggestionHandler: Failed to accept merge suggestion")
+			utils.WriteError(w, "failed to accept merge suggestion", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msgf("AcceptMergeSuggestionHandler: Merge suggestion %d not found", id)
+			utils.WriteError(w, "merge suggestion not found", http.StatusNotFound)
+			return
+		}
+
+		l.Debug().Msgf("AcceptMergeSuggestionHandler: Accepted merge suggestion %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DismissMergeSuggestionHandler marks the pair as not duplicates, so it is
+// not suggested again.
+func DismissMergeSuggestionHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("DismissMergeSuggestionHandler: Received request")
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("DismissMergeSuggestionHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.DismissMergeSuggestion(ctx, id)
+		if err != nil {
+			l.Err(err).Msg("DismissMergeSuggestionHandler: Failed to dismiss merge suggestion")
+			utils.WriteError(w, "failed to dismiss merge suggestion", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msgf("DismissMergeSuggestionHandler: Merge suggestion %d not found", id)
+			utils.WriteError(w, "merge suggestion not found", http.StatusNotFound)
+			return
+		}
+
+		l.Debug().Msgf("DismissMergeSuggestionHandler: Dismissed merge suggestion %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ScanMergeSuggestionsHandler starts a scan for duplicates without waiting for
+// it to finish.
+func ScanMergeSuggestionsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		l.Debug().Msg("ScanMergeSuggestionsHandler: Received request")
+		if !dedupe.Running() {
+			l.Debug().Msg("ScanMergeSuggestionsHandler: Duplicate analyzer is disabled")
+			utils.WriteError(w, "duplicate scanning is disabled", http.StatusConflict)
+			return
+		}
+		dedupe.Wake()
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
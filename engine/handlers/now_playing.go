@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/events"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
-	"github.com/SaturnX-Dev/Beat-Scrobble/internal/memkv"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
 )
@@ -13,6 +18,28 @@ import (
 type NowPlayingResponse struct {
 	CurrentlyPlaying bool         `json:"currently_playing"`
 	Track            models.Track `json:"track"`
+	StartedAt        *time.Time   `json:"started_at,omitempty"`
+	ExpiresAt        *time.Time   `json:"expires_at,omitempty"`
+}
+
+// how often a comment is written to idle streams so proxies don't close them
+const streamKeepAliveInterval = 30 * time.Second
+
+func nowPlayingResponse(ctx context.Context, store db.DB, userId int32) (NowPlayingResponse, error) {
+	np, ok := catalog.GetNowPlaying(userId)
+	if !ok {
+		return NowPlayingResponse{CurrentlyPlaying: false}, nil
+	}
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: np.TrackID, UserID: userId})
+	if err != nil {
+		return NowPlayingResponse{}, fmt.Errorf("nowPlayingResponse: %w", err)
+	}
+	return NowPlayingResponse{
+		CurrentlyPlaying: true,
+		Track:            *track,
+		StartedAt:        &np.StartedAt,
+		ExpiresAt:        &np.ExpiresAt,
+	}, nil
 }
 
 func NowPlayingHandler(store db.DB) http.HandlerFunc {
@@ -20,21 +47,87 @@ func NowPlayingHandler(store db.DB) http.HandlerFunc {
 		ctx := r.Context()
 		l := logger.FromContext(ctx)
 
-		l.Debug().Msg("NowPlayingHandler: Got request")
+		userId := UserIDFromRequest(r)
 
-		// Hardcoded user id as 1. Not great but it works until (if) multi-user is supported.
-		if trackIdI, ok := memkv.Store.Get("1"); !ok {
-			utils.WriteJSON(w, http.StatusOK, NowPlayingResponse{CurrentlyPlaying: false})
-		} else if trackId, ok := trackIdI.(int32); !ok {
-			l.Debug().Msg("NowPlayingHandler: Failed type assertion for trackIdI")
-			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
-		} else {
-			track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: trackId})
+		l.Debug().Msgf("NowPlayingHandler: Got request for user %d", userId)
+
+		resp, err := nowPlayingResponse(ctx, store, userId)
+		if err != nil {
+			l.Error().Err(err).Msg("NowPlayingHandler: Failed to get track from database")
+			utils.WriteError(w, "failed to fetch currently playing track from database", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// ActivityStreamHandler streams now playing changes and new listens for a user
+// as Server-Sent Events. The current now playing state is sent on connect.
+func ActivityStreamHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		userId := UserIDFromRequest(r)
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			utils.WriteError(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		sendEvent := func(eventType events.Type, data interface{}) {
+			jsonData, err := json.Marshal(data)
 			if err != nil {
-				l.Error().Err(err).Msg("NowPlayingHandler: Failed to get track from database")
-				utils.WriteError(w, "failed to fetch currently playing track from database", http.StatusInternalServerError)
-			} else {
-				utils.WriteJSON(w, http.StatusOK, NowPlayingResponse{CurrentlyPlaying: true, Track: *track})
+				l.Err(err).Msg("ActivityStreamHandler: Failed to marshal event")
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, jsonData)
+			flusher.Flush()
+		}
+
+		sub, unsubscribe := events.Bus.Subscribe(userId)
+		defer unsubscribe()
+
+		l.Debug().Msgf("ActivityStreamHandler: Streaming activity for user %d", userId)
+
+		resp, err := nowPlayingResponse(ctx, store, userId)
+		if err != nil {
+			l.Err(err).Msg("ActivityStreamHandler: Failed to get now playing track")
+		} else {
+			sendEvent(events.TypeNowPlaying, resp)
+		}
+
+		keepAlive := time.NewTicker(streamKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				l.Debug().Msgf("ActivityStreamHandler: Client for user %d disconnected", userId)
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				flusher.Flush()
+			case e, ok := <-sub:
+				if !ok {
+					return
+				}
+				switch e.Type {
+				case events.TypeNowPlaying:
+					resp, err := nowPlayingResponse(ctx, store, userId)
+					if err != nil {
+						l.Err(err).Msg("ActivityStreamHandler: Failed to get now playing track")
+						continue
+					}
+					sendEvent(events.TypeNowPlaying, resp)
+				default:
+					sendEvent(e.Type, e.Data)
+				}
 			}
 		}
 	}
@@ -130,17 +130,18 @@ func PublicProfileHandler(store db.DB) http.HandlerFunc {
 		}
 
 		// Fetch public stats (all time)
-		listens, _ := store.CountListens(ctx, db.PeriodAllTime)
-		tracks, _ := store.CountTracks(ctx, db.PeriodAllTime)
-		albums, _ := store.CountAlbums(ctx, db.PeriodAllTime)
-		artists, _ := store.CountArtists(ctx, db.PeriodAllTime)
-		timeListened, _ := store.CountTimeListened(ctx, db.PeriodAllTime)
+		listens, _ := store.CountListens(ctx, db.PeriodAllTime, user.ID)
+		tracks, _ := store.CountTracks(ctx, db.PeriodAllTime, user.ID)
+		albums, _ := store.CountAlbums(ctx, db.PeriodAllTime, user.ID)
+		artists, _ := store.CountArtists(ctx, db.PeriodAllTime, user.ID)
+		timeListened, _ := store.CountTimeListened(ctx, db.PeriodAllTime, user.ID)
 
 		// Fetch top artists using paginated method
 		topArtistsResp, _ := store.GetTopArtistsPaginated(ctx, db.GetItemsOpts{
 			Limit:  5,
 			Page:   1,
 			Period: db.PeriodAllTime,
+			UserID: user.ID,
 		})
 		topArtists := make([]PublicTopArtist, 0)
 		if topArtistsResp != nil {
@@ -159,6 +160,7 @@ func PublicProfileHandler(store db.DB) http.HandlerFunc {
 			Limit:  5,
 			Page:   1,
 			Period: db.PeriodAllTime,
+			UserID: user.ID,
 		})
 		topAlbums := make([]PublicTopAlbum, 0)
 		if topAlbumsResp != nil {
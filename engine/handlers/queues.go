@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/images"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/SaturnX-Dev/Beat-Scrobble/queue"
+)
+
+// QueueStatsHandler returns the stats of the request queues of MusicBrainz
+// and the image providers, by name. Queues that are not in use are left out.
+func QueueStatsHandler(mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		l.Debug().Msg("QueueStatsHandler: Received request")
+
+		stats := images.QueueStats()
+		if c, ok := mbzc.(interface{ QueueStats() queue.Stats }); ok {
+			stats["musicbrainz"] = c.QueueStats()
+		}
+
+		utils.WriteJSON(w, http.StatusOK, stats)
+	}
+}
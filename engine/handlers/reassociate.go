@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// ReassociateListensHandler starts replaying the user's listens through the
+// association pipeline in the background. Unless dry_run is set to false,
+// nothing is changed and the result only lists the tracks whose listens would
+// be moved. The result is returned by ReassociationStatusHandler.
+func ReassociateListensHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("ReassociateListensHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("ReassociateListensHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("ReassociateListensHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		opts := catalog.ReassociateListensOpts{
+			ListenFilterOpts: db.ListenFilterOpts{
+				UserID: user.ID,
+				Client: r.FormValue("client"),
+			},
+			DryRun:    true,
+			MbzCaller: mbzc,
+		}
+		if v := r.FormValue("from"); v != "" {
+			unix, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("ReassociateListensHandler: Invalid from parameter")
+				utils.WriteError(w, "invalid from", http.StatusBadRequest)
+				return
+			}
+			opts.From = time.Unix(unix, 0)
+		}
+		if v := r.FormValue("to"); v != "" {
+			unix, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("ReassociateListensHandler: Invalid to parameter")
+				utils.WriteError(w, "invalid to", http.StatusBadRequest)
+				return
+			}
+			opts.To = time.Unix(unix, 0)
+		}
+		if v := r.FormValue("artist_id"); v != "" {
+			artistId, err := strconv.Atoi(v)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("ReassociateListensHandler: Invalid artist_id parameter")
+				utils.WriteError(w, "invalid artist_id", http.StatusBadRequest)
+				return
+			}
+			opts.ArtistID = int32(artistId)
+		}
+		if v := r.FormValue("dry_run"); v != "" {
+			dryRun, err := strconv.ParseBool(v)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("ReassociateListensHandler: Invalid dry_run parameter")
+				utils.WriteError(w, "invalid dry_run", http.StatusBadRequest)
+				return
+			}
+			opts.DryRun = dryRun
+		}
+
+		// the reassociation outlives the request
+		if !catalog.StartReassociation(context.WithoutCancel(ctx), store, opts) {
+			l.Debug().Msg("ReassociateListensHandler: Reassociation already running")
+			utils.WriteError(w, "a reassociation is already running", http.StatusConflict)
+			return
+		}
+
+		l.Debug().Msgf("ReassociateListensHandler: Started reassociation (dry run: %t)", opts.DryRun)
+		utils.WriteJSON(w, http.StatusAccepted, catalog.GetReassociationStatus(user.ID))
+	}
+}
+
+// ReassociationStatusHandler returns how far the user's latest reassociation
+// got, and its result once it is done.
+func ReassociationStatusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("ReassociationStatusHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("ReassociationStatusHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		status := catalog.GetReassociationStatus(user.ID)
+		if status == nil {
+			utils.WriteError(w, "no reassociation has been started", http.StatusNotFound)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, status)
+	}
+}
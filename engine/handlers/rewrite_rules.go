@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/rewrite"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// rewriteRuleFromForm overwrites the fields of the rule that are present in
+// the parsed form, so the same parsing serves both creating and updating.
+func rewriteRuleFromForm(r *http.Request, rule *models.RewriteRule) error {
+	if _, ok := r.Form["field"]; ok {
+		rule.Field = models.RewriteField(strings.ToLower(r.FormValue("field")))
+	}
+	if _, ok := r.Form["pattern"]; ok {
+		rule.Pattern = r.FormValue("pattern")
+	}
+	if _, ok := r.Form["action"]; ok {
+		rule.Action = models.RewriteAction(strings.ToLower(r.FormValue("action")))
+	}
+	if _, ok := r.Form["target"]; ok {
+		rule.Target = models.RewriteField(strings.ToLower(r.FormValue("target")))
+	}
+	if _, ok := r.Form["replacement"]; ok {
+		rule.Replacement = r.FormValue("replacement")
+	}
+	if _, ok := r.Form["position"]; ok {
+		position, err := strconv.Atoi(r.FormValue("position"))
+		if err != nil {
+			return errors.New("invalid position")
+		}
+		rule.Position = int32(position)
+	}
+	if _, ok := r.Form["enabled"]; ok {
+		enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+		if err != nil {
+			return errors.New("invalid enabled value")
+		}
+		rule.Enabled = enabled
+	}
+	return rewrite.Validate(*rule)
+}
+
+func GetRewriteRulesHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetRewriteRulesHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("GetRewriteRulesHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		rules, err := store.GetRewriteRulesByUserID(ctx, user.ID)
+		if err != nil {
+			l.Err(err).Msg("GetRewriteRulesHandler: Failed to get rewrite rules")
+			utils.WriteError(w, "failed to get rewrite rules", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetRewriteRulesHandler: Retrieved %d rewrite rules", len(rules))
+		utils.WriteJSON(w, http.StatusOK, rules)
+	}
+}
+
+func CreateRewriteRuleHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("CreateRewriteRuleHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("CreateRewriteRuleHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("CreateRewriteRuleHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		rule := models.RewriteRule{Enabled: true}
+		if err := rewriteRuleFromForm(r, &rule); err != nil {
+			l.Debug().AnErr("error", err).Msg("CreateRewriteRuleHandler: Invalid rewrite rule")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		saved, err := store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+			UserID:      user.ID,
+			Position:    rule.Position,
+			Enabled:     rule.Enabled,
+			Field:       rule.Field,
+			Pattern:     rule.Pattern,
+			Action:      rule.Action,
+			Target:      rule.Target,
+			Replacement: rule.Replacement,
+		})
+		if err != nil {
+			l.Err(err).Msg("CreateRewriteRuleHandler: Failed to save rewrite rule")
+			utils.WriteError(w, "failed to save rewrite rule", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("CreateRewriteRuleHandler: Created rewrite rule %d", saved.ID)
+		utils.WriteJSON(w, http.StatusCreated, saved)
+	}
+}
+
+// UpdateRewriteRuleHandler changes only the fields that are present in the
+// request.
+func UpdateRewriteRuleHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("UpdateRewriteRuleHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("UpdateRewriteRuleHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateRewriteRuleHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateRewriteRuleHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		rule, err := store.GetRewriteRule(ctx, int32(id), user.ID)
+		if err != nil {
+			l.Err(err).Msg("UpdateRewriteRuleHandler: Failed to get rewrite rule")
+			utils.WriteError(w, "failed to get rewrite rule", http.StatusInternalServerError)
+			return
+		}
+		if rule == nil {
+			l.Debug().Msgf("UpdateRewriteRuleHandler: Rewrite rule %d not found", id)
+			utils.WriteError(w, "rewrite rule not found", http.StatusNotFound)
+			return
+		}
+
+		if err := rewriteRuleFromForm(r, rule); err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateRewriteRuleHandler: Invalid rewrite rule")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		_, err = store.UpdateRewriteRule(ctx, db.UpdateRewriteRuleOpts{
+			ID:          rule.ID,
+			UserID:      user.ID,
+			Position:    rule.Position,
+			Enabled:     rule.Enabled,
+			Field:       rule.Field,
+			Pattern:     rule.Pattern,
+			Action:      rule.Action,
+			Target:      rule.Target,
+			Replacement: rule.Replacement,
+		})
+		if err != nil {
+			l.Err(err).Msg("UpdateRewriteRuleHandler: Failed to update rewrite rule")
+			utils.WriteError(w, "failed to update rewrite rule", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("UpdateRewriteRuleHandler: Updated rewrite rule %d", rule.ID)
+		utils.WriteJSON(w, http.StatusOK, rule)
+	}
+}
+
+func DeleteRewriteRuleHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("DeleteRewriteRuleHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("DeleteRewriteRuleHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("DeleteRewriteRuleHandler: Invalid id parameter")
+			utils.WriteError(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.DeleteRewriteRule(ctx, int32(id), user.ID)
+		if err != nil {
+			l.Err(err).Msg("DeleteRewriteRuleHandler: Failed to delete rewrite rule")
+			utils.WriteError(w, "failed to delete rewrite rule", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			l.Debug().Msgf("DeleteRewriteRuleHandler: Rewrite rule %d not found", id)
+			utils.WriteError(w, "rewrite rule not found", http.StatusNotFound)
+			return
+		}
+
+		l.Debug().Msgf("DeleteRewriteRuleHandler: Deleted rewrite rule %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// TestRewriteRulesHandler shows how the user's enabled rules would rewrite a
+// sample listen, without saving anything.
+func TestRewriteRulesHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("TestRewriteRulesHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("TestRewriteRulesHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			l.Debug().AnErr("error", err).Msg("TestRewriteRulesHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		rules, err := store.GetEnabledRewriteRulesByUserID(ctx, user.ID)
+		if err != nil {
+			l.Err(err).Msg("TestRewriteRulesHandler: Failed to get rewrite rules")
+			utils.WriteError(w, "failed to get rewrite rules", http.StatusInternalServerError)
+			return
+		}
+
+		result := rewrite.Apply(rules, rewrite.Listen{
+			Artist: r.FormValue("artist"),
+			Title:  r.FormValue("title"),
+			Album:  r.FormValue("album"),
+			Client: r.FormValue("client"),
+		})
+
+		l.Debug().Msgf("TestRewriteRulesHandler: Applied %d rewrite rules", len(result.Applied))
+		utils.WriteJSON(w, http.StatusOK, result)
+	}
+}
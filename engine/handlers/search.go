@@ -33,7 +33,7 @@ func SearchHandler(store db.DB) http.HandlerFunc {
 			idStr := strings.TrimPrefix(q, "id:")
 			id, _ := strconv.Atoi(idStr)
 
-			artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: int32(id)})
+			artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: int32(id), UserID: UserIDFromRequest(r)})
 			if err != nil {
 				l.Debug().Msg("No artists found with id")
 			}
@@ -41,7 +41,7 @@ func SearchHandler(store db.DB) http.HandlerFunc {
 				artists = append(artists, artist)
 			}
 
-			album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: int32(id)})
+			album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: int32(id), UserID: UserIDFromRequest(r)})
 			if err != nil {
 				l.Debug().Msg("No albums found with id")
 			}
@@ -49,7 +49,7 @@ func SearchHandler(store db.DB) http.HandlerFunc {
 				albums = append(albums, album)
 			}
 
-			track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: int32(id)})
+			track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: int32(id), UserID: UserIDFromRequest(r)})
 			if err != nil {
 				l.Debug().Msg("No tracks found with id")
 			}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/engine/middleware"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// splitRequest holds the form values shared by all splits. Aliases and tracks
+// to move are given by repeating alias and track_id. Unless dry_run is set to
+// false, nothing is changed.
+type splitRequest struct {
+	ID       int32
+	Aliases  []string
+	TrackIDs []int32
+	DryRun   bool
+}
+
+func splitRequestFromForm(r *http.Request) (splitRequest, error) {
+	req := splitRequest{DryRun: true}
+	if err := r.ParseForm(); err != nil {
+		return req, errors.New("invalid request")
+	}
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		return req, errors.New("id is invalid")
+	}
+	req.ID = int32(id)
+	req.Aliases = r.Form["alias"]
+	for _, v := range r.Form["track_id"] {
+		trackId, err := strconv.Atoi(v)
+		if err != nil {
+			return req, errors.New("track_id is invalid")
+		}
+		req.TrackIDs = append(req.TrackIDs, int32(trackId))
+	}
+	if v := r.FormValue("dry_run"); v != "" {
+		req.DryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			return req, errors.New("invalid dry_run")
+		}
+	}
+	return req, nil
+}
+
+// writeSplitResult writes the result of a split, or the error it failed with.
+func writeSplitResult(w http.ResponseWriter, r *http.Request, handler string, result *db.SplitResult, err error) {
+	l := logger.FromContext(r.Context())
+	if errors.Is(err, db.ErrInvalidSplit) {
+		l.Debug().AnErr("error", err).Msgf("%s: Invalid split", handler)
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		l.Err(err).Msgf("%s: Failed to split", handler)
+		writeAuditedError(w, err, "failed to split")
+		return
+	}
+	l.Debug().Msgf("%s: Split off %d tracks and %d listens (dry run: %t)", handler, len(result.TrackIDs), result.Listens, result.DryRun)
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+// SplitArtistHandler creates an artist named name and moves the given aliases
+// and tracks of the artist to it. With keep_credits=true the tracks stay
+// credited to the artist as well.
+func SplitArtistHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("SplitArtistHandler: Received request")
+
+		req, err := splitRequestFromForm(r)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("SplitArtistHandler: Invalid request")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var keepCredits bool
+		if v := r.FormValue("keep_credits"); v != "" {
+			keepCredits, err = strconv.ParseBool(v)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("SplitArtistHandler: Invalid keep_credits parameter")
+				utils.WriteError(w, "invalid keep_credits", http.StatusBadRequest)
+				return
+			}
+		}
+		if _, err := store.GetArtist(ctx, db.GetArtistOpts{ID: req.ID}); err != nil {
+			l.Debug().AnErr("error", err).Msgf("SplitArtistHandler: Artist %d not found", req.ID)
+			utils.WriteError(w, "artist not found", http.StatusNotFound)
+			return
+		}
+
+		result, err := store.SplitArtist(ctx, db.SplitArtistOpts{
+			ID:          req.ID,
+			Name:        r.FormValue("name"),
+			Aliases:     req.Aliases,
+			TrackIDs:    req.TrackIDs,
+			KeepCredits: keepCredits,
+			DryRun:      req.DryRun,
+		})
+		writeSplitResult(w, r, "SplitArtistHandler", result, err)
+	}
+}
+
+// SplitAlbumHandler creates an album titled title and moves the given aliases
+// and tracks of the album to it.
+func SplitAlbumHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("SplitAlbumHandler: Received request")
+
+		req, err := splitRequestFromForm(r)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("SplitAlbumHandler: Invalid request")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: req.ID}); err != nil {
+			l.Debug().AnErr("error", err).Msgf("SplitAlbumHandler: Album %d not found", req.ID)
+			utils.WriteError(w, "album not found", http.StatusNotFound)
+			return
+		}
+
+		result, err := store.SplitAlbum(ctx, db.SplitAlbumOpts{
+			ID:       req.ID,
+			Title:    r.FormValue("title"),
+			Aliases:  req.Aliases,
+			TrackIDs: req.TrackIDs,
+			DryRun:   req.DryRun,
+		})
+		writeSplitResult(w, r, "SplitAlbumHandler", result, err)
+	}
+}
+
+// SplitTrackHandler creates a track titled title, on the same album and by the
+// same artists, and moves the given aliases of the track and the user's
+// listens of it to it. Listens can be narrowed down by time range (from, to as
+// unix timestamps) and client.
+func SplitTrackHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("SplitTrackHandler: Received request")
+
+		user := middleware.GetUserFromContext(ctx)
+		if user == nil {
+			l.Debug().Msg("SplitTrackHandler: Invalid user context")
+			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		req, err := splitRequestFromForm(r)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("SplitTrackHandler: Invalid request")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts := db.SplitTrackOpts{
+			ListenFilterOpts: db.ListenFilterOpts{
+				UserID: user.ID,
+				Client: r.FormValue("client"),
+			},
+			ID:      req.ID,
+			Title:   r.FormValue("title"),
+			Aliases: req.Aliases,
+			DryRun:  req.DryRun,
+		}
+		if v := r.FormValue("from"); v != "" {
+			unix, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("SplitTrackHandler: Invalid from parameter")
+				utils.WriteError(w, "invalid from", http.StatusBadRequest)
+				return
+			}
+			opts.From = time.Unix(unix, 0)
+		}
+		if v := r.FormValue("to"); v != "" {
+			unix, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("SplitTrackHandler: Invalid to parameter")
+				utils.WriteError(w, "invalid to", http.StatusBadRequest)
+				return
+			}
+			opts.To = time.Unix(unix, 0)
+		}
+		if _, err := store.GetTrack(ctx, db.GetTrackOpts{ID: req.ID}); err != nil {
+			l.Debug().AnErr("error", err).Msgf("SplitTrackHandler: Track %d not found", req.ID)
+			utils.WriteError(w, "track not found", http.StatusNotFound)
+			return
+		}
+
+		result, err := store.SplitTrack(ctx, opts)
+		writeSplitResult(w, r, "SplitTrackHandler", result, err)
+	}
+}
@@ -610,6 +610,7 @@ func SpotifyBulkFetchSSEHandler(store db.DB) http.HandlerFunc {
 			Period: db.PeriodAllTime,
 			Limit:  100,
 			Page:   1,
+			UserID: user.ID,
 		})
 		if err == nil {
 			for i, artist := range artistResp.Items {
@@ -670,6 +671,7 @@ func SpotifyBulkFetchSSEHandler(store db.DB) http.HandlerFunc {
 			Period: db.PeriodAllTime,
 			Limit:  100,
 			Page:   1,
+			UserID: user.ID,
 		})
 		if err == nil {
 			for i, album := range albumResp.Items {
@@ -730,6 +732,7 @@ func SpotifyBulkFetchSSEHandler(store db.DB) http.HandlerFunc {
 			Period: db.PeriodAllTime,
 			Limit:  100,
 			Page:   1,
+			UserID: user.ID,
 		})
 		if err == nil {
 			for i, track := range trackResp.Items {
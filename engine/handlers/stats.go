@@ -40,37 +40,39 @@ func StatsHandler(store db.DB) http.HandlerFunc {
 			period = db.PeriodDay
 		}
 
-		l.Debug().Msgf("StatsHandler: Fetching statistics for period '%s'", period)
+		userId := UserIDFromRequest(r)
 
-		listens, err := store.CountListens(r.Context(), period)
+		l.Debug().Msgf("StatsHandler: Fetching statistics for period '%s' and user %d", period, userId)
+
+		listens, err := store.CountListens(r.Context(), period, userId)
 		if err != nil {
 			l.Err(err).Msg("StatsHandler: Failed to fetch listen count")
 			utils.WriteError(w, "failed to get listens: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		tracks, err := store.CountTracks(r.Context(), period)
+		tracks, err := store.CountTracks(r.Context(), period, userId)
 		if err != nil {
 			l.Err(err).Msg("StatsHandler: Failed to fetch track count")
 			utils.WriteError(w, "failed to get tracks: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		albums, err := store.CountAlbums(r.Context(), period)
+		albums, err := store.CountAlbums(r.Context(), period, userId)
 		if err != nil {
 			l.Err(err).Msg("StatsHandler: Failed to fetch album count")
 			utils.WriteError(w, "failed to get albums: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		artists, err := store.CountArtists(r.Context(), period)
+		artists, err := store.CountArtists(r.Context(), period, userId)
 		if err != nil {
 			l.Err(err).Msg("StatsHandler: Failed to fetch artist count")
 			utils.WriteError(w, "failed to get artists: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		timeListenedS, err := store.CountTimeListened(r.Context(), period)
+		timeListenedS, err := store.CountTimeListened(r.Context(), period, userId)
 		if err != nil {
 			l.Err(err).Msg("StatsHandler: Failed to fetch time listened")
 			utils.WriteError(w, "failed to get time listened: "+err.Error(), http.StatusInternalServerError)
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/ingest"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// Plex sends the thumbnail of the item along with the payload
+	maxPlexWebhookMemory = 4 << 20
+	// Jellyfin and Emby durations are in ticks of 100 nanoseconds
+	ticksPerMillisecond = 10000
+)
+
+type PlexWebhookPayload struct {
+	Event   string `json:"event"`
+	Account struct {
+		Title string `json:"title"`
+	} `json:"Account"`
+	Player struct {
+		Title string `json:"title"`
+	} `json:"Player"`
+	Metadata struct {
+		Type             string `json:"type"`
+		Title            string `json:"title"`
+		ParentTitle      string `json:"parentTitle"`
+		GrandparentTitle string `json:"grandparentTitle"`
+		OriginalTitle    string `json:"originalTitle"`
+		Duration         int64  `json:"duration"`   // in milliseconds
+		ViewOffset       int64  `json:"viewOffset"` // in milliseconds
+		Guid             []struct {
+			ID string `json:"id"`
+		} `json:"Guid"`
+	} `json:"Metadata"`
+}
+
+// JellyfinWebhookPayload holds the variables of the Jellyfin webhook plugin
+// that are used, so the template must render them under the same names. All
+// values are strings, since the plugin renders booleans as True and False and
+// leaves missing numbers empty.
+type JellyfinWebhookPayload struct {
+	NotificationType             string `json:"NotificationType"`
+	NotificationUsername         string `json:"NotificationUsername"`
+	ItemType                     string `json:"ItemType"`
+	Name                         string `json:"Name"`
+	Artist                       string `json:"Artist"`
+	Album                        string `json:"Album"`
+	RunTimeTicks                 string `json:"RunTimeTicks"`
+	PlaybackPositionTicks        string `json:"PlaybackPositionTicks"`
+	PlayedToCompletion           string `json:"PlayedToCompletion"`
+	ClientName                   string `json:"ClientName"`
+	ServerVersion                string `json:"ServerVersion"`
+	ProviderMusicBrainzRecording string `json:"Provider_musicbrainzrecording"`
+	ProviderMusicBrainzAlbum     string `json:"Provider_musicbrainzalbum"`
+	ProviderMusicBrainzGroup     string `json:"Provider_musicbrainzreleasegroup"`
+	ProviderMusicBrainzArtist    string `json:"Provider_musicbrainzartist"`
+}
+
+type EmbyWebhookPayload struct {
+	Event string `json:"Event"`
+	User  struct {
+		Name string `json:"Name"`
+	} `json:"User"`
+	Server struct {
+		Version string `json:"Version"`
+	} `json:"Server"`
+	Session struct {
+		Client string `json:"Client"`
+	} `json:"Session"`
+	Item struct {
+		Type         string            `json:"Type"`
+		Name         string            `json:"Name"`
+		Album        string            `json:"Album"`
+		AlbumArtist  string            `json:"AlbumArtist"`
+		Artists      []string          `json:"Artists"`
+		RunTimeTicks int64             `json:"RunTimeTicks"`
+		ProviderIds  map[string]string `json:"ProviderIds"`
+	} `json:"Item"`
+	PlaybackInfo struct {
+		PlayedToCompletion bool  `json:"PlayedToCompletion"`
+		PositionTicks      int64 `json:"PositionTicks"`
+	} `json:"PlaybackInfo"`
+}
+
+// webhookPlay is a play reported by a media server. Events that are neither a
+// play starting nor a finished play are ignored.
+type webhookPlay struct {
+	Username   string
+	NowPlaying bool
+	Finished   bool
+	Opts       catalog.SubmitListenOpts
+}
+
+// webhookUserFromRequest finds the user from the api key in the url.
+func webhookUserFromRequest(w http.ResponseWriter, r *http.Request, store db.DB) *models.User {
+	l := logger.FromContext(r.Context())
+
+	u, err := store.GetUserByApiKey(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		l.Err(err).Msg("webhookUserFromRequest: Failed to get user by api key")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	if u == nil {
+		utils.WriteError(w, "invalid key", http.StatusUnauthorized)
+		return nil
+	}
+	return u
+}
+
+func parseTicks(s string) int64 {
+	ticks, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || ticks < 0 {
+		return 0
+	}
+	return ticks
+}
+
+func parseMbid(s string) uuid.UUID {
+	id, err := uuid.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
+}
+
+// handleWebhookPlay sets the play as now playing or queues it as a listen. When
+// the user query parameter is set, plays from other accounts on the server
+// are ignored.
+func handleWebhookPlay(w http.ResponseWriter, r *http.Request, store db.DB, u *models.User, play webhookPlay) {
+	l := logger.FromContext(r.Context())
+
+	if user := r.URL.Query().Get("user"); user != "" && !strings.EqualFold(user, play.Username) {
+		l.Debug().Msgf("handleWebhookPlay: Ignoring play from account '%s'", play.Username)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if !play.NowPlaying && !play.Finished {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if play.Opts.Artist == "" && len(play.Opts.ArtistNames) < 1 || play.Opts.TrackTitle == "" {
+		l.Debug().Msg("handleWebhookPlay: Artist name or track name are missing")
+		utils.WriteError(w, "artist name or track name are missing", http.StatusBadRequest)
+		return
+	}
+	play.Opts.UserID = u.ID
+
+	if play.NowPlaying {
+		play.Opts.Time = time.Now()
+		play.Opts.IsNowPlaying = true
+		play.Opts.SkipSaveListen = true
+		if err := catalog.SubmitListen(r.Context(), store, play.Opts); err != nil {
+			l.Err(err).Msg("handleWebhookPlay: Failed to submit now playing")
+			utils.WriteError(w, "failed to submit now playing", http.StatusInternalServerError)
+			return
+		}
+		l.Debug().Msgf("handleWebhookPlay: Set now playing for user %d", u.ID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// the listen starts when playback started, not when the server reports it
+	play.Opts.Time = time.Now().Add(-time.Duration(play.Opts.DurationMs) * time.Millisecond)
+	if err := ingest.Enqueue(r.Context(), store, play.Opts); err != nil {
+		l.Err(err).Msg("handleWebhookPlay: Failed to queue listen")
+		utils.WriteError(w, "failed to queue listen", http.StatusInternalServerError)
+		return
+	}
+	l.Debug().Msgf("handleWebhookPlay: Queued listen for user %d", u.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PlexWebhookHandler accepts the multipart webhooks sent by Plex Media Server.
+// media.play and media.resume set the track as now playing, and media.scrobble,
+// which Plex sends once most of the track has been played, saves the listen.
+func PlexWebhookHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		l.Debug().Msg("PlexWebhookHandler: Received request")
+
+		u := webhookUserFromRequest(w, r, store)
+		if u == nil {
+			return
+		}
+
+		if err := r.ParseMultipartForm(maxPlexWebhookMemory); err != nil {
+			l.Debug().AnErr("error", err).Msg("PlexWebhookHandler: Failed to parse form")
+			utils.WriteError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		var payload PlexWebhookPayload
+		if err := json.Unmarshal([]byte(r.FormValue("payload")), &payload); err != nil {
+			l.Debug().AnErr("error", err).Msg("PlexWebhookHandler: Failed to decode payload")
+			utils.WriteError(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		if payload.Metadata.Type != "track" {
+			l.Debug().Msgf("PlexWebhookHandler: Ignoring %s for item of type '%s'", payload.Event, payload.Metadata.Type)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		play := webhookPlay{
+			Username:   payload.Account.Title,
+			NowPlaying: payload.Event == "media.play" || payload.Event == "media.resume",
+			Finished:   payload.Event == "media.scrobble",
+			Opts: catalog.SubmitListenOpts{
+				MbzCaller:    mbzc,
+				Artist:       payload.Metadata.GrandparentTitle,
+				TrackTitle:   payload.Metadata.Title,
+				ReleaseTitle: payload.Metadata.ParentTitle,
+				Duration:     int32(payload.Metadata.Duration / 1000),
+				DurationMs:   int32(payload.Metadata.ViewOffset),
+				Client:       "plex",
+				MediaPlayer:  payload.Player.Title,
+			},
+		}
+		// the track artist is only sent when it differs from the album artist
+		if payload.Metadata.OriginalTitle != "" {
+			play.Opts.Artist = payload.Metadata.OriginalTitle
+		}
+		for _, guid := range payload.Metadata.Guid {
+			if mbid, ok := strings.CutPrefix(guid.ID, "mbid://"); ok {
+				play.Opts.RecordingMbzID = parseMbid(mbid)
+			}
+		}
+
+		handleWebhookPlay(w, r, store, u, play)
+	}
+}
+
+// JellyfinWebhookHandler accepts the JSON sent by the Jellyfin webhook plugin.
+// PlaybackStart sets the track as now playing, and PlaybackStop saves the
+// listen when the track was played to completion.
+func JellyfinWebhookHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		l.Debug().Msg("JellyfinWebhookHandler: Received request")
+
+		u := webhookUserFromRequest(w, r, store)
+		if u == nil {
+			return
+		}
+
+		var payload JellyfinWebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			l.Debug().AnErr("error", err).Msg("JellyfinWebhookHandler: Failed to decode payload")
+			utils.WriteError(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		if payload.ItemType != "Audio" {
+			l.Debug().Msgf("JellyfinWebhookHandler: Ignoring %s for item of type '%s'", payload.NotificationType, payload.ItemType)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		play := webhookPlay{
+			Username:   payload.NotificationUsername,
+			NowPlaying: payload.NotificationType == "PlaybackStart",
+			Finished:   payload.NotificationType == "PlaybackStop" && strings.EqualFold(payload.PlayedToCompletion, "true"),
+			Opts: catalog.SubmitListenOpts{
+				MbzCaller:               mbzc,
+				Artist:                  payload.Artist,
+				TrackTitle:              payload.Name,
+				ReleaseTitle:            payload.Album,
+				Duration:                int32(parseTicks(payload.RunTimeTicks) / ticksPerMillisecond / 1000),
+				DurationMs:              int32(parseTicks(payload.PlaybackPositionTicks) / ticksPerMillisecond),
+				Client:                  "jellyfin",
+				MediaPlayer:             payload.ClientName,
+				SubmissionClientVersion: payload.ServerVersion,
+				RecordingMbzID:          parseMbid(payload.ProviderMusicBrainzRecording),
+				ReleaseMbzID:            parseMbid(payload.ProviderMusicBrainzAlbum),
+				ReleaseGroupMbzID:       parseMbid(payload.ProviderMusicBrainzGroup),
+			},
+		}
+		// the artist provider id only holds a single id, so it is only used
+		// when there is a single artist, or the other artists would be lost
+		id := parseMbid(payload.ProviderMusicBrainzArtist)
+		if id != uuid.Nil && len(catalog.ParseArtists(payload.Artist, payload.Name, cfg.ArtistSeparators())) == 1 {
+			play.Opts.ArtistMbzIDs = []uuid.UUID{id}
+		}
+
+		handleWebhookPlay(w, r, store, u, play)
+	}
+}
+
+// EmbyWebhookHandler accepts the JSON webhooks sent by Emby Server.
+// playback.start and playback.unpause set the track as now playing, and
+// playback.stop saves the listen when the track was played to completion.
+func EmbyWebhookHandler(store db.DB, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		l.Debug().Msg("EmbyWebhookHandler: Received request")
+
+		u := webhookUserFromRequest(w, r, store)
+		if u == nil {
+			return
+		}
+
+		var payload EmbyWebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			l.Debug().AnErr("error", err).Msg("EmbyWebhookHandler: Failed to decode payload")
+			utils.WriteError(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		if payload.Item.Type != "Audio" {
+			l.Debug().Msgf("EmbyWebhookHandler: Ignoring %s for item of type '%s'", payload.Event, payload.Item.Type)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		play := webhookPlay{
+			Username:   payload.User.Name,
+			NowPlaying: payload.Event == "playback.start" || payload.Event == "playback.unpause",
+			Finished:   payload.Event == "playback.stop" && payload.PlaybackInfo.PlayedToCompletion,
+			Opts: catalog.SubmitListenOpts{
+				MbzCaller:               mbzc,
+				Artist:                  payload.Item.AlbumArtist,
+				ArtistNames:             payload.Item.Artists,
+				TrackTitle:              payload.Item.Name,
+				ReleaseTitle:            payload.Item.Album,
+				Duration:                int32(payload.Item.RunTimeTicks / ticksPerMillisecond / 1000),
+				DurationMs:              int32(payload.PlaybackInfo.PositionTicks / ticksPerMillisecond),
+				Client:                  "emby",
+				MediaPlayer:             payload.Session.Client,
+				SubmissionClientVersion: payload.Server.Version,
+				ReleaseMbzID:            parseMbid(payload.Item.ProviderIds["MusicBrainzAlbum"]),
+				ReleaseGroupMbzID:       parseMbid(payload.Item.ProviderIds["MusicBrainzReleaseGroup"]),
+			},
+		}
+		if len(payload.Item.Artists) > 0 {
+			play.Opts.Artist = strings.Join(payload.Item.Artists, ", ")
+		}
+		// Emby stores the recording id read from the file tags as the track id
+		play.Opts.RecordingMbzID = parseMbid(payload.Item.ProviderIds["MusicBrainzRecording"])
+		if play.Opts.RecordingMbzID == uuid.Nil {
+			play.Opts.RecordingMbzID = parseMbid(payload.Item.ProviderIds["MusicBrainzTrack"])
+		}
+		if id := parseMbid(payload.Item.ProviderIds["MusicBrainzArtist"]); id != uuid.Nil && len(payload.Item.Artists) == 1 {
+			play.Opts.ArtistMbzIDs = []uuid.UUID{id}
+		}
+
+		handleWebhookPlay(w, r, store, u, play)
+	}
+}
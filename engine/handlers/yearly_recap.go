@@ -82,17 +82,18 @@ func YearlyRecapHandler(store db.DB) http.HandlerFunc {
 		l.Debug().Msgf("YearlyRecapHandler: Fetching recap for year %d", year)
 
 		// Use PeriodYear for all stats (approximation)
-		listens, _ := store.CountListens(ctx, db.PeriodYear)
-		tracks, _ := store.CountTracks(ctx, db.PeriodYear)
-		albums, _ := store.CountAlbums(ctx, db.PeriodYear)
-		artists, _ := store.CountArtists(ctx, db.PeriodYear)
-		timeListened, _ := store.CountTimeListened(ctx, db.PeriodYear)
+		listens, _ := store.CountListens(ctx, db.PeriodYear, user.ID)
+		tracks, _ := store.CountTracks(ctx, db.PeriodYear, user.ID)
+		albums, _ := store.CountAlbums(ctx, db.PeriodYear, user.ID)
+		artists, _ := store.CountArtists(ctx, db.PeriodYear, user.ID)
+		timeListened, _ := store.CountTimeListened(ctx, db.PeriodYear, user.ID)
 
 		// Get top artist using paginated method
 		topArtistsResp, err := store.GetTopArtistsPaginated(ctx, db.GetItemsOpts{
 			Limit:  1,
 			Page:   1,
 			Period: db.PeriodYear,
+			UserID: user.ID,
 		})
 		var topArtist *TopArtistRecap
 		if err == nil && len(topArtistsResp.Items) > 0 {
@@ -110,6 +111,7 @@ func YearlyRecapHandler(store db.DB) http.HandlerFunc {
 			Limit:  1,
 			Page:   1,
 			Period: db.PeriodYear,
+			UserID: user.ID,
 		})
 		var topAlbum *TopAlbumRecap
 		if err == nil && len(topAlbumsResp.Items) > 0 {
@@ -132,6 +134,7 @@ func YearlyRecapHandler(store db.DB) http.HandlerFunc {
 			Limit:  1,
 			Page:   1,
 			Period: db.PeriodYear,
+			UserID: user.ID,
 		})
 		var topTrack *TopTrackRecap
 		if err == nil && len(topTracksResp.Items) > 0 {
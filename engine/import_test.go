@@ -5,14 +5,18 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/SaturnX-Dev/Beat-Scrobble/engine"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importjob"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -35,7 +39,7 @@ func TestImportMaloja(t *testing.T) {
 	engine.RunImporter(logger.Get(), store, &mbz.MbzErrorCaller{})
 
 	// maloja test import is 38 Magnify Tokyo streams
-	a, err := store.GetArtist(context.Background(), db.GetArtistOpts{Name: "Magnify Tokyo"})
+	a, err := store.GetArtist(context.Background(), db.GetArtistOpts{Name: "Magnify Tokyo", UserID: 1})
 	require.NoError(t, err)
 	t.Log(a)
 	assert.Equal(t, "Magnify Tokyo", a.Name)
@@ -112,7 +116,7 @@ func TestImportLastFM(t *testing.T) {
 	track, err := store.GetTrack(context.Background(), db.GetTrackOpts{Title: "because I'm stupid?", ArtistIDs: []int32{artist.ID}})
 	require.NoError(t, err)
 	t.Log(track)
-	listens, err := store.GetListensPaginated(context.Background(), db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime})
+	listens, err := store.GetListensPaginated(context.Background(), db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, listens.Items, 1)
 	assert.WithinDuration(t, time.Unix(1749776100, 0), listens.Items[0].Time, 1*time.Second)
@@ -146,7 +150,7 @@ func TestImportLastFM_MbzDisabled(t *testing.T) {
 	track, err := store.GetTrack(context.Background(), db.GetTrackOpts{Title: "because I'm stupid?", ArtistIDs: []int32{artist.ID}})
 	require.NoError(t, err)
 	t.Log(track)
-	listens, err := store.GetListensPaginated(context.Background(), db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime})
+	listens, err := store.GetListensPaginated(context.Background(), db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, listens.Items, 1)
 	assert.WithinDuration(t, time.Unix(1749776100, 0), listens.Items[0].Time, 1*time.Second)
@@ -216,7 +220,7 @@ func TestImportListenBrainz(t *testing.T) {
 	track, err := store.GetTrack(context.Background(), db.GetTrackOpts{MusicBrainzID: uuid.MustParse("08e8f55b-f1a4-46b8-b2d1-fab4c592165c")})
 	require.NoError(t, err)
 	assert.Equal(t, "Desert", track.Title)
-	listens, err := store.GetListensPaginated(context.Background(), db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime})
+	listens, err := store.GetListensPaginated(context.Background(), db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime, UserID: 1})
 	require.NoError(t, err)
 	assert.Len(t, listens.Items, 1)
 	assert.WithinDuration(t, time.Unix(1749780612, 0), listens.Items[0].Time, 1*time.Second)
@@ -254,7 +258,7 @@ func TestImportListenBrainz_MbzDisabled(t *testing.T) {
 	track, err := store.GetTrack(context.Background(), db.GetTrackOpts{MusicBrainzID: uuid.MustParse("08e8f55b-f1a4-46b8-b2d1-fab4c592165c")})
 	require.NoError(t, err)
 	assert.Equal(t, "Desert", track.Title)
-	listens, err := store.GetListensPaginated(context.Background(), db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime})
+	listens, err := store.GetListensPaginated(context.Background(), db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime, UserID: 1})
 	require.NoError(t, err)
 	assert.Len(t, listens.Items, 1)
 	assert.WithinDuration(t, time.Unix(1749780612, 0), listens.Items[0].Time, 1*time.Second)
@@ -326,15 +330,204 @@ func TestImportBeatScrobble(t *testing.T) {
 	_, err = store.GetTrack(ctx, db.GetTrackOpts{Title: "GIRI GIRI", ArtistIDs: []int32{artist.ID}})
 	require.NoError(t, err)
 
-	count, err := store.CountTracks(ctx, db.PeriodAllTime)
+	count, err := store.CountTracks(ctx, db.PeriodAllTime, 1)
 	require.NoError(t, err)
 	assert.EqualValues(t, 4, count)
-	count, err = store.CountAlbums(ctx, db.PeriodAllTime)
+	count, err = store.CountAlbums(ctx, db.PeriodAllTime, 1)
 	require.NoError(t, err)
 	assert.EqualValues(t, 3, count)
-	count, err = store.CountArtists(ctx, db.PeriodAllTime)
+	count, err = store.CountArtists(ctx, db.PeriodAllTime, 1)
 	require.NoError(t, err)
 	assert.EqualValues(t, 6, count)
 
 	truncateTestData(t)
 }
+
+func TestImportScrobblerLog(t *testing.T) {
+
+	src := path.Join("..", "test_assets", "rockbox_import_test.scrobbler.log")
+	destDir := filepath.Join(cfg.ConfigDir(), "import")
+	dest := filepath.Join(destDir, "rockbox_import_test.scrobbler.log")
+
+	ctx := context.Background()
+
+	input, err := os.ReadFile(src)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(dest, input, os.ModePerm))
+
+	engine.RunImporter(logger.Get(), store, &mbz.MbzErrorCaller{})
+
+	// the track rated S is skipped
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: uuid.MustParse("3e6c4cf8-5bdf-4a6e-a5e5-5d0d2e8e9f9a")})
+	require.NoError(t, err)
+	assert.Equal(t, "Night Drive", track.Title)
+	assert.EqualValues(t, 214, track.Duration)
+	listens, err := store.GetListensPaginated(ctx, db.GetItemsOpts{TrackID: int(track.ID), Period: db.PeriodAllTime, UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, listens.Items, 1)
+	assert.WithinDuration(t, time.Unix(1749776100, 0), listens.Items[0].Time, 1*time.Second)
+
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{Name: "Magnify Tokyo", UserID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, artist.ListenCount)
+	_, err = store.GetTrack(ctx, db.GetTrackOpts{Title: "Skipped Song", ArtistIDs: []int32{artist.ID}})
+	assert.Error(t, err)
+
+	truncateTestData(t)
+}
+
+func TestImportCsv_AppleMusic(t *testing.T) {
+
+	src := path.Join("..", "test_assets", "Apple Music Play Activity.csv")
+	destDir := filepath.Join(cfg.ConfigDir(), "import")
+	dest := filepath.Join(destDir, "Apple Music Play Activity.csv")
+
+	ctx := context.Background()
+
+	input, err := os.ReadFile(src)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(dest, input, os.ModePerm))
+
+	engine.RunImporter(logger.Get(), store, &mbz.MbzErrorCaller{})
+
+	// only PLAY_END events of plays that were not skipped are listens
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{Name: "Danger Mouse", UserID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, artist.ListenCount)
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{Title: "Ministry", ArtistIDs: []int32{artist.ID}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 291, track.Duration)
+	_, err = store.GetTrack(ctx, db.GetTrackOpts{Title: "Woke Up Lonely", ArtistIDs: []int32{artist.ID}})
+	assert.Error(t, err)
+
+	truncateTestData(t)
+}
+
+func TestImportYouTubeMusic(t *testing.T) {
+
+	src := path.Join("..", "test_assets", "watch-history.json")
+	destDir := filepath.Join(cfg.ConfigDir(), "import")
+	dest := filepath.Join(destDir, "watch-history.json")
+
+	ctx := context.Background()
+
+	input, err := os.ReadFile(src)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(dest, input, os.ModePerm))
+
+	engine.RunImporter(logger.Get(), store, &mbz.MbzErrorCaller{})
+
+	// the topic channel and the music video are the same track
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{Name: "Danger Mouse", UserID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, artist.ListenCount)
+	_, err = store.GetTrack(ctx, db.GetTrackOpts{Title: "Ministry", ArtistIDs: []int32{artist.ID}})
+	require.NoError(t, err)
+	_, err = store.GetArtist(ctx, db.GetArtistOpts{Name: "Home Tips"})
+	assert.Error(t, err)
+
+	truncateTestData(t)
+}
+
+func TestImportJob(t *testing.T) {
+
+	src := path.Join("..", "test_assets", "maloja_import_test.json")
+
+	ctx := context.Background()
+	defer func() {
+		require.NoError(t, store.Exec(ctx, `TRUNCATE import_jobs RESTART IDENTITY`))
+	}()
+
+	file, err := os.Open(src)
+	require.NoError(t, err)
+	defer file.Close()
+
+	job, err := importjob.Create(ctx, store, importjob.CreateOpts{
+		UserID:   1,
+		Filename: "maloja_import_test.json",
+		File:     file,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportMaloja, job.Format)
+	assert.Equal(t, models.ImportJobQueued, job.Status)
+
+	// resume as if the job was interrupted after 30 of its 38 listens
+	ok, err := store.StartImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	job.Offset = 30
+	job.Imported = 30
+	_, err = store.SaveImportJobProgress(ctx, job)
+	require.NoError(t, err)
+
+	next, err := store.GetNextImportJob(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	require.NoError(t, importjob.Run(ctx, store, &mbz.MbzErrorCaller{}, next))
+
+	job, err = store.GetImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportJobSucceeded, job.Status)
+	assert.Equal(t, 38, job.Offset)
+	assert.Equal(t, 38, job.Imported)
+	assert.Equal(t, job.Size, job.BytesRead)
+	assert.NotNil(t, job.FinishedAt)
+
+	a, err := store.GetArtist(ctx, db.GetArtistOpts{Name: "Magnify Tokyo", UserID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 8, a.ListenCount)
+
+	// its file is removed once the job finishes
+	_, err = os.Stat(filepath.Join(importjob.Dir(), strconv.FormatInt(job.ID, 10)))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	truncateTestData(t)
+}
+
+func TestImportJob_Cancel(t *testing.T) {
+
+	ctx := context.Background()
+	defer func() {
+		require.NoError(t, store.Exec(ctx, `TRUNCATE import_jobs RESTART IDENTITY`))
+	}()
+
+	_, err := importjob.Create(ctx, store, importjob.CreateOpts{
+		UserID:   1,
+		Filename: "maloja_import_test.json",
+		File:     strings.NewReader(`{"scrobbles": [`),
+	})
+	assert.ErrorIs(t, err, importjob.ErrInvalidFile)
+	_, err = importjob.Create(ctx, store, importjob.CreateOpts{
+		UserID:   1,
+		Filename: "listens.txt",
+		File:     strings.NewReader(""),
+	})
+	assert.ErrorIs(t, err, importjob.ErrUnknownFormat)
+
+	file, err := os.Open(path.Join("..", "test_assets", "maloja_import_test.json"))
+	require.NoError(t, err)
+	defer file.Close()
+	job, err := importjob.Create(ctx, store, importjob.CreateOpts{
+		UserID:   1,
+		Filename: "maloja_import_test.json",
+		File:     file,
+	})
+	require.NoError(t, err)
+
+	ok, err := importjob.Cancel(ctx, store, 1, job.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = importjob.Cancel(ctx, store, 1, job.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	job, err = store.GetImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportJobCanceled, job.Status)
+	next, err := store.GetNextImportJob(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+}
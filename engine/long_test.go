@@ -3,6 +3,8 @@ package engine_test
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -21,6 +23,7 @@ import (
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/rewrite"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,6 +90,15 @@ func truncateTestData(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// waitForIngest blocks until the ingest workers have processed every queued
+// submission.
+func waitForIngest(t *testing.T) {
+	require.Eventually(t, func() bool {
+		count, err := store.Count(context.Background(), `SELECT COUNT(*) FROM listen_submissions WHERE status <> 'dead'`)
+		return err == nil && count == 0
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
 func doSubmitListens(t *testing.T) {
 	login(t)
 	getApiKey(t, session)
@@ -181,6 +193,8 @@ func doSubmitListens(t *testing.T) {
 		respBytes, err := io.ReadAll(resp.Body)
 		require.NoError(t, err)
 		assert.Equal(t, `{"status": "ok"}`, string(respBytes))
+		// wait between submissions so ids are assigned in submission order
+		waitForIngest(t)
 	}
 }
 
@@ -219,6 +233,10 @@ func TestGetters(t *testing.T) {
 	require.Len(t, listens.Items, 3)
 	assert.EqualValues(t, 2, listens.Items[0].Track.ID)
 	assert.Equal(t, "Where Our Blue Is", listens.Items[0].Track.Title)
+	// details from additional_info are kept with the listen
+	assert.Equal(t, "navidrome", listens.Items[0].Client)
+	assert.Equal(t, "0.56.1 (fa2cf362)", listens.Items[0].SubmissionClientVersion)
+	assert.EqualValues(t, 197270, listens.Items[0].DurationMs)
 
 	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/top-artists")
 	assert.NoError(t, err)
@@ -640,6 +658,7 @@ func TestDeleteListen(t *testing.T) {
 	respBytes, err := io.ReadAll(resp.Body)
 	require.NoError(t, err)
 	assert.Equal(t, `{"status": "ok"}`, string(respBytes))
+	waitForIngest(t)
 
 	resp, err = makeAuthRequest(t, session, "DELETE", "/apis/web/v1/listen?track_id=1&unix=1749475719", nil)
 	require.NoError(t, err)
@@ -691,7 +710,7 @@ func TestArtistReplaceImage(t *testing.T) {
 	newid, err := uuid.Parse(response.Image)
 	require.NoError(t, err)
 
-	a, err := store.GetArtist(context.Background(), db.GetArtistOpts{ID: 1})
+	a, err := store.GetArtist(context.Background(), db.GetArtistOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.NotNil(t, a.Image)
 	assert.Equal(t, newid, *a.Image)
@@ -729,7 +748,7 @@ func TestAlbumReplaceImage(t *testing.T) {
 	newid, err := uuid.Parse(response.Image)
 	require.NoError(t, err)
 
-	a, err := store.GetAlbum(context.Background(), db.GetAlbumOpts{ID: 1})
+	a, err := store.GetAlbum(context.Background(), db.GetAlbumOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.NotNil(t, a.Image)
 	assert.Equal(t, newid, *a.Image)
@@ -847,6 +866,7 @@ func TestSetPrimaryArtist(t *testing.T) {
 	respBytes, err := io.ReadAll(resp.Body)
 	require.NoError(t, err)
 	assert.Equal(t, `{"status": "ok"}`, string(respBytes))
+	waitForIngest(t)
 
 	// set both artists as primary
 
@@ -974,3 +994,472 @@ func TestNowPlaying(t *testing.T) {
 	require.True(t, result.CurrentlyPlaying)
 	require.Equal(t, "花の塔", result.Track.Title)
 }
+
+func doLastFMRequest(t *testing.T, params url.Values) (*http.Response, map[string]any) {
+	params.Set("api_key", "lastfmclient")
+	params.Set("format", "json")
+	params.Set("api_sig", handlers.LastFMSignature(params, "secret"))
+	resp, err := http.DefaultClient.Post(host()+"/apis/lastfm/2.0/", "application/x-www-form-urlencoded", strings.NewReader(params.Encode()))
+	require.NoError(t, err)
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp, body
+}
+
+func TestLastFMScrobble(t *testing.T) {
+	login(t)
+	getApiKey(t, session)
+
+	// session key is the api key
+	params := url.Values{}
+	params.Set("method", "auth.getMobileSession")
+	params.Set("username", cfg.DefaultUsername())
+	params.Set("password", apikey)
+	resp, body := doLastFMRequest(t, params)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, body, "session")
+	assert.Equal(t, apikey, body["session"].(map[string]any)["key"])
+
+	// account password also returns a session
+	params.Set("password", cfg.DefaultPassword())
+	resp, body = doLastFMRequest(t, params)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, apikey, body["session"].(map[string]any)["key"])
+
+	params.Set("password", "wrongpassword")
+	resp, body = doLastFMRequest(t, params)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.EqualValues(t, 4, body["error"])
+
+	params = url.Values{}
+	params.Set("method", "track.updateNowPlaying")
+	params.Set("sk", apikey)
+	params.Set("artist", "さユり")
+	params.Set("track", "花の塔")
+	params.Set("album", "酸欠少女")
+	params.Set("duration", "275")
+	resp, _ = doLastFMRequest(t, params)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/now-playing")
+	require.NoError(t, err)
+	var np handlers.NowPlayingResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&np))
+	require.True(t, np.CurrentlyPlaying)
+	assert.Equal(t, "花の塔", np.Track.Title)
+
+	params = url.Values{}
+	params.Set("method", "track.scrobble")
+	params.Set("sk", apikey)
+	params.Set("artist[0]", "さユり")
+	params.Set("track[0]", "花の塔")
+	params.Set("album[0]", "酸欠少女")
+	params.Set("timestamp[0]", strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10))
+	params.Set("artist[1]", "ネクライトーキー")
+	params.Set("track[1]", "こんがらがった！")
+	params.Set("album[1]", "ONE!")
+	params.Set("timestamp[1]", strconv.FormatInt(time.Now().Add(-5*time.Minute).Unix(), 10))
+	params.Set("artist[2]", "ネクライトーキー")
+	params.Set("track[2]", "")
+	params.Set("timestamp[2]", strconv.FormatInt(time.Now().Unix(), 10))
+	resp, body = doLastFMRequest(t, params)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	attr := body["scrobbles"].(map[string]any)["@attr"].(map[string]any)
+	assert.EqualValues(t, 2, attr["accepted"])
+	assert.EqualValues(t, 1, attr["ignored"])
+
+	waitForIngest(t)
+	count, _ := store.Count(context.Background(), `SELECT COUNT(*) FROM listens`)
+	assert.Equal(t, 2, count)
+
+	params.Set("sk", "thisisasuperinvalidtoken")
+	resp, body = doLastFMRequest(t, params)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.EqualValues(t, 9, body["error"])
+
+	truncateTestData(t)
+}
+
+func TestAudioscrobblerSubmission(t *testing.T) {
+	login(t)
+	getApiKey(t, session)
+
+	md5hex := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	handshake := func(auth string) []string {
+		q := url.Values{}
+		q.Set("hs", "true")
+		q.Set("p", "1.2")
+		q.Set("c", "tst")
+		q.Set("v", "1.0")
+		q.Set("u", cfg.DefaultUsername())
+		q.Set("t", ts)
+		q.Set("a", auth)
+		resp, err := http.DefaultClient.Get(host() + "/apis/audioscrobbler/?" + q.Encode())
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(body)), "\n")
+	}
+
+	lines := handshake(md5hex(md5hex("notanapikey") + ts))
+	assert.Equal(t, []string{"BADAUTH"}, lines)
+
+	lines = handshake(md5hex(md5hex(apikey) + ts))
+	require.Len(t, lines, 4)
+	require.Equal(t, "OK", lines[0])
+	sid := lines[1]
+	assert.True(t, strings.HasSuffix(lines[2], "/apis/audioscrobbler/nowplaying"))
+	assert.True(t, strings.HasSuffix(lines[3], "/apis/audioscrobbler/submissions"))
+
+	post := func(endpoint string, form url.Values) string {
+		resp, err := http.DefaultClient.Post(host()+endpoint, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return strings.TrimSpace(string(body))
+	}
+
+	form := url.Values{}
+	form.Set("s", sid)
+	form.Set("a", "さユり")
+	form.Set("t", "花の塔")
+	form.Set("b", "酸欠少女")
+	form.Set("l", "275")
+	assert.Equal(t, "OK", post("/apis/audioscrobbler/nowplaying", form))
+
+	form = url.Values{}
+	form.Set("s", sid)
+	form.Set("a[0]", "さユり")
+	form.Set("t[0]", "花の塔")
+	form.Set("b[0]", "酸欠少女")
+	form.Set("i[0]", strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10))
+	form.Set("o[0]", "P")
+	form.Set("a[1]", "ネクライトーキー")
+	form.Set("t[1]", "こんがらがった！")
+	form.Set("i[1]", strconv.FormatInt(time.Now().Add(-5*time.Minute).Unix(), 10))
+	form.Set("o[1]", "L")
+	form.Set("r[1]", "S")
+	assert.Equal(t, "OK", post("/apis/audioscrobbler/submissions", form))
+
+	waitForIngest(t)
+	count, _ := store.Count(context.Background(), `SELECT COUNT(*) FROM listens WHERE client = 'tst'`)
+	assert.Equal(t, 1, count)
+
+	form.Set("s", "notasession")
+	assert.Equal(t, "BADSESSION", post("/apis/audioscrobbler/submissions", form))
+
+	truncateTestData(t)
+}
+
+func TestLbzReadApi(t *testing.T) {
+
+	t.Run("Submit Listens", doSubmitListens)
+
+	resp, err := http.DefaultClient.Get(host() + "/apis/listenbrainz/1/user/test/listens?count=2")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listens handlers.LbzListensResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listens))
+	require.Len(t, listens.Payload.Listens, 2)
+	assert.Equal(t, 2, listens.Payload.Count)
+	assert.Equal(t, "test", listens.Payload.UserID)
+	assert.Equal(t, "Where Our Blue Is", listens.Payload.Listens[0].TrackMeta.TrackName)
+	assert.Equal(t, listens.Payload.Listens[0].ListenedAt, listens.Payload.LatestListenTs)
+
+	// only listens before the most recent one
+	resp, err = http.DefaultClient.Get(fmt.Sprintf("%s/apis/listenbrainz/1/user/test/listens?max_ts=%d", host(), listens.Payload.LatestListenTs))
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listens))
+	assert.Len(t, listens.Payload.Listens, 2)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/listenbrainz/1/user/test/playing-now")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listens))
+	assert.False(t, listens.Payload.PlayingNow)
+	assert.Empty(t, listens.Payload.Listens)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/listenbrainz/1/stats/user/test/artists?range=all_time")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var artists handlers.LbzStatsResponse[handlers.LbzArtistStatsPayload]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&artists))
+	assert.Len(t, artists.Payload.Artists, 3)
+	assert.EqualValues(t, 3, artists.Payload.TotalArtistCount)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/listenbrainz/1/stats/user/test/recordings?count=1&offset=1")
+	require.NoError(t, err)
+	var recordings handlers.LbzStatsResponse[handlers.LbzRecordingStatsPayload]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&recordings))
+	assert.Len(t, recordings.Payload.Recordings, 1)
+	assert.Equal(t, 1, recordings.Payload.Offset)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/listenbrainz/1/stats/user/test/releases?range=fortnight")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/listenbrainz/1/user/nobody/listens")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	truncateTestData(t)
+}
+
+func TestIngestDeadLetters(t *testing.T) {
+	login(t)
+	truncateTestData(t)
+
+	ctx := context.Background()
+	payload := fmt.Sprintf(`{"Artist": "さユり", "TrackTitle": "花の塔", "ReleaseTitle": "酸欠少女", "Time": "%s"}`, time.Now().Add(-time.Hour).Format(time.RFC3339))
+	err := store.Exec(ctx, `INSERT INTO listen_submissions (user_id, payload, status, attempts, last_error) VALUES (1, $1, 'dead', 8, 'failed'), (1, $1, 'dead', 8, 'failed')`, payload)
+	require.NoError(t, err)
+
+	resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/ingest/dead-letters", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var dead db.PaginatedResponse[models.ListenSubmission]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&dead))
+	require.Len(t, dead.Items, 2)
+	assert.Equal(t, "failed", dead.Items[0].LastError)
+
+	resp, err = makeAuthRequest(t, session, "DELETE", fmt.Sprintf("/apis/web/v1/ingest/dead-letters?id=%d", dead.Items[0].ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/ingest/dead-letters/retry?id=%d", dead.Items[0].ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// retried submissions are processed by the workers
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/ingest/dead-letters/retry?id=%d", dead.Items[1].ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	waitForIngest(t)
+	count, _ := store.Count(ctx, `SELECT COUNT(*) FROM listens`)
+	assert.Equal(t, 1, count)
+	count, _ = store.Count(ctx, `SELECT COUNT(*) FROM listen_submissions`)
+	assert.Equal(t, 0, count)
+
+	truncateTestData(t)
+}
+
+func TestRewriteRules(t *testing.T) {
+	login(t)
+	getApiKey(t, session)
+	truncateTestData(t)
+	defer func() {
+		require.NoError(t, store.Exec(context.Background(), `TRUNCATE rewrite_rules RESTART IDENTITY`))
+	}()
+
+	createRule := func(form url.Values) models.RewriteRule {
+		resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/rewrite-rules", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var rule models.RewriteRule
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rule))
+		return rule
+	}
+	topic := createRule(url.Values{"field": {"artist"}, "pattern": {` - Topic$`}, "action": {"replace"}})
+	createRule(url.Values{"field": {"title"}, "pattern": {`(?i)\s*\(official video\)`}, "action": {"replace"}, "position": {"1"}})
+	drop := createRule(url.Values{"field": {"client"}, "pattern": {`^podcasts$`}, "action": {"drop"}, "position": {"2"}})
+
+	// invalid rules are rejected
+	resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/rewrite-rules", strings.NewReader(url.Values{"field": {"title"}, "pattern": {"("}, "action": {"replace"}}.Encode()))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/rewrite-rules", nil)
+	require.NoError(t, err)
+	var rules []models.RewriteRule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rules))
+	require.Len(t, rules, 3)
+	assert.Equal(t, topic.ID, rules[0].ID)
+
+	form := url.Values{"artist": {"ヨルシカ - Topic"}, "title": {"春泥棒 (Official Video)"}, "album": {"盗作"}, "client": {"youtube"}}
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/rewrite-rules/test", strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var result rewrite.Result
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.False(t, result.Dropped)
+	assert.Equal(t, "ヨルシカ", result.Listen.Artist)
+	assert.Equal(t, "春泥棒", result.Listen.Title)
+	assert.Len(t, result.Applied, 2)
+
+	// disabled rules are not applied
+	resp, err = makeAuthRequest(t, session, "PATCH", fmt.Sprintf("/apis/web/v1/rewrite-rules?id=%d", topic.ID), strings.NewReader("enabled=false"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/rewrite-rules/test", strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "ヨルシカ - Topic", result.Listen.Artist)
+	resp, err = makeAuthRequest(t, session, "PATCH", fmt.Sprintf("/apis/web/v1/rewrite-rules?id=%d", topic.ID), strings.NewReader("enabled=true"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// rules are applied to submitted listens
+	submit := func(client string) {
+		body := fmt.Sprintf(`{
+			"listen_type": "single",
+			"payload": [
+				{
+					"listened_at": %d,
+					"track_metadata": {
+						"additional_info": {
+							"artist_names": ["ヨルシカ - Topic"],
+							"submission_client": "%s"
+						},
+						"artist_name": "ヨルシカ - Topic",
+						"release_name": "盗作",
+						"track_name": "春泥棒 (Official Video)"
+					}
+				}
+			]
+		}`, time.Now().Add(-time.Hour).Unix(), client)
+		req, err := http.NewRequest("POST", host()+"/apis/listenbrainz/1/submit-listens", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Add("Authorization", fmt.Sprintf("Token %s", apikey))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		waitForIngest(t)
+	}
+	submit("youtube")
+	submit("podcasts")
+
+	count, _ := store.Count(context.Background(), `SELECT COUNT(*) FROM listens`)
+	assert.Equal(t, 1, count)
+	count, _ = store.Count(context.Background(), `SELECT COUNT(*) FROM artists_with_name WHERE name = 'ヨルシカ'`)
+	assert.Equal(t, 1, count)
+	count, _ = store.Count(context.Background(), `SELECT COUNT(*) FROM tracks_with_title WHERE title = '春泥棒'`)
+	assert.Equal(t, 1, count)
+
+	resp, err = makeAuthRequest(t, session, "DELETE", fmt.Sprintf("/apis/web/v1/rewrite-rules?id=%d", drop.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, err = makeAuthRequest(t, session, "DELETE", fmt.Sprintf("/apis/web/v1/rewrite-rules?id=%d", drop.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	truncateTestData(t)
+}
+
+func TestMediaServerWebhooks(t *testing.T) {
+	login(t)
+	getApiKey(t, session)
+	truncateTestData(t)
+	ctx := context.Background()
+
+	postJSON := func(endpoint, body string) int {
+		resp, err := http.DefaultClient.Post(host()+endpoint, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	jellyfin := func(event, completed string) string {
+		return fmt.Sprintf(`{
+			"NotificationType": "%s",
+			"NotificationUsername": "tester",
+			"ItemType": "Audio",
+			"Name": "花の塔",
+			"Artist": "さユり",
+			"Album": "酸欠少女",
+			"RunTimeTicks": "2759600000",
+			"PlaybackPositionTicks": "2759000000",
+			"PlayedToCompletion": "%s",
+			"ClientName": "Finamp",
+			"ServerVersion": "10.10.3",
+			"Provider_musicbrainzrecording": "21524d55-b1f8-45d1-b172-976cba447199",
+			"Provider_musicbrainzartist": "efc787f0-046f-4a60-beff-77b398c8cdf4"
+		}`, event, completed)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, postJSON("/apis/webhooks/notakey/jellyfin", jellyfin("PlaybackStart", "False")))
+
+	// playback start only updates now playing
+	assert.Equal(t, http.StatusNoContent, postJSON("/apis/webhooks/"+apikey+"/jellyfin", jellyfin("PlaybackStart", "False")))
+	resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/now-playing")
+	require.NoError(t, err)
+	var np handlers.NowPlayingResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&np))
+	assert.True(t, np.CurrentlyPlaying)
+	assert.Equal(t, "花の塔", np.Track.Title)
+
+	// stopping before the end is not a listen
+	assert.Equal(t, http.StatusNoContent, postJSON("/apis/webhooks/"+apikey+"/jellyfin", jellyfin("PlaybackStop", "False")))
+	// plays from other accounts are ignored when filtering by user
+	assert.Equal(t, http.StatusNoContent, postJSON("/apis/webhooks/"+apikey+"/jellyfin?user=someone", jellyfin("PlaybackStop", "True")))
+	assert.Equal(t, http.StatusNoContent, postJSON("/apis/webhooks/"+apikey+"/jellyfin?user=tester", jellyfin("PlaybackStop", "True")))
+	waitForIngest(t)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE client = 'jellyfin' AND media_player = 'Finamp' AND duration_ms = 275900`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM tracks WHERE musicbrainz_id = '21524d55-b1f8-45d1-b172-976cba447199'`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// emby
+	emby := `{
+		"Event": "playback.stop",
+		"User": {"Name": "tester"},
+		"Server": {"Version": "4.8.10.0"},
+		"Session": {"Client": "Emby Web"},
+		"Item": {
+			"Type": "Audio",
+			"Name": "Where Our Blue Is",
+			"Album": "Where Our Blue Is",
+			"AlbumArtist": "キタニタツヤ",
+			"Artists": ["キタニタツヤ"],
+			"RunTimeTicks": 1972700000,
+			"ProviderIds": {"MusicBrainzTrack": "4e909c21-e7a8-404d-b75a-0c8c2926efb0"}
+		},
+		"PlaybackInfo": {"PlayedToCompletion": true, "PositionTicks": 1972700000}
+	}`
+	assert.Equal(t, http.StatusNoContent, postJSON("/apis/webhooks/"+apikey+"/emby", emby))
+	waitForIngest(t)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE client = 'emby' AND submission_client_version = '4.8.10.0'`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// plex sends the payload as a multipart form
+	plex := func(event string) int {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		require.NoError(t, mw.WriteField("payload", fmt.Sprintf(`{
+			"event": "%s",
+			"Account": {"title": "tester"},
+			"Player": {"title": "Plexamp"},
+			"Metadata": {
+				"type": "track",
+				"title": "こんがらがった！",
+				"parentTitle": "ONE!",
+				"grandparentTitle": "ネクライトーキー",
+				"duration": 241560,
+				"Guid": [{"id": "mbid://8eec4f3f-a059-4217-aad1-fbf82e33e756"}]
+			}
+		}`, event)))
+		require.NoError(t, mw.Close())
+		resp, err := http.DefaultClient.Post(host()+"/apis/webhooks/"+apikey+"/plex", mw.FormDataContentType(), &buf)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusNoContent, plex("media.pause"))
+	assert.Equal(t, http.StatusNoContent, plex("media.scrobble"))
+	waitForIngest(t)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE client = 'plex' AND media_player = 'Plexamp'`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens`)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	truncateTestData(t)
+}
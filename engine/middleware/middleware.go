@@ -112,3 +112,18 @@ func Logger(baseLogger *zerolog.Logger) func(next http.Handler) http.Handler {
 		return http.HandlerFunc(fn)
 	}
 }
+
+// CancelOnDone cancels the context of requests once done is done. Streams of
+// events never finish on their own, so they are wrapped with it to end when
+// the server shuts down rather than hold the shutdown up.
+func CancelOnDone(done context.Context) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			stop := context.AfterFunc(done, cancel)
+			defer stop()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -71,6 +71,41 @@ func ValidateSession(store db.DB) func(next http.Handler) http.Handler {
 	}
 }
 
+// OptionalSession attaches the user owning the session cookie to the request
+// context when one is present, but never rejects the request. It is used on
+// read-only routes when the login gate is disabled so that responses can still
+// be scoped to the logged in user.
+func OptionalSession(store db.DB) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := logger.FromContext(r.Context())
+
+			cookie, err := r.Cookie("beat_scrobble_session")
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			sid, err := uuid.Parse(cookie.Value)
+			if err != nil {
+				l.Debug().Msg("OptionalSession: Could not parse UUID from session cookie; continuing without user")
+				next.ServeHTTP(w, r)
+				return
+			}
+			u, err := store.GetUserBySession(r.Context(), sid)
+			if err != nil {
+				l.Err(fmt.Errorf("OptionalSession: %w", err)).Msg("Error accessing database")
+				next.ServeHTTP(w, r)
+				return
+			}
+			if u != nil {
+				r = r.WithContext(context.WithValue(r.Context(), UserContextKey, u))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func ValidateApiKey(store db.DB) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -123,3 +158,20 @@ func GetUserFromContext(ctx context.Context) *models.User {
 	}
 	return user
 }
+
+// RequireAdmin rejects requests from users who are not admins. It must be
+// used after ValidateSession.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		u := GetUserFromContext(r.Context())
+		if u == nil || u.Role != models.UserRoleAdmin {
+			l.Debug().Msg("RequireAdmin: User is not an admin")
+			utils.WriteError(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
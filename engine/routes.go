@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -24,6 +25,8 @@ func bindRoutes(
 	ready *atomic.Bool,
 	db db.DB,
 	mbz mbz.MusicBrainzCaller,
+	// done once the server starts shutting down, which ends event streams
+	shutdown context.Context,
 ) {
 	if !(len(cfg.AllowedOrigins()) == 0) && !(cfg.AllowedOrigins()[0] == "") {
 		r.Use(cors.Handler(cors.Options{
@@ -34,12 +37,27 @@ func bindRoutes(
 	r.With(chimiddleware.RequestSize(5<<20)).
 		Get("/images/{size}/{filename}", handlers.ImageHandler(db))
 
+	// passwords are checked by /login and by the Last.fm API, which share a
+	// limit on how often they can be tried
+	var loginLimiter *httprate.RateLimiter
+	if !cfg.RateLimitDisabled() {
+		loginLimiter = httprate.NewRateLimiter(
+			10,
+			time.Minute,
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"error":"too many requests"}`, http.StatusTooManyRequests)
+			}),
+		)
+	}
+
 	r.Route("/apis/web/v1", func(r chi.Router) {
 		r.Get("/config", handlers.GetCfgHandler())
 
 		r.Group(func(r chi.Router) {
 			if cfg.LoginGate() {
 				r.Use(middleware.ValidateSession(db))
+			} else {
+				r.Use(middleware.OptionalSession(db))
 			}
 			r.Get("/artist", handlers.GetArtistHandler(db))
 			r.Get("/artists", handlers.GetArtistsForItemHandler(db))
@@ -51,19 +69,14 @@ func bindRoutes(
 			r.Get("/listens", handlers.GetListensHandler(db))
 			r.Get("/listen-activity", handlers.GetListenActivityHandler(db))
 			r.Get("/now-playing", handlers.NowPlayingHandler(db))
+			r.With(middleware.CancelOnDone(shutdown)).Get("/now-playing/stream", handlers.ActivityStreamHandler(db))
 			r.Get("/stats", handlers.StatsHandler(db))
 			r.Get("/search", handlers.SearchHandler(db))
 			r.Get("/aliases", handlers.GetAliasesHandler(db))
 		})
 		r.Post("/logout", handlers.LogoutHandler(db))
-		if !cfg.RateLimitDisabled() {
-			r.With(httprate.Limit(
-				10,
-				time.Minute,
-				httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
-					http.Error(w, `{"error":"too many requests"}`, http.StatusTooManyRequests)
-				}),
-			)).Post("/login", handlers.LoginHandler(db))
+		if loginLimiter != nil {
+			r.With(loginLimiter.Handler).Post("/login", handlers.LoginHandler(db))
 		} else {
 			r.Post("/login", handlers.LoginHandler(db))
 		}
@@ -81,15 +94,50 @@ func bindRoutes(
 			r.Get("/export", handlers.ExportHandler(db))
 			r.Post("/replace-image", handlers.ReplaceImageHandler(db))
 			r.Patch("/album", handlers.UpdateAlbumHandler(db))
+			r.Patch("/track", handlers.UpdateTrackHandler(db, mbz))
+			r.Patch("/artist", handlers.UpdateArtistHandler(db, mbz))
 			r.Post("/merge/tracks", handlers.MergeTracksHandler(db))
 			r.Post("/merge/albums", handlers.MergeReleaseGroupsHandler(db))
 			r.Post("/merge/artists", handlers.MergeArtistsHandler(db))
+			r.Post("/split/artist", handlers.SplitArtistHandler(db))
+			r.Post("/split/album", handlers.SplitAlbumHandler(db))
+			r.Post("/split/track", handlers.SplitTrackHandler(db))
 			r.Delete("/artist", handlers.DeleteArtistHandler(db))
 			r.Post("/artists/primary", handlers.SetPrimaryArtistHandler(db))
 			r.Delete("/album", handlers.DeleteAlbumHandler(db))
 			r.Delete("/track", handlers.DeleteTrackHandler(db))
 			r.Post("/listen", handlers.SubmitListenWithIDHandler(db))
 			r.Delete("/listen", handlers.DeleteListenHandler(db))
+			r.Get("/ingest/dead-letters", handlers.GetDeadLettersHandler(db))
+			r.Post("/ingest/dead-letters/retry", handlers.RetryDeadLetterHandler(db))
+			r.Delete("/ingest/dead-letters", handlers.DeleteDeadLetterHandler(db))
+			r.Get("/rewrite-rules", handlers.GetRewriteRulesHandler(db))
+			r.Post("/rewrite-rules", handlers.CreateRewriteRuleHandler(db))
+			r.Patch("/rewrite-rules", handlers.UpdateRewriteRuleHandler(db))
+			r.Delete("/rewrite-rules", handlers.DeleteRewriteRuleHandler(db))
+			r.Post("/rewrite-rules/test", handlers.TestRewriteRulesHandler(db))
+			r.Get("/listen-policy", handlers.GetListenPolicyHandler(db))
+			r.Patch("/listen-policy", handlers.UpdateListenPolicyHandler(db))
+			r.Get("/rejected-listens", handlers.GetRejectedListensHandler(db))
+			r.Post("/rejected-listens/accept", handlers.AcceptRejectedListenHandler(db))
+			r.Delete("/rejected-listens", handlers.DeleteRejectedListenHandler(db))
+			r.Get("/match-inbox", handlers.GetMatchInboxHandler(db))
+			r.Post("/match-inbox/accept", handlers.AcceptMatchHandler(db))
+			r.Post("/match-inbox/reassign", handlers.ReassignMatchHandler(db))
+			r.Post("/match-inbox/merge", handlers.MergeMatchHandler(db))
+			r.Get("/merge-suggestions", handlers.GetMergeSuggestionsHandler(db))
+			r.Post("/merge-suggestions/accept", handlers.AcceptMergeSuggestionHandler(db))
+			r.Post("/merge-suggestions/dismiss", handlers.DismissMergeSuggestionHandler(db))
+			r.Post("/merge-suggestions/scan", handlers.ScanMergeSuggestionsHandler())
+			r.Get("/audit", handlers.GetAuditLogHandler(db))
+			r.Post("/audit/undo", handlers.UndoAuditEntryHandler(db))
+			r.Get("/reassociate", handlers.ReassociationStatusHandler())
+			r.Post("/reassociate", handlers.ReassociateListensHandler(db, mbz))
+			r.Post("/musicbrainz/backfill", handlers.BackfillMbzIDsHandler(db, mbz))
+			r.With(middleware.RequireAdmin).Delete("/musicbrainz/cache", handlers.PurgeMbzCacheHandler(db))
+			r.Get("/enrichment", handlers.EnrichmentStatusHandler(db))
+			r.Post("/enrichment/run", handlers.RunEnrichmentHandler())
+			r.Get("/queues", handlers.QueueStatsHandler(mbz))
 			r.Post("/aliases", handlers.CreateAliasHandler(db))
 			r.Post("/aliases/delete", handlers.DeleteAliasHandler(db))
 			r.Post("/aliases/primary", handlers.SetPrimaryAliasHandler(db))
@@ -118,6 +166,16 @@ func bindRoutes(
 			r.Get("/yearly-recap", handlers.YearlyRecapHandler(db))
 			// Import/Backup
 			r.Post("/import", handlers.ImportHandler(db))
+			r.Post("/import/scrobbler-log", handlers.ImportScrobblerLogHandler(db))
+			r.Post("/import/csv", handlers.ImportCsvHandler(db))
+			r.Get("/import/csv/profiles", handlers.GetCsvImportProfilesHandler(db))
+			r.Post("/import/csv/profiles", handlers.SaveCsvImportProfileHandler(db))
+			r.Delete("/import/csv/profiles", handlers.DeleteCsvImportProfileHandler(db))
+			r.Get("/import/jobs", handlers.GetImportJobsHandler(db))
+			r.Post("/import/jobs", handlers.CreateImportJobHandler(db))
+			r.Get("/import/job", handlers.GetImportJobHandler(db))
+			r.With(middleware.CancelOnDone(shutdown)).Get("/import/job/stream", handlers.ImportJobStreamHandler(db))
+			r.Post("/import/job/cancel", handlers.CancelImportJobHandler(db))
 			// Profile Image
 			r.Post("/user/profile-image", handlers.UploadProfileImageBase64Handler(db))
 			// Background Image
@@ -148,6 +206,40 @@ func bindRoutes(
 
 		r.With(middleware.ValidateApiKey(db)).Post("/submit-listens", handlers.LbzSubmitListenHandler(db, mbz))
 		r.With(middleware.ValidateApiKey(db)).Get("/validate-token", handlers.LbzValidateTokenHandler(db))
+
+		r.Group(func(r chi.Router) {
+			if cfg.LoginGate() {
+				r.Use(middleware.ValidateApiKey(db))
+			}
+			r.Get("/user/{username}/listens", handlers.LbzGetListensHandler(db))
+			r.Get("/user/{username}/playing-now", handlers.LbzPlayingNowHandler(db))
+			r.Get("/stats/user/{username}/artists", handlers.LbzUserArtistStatsHandler(db))
+			r.Get("/stats/user/{username}/releases", handlers.LbzUserReleaseStatsHandler(db))
+			r.Get("/stats/user/{username}/recordings", handlers.LbzUserRecordingStatsHandler(db))
+		})
+	})
+
+	r.Route("/apis/lastfm/2.0", func(r chi.Router) {
+		r.Use(cors.Handler(cors.Options{
+			AllowedOrigins: []string{"*"},
+			AllowedHeaders: []string{"Content-Type"},
+		}))
+
+		r.Get("/", handlers.LastFMHandler(db, mbz, loginLimiter))
+		r.Post("/", handlers.LastFMHandler(db, mbz, loginLimiter))
+	})
+
+	r.Route("/apis/audioscrobbler", func(r chi.Router) {
+		r.Get("/", handlers.AudioscrobblerHandshakeHandler(db))
+		r.Post("/nowplaying", handlers.AudioscrobblerNowPlayingHandler(db, mbz))
+		r.Post("/submissions", handlers.AudioscrobblerSubmissionHandler(db, mbz))
+	})
+
+	// media servers can't send headers, so the api key is part of the url
+	r.Route("/apis/webhooks/{key}", func(r chi.Router) {
+		r.Post("/plex", handlers.PlexWebhookHandler(db, mbz))
+		r.Post("/jellyfin", handlers.JellyfinWebhookHandler(db, mbz))
+		r.Post("/emby", handlers.EmbyWebhookHandler(db, mbz))
 	})
 
 	// serve react client
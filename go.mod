@@ -17,6 +17,7 @@ require (
 )
 
 require (
+	github.com/go-chi/httprate v0.15.0
 	github.com/gosimple/unidecode v1.0.1
 	golang.org/x/crypto v0.38.0
 )
@@ -33,7 +34,6 @@ require (
 	github.com/docker/docker v28.1.1+incompatible // indirect
 	github.com/docker/go-connections v0.5.0 // indirect
 	github.com/docker/go-units v0.5.0 // indirect
-	github.com/go-chi/httprate v0.15.0 // indirect
 	github.com/go-viper/mapstructure/v2 v2.2.1 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/google/shlex v0.0.0-20191202100458-e7afc7fbc510 // indirect
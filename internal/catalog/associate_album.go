@@ -25,6 +25,9 @@ type AssociateAlbumOpts struct {
 	TrackName         string // required
 	Mbzc              mbz.MusicBrainzCaller
 	SkipCacheImage    bool
+
+	// records how the album was matched when set
+	match *models.Match
 }
 
 func AssociateAlbum(ctx context.Context, d db.DB, opts AssociateAlbumOpts) (*models.Album, error) {
@@ -50,6 +53,7 @@ func matchAlbumByMbzReleaseID(ctx context.Context, d db.DB, opts AssociateAlbumO
 	a, err := d.GetAlbum(ctx, db.GetAlbumOpts{MusicBrainzID: opts.ReleaseMbzID})
 	if err == nil {
 		l.Debug().Msgf("Found release '%s' by MusicBrainz Release ID", a.Title)
+		recordMatch(opts.match, models.MatchMethodMBID)
 		return &models.Album{
 			ID:             a.ID,
 			MbzID:          &opts.ReleaseMbzID,
@@ -98,6 +102,7 @@ func createOrUpdateAlbumWithMbzReleaseID(ctx context.Context, d db.DB, opts Asso
 			return nil, fmt.Errorf("createOrUpdateAlbumWithMbzReleaseID: %w", err)
 		}
 		l.Debug().Msgf("Updated album '%s' with MusicBrainz Release ID", album.Title)
+		recordMatch(opts.match, models.MatchMethodAlias)
 
 		if opts.ReleaseGroupMbzID != uuid.Nil {
 			aliases, err := opts.Mbzc.GetReleaseTitles(ctx, opts.ReleaseGroupMbzID)
@@ -180,6 +185,7 @@ func createOrUpdateAlbumWithMbzReleaseID(ctx context.Context, d db.DB, opts Asso
 		}
 
 		l.Info().Msgf("Created album '%s' with MusicBrainz Release ID", album.Title)
+		recordMatch(opts.match, models.MatchMethodMBID)
 	}
 
 	return &models.Album{
@@ -206,6 +212,12 @@ func matchAlbumByTitle(ctx context.Context, d db.DB, opts AssociateAlbumOpts) (*
 	})
 	if err == nil {
 		l.Debug().Msgf("Found album '%s' by artist and title", a.Title)
+		if opts.ReleaseName != "" {
+			recordMatch(opts.match, models.MatchMethodAlias)
+		} else {
+			// the album was guessed from the track title
+			recordMatch(opts.match, models.MatchMethodFuzzy)
+		}
 		if a.MbzID == nil && opts.ReleaseMbzID != uuid.Nil {
 			l.Debug().Msgf("Updating album with id %d with MusicBrainz ID %s", a.ID, opts.ReleaseMbzID)
 			err = d.UpdateAlbum(ctx, db.UpdateAlbumOpts{
@@ -256,6 +268,11 @@ func matchAlbumByTitle(ctx context.Context, d db.DB, opts AssociateAlbumOpts) (*
 			return nil, fmt.Errorf("matchAlbumByTitle: %w", err)
 		}
 		l.Info().Msgf("Created album '%s' with artist and title", a.Title)
+		if opts.ReleaseMbzID != uuid.Nil {
+			recordMatch(opts.match, models.MatchMethodMBID)
+		} else {
+			recordMatch(opts.match, models.MatchMethodCreated)
+		}
 	}
 
 	return &models.Album{
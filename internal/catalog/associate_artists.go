@@ -26,6 +26,9 @@ type AssociateArtistsOpts struct {
 	Mbzc          mbz.MusicBrainzCaller
 
 	SkipCacheImage bool
+
+	// records how the artists were matched when set
+	match *models.Match
 }
 
 func AssociateArtists(ctx context.Context, d db.DB, opts AssociateArtistsOpts) ([]*models.Artist, error) {
@@ -84,6 +87,7 @@ func matchArtistsByMBIDMappings(ctx context.Context, d db.DB, opts AssociateArti
 		})
 		if err == nil {
 			l.Debug().Msgf("Artist '%s' found by MusicBrainz ID", artist.Name)
+			recordMatch(opts.match, models.MatchMethodMBID)
 			result = append(result, artist)
 			continue
 		}
@@ -102,6 +106,7 @@ func matchArtistsByMBIDMappings(ctx context.Context, d db.DB, opts AssociateArti
 			} else {
 				artist.MbzID = &a.Mbid
 			}
+			recordMatch(opts.match, models.MatchMethodAlias)
 			result = append(result, artist)
 			continue
 		}
@@ -148,6 +153,7 @@ func matchArtistsByMBIDMappings(ctx context.Context, d db.DB, opts AssociateArti
 			}
 		}
 
+		recordMatch(opts.match, models.MatchMethodMBID)
 		result = append(result, artist)
 	}
 
@@ -172,6 +178,7 @@ func matchArtistsByMBID(ctx context.Context, d db.DB, opts AssociateArtistsOpts,
 		})
 		if err == nil {
 			l.Debug().Msgf("Artist '%s' found by MusicBrainz ID", a.Name)
+			recordMatch(opts.match, models.MatchMethodMBID)
 			result = append(result, a)
 			continue
 		}
@@ -189,6 +196,7 @@ func matchArtistsByMBID(ctx context.Context, d db.DB, opts AssociateArtistsOpts,
 			return matchArtistsByNames(ctx, opts.ArtistNames, result, d, opts)
 		}
 
+		recordMatch(opts.match, models.MatchMethodMBID)
 		result = append(result, a)
 	}
 
@@ -283,6 +291,11 @@ func matchArtistsByNames(ctx context.Context, names []string, existing []*models
 		})
 		if err == nil {
 			l.Debug().Msgf("Artist '%s' found in DB", name)
+			if nameWasSubmitted(name, opts) {
+				recordMatch(opts.match, models.MatchMethodAlias)
+			} else {
+				recordMatch(opts.match, models.MatchMethodFuzzy)
+			}
 			result = append(result, a)
 			continue
 		}
@@ -314,6 +327,7 @@ func matchArtistsByNames(ctx context.Context, names []string, existing []*models
 				return nil, fmt.Errorf("matchArtistsByNames: %w", err)
 			}
 			l.Info().Msgf("Created artist '%s' with artist name", name)
+			recordMatch(opts.match, models.MatchMethodCreated)
 			result = append(result, a)
 		} else {
 			return nil, fmt.Errorf("matchArtistsByNames: %w", err)
@@ -322,6 +336,18 @@ func matchArtistsByNames(ctx context.Context, names []string, existing []*models
 	return result, nil
 }
 
+// nameWasSubmitted reports whether the name is one of the submitted artists,
+// rather than one only featured in the track title.
+func nameWasSubmitted(name string, opts AssociateArtistsOpts) bool {
+	submitted := slices.Concat(opts.ArtistNames, ParseArtists(opts.ArtistName, "", cfg.ArtistSeparators()))
+	for _, n := range submitted {
+		if strings.EqualFold(name, n) {
+			return true
+		}
+	}
+	return false
+}
+
 func artistExists(name string, artists []*models.Artist) bool {
 	for _, a := range artists {
 		allAliases := append(a.Aliases, a.Name)
@@ -20,6 +20,9 @@ type AssociateTrackOpts struct {
 	TrackName  string
 	Duration   int32
 	Mbzc       mbz.MusicBrainzCaller
+
+	// records how the track was matched when set
+	match *models.Match
 }
 
 func AssociateTrack(ctx context.Context, d db.DB, opts AssociateTrackOpts) (*models.Track, error) {
@@ -51,6 +54,7 @@ func matchTrackByMbzID(ctx context.Context, d db.DB, opts AssociateTrackOpts) (*
 	})
 	if err == nil {
 		l.Debug().Msgf("Found track '%s' by MusicBrainz ID", track.Title)
+		recordMatch(opts.match, models.MatchMethodMBID)
 		return track, nil
 	} else if !errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("matchTrackByMbzID: %w", err)
@@ -82,6 +86,7 @@ func matchTrackByTitleAndArtist(ctx context.Context, d db.DB, opts AssociateTrac
 	})
 	if err == nil {
 		l.Debug().Msgf("Track '%s' found by title and artist match", track.Title)
+		recordMatch(opts.match, models.MatchMethodAlias)
 		return track, nil
 	} else if !errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("matchTrackByTitleAndArtist: %w", err)
@@ -95,6 +100,7 @@ func matchTrackByTitleAndArtist(ctx context.Context, d db.DB, opts AssociateTrac
 				})
 				if err == nil {
 					l.Debug().Msgf("Track '%s' found by MusicBrainz title and artist match", opts.TrackName)
+					recordMatch(opts.match, models.MatchMethodFuzzy)
 					return track, nil
 				}
 			}
@@ -112,8 +118,10 @@ func matchTrackByTitleAndArtist(ctx context.Context, d db.DB, opts AssociateTrac
 		}
 		if opts.TrackMbzID == uuid.Nil {
 			l.Info().Msgf("Created track '%s' with title and artist", opts.TrackName)
+			recordMatch(opts.match, models.MatchMethodCreated)
 		} else {
 			l.Info().Msgf("Created track '%s' with MusicBrainz Recording ID", opts.TrackName)
+			recordMatch(opts.match, models.MatchMethodMBID)
 		}
 		return t, nil
 	}
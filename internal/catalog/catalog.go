@@ -8,15 +8,15 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/events"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
-	"github.com/SaturnX-Dev/Beat-Scrobble/internal/memkv"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/rewrite"
 	"github.com/google/uuid"
 )
 
@@ -45,7 +45,11 @@ type SubmitListenOpts struct {
 	// When true, skips caching the images and only stores the image url in the db
 	SkipCacheImage bool
 
-	MbzCaller          mbz.MusicBrainzCaller
+	// When true, new tracks are not searched for on MusicBrainz, leaving them
+	// to the backfill
+	SkipMbzSearch bool
+
+	MbzCaller          mbz.MusicBrainzCaller `json:"-"`
 	ArtistNames        []string
 	Artist             string
 	ArtistMbzIDs       []uuid.UUID
@@ -61,6 +65,13 @@ type SubmitListenOpts struct {
 	UserID       int32
 	Client       string
 	IsNowPlaying bool
+
+	// optional details about the listen, saved along with it
+	DurationMs              int32 // how long the track was played for
+	MediaPlayer             string
+	SubmissionClientVersion string
+	Tags                    []string
+	OriginURL               string
 }
 
 const (
@@ -70,6 +81,14 @@ const (
 func SubmitListen(ctx context.Context, store db.DB, opts SubmitListenOpts) error {
 	l := logger.FromContext(ctx)
 
+	dropped, err := rewriteListen(ctx, store, &opts)
+	if err != nil {
+		return fmt.Errorf("SubmitListen: %w", err)
+	}
+	if dropped {
+		return nil
+	}
+
 	if opts.Artist == "" || opts.TrackTitle == "" {
 		return errors.New("track name and artist are required")
 	}
@@ -77,6 +96,173 @@ func SubmitListen(ctx context.Context, store db.DB, opts SubmitListenOpts) error
 	// bandaid to ensure new activity does not have sub-second precision
 	opts.Time = opts.Time.Truncate(time.Second)
 
+	var match models.ListenMatch
+	artists, rg, track, err := associateListen(ctx, store, opts, &match)
+	if err != nil {
+		return fmt.Errorf("SubmitListen: %w", err)
+	}
+
+	duration := track.Duration
+	if track.Duration == 0 {
+		if opts.Duration != 0 {
+			l.Debug().Msg("Updating duration using request information")
+			err := store.UpdateTrack(ctx, db.UpdateTrackOpts{
+				ID:       track.ID,
+				Duration: opts.Duration,
+			})
+			if err != nil {
+				l.Err(err).Msgf("Failed to update duration for track %s", track.Title)
+			} else {
+				duration = opts.Duration
+				l.Info().Msgf("Duration updated to %d for track '%s'", opts.Duration, track.Title)
+			}
+		} else if track.MbzID != nil && *track.MbzID != uuid.Nil {
+			l.Debug().Msg("Attempting to update duration using MusicBrainz ID")
+			mbztrack, err := opts.MbzCaller.GetTrack(ctx, *track.MbzID)
+			if err != nil {
+				l.Err(err).Msg("Failed to make request to MusicBrainz")
+			} else {
+				err = store.UpdateTrack(ctx, db.UpdateTrackOpts{
+					ID:       track.ID,
+					Duration: int32(mbztrack.LengthMs / 1000),
+				})
+				if err != nil {
+					l.Err(err).Msgf("Failed to update duration for track %s", track.Title)
+				} else {
+					duration = int32(mbztrack.LengthMs / 1000)
+					l.Info().Msgf("Duration updated to %d for track '%s'", mbztrack.LengthMs/1000, track.Title)
+				}
+			}
+		}
+	}
+
+	if match.Track.Method == models.MatchMethodCreated && !opts.SkipMbzSearch && MbzSearchEnabled(opts.MbzCaller) {
+		l.Debug().Msgf("Searching MusicBrainz for new track '%s'", track.Title)
+		if _, err := AttachMbzIDs(ctx, store, opts.MbzCaller, track.ID); err != nil {
+			l.Err(err).Msgf("Failed to search MusicBrainz for track '%s'", track.Title)
+		}
+	}
+
+	if opts.IsNowPlaying {
+		SetNowPlaying(opts.UserID, track.ID, duration)
+	}
+
+	if opts.SkipSaveListen {
+		return nil
+	}
+
+	l.Info().Msgf("Received listen: '%s' by %s, from release '%s'", track.Title, buildArtistStr(artists), rg.Title)
+
+	listen := db.SaveListenOpts{
+		TrackID:                 track.ID,
+		Time:                    opts.Time,
+		UserID:                  opts.UserID,
+		Client:                  opts.Client,
+		DurationMs:              opts.DurationMs,
+		MediaPlayer:             opts.MediaPlayer,
+		SubmissionClientVersion: opts.SubmissionClientVersion,
+		Tags:                    opts.Tags,
+		OriginURL:               opts.OriginURL,
+		Match:                   &match,
+	}
+	reason, err := checkListenPolicy(ctx, store, &listen, duration)
+	if err != nil {
+		return fmt.Errorf("SubmitListen: %w", err)
+	}
+	if reason != "" {
+		l.Info().Msgf("Rejected listen of '%s' by %s: %s", track.Title, buildArtistStr(artists), reason)
+		err = store.SaveRejectedListen(ctx, db.SaveRejectedListenOpts{
+			Reason: reason,
+			Listen: listen,
+		})
+		if err != nil {
+			return fmt.Errorf("SubmitListen: %w", err)
+		}
+		return nil
+	}
+
+	err = store.SaveListen(ctx, listen)
+	if err != nil {
+		return fmt.Errorf("SubmitListen: %w", err)
+	}
+
+	simpleArtists := make([]models.SimpleArtist, len(artists))
+	for i, a := range artists {
+		simpleArtists[i] = models.SimpleArtist{ID: a.ID, Name: a.Name}
+	}
+	events.Bus.Publish(events.Event{
+		Type:   events.TypeListen,
+		UserID: opts.UserID,
+		Data: models.Listen{
+			Time: opts.Time,
+			Track: models.Track{
+				ID:       track.ID,
+				Title:    track.Title,
+				Artists:  simpleArtists,
+				MbzID:    track.MbzID,
+				Duration: duration,
+				Image:    rg.Image,
+				AlbumID:  rg.ID,
+				Album:    &rg.Title,
+			},
+			Client:                  opts.Client,
+			DurationMs:              opts.DurationMs,
+			MediaPlayer:             opts.MediaPlayer,
+			SubmissionClientVersion: opts.SubmissionClientVersion,
+			Tags:                    opts.Tags,
+			OriginURL:               opts.OriginURL,
+			Overlap:                 listen.Overlap,
+		},
+	})
+
+	return nil
+}
+
+// rewriteListen applies the user's rewrite rules to the listen in place.
+// Returns true when a rule dropped the listen.
+func rewriteListen(ctx context.Context, store db.DB, opts *SubmitListenOpts) (bool, error) {
+	l := logger.FromContext(ctx)
+
+	rules, err := store.GetEnabledRewriteRulesByUserID(ctx, opts.UserID)
+	if err != nil {
+		return false, fmt.Errorf("rewriteListen: %w", err)
+	}
+	if len(rules) < 1 {
+		return false, nil
+	}
+	result := rewrite.Apply(rules, rewrite.Listen{
+		Artist:      opts.Artist,
+		ArtistNames: opts.ArtistNames,
+		Title:       opts.TrackTitle,
+		Album:       opts.ReleaseTitle,
+		Client:      opts.Client,
+	})
+	if result.Dropped {
+		l.Info().Msgf("Dropped listen '%s' by %s due to rewrite rule %d", opts.TrackTitle, opts.Artist, result.Applied[len(result.Applied)-1])
+		return true, nil
+	}
+	if len(result.Applied) > 0 {
+		l.Debug().Any("listen", result.Listen).Msgf("Listen rewritten by rules %v", result.Applied)
+	}
+	opts.Artist = result.Listen.Artist
+	opts.ArtistNames = result.Listen.ArtistNames
+	opts.TrackTitle = result.Listen.Title
+	opts.ReleaseTitle = result.Listen.Album
+	opts.Client = result.Listen.Client
+	return false, nil
+}
+
+// associateListen matches the listen to its artists, album and track, creating
+// any of them that do not exist yet. How each was matched is recorded in match
+// when it is set.
+func associateListen(ctx context.Context, store db.DB, opts SubmitListenOpts, match *models.ListenMatch) ([]*models.Artist, *models.Album, *models.Track, error) {
+	l := logger.FromContext(ctx)
+
+	var artistMatch, albumMatch, trackMatch *models.Match
+	if match != nil {
+		artistMatch, albumMatch, trackMatch = &match.Artist, &match.Album, &match.Track
+	}
+
 	artists, err := AssociateArtists(
 		ctx,
 		store,
@@ -88,10 +274,11 @@ func SubmitListen(ctx context.Context, store db.DB, opts SubmitListenOpts) error
 			Mbzc:           opts.MbzCaller,
 			TrackTitle:     opts.TrackTitle,
 			SkipCacheImage: opts.SkipCacheImage,
+			match:          artistMatch,
 		})
 	if err != nil {
 		l.Err(err).Msg("Failed to associate artists to listen")
-		return fmt.Errorf("SubmitListen: %w", err)
+		return nil, nil, nil, fmt.Errorf("associateListen: %w", err)
 	} else if len(artists) < 1 {
 		l.Debug().Msg("Failed to associate any artists to release")
 	}
@@ -110,10 +297,11 @@ func SubmitListen(ctx context.Context, store db.DB, opts SubmitListenOpts) error
 		Mbzc:              opts.MbzCaller,
 		Artists:           artists,
 		SkipCacheImage:    opts.SkipCacheImage,
+		match:             albumMatch,
 	})
 	if err != nil {
 		l.Error().Err(err).Msg("Failed to associate release group to listen")
-		return fmt.Errorf("SubmitListen: %w", err)
+		return nil, nil, nil, fmt.Errorf("associateListen: %w", err)
 	}
 	l.Debug().Any("album", rg).Msg("Matched listen to release")
 
@@ -130,66 +318,19 @@ func SubmitListen(ctx context.Context, store db.DB, opts SubmitListenOpts) error
 		TrackName:  opts.TrackTitle,
 		Duration:   opts.Duration,
 		Mbzc:       opts.MbzCaller,
+		match:      trackMatch,
 	})
 	if err != nil {
 		l.Error().Err(err).Msg("Failed to associate track to listen")
-		return fmt.Errorf("SubmitListen: %w", err)
+		return nil, nil, nil, fmt.Errorf("associateListen: %w", err)
 	}
 	l.Debug().Any("track", track).Msg("Matched listen to track")
 
-	if track.Duration == 0 {
-		if opts.Duration != 0 {
-			l.Debug().Msg("Updating duration using request information")
-			err := store.UpdateTrack(ctx, db.UpdateTrackOpts{
-				ID:       track.ID,
-				Duration: opts.Duration,
-			})
-			if err != nil {
-				l.Err(err).Msgf("Failed to update duration for track %s", track.Title)
-			} else {
-				l.Info().Msgf("Duration updated to %d for track '%s'", opts.Duration, track.Title)
-			}
-		} else if track.MbzID != nil && *track.MbzID != uuid.Nil {
-			l.Debug().Msg("Attempting to update duration using MusicBrainz ID")
-			mbztrack, err := opts.MbzCaller.GetTrack(ctx, *track.MbzID)
-			if err != nil {
-				l.Err(err).Msg("Failed to make request to MusicBrainz")
-			} else {
-				err = store.UpdateTrack(ctx, db.UpdateTrackOpts{
-					ID:       track.ID,
-					Duration: int32(mbztrack.LengthMs / 1000),
-				})
-				if err != nil {
-					l.Err(err).Msgf("Failed to update duration for track %s", track.Title)
-				} else {
-					l.Info().Msgf("Duration updated to %d for track '%s'", mbztrack.LengthMs/1000, track.Title)
-				}
-			}
-		}
-	}
-
-	if opts.IsNowPlaying {
-		if track.Duration == 0 {
-			memkv.Store.Set(strconv.Itoa(int(opts.UserID)), track.ID)
-		} else {
-			memkv.Store.Set(strconv.Itoa(int(opts.UserID)), track.ID, time.Duration(track.Duration)*time.Second)
-		}
-	}
-
-	if opts.SkipSaveListen {
-		return nil
+	if match != nil {
+		finishMatch(match)
 	}
-
-	l.Info().Msgf("Received listen: '%s' by %s, from release '%s'", track.Title, buildArtistStr(artists), rg.Title)
-
-	return store.SaveListen(ctx, db.SaveListenOpts{
-		TrackID: track.ID,
-		Time:    opts.Time,
-		UserID:  opts.UserID,
-		Client:  opts.Client,
-	})
+	return artists, rg, track, nil
 }
-
 func buildArtistStr(artists []*models.Artist) string {
 	artistNames := make([]string, len(artists))
 	for i, artist := range artists {
@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+)
+
+// checkListenPolicy applies the user's listen policy to a listen about to be
+// saved. Returns the reason when the listen is rejected, and sets Overlap on
+// the listen when the policy flags overlaps and it starts before the previous
+// listen could have ended.
+func checkListenPolicy(ctx context.Context, store db.DB, listen *db.SaveListenOpts, duration int32) (models.RejectedListenReason, error) {
+	l := logger.FromContext(ctx)
+
+	policy, err := store.GetListenPolicy(ctx, listen.UserID)
+	if err != nil {
+		return "", fmt.Errorf("checkListenPolicy: %w", err)
+	}
+
+	if playedTooShort(policy, listen.DurationMs, duration) {
+		l.Debug().Msgf("Listen of track %d played for %dms is too short", listen.TrackID, listen.DurationMs)
+		return models.RejectedListenTooShort, nil
+	}
+
+	if policy.DuplicateWindowSeconds > 0 {
+		exists, err := store.ListenOfTrackExists(ctx, db.ListenOfTrackExistsOpts{
+			UserID:  listen.UserID,
+			TrackID: listen.TrackID,
+			Time:    listen.Time,
+			Window:  time.Duration(policy.DuplicateWindowSeconds) * time.Second,
+		})
+		if err != nil {
+			return "", fmt.Errorf("checkListenPolicy: %w", err)
+		}
+		if exists {
+			l.Debug().Msgf("Listen of track %d at %v is a duplicate", listen.TrackID, listen.Time)
+			return models.RejectedListenDuplicate, nil
+		}
+	}
+
+	if policy.FlagOverlaps {
+		prev, prevDuration, err := store.GetListenBefore(ctx, listen.UserID, listen.Time)
+		if err != nil {
+			return "", fmt.Errorf("checkListenPolicy: %w", err)
+		}
+		if !prev.IsZero() && prevDuration > 0 && prev.Add(time.Duration(prevDuration)*time.Second).After(listen.Time) {
+			l.Debug().Msgf("Listen of track %d at %v overlaps the listen at %v", listen.TrackID, listen.Time, prev)
+			listen.Overlap = true
+		}
+	}
+
+	return "", nil
+}
+
+// playedTooShort reports whether a listen played for playedMs of a track
+// lasting duration seconds falls short of the policy. Either minimum that is
+// set is enough, and listens without a played time are never too short.
+func playedTooShort(policy *models.ListenPolicy, playedMs int32, duration int32) bool {
+	if playedMs <= 0 {
+		return false
+	}
+	checked := false
+	if policy.MinPlayedSeconds > 0 {
+		if playedMs >= policy.MinPlayedSeconds*1000 {
+			return false
+		}
+		checked = true
+	}
+	if policy.MinPlayedFraction > 0 && duration > 0 {
+		if float64(playedMs) >= policy.MinPlayedFraction*float64(duration)*1000 {
+			return false
+		}
+		checked = true
+	}
+	return checked
+}
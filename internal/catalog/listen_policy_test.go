@@ -0,0 +1,68 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitListen_ListenPolicy(t *testing.T) {
+	setupTestDataSansMbzIDs(t)
+	ctx := context.Background()
+	require.NoError(t, store.Exec(ctx, `TRUNCATE listen_policies`))
+	defer store.Exec(ctx, `TRUNCATE listen_policies`)
+
+	require.NoError(t, store.SaveListenPolicy(ctx, db.SaveListenPolicyOpts{
+		UserID:                 1,
+		MinPlayedFraction:      0.5,
+		MinPlayedSeconds:       240,
+		DuplicateWindowSeconds: 60,
+		FlagOverlaps:           true,
+	}))
+
+	start := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	submit := func(at time.Time, playedMs int32) {
+		err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+			MbzCaller:    &mbz.MbzMockCaller{},
+			ArtistNames:  []string{"ATARASHII GAKKO!"},
+			Artist:       "ATARASHII GAKKO!",
+			TrackTitle:   "Tokyo Calling",
+			ReleaseTitle: "AG! Calling",
+			Duration:     200,
+			DurationMs:   playedMs,
+			Time:         at,
+			UserID:       1,
+		})
+		require.NoError(t, err)
+	}
+
+	// played for less than half of the track
+	submit(start, 30000)
+	// played for more than half, so accepted
+	submit(start.Add(time.Minute), 120000)
+	// the same track again within the duplicate window
+	submit(start.Add(90*time.Second), 0)
+	// no played time is reported, and starts before the last listen ended
+	submit(start.Add(3*time.Minute), 0)
+
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM listens`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE overlap`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected the last listen to be flagged as overlapping")
+
+	rejected, err := store.GetRejectedListensPaginated(ctx, db.GetItemsOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, rejected.Items, 2)
+	assert.Equal(t, models.RejectedListenDuplicate, rejected.Items[0].Reason)
+	assert.Equal(t, models.RejectedListenTooShort, rejected.Items[1].Reason)
+	assert.EqualValues(t, 30000, rejected.Items[1].DurationMs)
+}
@@ -0,0 +1,29 @@
+package catalog
+
+import (
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+)
+
+// recordMatch keeps the least certain method used for an association, so a
+// listen matched to several artists is only as certain as its weakest artist.
+func recordMatch(m *models.Match, method models.MatchMethod) {
+	if m == nil {
+		return
+	}
+	confidence := method.Confidence()
+	if m.Method == "" || confidence < m.Confidence {
+		m.Method = method
+		m.Confidence = confidence
+	}
+}
+
+// finishMatch sets the overall confidence of the match to the lowest of its
+// associations.
+func finishMatch(m *models.ListenMatch) {
+	m.Confidence = 1
+	for _, a := range []models.Match{m.Artist, m.Album, m.Track} {
+		if a.Method != "" {
+			m.Confidence = min(m.Confidence, a.Confidence)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+)
+
+// DefaultMatchInboxConfidence lists listens that were matched less certainly
+// than by an exact name, that is fuzzy matches and newly created entities.
+const DefaultMatchInboxConfidence = 0.9
+
+// MatchInboxListen identifies a listen in the match inbox
+type MatchInboxListen struct {
+	UserID  int32
+	TrackID int32
+	Time    time.Time
+}
+
+type MergeMatchKind string
+
+const (
+	MergeMatchArtist MergeMatchKind = "artist"
+	MergeMatchAlbum  MergeMatchKind = "album"
+	MergeMatchTrack  MergeMatchKind = "track"
+)
+
+type MergeMatchOpts struct {
+	Listen MatchInboxListen
+	Kind   MergeMatchKind
+	// the artist to merge away, only needed when the track has several artists
+	FromID       int32
+	ToID         int32
+	ReplaceImage bool
+}
+
+// ErrInvalidMatchMerge is returned when a merge of a match inbox listen does
+// not say what to merge
+var ErrInvalidMatchMerge = errors.New("invalid merge")
+
+// ReassignMatch moves a listen from the match inbox to an existing track and
+// takes it out of the inbox. Tracks, albums and artists left without listens
+// are deleted afterwards. Returns false when the user has no such listen.
+func ReassignMatch(ctx context.Context, store db.DB, listen MatchInboxListen, toTrackID int32) (bool, error) {
+	l := logger.FromContext(ctx)
+
+	moved, err := store.MoveListens(ctx, db.MoveListensOpts{
+		ListenFilterOpts: db.ListenFilterOpts{
+			UserID: listen.UserID,
+			From:   listen.Time,
+			To:     listen.Time,
+		},
+		FromTrackID: listen.TrackID,
+		ToTrackID:   toTrackID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("ReassignMatch: %w", err)
+	}
+	if moved == 0 {
+		return false, nil
+	}
+	l.Info().Msgf("Reassigned listen at %v from track %d to track %d", listen.Time, listen.TrackID, toTrackID)
+
+	if _, err := store.ReviewListenMatch(ctx, listen.UserID, toTrackID, listen.Time); err != nil {
+		return false, fmt.Errorf("ReassignMatch: %w", err)
+	}
+	if err := store.CleanOrphanedEntries(ctx); err != nil {
+		return false, fmt.Errorf("ReassignMatch: %w", err)
+	}
+	return true, nil
+}
+
+// MergeMatch merges the artist, album or track a listen from the match inbox
+// was matched to into an existing one, and takes the listen out of the inbox.
+// Returns false when the user has no such listen.
+func MergeMatch(ctx context.Context, store db.DB, opts MergeMatchOpts) (bool, error) {
+	listen := opts.Listen
+	match, err := store.GetListenMatch(ctx, listen.UserID, listen.TrackID, listen.Time)
+	if err != nil {
+		return false, fmt.Errorf("MergeMatch: %w", err)
+	}
+	if match == nil {
+		return false, nil
+	}
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: listen.TrackID})
+	if err != nil {
+		return false, fmt.Errorf("MergeMatch: %w", err)
+	}
+
+	trackID := track.ID
+	switch opts.Kind {
+	case MergeMatchTrack:
+		if err := store.MergeTracks(ctx, track.ID, opts.ToID); err != nil {
+			return false, fmt.Errorf("MergeMatch: %w", err)
+		}
+		trackID = opts.ToID
+	case MergeMatchAlbum:
+		if err := store.MergeAlbums(ctx, track.AlbumID, opts.ToID, opts.ReplaceImage); err != nil {
+			return false, fmt.Errorf("MergeMatch: %w", err)
+		}
+	case MergeMatchArtist:
+		fromID, err := matchMergeArtist(track, opts.FromID)
+		if err != nil {
+			return false, fmt.Errorf("MergeMatch: %w", err)
+		}
+		if err := store.MergeArtists(ctx, fromID, opts.ToID, opts.ReplaceImage); err != nil {
+			return false, fmt.Errorf("MergeMatch: %w", err)
+		}
+	default:
+		return false, fmt.Errorf("MergeMatch: %w: unknown kind '%s'", ErrInvalidMatchMerge, opts.Kind)
+	}
+
+	if _, err := store.ReviewListenMatch(ctx, listen.UserID, trackID, listen.Time); err != nil {
+		return false, fmt.Errorf("MergeMatch: %w", err)
+	}
+	return true, nil
+}
+
+// matchMergeArtist picks the artist of the track to merge away, which must be
+// given when the track has more than one.
+func matchMergeArtist(track *models.Track, fromID int32) (int32, error) {
+	if fromID == 0 {
+		if len(track.Artists) != 1 {
+			return 0, fmt.Errorf("%w: the track has several artists, from_id is required", ErrInvalidMatchMerge)
+		}
+		return track.Artists[0].ID, nil
+	}
+	if !slices.ContainsFunc(track.Artists, func(a models.SimpleArtist) bool { return a.ID == fromID }) {
+		return 0, fmt.Errorf("%w: artist %d is not an artist of the track", ErrInvalidMatchMerge, fromID)
+	}
+	return fromID, nil
+}
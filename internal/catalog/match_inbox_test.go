@@ -0,0 +1,110 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchInbox(t *testing.T) {
+	setupTestDataSansMbzIDs(t)
+	ctx := context.Background()
+
+	start := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	submit := func(artist, track, album string, at time.Time) {
+		err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+			MbzCaller:    &mbz.MbzErrorCaller{},
+			Artist:       artist,
+			TrackTitle:   track,
+			ReleaseTitle: album,
+			Time:         at,
+			UserID:       1,
+		})
+		require.NoError(t, err)
+	}
+	inbox := func() []*models.Listen {
+		resp, err := store.GetMatchInboxPaginated(ctx, db.GetMatchInboxOpts{
+			UserID: 1,
+			Below:  catalog.DefaultMatchInboxConfidence,
+		})
+		require.NoError(t, err)
+		return resp.Items
+	}
+
+	// everything matches by name
+	submit("ATARASHII GAKKO!", "Tokyo Calling", "AG! Calling", start)
+	match, err := store.GetListenMatch(ctx, 1, 1, start)
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, models.MatchMethodAlias, match.Artist.Method)
+	assert.Equal(t, models.MatchMethodAlias, match.Album.Method)
+	assert.Equal(t, models.MatchMethodAlias, match.Track.Method)
+	assert.Equal(t, models.MatchMethodAlias.Confidence(), match.Confidence)
+	assert.Empty(t, inbox())
+
+	// an unknown album is created
+	submit("ATARASHII GAKKO!", "Pineapple Kryptonite", "AG! Calling (Deluxe)", start.Add(time.Minute))
+	items := inbox()
+	require.Len(t, items, 1)
+	require.NotNil(t, items[0].Match)
+	assert.Equal(t, models.MatchMethodAlias, items[0].Match.Artist.Method)
+	assert.Equal(t, models.MatchMethodCreated, items[0].Match.Album.Method)
+	assert.Equal(t, models.MatchMethodCreated.Confidence(), items[0].Match.Confidence)
+
+	// the album is a duplicate, so merge it into the existing one
+	found, err := catalog.MergeMatch(ctx, store, catalog.MergeMatchOpts{
+		Listen: catalog.MatchInboxListen{UserID: 1, TrackID: items[0].Track.ID, Time: items[0].Time},
+		Kind:   catalog.MergeMatchAlbum,
+		ToID:   1,
+	})
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Empty(t, inbox())
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM releases`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: items[0].Track.ID})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, track.AlbumID)
+
+	_, err = catalog.MergeMatch(ctx, store, catalog.MergeMatchOpts{
+		Listen: catalog.MatchInboxListen{UserID: 1, TrackID: 1, Time: start},
+		Kind:   "genre",
+		ToID:   1,
+	})
+	assert.ErrorIs(t, err, catalog.ErrInvalidMatchMerge)
+
+	// nothing is known, so the listen is moved to the right track
+	submit("Atarashii Gakko", "Tokyo Callin", "Unknown Album", start.Add(2*time.Minute))
+	items = inbox()
+	require.Len(t, items, 1)
+	require.NotNil(t, items[0].Match)
+	assert.Equal(t, models.MatchMethodCreated, items[0].Match.Artist.Method)
+	found, err = catalog.ReassignMatch(ctx, store, catalog.MatchInboxListen{
+		UserID: 1, TrackID: items[0].Track.ID, Time: items[0].Time,
+	}, 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Empty(t, inbox())
+
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	// the entities created for the listen are gone
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artists`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	found, err = catalog.ReassignMatch(ctx, store, catalog.MatchInboxListen{
+		UserID: 1, TrackID: 1, Time: start.Add(time.Hour),
+	}, 1)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
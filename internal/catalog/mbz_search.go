@@ -0,0 +1,218 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/queue"
+	"github.com/google/uuid"
+)
+
+// MbzSearchEnabled reports whether tracks without MusicBrainz IDs are looked
+// up with a MusicBrainz search.
+func MbzSearchEnabled(mbzc mbz.MusicBrainzCaller) bool {
+	return mbzc != nil && cfg.MusicBrainzMatchConfidence() > 0
+}
+
+// AttachMbzIDs searches MusicBrainz for the track by its title, artists, album
+// and duration. When the best recording scores at least the configured
+// confidence, its ID is attached to the track, and the IDs of its artists and
+// release to the artists and album that have none. The search is recorded so
+// that the backfill skips the track. Returns false when the track already has
+// a MusicBrainz ID or nothing scored high enough.
+func AttachMbzIDs(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, trackID int32) (bool, error) {
+	l := logger.FromContext(ctx)
+	threshold := cfg.MusicBrainzMatchConfidence()
+
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: trackID})
+	if err != nil {
+		return false, fmt.Errorf("AttachMbzIDs: %w", err)
+	}
+	if track.MbzID != nil && *track.MbzID != uuid.Nil {
+		return false, nil
+	}
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: track.AlbumID})
+	if err != nil {
+		return false, fmt.Errorf("AttachMbzIDs: %w", err)
+	}
+	names := make([]string, len(track.Artists))
+	for i, a := range track.Artists {
+		names[i] = a.Name
+	}
+	q := mbz.SearchQuery{
+		Artist:      strings.Join(names, " & "),
+		ArtistNames: names,
+		Title:       track.Title,
+		Release:     album.Title,
+		Duration:    track.Duration,
+	}
+
+	recordings, err := mbzc.SearchRecordings(ctx, q)
+	if err != nil {
+		return false, fmt.Errorf("AttachMbzIDs: %w", err)
+	}
+	best, score := mbz.BestRecording(recordings, q)
+	if err := store.SaveMbzSearchAttempt(ctx, track.ID, score); err != nil {
+		return false, fmt.Errorf("AttachMbzIDs: %w", err)
+	}
+	if best == nil || score < threshold {
+		l.Debug().Msgf("No MusicBrainz recording found for track '%s' (best score %.2f)", track.Title, score)
+		return false, nil
+	}
+	recordingID, err := uuid.Parse(best.ID)
+	if err != nil {
+		return false, fmt.Errorf("AttachMbzIDs: invalid recording id '%s': %w", best.ID, err)
+	}
+
+	err = store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: track.ID, MusicBrainzID: recordingID})
+	if errors.Is(err, db.ErrMbzIDInUse) {
+		l.Info().Msgf("MusicBrainz recording %s found for track '%s' belongs to another track, which is likely a duplicate", recordingID, track.Title)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("AttachMbzIDs: %w", err)
+	}
+	l.Info().Msgf("Attached MusicBrainz recording %s to track '%s' (score %.2f)", recordingID, track.Title, score)
+
+	if err := AttachArtistMbzIDs(ctx, store, track.Artists, best.ArtistCredit, threshold); err != nil {
+		return true, fmt.Errorf("AttachMbzIDs: %w", err)
+	}
+	if album.MbzID == nil || *album.MbzID == uuid.Nil {
+		if err := attachAlbumMbzID(ctx, store, mbzc, album, best, q, threshold); err != nil {
+			return true, fmt.Errorf("AttachMbzIDs: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// AttachArtistMbzIDs attaches the ID of every credited artist to the one of
+// the artists whose name is at least threshold similar, unless it has one.
+func AttachArtistMbzIDs(ctx context.Context, store db.DB, artists []models.SimpleArtist, credit []mbz.MusicBrainzArtistCredit, threshold float64) error {
+	l := logger.FromContext(ctx)
+	for _, c := range credit {
+		artistMbzID, err := uuid.Parse(c.Artist.ID)
+		if err != nil {
+			continue
+		}
+		for _, a := range artists {
+			if mbz.Similarity(a.Name, c.Name) < threshold && mbz.Similarity(a.Name, c.Artist.Name) < threshold {
+				continue
+			}
+			artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: a.ID})
+			if err != nil {
+				return fmt.Errorf("AttachArtistMbzIDs: %w", err)
+			}
+			if artist.MbzID != nil && *artist.MbzID != uuid.Nil {
+				break
+			}
+			err = store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: a.ID, MusicBrainzID: artistMbzID})
+			if errors.Is(err, db.ErrMbzIDInUse) {
+				l.Info().Msgf("MusicBrainz artist %s found for artist '%s' belongs to another artist", artistMbzID, a.Name)
+			} else if err != nil {
+				return fmt.Errorf("AttachArtistMbzIDs: %w", err)
+			} else {
+				l.Info().Msgf("Attached MusicBrainz artist %s to artist '%s'", artistMbzID, a.Name)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// attachAlbumMbzID attaches the release of the recording that matches the
+// album best, searching for the album when none of them do.
+func attachAlbumMbzID(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, album *models.Album, recording *mbz.MusicBrainzRecording, q mbz.SearchQuery, threshold float64) error {
+	l := logger.FromContext(ctx)
+	releases := recording.Releases
+	for i := range releases {
+		// releases of a recording found by searching come without artists
+		if len(releases[i].ArtistCredit) == 0 {
+			releases[i].ArtistCredit = recording.ArtistCredit
+		}
+	}
+	release, score := mbz.BestRelease(releases, q)
+	if release == nil || score < threshold {
+		found, err := mbzc.SearchReleases(ctx, q)
+		if err != nil {
+			return fmt.Errorf("attachAlbumMbzID: %w", err)
+		}
+		release, score = mbz.BestRelease(found, q)
+	}
+	if release == nil || score < threshold {
+		l.Debug().Msgf("No MusicBrainz release found for album '%s' (best score %.2f)", album.Title, score)
+		return nil
+	}
+	releaseID, err := uuid.Parse(release.ID)
+	if err != nil {
+		return fmt.Errorf("attachAlbumMbzID: invalid release id '%s': %w", release.ID, err)
+	}
+	err = store.UpdateAlbum(ctx, db.UpdateAlbumOpts{ID: album.ID, MusicBrainzID: releaseID})
+	if errors.Is(err, db.ErrMbzIDInUse) {
+		l.Info().Msgf("MusicBrainz release %s found for album '%s' belongs to another album", releaseID, album.Title)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("attachAlbumMbzID: %w", err)
+	}
+	l.Info().Msgf("Attached MusicBrainz release %s to album '%s' (score %.2f)", releaseID, album.Title, score)
+	return nil
+}
+
+type MbzBackfillResult struct {
+	Searched int `json:"searched"`
+	Matched  int `json:"matched"`
+}
+
+// BackfillMbzIDs looks up to limit tracks without MusicBrainz IDs, that were
+// not searched for before, up with AttachMbzIDs. Stops at the first failed
+// search, leaving the remaining tracks for the next backfill.
+func BackfillMbzIDs(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, limit int) (*MbzBackfillResult, error) {
+	ids, err := store.GetTracksToSearch(ctx, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("BackfillMbzIDs: %w", err)
+	}
+	result := &MbzBackfillResult{}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("BackfillMbzIDs: %w", err)
+		}
+		matched, err := AttachMbzIDs(ctx, store, mbzc, id)
+		if err != nil {
+			return result, fmt.Errorf("BackfillMbzIDs: %w", err)
+		}
+		result.Searched++
+		if matched {
+			result.Matched++
+		}
+	}
+	return result, nil
+}
+
+var mbzBackfillRunning atomic.Bool
+
+// StartMbzBackfill runs BackfillMbzIDs in the background. Returns false when a
+// backfill is already running.
+func StartMbzBackfill(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, limit int) bool {
+	if !mbzBackfillRunning.CompareAndSwap(false, true) {
+		return false
+	}
+	go func() {
+		defer mbzBackfillRunning.Store(false)
+		ctx := queue.WithPriority(ctx, queue.PriorityBackground)
+		l := logger.FromContext(ctx)
+		result, err := BackfillMbzIDs(ctx, store, mbzc, limit)
+		if err != nil {
+			l.Err(err).Msg("MusicBrainz backfill stopped")
+		}
+		if result != nil {
+			l.Info().Msgf("MusicBrainz backfill searched %d tracks and matched %d", result.Searched, result.Matched)
+		}
+	}()
+	return true
+}
@@ -0,0 +1,122 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	searchRecordingID = uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	searchArtistID    = uuid.MustParse("00000000-0000-0000-0000-000000000102")
+	searchReleaseID   = uuid.MustParse("00000000-0000-0000-0000-000000000103")
+)
+
+func searchMbzCaller() *mbz.MbzMockCaller {
+	return &mbz.MbzMockCaller{
+		Recordings: []mbz.MusicBrainzRecording{
+			{
+				ID:       uuid.NewString(),
+				Title:    "Tokyo Calling (Live)",
+				LengthMs: 260000,
+				ArtistCredit: []mbz.MusicBrainzArtistCredit{
+					{Name: "ATARASHII GAKKO!", Artist: mbz.MusicBrainzArtist{ID: uuid.NewString(), Name: "ATARASHII GAKKO!"}},
+				},
+			},
+			{
+				ID:       searchRecordingID.String(),
+				Title:    "Tokyo Calling",
+				LengthMs: 200000,
+				ArtistCredit: []mbz.MusicBrainzArtistCredit{
+					{Name: "ATARASHII GAKKO!", Artist: mbz.MusicBrainzArtist{ID: searchArtistID.String(), Name: "ATARASHII GAKKO!"}},
+				},
+				Releases: []mbz.MusicBrainzRelease{
+					{ID: uuid.NewString(), Title: "Tokyo Calling", Status: "Official"},
+					{ID: searchReleaseID.String(), Title: "AG! Calling", Status: "Official"},
+				},
+			},
+		},
+	}
+}
+
+func TestSubmitListen_MbzSearch(t *testing.T) {
+	truncateTestData(t)
+	ctx := context.Background()
+
+	err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller:    searchMbzCaller(),
+		ArtistNames:  []string{"ATARASHII GAKKO!"},
+		Artist:       "ATARASHII GAKKO!",
+		TrackTitle:   "Tokyo Calling",
+		ReleaseTitle: "AG! Calling",
+		Duration:     200,
+		Time:         time.Now(),
+		UserID:       1,
+	})
+	require.NoError(t, err)
+
+	exists, err := store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM tracks WHERE musicbrainz_id = $1)`, searchRecordingID)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected recording id to be attached to the new track")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artists WHERE musicbrainz_id = $1)`, searchArtistID)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected artist id to be attached")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM releases WHERE musicbrainz_id = $1)`, searchReleaseID)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected the release matching the album title to be attached")
+
+	// importers leave new tracks to the backfill
+	err = catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller:     searchMbzCaller(),
+		ArtistNames:   []string{"ATARASHII GAKKO!"},
+		Artist:        "ATARASHII GAKKO!",
+		TrackTitle:    "Pineapple Kryptonite",
+		ReleaseTitle:  "AG! Calling",
+		Time:          time.Now(),
+		UserID:        1,
+		SkipMbzSearch: true,
+	})
+	require.NoError(t, err)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM mbz_search_attempts`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestBackfillMbzIDs(t *testing.T) {
+	setupTestDataSansMbzIDs(t)
+	ctx := context.Background()
+	require.NoError(t, store.Exec(ctx, `INSERT INTO tracks (release_id) VALUES (1)`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO track_aliases (track_id, alias, source, is_primary) VALUES (2, 'Something Else', 'Testing', true)`))
+	require.NoError(t, store.Exec(ctx, `INSERT INTO artist_tracks (artist_id, track_id) VALUES (1, 2)`))
+
+	result, err := catalog.BackfillMbzIDs(ctx, store, searchMbzCaller(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Searched)
+	assert.Equal(t, 1, result.Matched)
+	exists, err := store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM tracks WHERE id = 1 AND musicbrainz_id = $1)`, searchRecordingID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM tracks WHERE id = 2 AND musicbrainz_id IS NULL)`)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected track without a good match to be left alone")
+
+	// tracks are only searched for once
+	result, err = catalog.BackfillMbzIDs(ctx, store, searchMbzCaller(), 10)
+	require.NoError(t, err)
+	assert.Zero(t, result.Searched)
+
+	// a failed search is tried again by the next backfill
+	require.NoError(t, store.Exec(ctx, `TRUNCATE mbz_search_attempts`))
+	_, err = catalog.BackfillMbzIDs(ctx, store, &mbz.MbzErrorCaller{}, 10)
+	assert.Error(t, err)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM mbz_search_attempts`)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
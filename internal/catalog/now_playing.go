@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/events"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/memkv"
+)
+
+// used when the duration of the playing track is not known
+const defaultNowPlayingDuration = 10 * time.Minute
+
+type NowPlaying struct {
+	UserID    int32     `json:"user_id"`
+	TrackID   int32     `json:"track_id"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// timers that clear the now playing entries of users once they expire
+var (
+	expiryMu     sync.Mutex
+	expiryTimers = make(map[int32]*time.Timer)
+)
+
+func nowPlayingKey(userId int32) string {
+	return "now_playing_" + strconv.Itoa(int(userId))
+}
+
+// SetNowPlaying records the track a user is currently listening to. The entry
+// expires once the track would have finished playing, based on its duration in seconds,
+// and a now playing event without data is published when it does.
+func SetNowPlaying(userId, trackId, duration int32) NowPlaying {
+	exp := defaultNowPlayingDuration
+	if duration > 0 {
+		exp = time.Duration(duration) * time.Second
+	}
+	now := time.Now()
+	np := NowPlaying{
+		UserID:    userId,
+		TrackID:   trackId,
+		StartedAt: now,
+		ExpiresAt: now.Add(exp),
+	}
+	memkv.Store.Set(nowPlayingKey(userId), np, exp)
+	expireNowPlaying(userId, exp)
+	events.Bus.Publish(events.Event{
+		Type:   events.TypeNowPlaying,
+		UserID: userId,
+		Data:   np,
+	})
+	return np
+}
+
+// expireNowPlaying clears the now playing entry of a user after exp, unless
+// another track starts playing before that.
+func expireNowPlaying(userId int32, exp time.Duration) {
+	expiryMu.Lock()
+	defer expiryMu.Unlock()
+	if t, ok := expiryTimers[userId]; ok {
+		t.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(exp, func() {
+		expiryMu.Lock()
+		if expiryTimers[userId] != t {
+			// replaced by a newer entry
+			expiryMu.Unlock()
+			return
+		}
+		delete(expiryTimers, userId)
+		memkv.Store.Delete(nowPlayingKey(userId))
+		expiryMu.Unlock()
+		events.Bus.Publish(events.Event{
+			Type:   events.TypeNowPlaying,
+			UserID: userId,
+		})
+	})
+	expiryTimers[userId] = t
+}
+
+// GetNowPlaying returns the track a user is currently listening to, if any.
+func GetNowPlaying(userId int32) (NowPlaying, bool) {
+	v, ok := memkv.Store.Get(nowPlayingKey(userId))
+	if !ok {
+		return NowPlaying{}, false
+	}
+	np, ok := v.(NowPlaying)
+	return np, ok
+}
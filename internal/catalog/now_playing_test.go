@@ -0,0 +1,42 @@
+package catalog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNowPlayingExpiry(t *testing.T) {
+	sub, unsubscribe := events.Bus.Subscribe(42)
+	defer unsubscribe()
+
+	catalog.SetNowPlaying(42, 1, 1)
+	e := <-sub
+	assert.Equal(t, events.TypeNowPlaying, e.Type)
+	assert.NotNil(t, e.Data)
+
+	// a cleared now playing event is published once the entry expires
+	select {
+	case e = <-sub:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event published when now playing expired")
+	}
+	assert.Equal(t, events.TypeNowPlaying, e.Type)
+	assert.Nil(t, e.Data)
+	_, ok := catalog.GetNowPlaying(42)
+	require.False(t, ok)
+
+	// an entry replaced before it expires is not cleared by the earlier one
+	catalog.SetNowPlaying(42, 1, 1)
+	catalog.SetNowPlaying(42, 2, 3)
+	<-sub
+	<-sub
+	time.Sleep(1500 * time.Millisecond)
+	np, ok := catalog.GetNowPlaying(42)
+	require.True(t, ok)
+	assert.Equal(t, int32(2), np.TrackID)
+}
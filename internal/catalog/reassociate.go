@@ -0,0 +1,307 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/queue"
+	"github.com/google/uuid"
+)
+
+type ReassociateListensOpts struct {
+	db.ListenFilterOpts
+
+	// When true, only reports which listens would be moved
+	DryRun bool
+
+	MbzCaller mbz.MusicBrainzCaller
+
+	// called with how many tracks were checked so far when set
+	progress func(checked, total int)
+}
+
+// ReassociationTarget describes a track by the names it is matched with
+type ReassociationTarget struct {
+	Artists []string `json:"artists"`
+	Title   string   `json:"title"`
+	Album   string   `json:"album"`
+}
+
+type ReassociationChange struct {
+	TrackID int32               `json:"track_id"`
+	Listens int64               `json:"listens"`
+	From    ReassociationTarget `json:"from"`
+	To      ReassociationTarget `json:"to"`
+	// only set when not a dry run
+	NewTrackID int32 `json:"new_track_id,omitempty"`
+	Moved      int64 `json:"moved"`
+}
+
+type ReassociateListensResult struct {
+	DryRun        bool                  `json:"dry_run"`
+	TracksChecked int                   `json:"tracks_checked"`
+	ListensMoved  int64                 `json:"listens_moved"`
+	Changes       []ReassociationChange `json:"changes"`
+}
+
+// ReassociateListens replays the filtered listens through the association
+// pipeline, using the names and MusicBrainz IDs of the track they are attached
+// to and the current artist separators and rewrite rules, and moves them to the
+// track they would be matched to today. Afterwards, tracks, albums and artists
+// that are left without listens are deleted. The moves and deletes are recorded
+// in the audit log. Drop rules are not applied retroactively.
+func ReassociateListens(ctx context.Context, store db.DB, opts ReassociateListensOpts) (*ReassociateListensResult, error) {
+	l := logger.FromContext(ctx)
+
+	counts, err := store.GetTrackListenCounts(ctx, opts.ListenFilterOpts)
+	if err != nil {
+		return nil, fmt.Errorf("ReassociateListens: %w", err)
+	}
+
+	result := &ReassociateListensResult{
+		DryRun:        opts.DryRun,
+		TracksChecked: len(counts),
+		Changes:       []ReassociationChange{},
+	}
+	var moves []db.TrackMove
+	// the index of the change each move belongs to
+	var moved []int
+	for i, count := range counts {
+		if opts.progress != nil {
+			opts.progress(i, len(counts))
+		}
+		change, submitOpts, err := reassociationFor(ctx, store, opts, count)
+		if err != nil {
+			return nil, fmt.Errorf("ReassociateListens: %w", err)
+		}
+		if change == nil {
+			continue
+		}
+		if !opts.DryRun {
+			_, _, track, err := associateListen(ctx, store, *submitOpts, nil)
+			if err != nil {
+				return nil, fmt.Errorf("ReassociateListens: %w", err)
+			}
+			change.NewTrackID = track.ID
+			if track.ID != count.TrackID {
+				moves = append(moves, db.TrackMove{FromTrackID: count.TrackID, ToTrackID: track.ID})
+				moved = append(moved, len(result.Changes))
+			}
+		}
+		result.Changes = append(result.Changes, *change)
+	}
+
+	if len(moves) > 0 {
+		n, err := store.ReassociateListens(ctx, db.ReassociateListensOpts{
+			ListenFilterOpts: opts.ListenFilterOpts,
+			Moves:            moves,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ReassociateListens: %w", err)
+		}
+		for i, change := range moved {
+			result.Changes[change].Moved = n[i]
+			result.ListensMoved += n[i]
+		}
+	}
+	if opts.progress != nil {
+		opts.progress(len(counts), len(counts))
+	}
+
+	l.Info().Msgf("Reassociation checked %d tracks, found %d changes and moved %d listens (dry run: %t)",
+		result.TracksChecked, len(result.Changes), result.ListensMoved, opts.DryRun)
+	return result, nil
+}
+
+// ReassociationStatus is the state of the latest reassociation of a user's
+// listens
+type ReassociationStatus struct {
+	Running       bool                      `json:"running"`
+	DryRun        bool                      `json:"dry_run"`
+	TracksChecked int                       `json:"tracks_checked"`
+	TracksTotal   int                       `json:"tracks_total"`
+	StartedAt     time.Time                 `json:"started_at"`
+	FinishedAt    *time.Time                `json:"finished_at"`
+	Error         string                    `json:"error,omitempty"`
+	Result        *ReassociateListensResult `json:"result"`
+}
+
+var reassociations = struct {
+	sync.Mutex
+	byUser map[int32]*ReassociationStatus
+}{byUser: map[int32]*ReassociationStatus{}}
+
+// StartReassociation runs ReassociateListens in the background. Returns false
+// when a reassociation of the user's listens is already running.
+func StartReassociation(ctx context.Context, store db.DB, opts ReassociateListensOpts) bool {
+	reassociations.Lock()
+	defer reassociations.Unlock()
+	if s := reassociations.byUser[opts.UserID]; s != nil && s.Running {
+		return false
+	}
+	status := &ReassociationStatus{
+		Running:   true,
+		DryRun:    opts.DryRun,
+		StartedAt: time.Now(),
+	}
+	reassociations.byUser[opts.UserID] = status
+	opts.progress = func(checked, total int) {
+		reassociations.Lock()
+		defer reassociations.Unlock()
+		status.TracksChecked, status.TracksTotal = checked, total
+	}
+
+	go func() {
+		ctx := queue.WithPriority(ctx, queue.PriorityBackground)
+		l := logger.FromContext(ctx)
+		result, err := ReassociateListens(ctx, store, opts)
+		if err != nil {
+			l.Err(err).Msg("Reassociation stopped")
+		}
+		reassociations.Lock()
+		defer reassociations.Unlock()
+		now := time.Now()
+		status.Running = false
+		status.FinishedAt = &now
+		status.Result = result
+		if err != nil {
+			status.Error = "failed to reassociate listens"
+		}
+	}()
+	return true
+}
+
+// GetReassociationStatus returns the state of the latest reassociation of the
+// user's listens, or nil when none was started since the server started.
+func GetReassociationStatus(userId int32) *ReassociationStatus {
+	reassociations.Lock()
+	defer reassociations.Unlock()
+	s := reassociations.byUser[userId]
+	if s == nil {
+		return nil
+	}
+	status := *s
+	return &status
+}
+
+// reassociationFor rebuilds the listen for a track and returns the change that
+// replaying it would cause, along with the options to replay it with. Returns
+// a nil change when the track would stay the same.
+func reassociationFor(ctx context.Context, store db.DB, opts ReassociateListensOpts, count db.TrackListenCount) (*ReassociationChange, *SubmitListenOpts, error) {
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: count.TrackID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("reassociationFor: %w", err)
+	}
+	artists, err := store.GetArtistsForTrack(ctx, track.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reassociationFor: %w", err)
+	}
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: track.AlbumID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("reassociationFor: %w", err)
+	}
+
+	from := ReassociationTarget{Title: track.Title, Album: album.Title}
+	for _, a := range artists {
+		from.Artists = append(from.Artists, a.Name)
+	}
+
+	submitOpts := SubmitListenOpts{
+		MbzCaller:      opts.MbzCaller,
+		Artist:         strings.Join(from.Artists, ", "),
+		TrackTitle:     track.Title,
+		ReleaseTitle:   album.Title,
+		UserID:         opts.UserID,
+		Client:         opts.Client,
+		SkipCacheImage: !cfg.FetchImagesDuringImport(),
+	}
+	if len(from.Artists) == 1 {
+		submitOpts.Artist = from.Artists[0]
+	} else {
+		submitOpts.ArtistNames = slices.Clone(from.Artists)
+	}
+	// the listen is matched by the MBIDs the track, album and artists got since
+	// it was first submitted, like a listen that came with them
+	if track.MbzID != nil {
+		submitOpts.RecordingMbzID = *track.MbzID
+	}
+	if album.MbzID != nil {
+		submitOpts.ReleaseMbzID = *album.MbzID
+	}
+	for _, a := range artists {
+		if a.MbzID != nil {
+			submitOpts.ArtistMbzIDs = append(submitOpts.ArtistMbzIDs, *a.MbzID)
+		}
+	}
+	dropped, err := rewriteListen(ctx, store, &submitOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reassociationFor: %w", err)
+	}
+	if dropped || submitOpts.TrackTitle == "" {
+		return nil, nil, nil
+	}
+
+	// split every name with the current separators, so that artists saved
+	// before a separator was added are split as well
+	var names []string
+	if len(submitOpts.ArtistNames) > 0 {
+		for _, name := range submitOpts.ArtistNames {
+			names = append(names, ParseArtists(name, "", cfg.ArtistSeparators())...)
+		}
+		names = append(names, ParseArtists("", submitOpts.TrackTitle, nil)...)
+	} else {
+		names = ParseArtists(submitOpts.Artist, submitOpts.TrackTitle, cfg.ArtistSeparators())
+	}
+	names = uniqueFold(names)
+	if len(names) < 1 {
+		return nil, nil, nil
+	}
+	submitOpts.ArtistNames = names
+
+	to := ReassociationTarget{Artists: names, Title: submitOpts.TrackTitle, Album: submitOpts.ReleaseTitle}
+	if sameNames(from.Artists, to.Artists) && from.Title == to.Title && from.Album == to.Album {
+		return nil, nil, nil
+	}
+	// the recording MBID matches the listen to this track again
+	if submitOpts.RecordingMbzID != uuid.Nil {
+		return nil, nil, nil
+	}
+	return &ReassociationChange{
+		TrackID: track.ID,
+		Listens: count.Listens,
+		From:    from,
+		To:      to,
+	}, &submitOpts, nil
+}
+
+func uniqueFold(names []string) []string {
+	var out []string
+	for _, name := range names {
+		if !slices.ContainsFunc(out, func(s string) bool { return strings.EqualFold(s, name) }) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// sameNames reports whether both lists hold the same names, ignoring order and
+// case.
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, name := range a {
+		if !slices.ContainsFunc(b, func(s string) bool { return strings.EqualFold(s, name) }) {
+			return false
+		}
+	}
+	return true
+}
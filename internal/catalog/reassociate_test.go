@@ -0,0 +1,207 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReassociateListens(t *testing.T) {
+	truncateTestData(t)
+	ctx := context.Background()
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+	defer func() {
+		require.NoError(t, store.Exec(ctx, `TRUNCATE rewrite_rules, audit_log RESTART IDENTITY`))
+	}()
+
+	mbzc := &mbz.MbzErrorCaller{}
+	submit := func(artist, client string, at time.Time) {
+		err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+			MbzCaller:    mbzc,
+			Artist:       artist,
+			TrackTitle:   "春泥棒",
+			ReleaseTitle: "盗作",
+			Time:         at,
+			UserID:       1,
+			Client:       client,
+		})
+		require.NoError(t, err)
+	}
+	now := time.Now().Truncate(time.Second)
+	submit("ヨルシカ - Topic", "youtube", now.Add(-3*time.Hour))
+	submit("ヨルシカ - Topic", "youtube", now.Add(-2*time.Hour))
+	submit("ヨルシカ - Topic", "other", now.Add(-1*time.Hour))
+
+	// the rule only affects listens submitted after it was added
+	_, err := store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		UserID:  1,
+		Enabled: true,
+		Field:   models.RewriteFieldArtist,
+		Pattern: ` - Topic$`,
+		Action:  models.RewriteActionReplace,
+	})
+	require.NoError(t, err)
+
+	filter := db.ListenFilterOpts{UserID: 1, Client: "youtube"}
+	result, err := catalog.ReassociateListens(ctx, store, catalog.ReassociateListensOpts{
+		ListenFilterOpts: filter,
+		DryRun:           true,
+		MbzCaller:        mbzc,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TracksChecked)
+	require.Len(t, result.Changes, 1)
+	change := result.Changes[0]
+	assert.EqualValues(t, 2, change.Listens)
+	assert.Equal(t, []string{"ヨルシカ - Topic"}, change.From.Artists)
+	assert.Equal(t, []string{"ヨルシカ"}, change.To.Artists)
+	assert.Zero(t, change.Moved)
+	assert.Zero(t, change.NewTrackID)
+
+	// dry run changes nothing
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM artists_with_name WHERE name = 'ヨルシカ'`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	result, err = catalog.ReassociateListens(ctx, store, catalog.ReassociateListensOpts{
+		ListenFilterOpts: filter,
+		MbzCaller:        mbzc,
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, result.ListensMoved)
+	require.Len(t, result.Changes, 1)
+	newTrack := result.Changes[0].NewTrackID
+	assert.NotEqual(t, change.TrackID, newTrack)
+
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = $1`, newTrack)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	// the listen from the other client stays on the old track
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = $1 AND client = 'other'`, change.TrackID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	// the move is recorded in the audit log
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM audit_log WHERE action = 'reassociate_listens'`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// once the remaining listen is moved, the old entities are cleaned up
+	result, err = catalog.ReassociateListens(ctx, store, catalog.ReassociateListensOpts{
+		ListenFilterOpts: db.ListenFilterOpts{UserID: 1},
+		MbzCaller:        mbzc,
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, result.ListensMoved)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM tracks WHERE id = $1`, change.TrackID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artists_with_name WHERE name = 'ヨルシカ - Topic'`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// undoing the reassociation brings back the deleted track with its listen
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, models.AuditReassociateListens, entries[0].Action)
+	ok, err := store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = $1`, change.TrackID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// nothing left to do once the listen is moved again
+	result, err = catalog.ReassociateListens(ctx, store, catalog.ReassociateListensOpts{
+		ListenFilterOpts: db.ListenFilterOpts{UserID: 1},
+		MbzCaller:        mbzc,
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, result.ListensMoved)
+	result, err = catalog.ReassociateListens(ctx, store, catalog.ReassociateListensOpts{
+		ListenFilterOpts: db.ListenFilterOpts{UserID: 1},
+		MbzCaller:        mbzc,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+	assert.Equal(t, 1, result.TracksChecked)
+}
+
+func TestReassociateListensKeepsMbzIDs(t *testing.T) {
+	truncateTestData(t)
+	ctx := context.Background()
+	defer func() {
+		require.NoError(t, store.Exec(ctx, `TRUNCATE rewrite_rules, audit_log RESTART IDENTITY`))
+	}()
+
+	mbzc := &mbz.MbzErrorCaller{}
+	recording := uuid.New()
+	err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller:      mbzc,
+		Artist:         "ヨルシカ - Topic",
+		TrackTitle:     "春泥棒",
+		ReleaseTitle:   "盗作",
+		RecordingMbzID: recording,
+		Time:           time.Now(),
+		UserID:         1,
+	})
+	require.NoError(t, err)
+	_, err = store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		UserID:  1,
+		Enabled: true,
+		Field:   models.RewriteFieldArtist,
+		Pattern: ` - Topic$`,
+		Action:  models.RewriteActionReplace,
+	})
+	require.NoError(t, err)
+
+	// the recording MBID still matches the listen to its track
+	result, err := catalog.ReassociateListens(ctx, store, catalog.ReassociateListensOpts{
+		ListenFilterOpts: db.ListenFilterOpts{UserID: 1},
+		MbzCaller:        mbzc,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+	assert.Zero(t, result.ListensMoved)
+}
+
+func TestStartReassociation(t *testing.T) {
+	truncateTestData(t)
+	ctx := context.Background()
+
+	err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller:    &mbz.MbzErrorCaller{},
+		Artist:       "ヨルシカ",
+		TrackTitle:   "春泥棒",
+		ReleaseTitle: "盗作",
+		Time:         time.Now(),
+		UserID:       1,
+	})
+	require.NoError(t, err)
+
+	opts := catalog.ReassociateListensOpts{
+		ListenFilterOpts: db.ListenFilterOpts{UserID: 1},
+		DryRun:           true,
+		MbzCaller:        &mbz.MbzErrorCaller{},
+	}
+	require.True(t, catalog.StartReassociation(ctx, store, opts))
+	var status *catalog.ReassociationStatus
+	require.Eventually(t, func() bool {
+		status = catalog.GetReassociationStatus(1)
+		return !status.Running
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, status.DryRun)
+	assert.Empty(t, status.Error)
+	require.NotNil(t, status.Result)
+	assert.Equal(t, 1, status.Result.TracksChecked)
+	assert.Equal(t, 1, status.TracksChecked)
+	assert.Nil(t, catalog.GetReassociationStatus(2))
+}
@@ -0,0 +1,41 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/google/uuid"
+)
+
+// RefetchArtistAliases saves the name and primary aliases MusicBrainz has for
+// the artist as aliases of the artist, without changing its primary alias.
+func RefetchArtistAliases(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, id int32, mbzID uuid.UUID) error {
+	l := logger.FromContext(ctx)
+	aliases, err := mbzc.GetArtistPrimaryAliases(ctx, mbzID)
+	if err != nil {
+		return fmt.Errorf("RefetchArtistAliases: %w", err)
+	}
+	if err := store.SaveArtistAliases(ctx, id, aliases, "MusicBrainz"); err != nil {
+		return fmt.Errorf("RefetchArtistAliases: %w", err)
+	}
+	l.Info().Msgf("Saved %d aliases from MusicBrainz for artist %d", len(aliases), id)
+	return nil
+}
+
+// RefetchTrackAliases saves the title MusicBrainz has for the recording as an
+// alias of the track, without changing its primary alias.
+func RefetchTrackAliases(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, id int32, mbzID uuid.UUID) error {
+	l := logger.FromContext(ctx)
+	track, err := mbzc.GetTrack(ctx, mbzID)
+	if err != nil {
+		return fmt.Errorf("RefetchTrackAliases: %w", err)
+	}
+	if err := store.SaveTrackAliases(ctx, id, []string{track.Title}, "MusicBrainz"); err != nil {
+		return fmt.Errorf("RefetchTrackAliases: %w", err)
+	}
+	l.Info().Msgf("Saved alias '%s' from MusicBrainz for track %d", track.Title, id)
+	return nil
+}
@@ -63,7 +63,7 @@ func TestSubmitListen_CreateAllMbzIDs(t *testing.T) {
 	assert.True(t, exists, "expected listen row to exist")
 
 	// Verify that listen time is correct
-	p, err := store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 1, Page: 1})
+	p, err := store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 1, Page: 1, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, p.Items, 1)
 	l := p.Items[0]
@@ -970,3 +970,33 @@ func TestSubmitListen_MusicBrainzUnreachableMBIDMappings(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, exists, "expected artist to have correct musicbrainz id")
 }
+
+func TestSubmitListen_NowPlayingIsPerUser(t *testing.T) {
+	truncateTestData(t)
+
+	ctx := context.Background()
+	mbzc := &mbz.MbzMockCaller{}
+	opts := catalog.SubmitListenOpts{
+		MbzCaller:      mbzc,
+		ArtistNames:    []string{"ATARASHII GAKKO!"},
+		Artist:         "ATARASHII GAKKO!",
+		TrackTitle:     "Tokyo Calling",
+		ReleaseTitle:   "AG! Calling",
+		Duration:       191,
+		Time:           time.Now(),
+		UserID:         1,
+		IsNowPlaying:   true,
+		SkipSaveListen: true,
+	}
+
+	err := catalog.SubmitListen(ctx, store, opts)
+	require.NoError(t, err)
+
+	np, ok := catalog.GetNowPlaying(1)
+	require.True(t, ok)
+	assert.EqualValues(t, 1, np.TrackID)
+	assert.WithinDuration(t, np.StartedAt.Add(191*time.Second), np.ExpiresAt, time.Second)
+
+	_, ok = catalog.GetNowPlaying(2)
+	assert.False(t, ok, "expected now playing to be scoped to the submitting user")
+}
@@ -12,8 +12,13 @@ import (
 
 const (
 	// defaultBaseUrl        = "http://127.0.0.1"
-	defaultListenPort     = 4110
-	defaultMusicBrainzUrl = "https://musicbrainz.org"
+	defaultListenPort         = 4110
+	defaultMusicBrainzUrl     = "https://musicbrainz.org"
+	defaultIngestWorkers      = 2
+	defaultDuplicateScanHours = 24
+	defaultMbzMatchConfidence = 0.85
+	defaultEnrichmentHours    = 24
+	defaultMbzCacheDays       = 30
 )
 
 const (
@@ -29,6 +34,7 @@ const (
 	ENABLE_LBZ_RELAY_ENV           = "BEAT_SCROBBLE_ENABLE_LBZ_RELAY"
 	LBZ_RELAY_URL_ENV              = "BEAT_SCROBBLE_LBZ_RELAY_URL"
 	LBZ_RELAY_TOKEN_ENV            = "BEAT_SCROBBLE_LBZ_RELAY_TOKEN"
+	LASTFM_API_SECRET_ENV          = "BEAT_SCROBBLE_LASTFM_API_SECRET"
 	CONFIG_DIR_ENV                 = "BEAT_SCROBBLE_CONFIG_DIR"
 	DEFAULT_USERNAME_ENV           = "BEAT_SCROBBLE_DEFAULT_USERNAME"
 	DEFAULT_PASSWORD_ENV           = "BEAT_SCROBBLE_DEFAULT_PASSWORD"
@@ -48,6 +54,14 @@ const (
 	FETCH_IMAGES_DURING_IMPORT_ENV = "BEAT_SCROBBLE_FETCH_IMAGES_DURING_IMPORT"
 	ARTIST_SEPARATORS_ENV          = "BEAT_SCROBBLE_ARTIST_SEPARATORS_REGEX"
 	LOGIN_GATE_ENV                 = "BEAT_SCROBBLE_LOGIN_GATE"
+	INGEST_WORKERS_ENV             = "BEAT_SCROBBLE_INGEST_WORKERS"
+	MPD_ADDRESS_ENV                = "BEAT_SCROBBLE_MPD_ADDRESS"
+	MPD_PASSWORD_ENV               = "BEAT_SCROBBLE_MPD_PASSWORD"
+	MPD_USER_ENV                   = "BEAT_SCROBBLE_MPD_USER"
+	DUPLICATE_SCAN_HOURS_ENV       = "BEAT_SCROBBLE_DUPLICATE_SCAN_HOURS"
+	MBZ_MATCH_CONFIDENCE_ENV       = "BEAT_SCROBBLE_MUSICBRAINZ_MATCH_CONFIDENCE"
+	ENRICHMENT_HOURS_ENV           = "BEAT_SCROBBLE_ENRICHMENT_HOURS"
+	MUSICBRAINZ_CACHE_DAYS_ENV     = "BEAT_SCROBBLE_MUSICBRAINZ_CACHE_DAYS"
 )
 
 type config struct {
@@ -64,6 +78,7 @@ type config struct {
 	lbzRelayEnabled        bool
 	lbzRelayUrl            string
 	lbzRelayToken          string
+	lastFMApiSecret        string
 	defaultPw              string
 	defaultUsername        string
 	defaultTheme           string
@@ -85,6 +100,14 @@ type config struct {
 	importAfter            time.Time
 	artistSeparators       []*regexp.Regexp
 	loginGate              bool
+	ingestWorkers          int
+	mpdAddress             string
+	mpdPassword            string
+	mpdUser                string
+	duplicateScanHours     int
+	mbzMatchConfidence     float64
+	enrichmentHours        int
+	mbzCacheDays           int
 }
 
 var (
@@ -138,6 +161,8 @@ func loadConfig(getenv func(string) string, version string) (*config, error) {
 		cfg.lbzRelayUrl = getenv(LBZ_RELAY_URL_ENV)
 	}
 
+	cfg.lastFMApiSecret = getenv(LASTFM_API_SECRET_ENV)
+
 	beforeutx, _ := strconv.ParseInt(getenv(IMPORT_BEFORE_UNIX_ENV), 10, 64)
 	afterutx, _ := strconv.ParseInt(getenv(IMPORT_AFTER_UNIX_ENV), 10, 64)
 
@@ -150,6 +175,47 @@ func loadConfig(getenv func(string) string, version string) (*config, error) {
 
 	cfg.importThrottleMs, _ = strconv.Atoi(getenv(THROTTLE_IMPORTS_MS))
 
+	cfg.ingestWorkers, err = strconv.Atoi(getenv(INGEST_WORKERS_ENV))
+	if err != nil || cfg.ingestWorkers < 1 {
+		cfg.ingestWorkers = defaultIngestWorkers
+	}
+
+	cfg.mpdAddress = getenv(MPD_ADDRESS_ENV)
+	cfg.mpdPassword = getenv(MPD_PASSWORD_ENV)
+	cfg.mpdUser = getenv(MPD_USER_ENV)
+
+	cfg.duplicateScanHours = defaultDuplicateScanHours
+	if s := getenv(DUPLICATE_SCAN_HOURS_ENV); s != "" {
+		cfg.duplicateScanHours, err = strconv.Atoi(s)
+		if err != nil || cfg.duplicateScanHours < 0 {
+			return nil, fmt.Errorf("loadConfig: invalid configuration: %s must be a number of hours", DUPLICATE_SCAN_HOURS_ENV)
+		}
+	}
+
+	cfg.mbzMatchConfidence = defaultMbzMatchConfidence
+	if s := getenv(MBZ_MATCH_CONFIDENCE_ENV); s != "" {
+		cfg.mbzMatchConfidence, err = strconv.ParseFloat(s, 64)
+		if err != nil || cfg.mbzMatchConfidence < 0 || cfg.mbzMatchConfidence > 1 {
+			return nil, fmt.Errorf("loadConfig: invalid configuration: %s must be between 0 and 1", MBZ_MATCH_CONFIDENCE_ENV)
+		}
+	}
+
+	cfg.enrichmentHours = defaultEnrichmentHours
+	if s := getenv(ENRICHMENT_HOURS_ENV); s != "" {
+		cfg.enrichmentHours, err = strconv.Atoi(s)
+		if err != nil || cfg.enrichmentHours < 0 {
+			return nil, fmt.Errorf("loadConfig: invalid configuration: %s must be a number of hours", ENRICHMENT_HOURS_ENV)
+		}
+	}
+
+	cfg.mbzCacheDays = defaultMbzCacheDays
+	if s := getenv(MUSICBRAINZ_CACHE_DAYS_ENV); s != "" {
+		cfg.mbzCacheDays, err = strconv.Atoi(s)
+		if err != nil || cfg.mbzCacheDays < 0 {
+			return nil, fmt.Errorf("loadConfig: invalid configuration: %s must be a number of days", MUSICBRAINZ_CACHE_DAYS_ENV)
+		}
+	}
+
 	cfg.disableRateLimit = parseBool(getenv(DISABLE_RATE_LIMIT_ENV))
 
 	cfg.structuredLogging = parseBool(getenv(ENABLE_STRUCTURED_LOGGING_ENV))
@@ -265,6 +331,53 @@ func MusicBrainzUrl() string {
 	return globalConfig.musicBrainzUrl
 }
 
+func IngestWorkers() int {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.ingestWorkers
+}
+
+// MpdAddress is the host:port or socket path of the MPD server to watch. The
+// watcher is disabled when empty.
+func MpdAddress() string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.mpdAddress
+}
+
+func MpdPassword() string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.mpdPassword
+}
+
+// MpdUser is the user that plays from MPD are saved for, the default user when
+// not set.
+func MpdUser() string {
+	lock.RLock()
+	defer lock.RUnlock()
+	if globalConfig.mpdUser == "" {
+		return globalConfig.defaultUsername
+	}
+	return globalConfig.mpdUser
+}
+
+// DuplicateScanInterval is how often artists, albums and tracks are scanned
+// for duplicates. Scanning is disabled when zero.
+func DuplicateScanInterval() time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	return time.Duration(globalConfig.duplicateScanHours) * time.Hour
+}
+
+// MusicBrainzMatchConfidence is the lowest score a MusicBrainz search result
+// is attached to a track with. Searching is disabled when zero.
+func MusicBrainzMatchConfidence() float64 {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.mbzMatchConfidence
+}
+
 func MusicBrainzRateLimit() int {
 	lock.RLock()
 	defer lock.RUnlock()
@@ -301,6 +414,12 @@ func LbzRelayToken() string {
 	return globalConfig.lbzRelayToken
 }
 
+func LastFMApiSecret() string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.lastFMApiSecret
+}
+
 func DefaultPassword() string {
 	lock.RLock()
 	defer lock.RUnlock()
@@ -421,3 +540,19 @@ func LoginGate() bool {
 	defer lock.RUnlock()
 	return globalConfig.loginGate
 }
+
+// EnrichmentInterval is how long the enrichment worker waits before sweeping
+// for missing metadata again. The worker is disabled when zero.
+func EnrichmentInterval() time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	return time.Duration(globalConfig.enrichmentHours) * time.Hour
+}
+
+// MusicBrainzCacheTTL is how long responses of MusicBrainz lookups are
+// cached. Caching is disabled when zero.
+func MusicBrainzCacheTTL() time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	return time.Duration(globalConfig.mbzCacheDays) * 24 * time.Hour
+}
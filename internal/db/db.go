@@ -59,18 +59,18 @@ type DB interface {
 	DeleteArtist(ctx context.Context, id int32) error
 	DeleteAlbum(ctx context.Context, id int32) error
 	DeleteTrack(ctx context.Context, id int32) error
-	DeleteListen(ctx context.Context, trackId int32, listenedAt time.Time) error
+	DeleteListen(ctx context.Context, trackId int32, listenedAt time.Time, userId int32) error
 	DeleteArtistAlias(ctx context.Context, id int32, alias string) error
 	DeleteAlbumAlias(ctx context.Context, id int32, alias string) error
 	DeleteTrackAlias(ctx context.Context, id int32, alias string) error
 	DeleteSession(ctx context.Context, sessionId uuid.UUID) error
 	DeleteApiKey(ctx context.Context, id int32) error
 	// Count
-	CountListens(ctx context.Context, period Period) (int64, error)
-	CountTracks(ctx context.Context, period Period) (int64, error)
-	CountAlbums(ctx context.Context, period Period) (int64, error)
-	CountArtists(ctx context.Context, period Period) (int64, error)
-	CountTimeListened(ctx context.Context, period Period) (int64, error)
+	CountListens(ctx context.Context, period Period, userId int32) (int64, error)
+	CountTracks(ctx context.Context, period Period, userId int32) (int64, error)
+	CountAlbums(ctx context.Context, period Period, userId int32) (int64, error)
+	CountArtists(ctx context.Context, period Period, userId int32) (int64, error)
+	CountTimeListened(ctx context.Context, period Period, userId int32) (int64, error)
 	CountTimeListenedToItem(ctx context.Context, opts TimeListenedOpts) (int64, error)
 	CountUsers(ctx context.Context) (int64, error)
 	// Search
@@ -81,6 +81,15 @@ type DB interface {
 	MergeTracks(ctx context.Context, fromId, toId int32) error
 	MergeAlbums(ctx context.Context, fromId, toId int32, replaceImage bool) error
 	MergeArtists(ctx context.Context, fromId, toId int32, replaceImage bool) error
+	// Split
+	SplitArtist(ctx context.Context, opts SplitArtistOpts) (*SplitResult, error)
+	SplitAlbum(ctx context.Context, opts SplitAlbumOpts) (*SplitResult, error)
+	SplitTrack(ctx context.Context, opts SplitTrackOpts) (*SplitResult, error)
+	// Reassociation
+	GetTrackListenCounts(ctx context.Context, opts ListenFilterOpts) ([]TrackListenCount, error)
+	MoveListens(ctx context.Context, opts MoveListensOpts) (int64, error)
+	ReassociateListens(ctx context.Context, opts ReassociateListensOpts) ([]int64, error)
+	CleanOrphanedEntries(ctx context.Context) error
 	// Etc
 	ImageHasAssociation(ctx context.Context, image uuid.UUID) (bool, error)
 	GetImageSource(ctx context.Context, image uuid.UUID) (string, error)
@@ -92,6 +101,78 @@ type DB interface {
 	// Preferences
 	SaveUserPreferences(ctx context.Context, userId int32, preferencesData []byte) error
 	GetUserPreferences(ctx context.Context, userId int32) ([]byte, error)
+	// Listen submissions
+	SaveListenSubmission(ctx context.Context, opts SaveListenSubmissionOpts) (*models.ListenSubmission, error)
+	SaveListenSubmissions(ctx context.Context, opts []SaveListenSubmissionOpts) error
+	ClaimListenSubmission(ctx context.Context, opts ClaimListenSubmissionOpts) (*models.ListenSubmission, error)
+	FailListenSubmission(ctx context.Context, opts FailListenSubmissionOpts) error
+	DeleteListenSubmission(ctx context.Context, id int64) error
+	ResetProcessingListenSubmissions(ctx context.Context) (int64, error)
+	GetDeadListenSubmissionsPaginated(ctx context.Context, opts GetItemsOpts) (*PaginatedResponse[*models.ListenSubmission], error)
+	RequeueDeadListenSubmission(ctx context.Context, id int64, userId int32) (bool, error)
+	DeleteDeadListenSubmission(ctx context.Context, id int64, userId int32) (bool, error)
+	// Rewrite rules
+	GetRewriteRule(ctx context.Context, id, userId int32) (*models.RewriteRule, error)
+	GetRewriteRulesByUserID(ctx context.Context, userId int32) ([]*models.RewriteRule, error)
+	GetEnabledRewriteRulesByUserID(ctx context.Context, userId int32) ([]*models.RewriteRule, error)
+	SaveRewriteRule(ctx context.Context, opts SaveRewriteRuleOpts) (*models.RewriteRule, error)
+	UpdateRewriteRule(ctx context.Context, opts UpdateRewriteRuleOpts) (bool, error)
+	DeleteRewriteRule(ctx context.Context, id, userId int32) (bool, error)
+	// CSV import profiles
+	GetCsvImportProfile(ctx context.Context, userId int32, name string) (*models.CsvImportProfile, error)
+	GetCsvImportProfilesByUserID(ctx context.Context, userId int32) ([]*models.CsvImportProfile, error)
+	SaveCsvImportProfile(ctx context.Context, userId int32, p *models.CsvImportProfile) (*models.CsvImportProfile, error)
+	DeleteCsvImportProfile(ctx context.Context, userId int32, name string) (bool, error)
+	// Import jobs
+	CreateImportJob(ctx context.Context, opts CreateImportJobOpts) (*models.ImportJob, error)
+	GetImportJob(ctx context.Context, id int64) (*models.ImportJob, error)
+	GetImportJobsByUserID(ctx context.Context, userId int32, limit int) ([]*models.ImportJob, error)
+	GetNextImportJob(ctx context.Context) (*models.ImportJob, error)
+	StartImportJob(ctx context.Context, id int64) (bool, error)
+	SaveImportJobProgress(ctx context.Context, j *models.ImportJob) (models.ImportJobStatus, error)
+	CancelImportJob(ctx context.Context, userId int32, id int64) (bool, error)
+	// Listen policy
+	GetListenPolicy(ctx context.Context, userId int32) (*models.ListenPolicy, error)
+	SaveListenPolicy(ctx context.Context, opts SaveListenPolicyOpts) error
+	ListenOfTrackExists(ctx context.Context, opts ListenOfTrackExistsOpts) (bool, error)
+	GetListenBefore(ctx context.Context, userId int32, t time.Time) (time.Time, int32, error)
+	SaveRejectedListen(ctx context.Context, opts SaveRejectedListenOpts) error
+	GetRejectedListensPaginated(ctx context.Context, opts GetItemsOpts) (*PaginatedResponse[*models.RejectedListen], error)
+	AcceptRejectedListen(ctx context.Context, id int64, userId int32) (bool, error)
+	DeleteRejectedListen(ctx context.Context, id int64, userId int32) (bool, error)
+	// Listen matches
+	GetListenMatch(ctx context.Context, userId, trackId int32, listenedAt time.Time) (*models.ListenMatch, error)
+	GetMatchInboxPaginated(ctx context.Context, opts GetMatchInboxOpts) (*PaginatedResponse[*models.Listen], error)
+	ReviewListenMatch(ctx context.Context, userId, trackId int32, listenedAt time.Time) (bool, error)
+	// Merge suggestions
+	GetDuplicateCandidates(ctx context.Context, kind models.MergeSuggestionKind) ([]DuplicateCandidate, error)
+	GetDuplicateDetails(ctx context.Context, kind models.MergeSuggestionKind, id int32) (*DuplicateDetails, error)
+	SaveMergeSuggestion(ctx context.Context, opts SaveMergeSuggestionOpts) error
+	PruneMergeSuggestions(ctx context.Context, kind models.MergeSuggestionKind, before time.Time) error
+	GetMergeSuggestions(ctx context.Context, opts GetMergeSuggestionsOpts) ([]*models.MergeSuggestion, error)
+	GetMergeSuggestion(ctx context.Context, id int64) (*models.MergeSuggestion, error)
+	DismissMergeSuggestion(ctx context.Context, id int64) (bool, error)
+	DeleteMergeSuggestion(ctx context.Context, id int64) error
+	// Audit log
+	GetAuditLog(ctx context.Context, opts GetAuditLogOpts) ([]*models.AuditEntry, error)
+	UndoAuditEntry(ctx context.Context, id int64, userId int32) (bool, error)
+	// MusicBrainz search
+	GetTracksToSearch(ctx context.Context, limit int32) ([]int32, error)
+	SaveMbzSearchAttempt(ctx context.Context, trackId int32, score float64) error
+	// MusicBrainz cache
+	GetMbzCacheEntry(ctx context.Context, kind string, id uuid.UUID) (*models.MbzCacheEntry, error)
+	SaveMbzCacheEntry(ctx context.Context, e *models.MbzCacheEntry) error
+	PurgeMbzCache(ctx context.Context, opts PurgeMbzCacheOpts) (int64, error)
+	// Enrichment
+	GetEnrichmentProgress(ctx context.Context) ([]*models.EnrichmentProgress, error)
+	SaveEnrichmentProgress(ctx context.Context, p *models.EnrichmentProgress) error
+	SaveEnrichmentAttempt(ctx context.Context, task models.EnrichmentTask, id int32) error
+	TracksWithoutDuration(ctx context.Context, from int32) ([]int32, error)
+	ArtistsWithoutMbzID(ctx context.Context, from int32) ([]ArtistWithoutMbzID, error)
+	ArtistsWithoutGenres(ctx context.Context, from int32) ([]*models.Artist, error)
+	AlbumsWithoutGenres(ctx context.Context, from int32) ([]*models.Album, error)
+	UpdateArtistGenres(ctx context.Context, id int32, genres []string) error
+	UpdateAlbumGenres(ctx context.Context, id int32, genres []string) error
 	// Lifecycle
 	Ping(ctx context.Context) error
 	Close(ctx context.Context)
@@ -15,6 +15,9 @@ type GetAlbumOpts struct {
 	Title         string
 	Titles        []string
 	Image         uuid.UUID
+
+	// Scopes listen statistics to a single user
+	UserID int32
 }
 
 type GetArtistOpts struct {
@@ -22,6 +25,9 @@ type GetArtistOpts struct {
 	MusicBrainzID uuid.UUID
 	Name          string
 	Image         uuid.UUID
+
+	// Scopes listen statistics to a single user
+	UserID int32
 }
 
 type GetTrackOpts struct {
@@ -29,6 +35,9 @@ type GetTrackOpts struct {
 	MusicBrainzID uuid.UUID
 	Title         string
 	ArtistIDs     []int32
+
+	// Scopes listen statistics to a single user
+	UserID int32
 }
 
 type SaveTrackOpts struct {
@@ -81,12 +90,29 @@ type SaveListenOpts struct {
 	Time    time.Time
 	UserID  int32
 	Client  string
+
+	// optional details sent by the client
+	DurationMs              int32 // how long the track was played for
+	MediaPlayer             string
+	SubmissionClientVersion string
+	Tags                    []string
+	OriginURL               string
+
+	// set when the listen starts before the previous one could have ended
+	Overlap bool
+	// how the listen was associated, saved along with it when set
+	Match *models.ListenMatch
 }
 
 type UpdateTrackOpts struct {
 	ID            int32
 	MusicBrainzID uuid.UUID
 	Duration      int32
+	// moves the track to another album
+	AlbumID int32
+	// replaces the credited artists when not empty, the first one becoming
+	// the primary artist if the current one is no longer credited
+	ArtistIDs []int32
 }
 
 type UpdateArtistOpts struct {
@@ -132,6 +158,9 @@ type GetItemsOpts struct {
 
 	// Used for getting listens
 	TrackID int
+
+	// Only listens belonging to this user are considered
+	UserID int32
 }
 
 type ListenActivityOpts struct {
@@ -142,6 +171,7 @@ type ListenActivityOpts struct {
 	AlbumID  int32
 	ArtistID int32
 	TrackID  int32
+	UserID   int32
 }
 
 type TimeListenedOpts struct {
@@ -149,6 +179,170 @@ type TimeListenedOpts struct {
 	AlbumID  int32
 	ArtistID int32
 	TrackID  int32
+	UserID   int32
+}
+
+// PartitionKey is the lowercased artist name of the submission
+type SaveListenSubmissionOpts struct {
+	UserID       int32
+	Payload      []byte
+	PartitionKey string
+}
+
+// Submissions are spread across workers by partition key, so that two workers
+// never associate the same artist at the same time
+type ClaimListenSubmissionOpts struct {
+	Workers int
+	Worker  int
+}
+
+type FailListenSubmissionOpts struct {
+	ID      int64
+	Error   string
+	RetryAt time.Time
+	// moves the submission to the dead-letter list instead of retrying it
+	Dead bool
+}
+
+// Selects the listens of a user in a time range, optionally only those from a
+// client or of tracks by an artist
+type ListenFilterOpts struct {
+	UserID   int32
+	From     time.Time
+	To       time.Time
+	Client   string
+	ArtistID int32
+}
+
+type MoveListensOpts struct {
+	ListenFilterOpts
+	FromTrackID int32
+	ToTrackID   int32
+}
+
+type TrackMove struct {
+	FromTrackID int32
+	ToTrackID   int32
+}
+
+type ReassociateListensOpts struct {
+	ListenFilterOpts
+	Moves []TrackMove
+}
+
+type SaveRewriteRuleOpts struct {
+	UserID      int32
+	Position    int32
+	Enabled     bool
+	Field       models.RewriteField
+	Pattern     string
+	Action      models.RewriteAction
+	Target      models.RewriteField
+	Replacement string
+}
+
+type UpdateRewriteRuleOpts struct {
+	ID          int32
+	UserID      int32
+	Position    int32
+	Enabled     bool
+	Field       models.RewriteField
+	Pattern     string
+	Action      models.RewriteAction
+	Target      models.RewriteField
+	Replacement string
+}
+
+type SaveListenPolicyOpts struct {
+	UserID                 int32
+	MinPlayedFraction      float64
+	MinPlayedSeconds       int32
+	DuplicateWindowSeconds int32
+	FlagOverlaps           bool
+}
+
+type ListenOfTrackExistsOpts struct {
+	UserID  int32
+	TrackID int32
+	Time    time.Time
+	// listens within this long before or after Time are matched
+	Window time.Duration
+}
+
+type SaveRejectedListenOpts struct {
+	Reason models.RejectedListenReason
+	Listen SaveListenOpts
+}
+
+type GetMatchInboxOpts struct {
+	UserID int32
+	// listens matched with a lower confidence than this are listed
+	Below float64
+	Limit int
+	Page  int
+}
+
+type SaveMergeSuggestionOpts struct {
+	Kind    models.MergeSuggestionKind
+	FromID  int32
+	ToID    int32
+	Score   float64
+	Reasons []string
+	// suggestions of the kind not saved since are removed by PruneMergeSuggestions
+	Time time.Time
+}
+
+type GetMergeSuggestionsOpts struct {
+	// all kinds are listed when empty
+	Kind      models.MergeSuggestionKind
+	Dismissed bool
+	Limit     int
+}
+
+type CreateImportJobOpts struct {
+	UserID   int32
+	Format   models.ImportFormat
+	Filename string
+	Options  models.ImportJobOptions
+	// of the file, in the units the job counts BytesRead in
+	Size int64
+}
+
+type SplitArtistOpts struct {
+	ID int32
+	// the name of the new artist
+	Name     string
+	Aliases  []string
+	TrackIDs []int32
+	// keeps the moved tracks credited to the artist as well, for tracks of two
+	// artists that were saved as one
+	KeepCredits bool
+	DryRun      bool
+}
+
+type SplitAlbumOpts struct {
+	ID int32
+	// the title of the new album
+	Title    string
+	Aliases  []string
+	TrackIDs []int32
+	DryRun   bool
+}
+
+type SplitTrackOpts struct {
+	// the listens to move, UserID is required
+	ListenFilterOpts
+	ID int32
+	// the title of the new track
+	Title   string
+	Aliases []string
+	DryRun  bool
+}
+
+type GetAuditLogOpts struct {
+	// listen deletes of other users are left out
+	UserID int32
+	Limit  int
 }
 
 type GetExportPageOpts struct {
@@ -193,3 +387,10 @@ type UpdateTrackMetadataParams struct {
 	Valence          pgtype.Float8
 	Tempo            pgtype.Float8
 }
+
+type PurgeMbzCacheOpts struct {
+	// only entries of this kind when set
+	Kind string
+	// only entries that expired
+	ExpiredOnly bool
+}
@@ -101,6 +101,7 @@ func (d *Psql) GetAlbum(ctx context.Context, opts db.GetAlbumOpts) (*models.Albu
 		ListenedAt:   time.Unix(0, 0),
 		ListenedAt_2: time.Now(),
 		ReleaseID:    ret.ID,
+		UserID:       opts.UserID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetAlbum: CountListensFromRelease: %w", err)
@@ -109,12 +110,16 @@ func (d *Psql) GetAlbum(ctx context.Context, opts db.GetAlbumOpts) (*models.Albu
 	seconds, err := d.CountTimeListenedToItem(ctx, db.TimeListenedOpts{
 		Period:  db.PeriodAllTime,
 		AlbumID: ret.ID,
+		UserID:  opts.UserID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetAlbum: CountTimeListenedToItem: %w", err)
 	}
 
-	firstListen, err := d.q.GetFirstListenFromRelease(ctx, ret.ID)
+	firstListen, err := d.q.GetFirstListenFromRelease(ctx, repository.GetFirstListenFromReleaseParams{
+		ReleaseID: ret.ID,
+		UserID:    opts.UserID,
+	})
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("GetAlbum: GetFirstListenFromRelease: %w", err)
 	}
@@ -239,7 +244,13 @@ func (d *Psql) UpdateAlbum(ctx context.Context, opts db.UpdateAlbumOpts) error {
 	qtx := d.q.WithTx(tx)
 	if opts.MusicBrainzID != uuid.Nil {
 		l.Debug().Msgf("Updating release with ID %d with MusicBrainz ID %s", opts.ID, opts.MusicBrainzID)
-		err := qtx.UpdateReleaseMbzID(ctx, repository.UpdateReleaseMbzIDParams{
+		existing, err := qtx.GetReleaseByMbzID(ctx, &opts.MusicBrainzID)
+		if err == nil && existing.ID != opts.ID {
+			return fmt.Errorf("UpdateAlbum: %w: album %d", db.ErrMbzIDInUse, existing.ID)
+		} else if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("UpdateAlbum: GetReleaseByMbzID: %w", err)
+		}
+		err = qtx.UpdateReleaseMbzID(ctx, repository.UpdateReleaseMbzIDParams{
 			ID:            opts.ID,
 			MusicBrainzID: &opts.MusicBrainzID,
 		})
@@ -308,8 +319,25 @@ func (d *Psql) SaveAlbumAliases(ctx context.Context, id int32, aliases []string,
 	return tx.Commit(ctx)
 }
 
+// DeleteAlbum deletes the album along with what depends on it, recording it in the
+// audit log first.
 func (d *Psql) DeleteAlbum(ctx context.Context, id int32) error {
-	return d.q.DeleteRelease(ctx, id)
+	l := logger.FromContext(ctx)
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return fmt.Errorf("DeleteAlbum: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+	err = recordAudit(ctx, qtx, models.AuditDeleteAlbum, 0, map[string]any{"id": id}, auditEntities{Releases: []int32{id}})
+	if err != nil {
+		return fmt.Errorf("DeleteAlbum: %w", err)
+	}
+	if err := qtx.DeleteRelease(ctx, id); err != nil {
+		return fmt.Errorf("DeleteAlbum: %w", err)
+	}
+	return tx.Commit(ctx)
 }
 func (d *Psql) DeleteAlbumAlias(ctx context.Context, id int32, alias string) error {
 	return d.q.DeleteReleaseAlias(ctx, repository.DeleteReleaseAliasParams{
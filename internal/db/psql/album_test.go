@@ -51,7 +51,7 @@ func TestGetAlbum(t *testing.T) {
 	ctx := context.Background()
 
 	// Test GetAlbum by ID
-	result, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: 1})
+	result, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, result.ID)
 	assert.Equal(t, "Release One", result.Title)
@@ -59,7 +59,7 @@ func TestGetAlbum(t *testing.T) {
 	assert.EqualValues(t, 400, result.TimeListened)
 
 	// Test GetAlbum with insufficient information
-	_, err = store.GetAlbum(ctx, db.GetAlbumOpts{})
+	_, err = store.GetAlbum(ctx, db.GetAlbumOpts{UserID: 1})
 	assert.Error(t, err)
 
 	truncateTestData(t)
@@ -125,7 +125,7 @@ func TestUpdateAlbum(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	result, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: rg.ID})
+	result, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: rg.ID, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, newMbzID, *result.MbzID)
 	assert.Equal(t, imgid, *result.Image)
@@ -191,7 +191,7 @@ func TestSaveAlbumAliases(t *testing.T) {
 
 	err = store.SetPrimaryAlbumAlias(ctx, 1, "Alias 1")
 	require.NoError(t, err)
-	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: rg.ID})
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: rg.ID, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, "Alias 1", album.Title)
 
@@ -282,7 +282,7 @@ func TestDeleteAlbumAlias(t *testing.T) {
 	// Ensure primary alias cannot be deleted
 	err = store.DeleteAlbumAlias(ctx, rg.ID, "Test Album")
 	require.NoError(t, err) // shouldn't error when nothing is deleted
-	rg, err = store.GetAlbum(ctx, db.GetAlbumOpts{ID: rg.ID})
+	rg, err = store.GetAlbum(ctx, db.GetAlbumOpts{ID: rg.ID, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, "Test Album", rg.Title)
 
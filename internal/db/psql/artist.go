@@ -30,6 +30,7 @@ func (d *Psql) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.Ar
 			ListenedAt:   time.Unix(0, 0),
 			ListenedAt_2: time.Now(),
 			ArtistID:     row.ID,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetArtist: CountListensFromArtist: %w", err)
@@ -37,11 +38,15 @@ func (d *Psql) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.Ar
 		seconds, err := d.CountTimeListenedToItem(ctx, db.TimeListenedOpts{
 			Period:   db.PeriodAllTime,
 			ArtistID: row.ID,
+			UserID:   opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetArtist: CountTimeListenedToItem: %w", err)
 		}
-		firstListen, err := d.q.GetFirstListenFromArtist(ctx, row.ID)
+		firstListen, err := d.q.GetFirstListenFromArtist(ctx, repository.GetFirstListenFromArtistParams{
+			ArtistID: row.ID,
+			UserID:   opts.UserID,
+		})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetAlbum: GetFirstListenFromArtist: %w", err)
 		}
@@ -69,6 +74,7 @@ func (d *Psql) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.Ar
 			ListenedAt:   time.Unix(0, 0),
 			ListenedAt_2: time.Now(),
 			ArtistID:     row.ID,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetArtist: CountListensFromArtist: %w", err)
@@ -76,11 +82,15 @@ func (d *Psql) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.Ar
 		seconds, err := d.CountTimeListenedToItem(ctx, db.TimeListenedOpts{
 			Period:   db.PeriodAllTime,
 			ArtistID: row.ID,
+			UserID:   opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetArtist: CountTimeListenedToItem: %w", err)
 		}
-		firstListen, err := d.q.GetFirstListenFromArtist(ctx, row.ID)
+		firstListen, err := d.q.GetFirstListenFromArtist(ctx, repository.GetFirstListenFromArtistParams{
+			ArtistID: row.ID,
+			UserID:   opts.UserID,
+		})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetAlbum: GetFirstListenFromArtist: %w", err)
 		}
@@ -108,6 +118,7 @@ func (d *Psql) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.Ar
 			ListenedAt:   time.Unix(0, 0),
 			ListenedAt_2: time.Now(),
 			ArtistID:     row.ID,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetArtist: CountListensFromArtist: %w", err)
@@ -115,11 +126,15 @@ func (d *Psql) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.Ar
 		seconds, err := d.CountTimeListenedToItem(ctx, db.TimeListenedOpts{
 			Period:   db.PeriodAllTime,
 			ArtistID: row.ID,
+			UserID:   opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetArtist: CountTimeListenedToItem: %w", err)
 		}
-		firstListen, err := d.q.GetFirstListenFromArtist(ctx, row.ID)
+		firstListen, err := d.q.GetFirstListenFromArtist(ctx, repository.GetFirstListenFromArtistParams{
+			ArtistID: row.ID,
+			UserID:   opts.UserID,
+		})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("GetAlbum: GetFirstListenFromArtist: %w", err)
 		}
@@ -184,8 +199,25 @@ func (d *Psql) SaveArtistAliases(ctx context.Context, id int32, aliases []string
 	return tx.Commit(ctx)
 }
 
+// DeleteArtist deletes the artist along with what depends on it, recording it in the
+// audit log first.
 func (d *Psql) DeleteArtist(ctx context.Context, id int32) error {
-	return d.q.DeleteArtist(ctx, id)
+	l := logger.FromContext(ctx)
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return fmt.Errorf("DeleteArtist: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+	err = recordAudit(ctx, qtx, models.AuditDeleteArtist, 0, map[string]any{"id": id}, auditEntities{Artists: []int32{id}})
+	if err != nil {
+		return fmt.Errorf("DeleteArtist: %w", err)
+	}
+	if err := qtx.DeleteArtist(ctx, id); err != nil {
+		return fmt.Errorf("DeleteArtist: %w", err)
+	}
+	return tx.Commit(ctx)
 }
 
 // Equivalent to Psql.SaveArtist, then Psql.SaveMbzAliases
@@ -267,7 +299,13 @@ func (d *Psql) UpdateArtist(ctx context.Context, opts db.UpdateArtistOpts) error
 	qtx := d.q.WithTx(tx)
 	if opts.MusicBrainzID != uuid.Nil {
 		l.Debug().Msgf("Updating artist with id %d with MusicBrainz ID %s", opts.ID, opts.MusicBrainzID)
-		err := qtx.UpdateArtistMbzID(ctx, repository.UpdateArtistMbzIDParams{
+		existing, err := qtx.GetArtistByMbzID(ctx, &opts.MusicBrainzID)
+		if err == nil && existing.ID != opts.ID {
+			return fmt.Errorf("UpdateArtist: %w: artist %d", db.ErrMbzIDInUse, existing.ID)
+		} else if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("UpdateArtist: GetArtistByMbzID: %w", err)
+		}
+		err = qtx.UpdateArtistMbzID(ctx, repository.UpdateArtistMbzIDParams{
 			ID:            opts.ID,
 			MusicBrainzID: &opts.MusicBrainzID,
 		})
@@ -18,7 +18,7 @@ func TestGetArtist(t *testing.T) {
 	mbzId := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 
 	// Test GetArtist by ID
-	result, err := store.GetArtist(ctx, db.GetArtistOpts{ID: 1})
+	result, err := store.GetArtist(ctx, db.GetArtistOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, result.ID)
 	assert.Equal(t, "Artist One", result.Name)
@@ -26,7 +26,7 @@ func TestGetArtist(t *testing.T) {
 	assert.EqualValues(t, 400, result.TimeListened)
 
 	// Test GetArtist by Name
-	result, err = store.GetArtist(ctx, db.GetArtistOpts{Name: "Artist One"})
+	result, err = store.GetArtist(ctx, db.GetArtistOpts{Name: "Artist One", UserID: 1})
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, result.ID)
 	assert.Equal(t, "Artist One", result.Name)
@@ -34,7 +34,7 @@ func TestGetArtist(t *testing.T) {
 	assert.EqualValues(t, 400, result.TimeListened)
 
 	// Test GetArtist by MusicBrainzID
-	result, err = store.GetArtist(ctx, db.GetArtistOpts{MusicBrainzID: mbzId})
+	result, err = store.GetArtist(ctx, db.GetArtistOpts{MusicBrainzID: mbzId, UserID: 1})
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, result.ID)
 	assert.Equal(t, "Artist One", result.Name)
@@ -42,7 +42,7 @@ func TestGetArtist(t *testing.T) {
 	assert.EqualValues(t, 400, result.TimeListened)
 
 	// Test GetArtist with insufficient information
-	_, err = store.GetArtist(ctx, db.GetArtistOpts{})
+	_, err = store.GetArtist(ctx, db.GetArtistOpts{UserID: 1})
 	assert.Error(t, err)
 
 	truncateTestData(t)
@@ -75,7 +75,7 @@ func TestSaveAliases(t *testing.T) {
 
 	err = store.SetPrimaryArtistAlias(ctx, 1, "Alias1")
 	require.NoError(t, err)
-	artist, err = store.GetArtist(ctx, db.GetArtistOpts{ID: artist.ID})
+	artist, err = store.GetArtist(ctx, db.GetArtistOpts{ID: artist.ID, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, "Alias1", artist.Name)
 
@@ -130,10 +130,20 @@ func TestUpdateArtist(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	result, err := store.GetArtist(ctx, db.GetArtistOpts{ID: artist.ID})
+	result, err := store.GetArtist(ctx, db.GetArtistOpts{ID: artist.ID, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, imgid, *result.Image)
 
+	other, err := store.SaveArtist(ctx, db.SaveArtistOpts{
+		Name: "Other Name",
+	})
+	require.NoError(t, err)
+	mbzID := uuid.New()
+	err = store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: artist.ID, MusicBrainzID: mbzID})
+	require.NoError(t, err)
+	err = store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: other.ID, MusicBrainzID: mbzID})
+	assert.ErrorIs(t, err, db.ErrMbzIDInUse)
+
 	truncateTestData(t)
 }
 func TestGetAllArtistAliases(t *testing.T) {
@@ -201,7 +211,7 @@ func TestDeleteArtistAlias(t *testing.T) {
 	// Ensure primary alias cannot be deleted
 	err = store.DeleteArtistAlias(ctx, artist.ID, "Alias Artist")
 	require.NoError(t, err) // shouldn't error when nothing is deleted
-	artist, err = store.GetArtist(ctx, db.GetArtistOpts{ID: 1})
+	artist, err = store.GetArtist(ctx, db.GetArtistOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, "Alias Artist", artist.Name)
 
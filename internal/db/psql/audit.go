@@ -0,0 +1,234 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// auditEntities are the artists, albums and tracks an operation starts from.
+// Their albums, tracks, artists, aliases, artist links and listens are
+// recorded along with them.
+type auditEntities struct {
+	Artists  []int32
+	Releases []int32
+	Tracks   []int32
+}
+
+// the largest snapshot an audit entry is saved with. Operations that could
+// change more are refused, rather than copying every listen of a large artist
+// into the audit log.
+const maxAuditSnapshotSize = 32 << 20
+
+// recordAudit saves what the catalog operation about to be made in the
+// transaction could change, so that it can be undone. userId is that of the
+// user whose listens the operation moves, or 0 when it changes the catalog
+// shared by all users.
+func recordAudit(ctx context.Context, qtx *repository.Queries, action models.AuditAction, userId int32, inputs map[string]any, entities auditEntities) error {
+	snapshot, err := qtx.GetCatalogSnapshot(ctx, repository.GetCatalogSnapshotParams{
+		ReleaseIds: nonNil(entities.Releases),
+		ArtistIds:  nonNil(entities.Artists),
+		TrackIds:   nonNil(entities.Tracks),
+	})
+	if err != nil {
+		return fmt.Errorf("recordAudit: GetCatalogSnapshot: %w", err)
+	}
+	return insertAuditEntry(ctx, qtx, action, userId, inputs, snapshot)
+}
+
+// insertAuditEntry saves the entry unless the snapshot is empty, that is the
+// operation has nothing to change. db.ErrAuditSnapshotTooLarge is returned
+// when the snapshot is larger than maxAuditSnapshotSize.
+func insertAuditEntry(ctx context.Context, qtx *repository.Queries, action models.AuditAction, userId int32, inputs map[string]any, snapshot []byte) error {
+	if len(snapshot) > maxAuditSnapshotSize {
+		return fmt.Errorf("insertAuditEntry: %w", db.ErrAuditSnapshotTooLarge)
+	}
+	var rows map[string][]json.RawMessage
+	if err := json.Unmarshal(snapshot, &rows); err != nil {
+		return fmt.Errorf("insertAuditEntry: %w", err)
+	}
+	empty := true
+	for _, r := range rows {
+		if len(r) > 0 {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		return nil
+	}
+	inputsJson, err := json.Marshal(inputs)
+	if err != nil {
+		return fmt.Errorf("insertAuditEntry: %w", err)
+	}
+	err = qtx.InsertAuditEntry(ctx, repository.InsertAuditEntryParams{
+		Action:   string(action),
+		UserID:   pgtype.Int4{Int32: userId, Valid: userId != 0},
+		Inputs:   inputsJson,
+		Snapshot: snapshot,
+	})
+	if err != nil {
+		return fmt.Errorf("insertAuditEntry: %w", err)
+	}
+	return nil
+}
+
+func nonNil(ids []int32) []int32 {
+	if ids == nil {
+		return []int32{}
+	}
+	return ids
+}
+
+// GetAuditLog returns the newest entries first.
+func (d *Psql) GetAuditLog(ctx context.Context, opts db.GetAuditLogOpts) ([]*models.AuditEntry, error) {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultItemsPerPage
+	}
+	rows, err := d.q.GetAuditLog(ctx, repository.GetAuditLogParams{
+		UserID: pgtype.Int4{Int32: opts.UserID, Valid: true},
+		Limit:  int32(opts.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetAuditLog: %w", err)
+	}
+	entries := make([]*models.AuditEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = &models.AuditEntry{
+			ID:     row.ID,
+			Action: models.AuditAction(row.Action),
+			Inputs: row.Inputs,
+			Recorded: models.AuditRecorded{
+				Artists:     row.Artists,
+				Albums:      row.Albums,
+				Tracks:      row.Tracks,
+				Listens:     row.Listens,
+				Aliases:     row.Aliases,
+				ArtistLinks: row.ArtistLinks,
+			},
+			Undoable:  row.Undoable,
+			CreatedAt: row.CreatedAt,
+		}
+		if row.UndoneAt.Valid {
+			entries[i].UndoneAt = &row.UndoneAt.Time
+		}
+	}
+	return entries, nil
+}
+
+// UndoAuditEntry puts back the artists, albums, tracks, aliases, artist links
+// and listens recorded by the entry as they were before the operation, and
+// deletes what a split created. Only the latest entry that was not undone of
+// those the user sees can be undone, otherwise db.ErrNotUndoable is returned.
+// When the images, albums or primary artists the operation left were changed
+// since, db.ErrUndoConflict is returned instead of overwriting them. Returns
+// false when there is no such entry, or it is another user's listen delete.
+func (d *Psql) UndoAuditEntry(ctx context.Context, id int64, userId int32) (bool, error) {
+	l := logger.FromContext(ctx)
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return false, fmt.Errorf("UndoAuditEntry: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+
+	entry, err := qtx.GetAuditEntryForUpdate(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("UndoAuditEntry: GetAuditEntryForUpdate: %w", err)
+	}
+	if entry.UserID.Valid && entry.UserID.Int32 != userId {
+		return false, nil
+	}
+	latest, err := qtx.GetLatestAuditEntryID(ctx, pgtype.Int4{Int32: userId, Valid: true})
+	if err != nil {
+		return false, fmt.Errorf("UndoAuditEntry: GetLatestAuditEntryID: %w", err)
+	}
+	if entry.UndoneAt.Valid || latest != entry.ID {
+		return false, fmt.Errorf("UndoAuditEntry: %w", db.ErrNotUndoable)
+	}
+	changed, err := qtx.AuditEntryChangedSince(ctx, entry.ID)
+	if err != nil {
+		return false, fmt.Errorf("UndoAuditEntry: AuditEntryChangedSince: %w", err)
+	}
+	if changed {
+		return false, fmt.Errorf("UndoAuditEntry: %w", db.ErrUndoConflict)
+	}
+
+	l.Info().Msgf("Undoing %s (audit entry %d)", entry.Action, entry.ID)
+	// entities come back first, then what refers to them. Links are added
+	// before the ones the operation made are removed, so that albums are never
+	// left without artists and deleted.
+	steps := []struct {
+		name string
+		fn   func(context.Context, []byte) error
+	}{
+		{"RestoreReleases", qtx.RestoreReleases},
+		{"RestoreArtists", qtx.RestoreArtists},
+		{"RestoreTracks", qtx.RestoreTracks},
+		{"RestoreArtistAliases", qtx.RestoreArtistAliases},
+		{"RestoreReleaseAliases", qtx.RestoreReleaseAliases},
+		{"RestoreTrackAliases", qtx.RestoreTrackAliases},
+		{"RestoreArtistReleases", qtx.RestoreArtistReleases},
+		{"RestoreArtistTracks", qtx.RestoreArtistTracks},
+		{"DeleteUnrecordedArtistReleases", qtx.DeleteUnrecordedArtistReleases},
+		{"DeleteUnrecordedArtistTracks", qtx.DeleteUnrecordedArtistTracks},
+		{"DeleteMovedListens", qtx.DeleteMovedListens},
+		{"RestoreListens", qtx.RestoreListens},
+		{"RestoreListenMatches", qtx.RestoreListenMatches},
+	}
+	for _, step := range steps {
+		if err := step.fn(ctx, entry.Snapshot); err != nil {
+			return false, fmt.Errorf("UndoAuditEntry: %s: %w", step.name, err)
+		}
+	}
+	if err := deleteSplitOff(ctx, qtx, entry); err != nil {
+		return false, fmt.Errorf("UndoAuditEntry: %w", err)
+	}
+	if err := qtx.MarkAuditEntryUndone(ctx, entry.ID); err != nil {
+		return false, fmt.Errorf("UndoAuditEntry: MarkAuditEntryUndone: %w", err)
+	}
+	return true, tx.Commit(ctx)
+}
+
+// deleteSplitOff deletes the artist, album or track a split created, once
+// what was moved to it is back. Listens added to it since are deleted with it.
+func deleteSplitOff(ctx context.Context, qtx *repository.Queries, entry repository.AuditLog) error {
+	var inputs struct {
+		ID    int32 `json:"id"`
+		NewID int32 `json:"new_id"`
+	}
+	if err := json.Unmarshal(entry.Inputs, &inputs); err != nil {
+		return fmt.Errorf("deleteSplitOff: %w", err)
+	}
+	var err error
+	switch models.AuditAction(entry.Action) {
+	case models.AuditSplitArtist:
+		err = qtx.DeleteArtist(ctx, inputs.NewID)
+	case models.AuditSplitAlbum:
+		err = qtx.DeleteRelease(ctx, inputs.NewID)
+	case models.AuditSplitTrack:
+		// rejected listens are not in the snapshot, they are moved back here
+		err = qtx.UpdateTrackIdForRejectedListens(ctx, repository.UpdateTrackIdForRejectedListensParams{
+			TrackID:   inputs.NewID,
+			TrackID_2: inputs.ID,
+		})
+		if err == nil {
+			err = qtx.DeleteTrack(ctx, inputs.NewID)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("deleteSplitOff: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog_UndoMergeArtistsAndDelete(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+
+	require.NoError(t, store.MergeArtists(ctx, 1, 2, true))
+	exists, err := store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artists WHERE id = 1)`)
+	require.NoError(t, err)
+	require.False(t, exists, "expected merged artist to be deleted")
+	require.NoError(t, store.DeleteTrack(ctx, 2))
+
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.AuditDeleteTrack, entries[0].Action)
+	assert.True(t, entries[0].Undoable)
+	assert.Equal(t, models.AuditMergeArtists, entries[1].Action)
+	assert.False(t, entries[1].Undoable)
+	assert.JSONEq(t, `{"from_id": 1, "to_id": 2, "replace_image": true}`, string(entries[1].Inputs))
+	assert.EqualValues(t, 2, entries[1].Recorded.Artists)
+	assert.EqualValues(t, 4, entries[1].Recorded.Listens)
+
+	// the merge cannot be undone before the delete that came after it
+	_, err = store.UndoAuditEntry(ctx, entries[1].ID, 1)
+	require.ErrorIs(t, err, db.ErrNotUndoable)
+
+	found, err := store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.NoError(t, err)
+	require.True(t, found)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = 2`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected listen of deleted track to be restored")
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM track_aliases WHERE track_id = 2 AND alias = 'Track Two'`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected alias of deleted track to be restored")
+
+	found, err = store.UndoAuditEntry(ctx, entries[1].ID, 1)
+	require.NoError(t, err)
+	require.True(t, found)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artist_aliases WHERE artist_id = 1 AND alias = 'Artist One'`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected merged artist to be restored with its aliases")
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artists WHERE image = '10000000-0000-0000-0000-000000000000'`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected replaced image to be restored")
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artist_tracks WHERE artist_id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artist_tracks WHERE artist_id = 2`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artist_releases WHERE artist_id = 2`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// entries are only undone once
+	_, err = store.UndoAuditEntry(ctx, entries[1].ID, 1)
+	require.ErrorIs(t, err, db.ErrNotUndoable)
+
+	truncateTestData(t)
+}
+
+func TestAuditLog_UndoMergeTracks(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+
+	require.NoError(t, store.MergeTracks(ctx, 1, 2))
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	found, err := store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected listen to be moved back")
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = 2`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	exists, err := store.RowExists(ctx, `
+    SELECT EXISTS (
+      SELECT 1 FROM artist_releases
+      WHERE release_id = $1 AND artist_id = $2
+    )`, 2, 1)
+	require.NoError(t, err)
+	assert.False(t, exists, "expected artist link made by the merge to be removed")
+
+	entries, err = store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.NotNil(t, entries[0].UndoneAt)
+	assert.False(t, entries[0].Undoable)
+
+	truncateTestData(t)
+}
+
+func TestAuditLog_UndoDeleteListen(t *testing.T) {
+	ctx := context.Background()
+	testDataForListens(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+
+	listenedAt := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 1, Time: listenedAt, UserID: 1, Client: "Navidrome"}))
+	require.NoError(t, store.DeleteListen(ctx, 1, listenedAt, 1))
+
+	// listen deletes are not shown to other users
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 2})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	entries, err = store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditDeleteListen, entries[0].Action)
+
+	found, err := store.UndoAuditEntry(ctx, entries[0].ID, 2)
+	require.NoError(t, err)
+	assert.False(t, found)
+	found, err = store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	resp, err := store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, Page: 1, UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, listenedAt.Unix(), resp.Items[0].Time.Unix())
+	assert.Equal(t, "Navidrome", resp.Items[0].Client)
+
+	// deleting a listen that does not exist is not recorded
+	require.NoError(t, store.DeleteListen(ctx, 2, listenedAt, 1))
+	entries, err = store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestAuditLog_UndoTrackEdit(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+
+	// track 4 is the only track of album 3, which is deleted once it is moved
+	require.NoError(t, store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 4, AlbumID: 2}))
+	require.NoError(t, store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 3, ArtistIDs: []int32{2}}))
+	// edits of other fields are not recorded
+	require.NoError(t, store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 3, Duration: 100}))
+
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.AuditEditTrack, entries[0].Action)
+	assert.Equal(t, models.AuditEditTrack, entries[1].Action)
+
+	found, err := store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+	exists, err := store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_tracks WHERE artist_id = 1 AND track_id = 3)`)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected the artist credit to be restored")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_tracks WHERE artist_id = 2 AND track_id = 3)`)
+	require.NoError(t, err)
+	assert.False(t, exists, "expected the new artist credit to be removed")
+
+	found, err = store.UndoAuditEntry(ctx, entries[1].ID, 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 4})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, track.AlbumID)
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 3)`)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected the deleted album to be restored with its artists")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 2)`)
+	require.NoError(t, err)
+	assert.False(t, exists, "expected the artist to be taken off the album the track was moved to")
+
+	truncateTestData(t)
+}
+
+func TestAuditLog_UndoConflict(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+
+	require.NoError(t, store.MergeArtists(ctx, 1, 2, true))
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// the image the merge set is replaced without going through the audit log
+	require.NoError(t, store.Exec(ctx, `UPDATE artists SET image = '30000000-0000-0000-0000-000000000000' WHERE id = 2`))
+	_, err = store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.ErrorIs(t, err, db.ErrUndoConflict)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM artists WHERE image = '30000000-0000-0000-0000-000000000000'`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected the newer image to be kept")
+
+	// once it is back as the merge left it, the merge can be undone
+	require.NoError(t, store.Exec(ctx, `UPDATE artists SET image = '10000000-0000-0000-0000-000000000000' WHERE id = 2`))
+	found, err := store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	truncateTestData(t)
+}
+
+func TestAuditLog_UndoIsScopedToUser(t *testing.T) {
+	ctx := context.Background()
+	testDataForListens(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO users (username, password) VALUES ('second_user', DECODE('abc123', 'hex'))`))
+
+	listenedAt := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 1, Time: listenedAt, UserID: 1}))
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 1, Time: listenedAt, UserID: 2}))
+	require.NoError(t, store.DeleteListen(ctx, 1, listenedAt, 1))
+	require.NoError(t, store.DeleteListen(ctx, 1, listenedAt, 2))
+
+	// the other user's later delete does not hold back undoing this one
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Undoable)
+	found, err := store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	truncateTestData(t)
+	require.NoError(t, store.Exec(ctx, `DELETE FROM users WHERE id <> 1`))
+	require.NoError(t, store.Exec(ctx, `ALTER SEQUENCE users_id_seq RESTART WITH 2`))
+}
@@ -10,12 +10,13 @@ import (
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
 )
 
-func (p *Psql) CountListens(ctx context.Context, period db.Period) (int64, error) {
+func (p *Psql) CountListens(ctx context.Context, period db.Period, userId int32) (int64, error) {
 	t2 := time.Now()
 	t1 := db.StartTimeFromPeriod(period)
 	count, err := p.q.CountListens(ctx, repository.CountListensParams{
 		ListenedAt:   t1,
 		ListenedAt_2: t2,
+		UserID:       userId,
 	})
 	if err != nil {
 		return 0, fmt.Errorf("CountListens: %w", err)
@@ -23,12 +24,13 @@ func (p *Psql) CountListens(ctx context.Context, period db.Period) (int64, error
 	return count, nil
 }
 
-func (p *Psql) CountTracks(ctx context.Context, period db.Period) (int64, error) {
+func (p *Psql) CountTracks(ctx context.Context, period db.Period, userId int32) (int64, error) {
 	t2 := time.Now()
 	t1 := db.StartTimeFromPeriod(period)
 	count, err := p.q.CountTopTracks(ctx, repository.CountTopTracksParams{
 		ListenedAt:   t1,
 		ListenedAt_2: t2,
+		UserID:       userId,
 	})
 	if err != nil {
 		return 0, fmt.Errorf("CountTracks: %w", err)
@@ -36,12 +38,13 @@ func (p *Psql) CountTracks(ctx context.Context, period db.Period) (int64, error)
 	return count, nil
 }
 
-func (p *Psql) CountAlbums(ctx context.Context, period db.Period) (int64, error) {
+func (p *Psql) CountAlbums(ctx context.Context, period db.Period, userId int32) (int64, error) {
 	t2 := time.Now()
 	t1 := db.StartTimeFromPeriod(period)
 	count, err := p.q.CountTopReleases(ctx, repository.CountTopReleasesParams{
 		ListenedAt:   t1,
 		ListenedAt_2: t2,
+		UserID:       userId,
 	})
 	if err != nil {
 		return 0, fmt.Errorf("CountAlbums: %w", err)
@@ -49,12 +52,13 @@ func (p *Psql) CountAlbums(ctx context.Context, period db.Period) (int64, error)
 	return count, nil
 }
 
-func (p *Psql) CountArtists(ctx context.Context, period db.Period) (int64, error) {
+func (p *Psql) CountArtists(ctx context.Context, period db.Period, userId int32) (int64, error) {
 	t2 := time.Now()
 	t1 := db.StartTimeFromPeriod(period)
 	count, err := p.q.CountTopArtists(ctx, repository.CountTopArtistsParams{
 		ListenedAt:   t1,
 		ListenedAt_2: t2,
+		UserID:       userId,
 	})
 	if err != nil {
 		return 0, fmt.Errorf("CountArtists: %w", err)
@@ -62,12 +66,13 @@ func (p *Psql) CountArtists(ctx context.Context, period db.Period) (int64, error
 	return count, nil
 }
 
-func (p *Psql) CountTimeListened(ctx context.Context, period db.Period) (int64, error) {
+func (p *Psql) CountTimeListened(ctx context.Context, period db.Period, userId int32) (int64, error) {
 	t2 := time.Now()
 	t1 := db.StartTimeFromPeriod(period)
 	count, err := p.q.CountTimeListened(ctx, repository.CountTimeListenedParams{
 		ListenedAt:   t1,
 		ListenedAt_2: t2,
+		UserID:       userId,
 	})
 	if err != nil {
 		return 0, fmt.Errorf("CountTimeListened: %w", err)
@@ -84,6 +89,7 @@ func (p *Psql) CountTimeListenedToItem(ctx context.Context, opts db.TimeListened
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
 			ArtistID:     opts.ArtistID,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return 0, fmt.Errorf("CountTimeListenedToItem (Artist): %w", err)
@@ -94,6 +100,7 @@ func (p *Psql) CountTimeListenedToItem(ctx context.Context, opts db.TimeListened
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
 			ReleaseID:    opts.AlbumID,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return 0, fmt.Errorf("CountTimeListenedToItem (Album): %w", err)
@@ -104,6 +111,7 @@ func (p *Psql) CountTimeListenedToItem(ctx context.Context, opts db.TimeListened
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
 			ID:           opts.TrackID,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return 0, fmt.Errorf("CountTimeListenedToItem (Track): %w", err)
@@ -15,7 +15,7 @@ func TestCountListens(t *testing.T) {
 
 	// Test CountListens
 	period := db.PeriodWeek
-	count, err := store.CountListens(ctx, period)
+	count, err := store.CountListens(ctx, period, 1)
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), count, "expected listens count to match inserted data")
 
@@ -28,7 +28,7 @@ func TestCountTracks(t *testing.T) {
 
 	// Test CountTracks
 	period := db.PeriodMonth
-	count, err := store.CountTracks(ctx, period)
+	count, err := store.CountTracks(ctx, period, 1)
 	require.NoError(t, err)
 	assert.Equal(t, int64(2), count, "expected tracks count to match inserted data")
 
@@ -41,7 +41,7 @@ func TestCountAlbums(t *testing.T) {
 
 	// Test CountAlbums
 	period := db.PeriodYear
-	count, err := store.CountAlbums(ctx, period)
+	count, err := store.CountAlbums(ctx, period, 1)
 	require.NoError(t, err)
 	assert.Equal(t, int64(3), count, "expected albums count to match inserted data")
 
@@ -54,7 +54,7 @@ func TestCountArtists(t *testing.T) {
 
 	// Test CountArtists
 	period := db.PeriodAllTime
-	count, err := store.CountArtists(ctx, period)
+	count, err := store.CountArtists(ctx, period, 1)
 	require.NoError(t, err)
 	assert.Equal(t, int64(4), count, "expected artists count to match inserted data")
 
@@ -67,7 +67,7 @@ func TestCountTimeListened(t *testing.T) {
 
 	// Test CountTimeListened
 	period := db.PeriodMonth
-	count, err := store.CountTimeListened(ctx, period)
+	count, err := store.CountTimeListened(ctx, period, 1)
 	require.NoError(t, err)
 	// 3 listens in past month, each 100 seconds
 	assert.Equal(t, int64(300), count, "expected total time listened to match inserted data")
@@ -79,7 +79,7 @@ func TestCountTimeListenedToArtist(t *testing.T) {
 	ctx := context.Background()
 	testDataForTopItems(t)
 	period := db.PeriodAllTime
-	count, err := store.CountTimeListenedToItem(ctx, db.TimeListenedOpts{Period: period, ArtistID: 1})
+	count, err := store.CountTimeListenedToItem(ctx, db.TimeListenedOpts{Period: period, ArtistID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.EqualValues(t, 400, count)
 	truncateTestData(t)
@@ -89,7 +89,7 @@ func TestCountTimeListenedToAlbum(t *testing.T) {
 	ctx := context.Background()
 	testDataForTopItems(t)
 	period := db.PeriodAllTime
-	count, err := store.CountTimeListenedToItem(ctx, db.TimeListenedOpts{Period: period, AlbumID: 2})
+	count, err := store.CountTimeListenedToItem(ctx, db.TimeListenedOpts{Period: period, AlbumID: 2, UserID: 1})
 	require.NoError(t, err)
 	assert.EqualValues(t, 300, count)
 	truncateTestData(t)
@@ -99,8 +99,45 @@ func TestCountTimeListenedToTrack(t *testing.T) {
 	ctx := context.Background()
 	testDataForTopItems(t)
 	period := db.PeriodAllTime
-	count, err := store.CountTimeListenedToItem(ctx, db.TimeListenedOpts{Period: period, TrackID: 3})
+	count, err := store.CountTimeListenedToItem(ctx, db.TimeListenedOpts{Period: period, TrackID: 3, UserID: 1})
 	require.NoError(t, err)
 	assert.EqualValues(t, 200, count)
 	truncateTestData(t)
 }
+
+func TestCountsAreScopedToUser(t *testing.T) {
+	ctx := context.Background()
+	testDataForTopItems(t)
+
+	err := store.Exec(ctx,
+		`INSERT INTO users (username, password) VALUES ('second_user', DECODE('abc123', 'hex'))`)
+	require.NoError(t, err)
+	// the same track at the same instant as one of user 1's listens must not collide
+	err = store.Exec(ctx,
+		`INSERT INTO listens (user_id, track_id, listened_at)
+			SELECT 2, track_id, listened_at FROM listens WHERE track_id = 4`)
+	require.NoError(t, err)
+
+	count, err := store.CountListens(ctx, db.PeriodAllTime, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, count)
+
+	count, err = store.CountListens(ctx, db.PeriodAllTime, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	count, err = store.CountArtists(ctx, db.PeriodAllTime, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	resp, err := store.GetTopTracksPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, UserID: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 1)
+	assert.EqualValues(t, 4, resp.Items[0].ID)
+
+	truncateTestData(t)
+	err = store.Exec(ctx, `DELETE FROM users WHERE id <> 1`)
+	require.NoError(t, err)
+	err = store.Exec(ctx, `ALTER SEQUENCE users_id_seq RESTART WITH 2`)
+	require.NoError(t, err)
+}
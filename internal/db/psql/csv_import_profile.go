@@ -0,0 +1,99 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+func csvImportProfileFromRow(row repository.CsvImportProfile) *models.CsvImportProfile {
+	return &models.CsvImportProfile{
+		ID:              row.ID,
+		Name:            row.Name,
+		Delimiter:       row.Delimiter,
+		ArtistColumn:    row.ArtistColumn,
+		TitleColumn:     row.TitleColumn,
+		AlbumColumn:     row.AlbumColumn,
+		TimestampColumn: row.TimestampColumn,
+		TimestampFormat: row.TimestampFormat,
+		Timezone:        row.Timezone,
+		DurationColumn:  row.DurationColumn,
+		DurationUnit:    models.CsvDurationUnit(row.DurationUnit),
+		MbzIDColumn:     row.MbidColumn,
+		FilterColumn:    row.FilterColumn,
+		FilterValue:     row.FilterValue,
+		PlayedColumn:    row.PlayedColumn,
+		CreatedAt:       row.CreatedAt,
+		UpdatedAt:       row.UpdatedAt,
+	}
+}
+
+// GetCsvImportProfile returns nil, nil when the user has no profile with the
+// name.
+func (d *Psql) GetCsvImportProfile(ctx context.Context, userId int32, name string) (*models.CsvImportProfile, error) {
+	row, err := d.q.GetCsvImportProfile(ctx, repository.GetCsvImportProfileParams{
+		UserID: userId,
+		Name:   name,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("GetCsvImportProfile: %w", err)
+	}
+	return csvImportProfileFromRow(row), nil
+}
+
+func (d *Psql) GetCsvImportProfilesByUserID(ctx context.Context, userId int32) ([]*models.CsvImportProfile, error) {
+	rows, err := d.q.GetCsvImportProfilesByUserID(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("GetCsvImportProfilesByUserID: %w", err)
+	}
+	profiles := make([]*models.CsvImportProfile, len(rows))
+	for i, row := range rows {
+		profiles[i] = csvImportProfileFromRow(row)
+	}
+	return profiles, nil
+}
+
+// SaveCsvImportProfile creates the profile, or replaces the user's profile
+// with the same name.
+func (d *Psql) SaveCsvImportProfile(ctx context.Context, userId int32, p *models.CsvImportProfile) (*models.CsvImportProfile, error) {
+	row, err := d.q.SaveCsvImportProfile(ctx, repository.SaveCsvImportProfileParams{
+		UserID:          userId,
+		Name:            p.Name,
+		Delimiter:       p.Delimiter,
+		ArtistColumn:    p.ArtistColumn,
+		TitleColumn:     p.TitleColumn,
+		AlbumColumn:     p.AlbumColumn,
+		TimestampColumn: p.TimestampColumn,
+		TimestampFormat: p.TimestampFormat,
+		Timezone:        p.Timezone,
+		DurationColumn:  p.DurationColumn,
+		DurationUnit:    string(p.DurationUnit),
+		MbidColumn:      p.MbzIDColumn,
+		FilterColumn:    p.FilterColumn,
+		FilterValue:     p.FilterValue,
+		PlayedColumn:    p.PlayedColumn,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SaveCsvImportProfile: %w", err)
+	}
+	return csvImportProfileFromRow(row), nil
+}
+
+// DeleteCsvImportProfile returns false when the user has no profile with the
+// name.
+func (d *Psql) DeleteCsvImportProfile(ctx context.Context, userId int32, name string) (bool, error) {
+	n, err := d.q.DeleteCsvImportProfile(ctx, repository.DeleteCsvImportProfileParams{
+		UserID: userId,
+		Name:   name,
+	})
+	if err != nil {
+		return false, fmt.Errorf("DeleteCsvImportProfile: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,81 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func truncateTestDataForCsvImportProfiles(t *testing.T) {
+	err := store.Exec(context.Background(),
+		`TRUNCATE csv_import_profiles RESTART IDENTITY`,
+	)
+	require.NoError(t, err)
+}
+
+func TestCsvImportProfiles(t *testing.T) {
+	ctx := context.Background()
+	truncateTestDataForCsvImportProfiles(t)
+	defer truncateTestDataForCsvImportProfiles(t)
+
+	profile := &models.CsvImportProfile{
+		Name:            "notebook",
+		Delimiter:       ";",
+		ArtistColumn:    "artist",
+		TitleColumn:     "song",
+		TimestampColumn: "played",
+		TimestampFormat: "02/01/2006 15:04",
+		Timezone:        "Europe/Berlin",
+		DurationUnit:    models.CsvDurationSeconds,
+	}
+	saved, err := store.SaveCsvImportProfile(ctx, 1, profile)
+	require.NoError(t, err)
+	assert.NotZero(t, saved.ID)
+	assert.Equal(t, "Europe/Berlin", saved.Timezone)
+	assert.Empty(t, saved.AlbumColumn)
+
+	// saving a profile with the same name replaces it
+	profile.AlbumColumn = "album"
+	profile.PlayedColumn = "seconds played"
+	replaced, err := store.SaveCsvImportProfile(ctx, 1, profile)
+	require.NoError(t, err)
+	assert.Equal(t, saved.ID, replaced.ID)
+	assert.Equal(t, "album", replaced.AlbumColumn)
+	assert.Equal(t, "seconds played", replaced.PlayedColumn)
+
+	_, err = store.SaveCsvImportProfile(ctx, 1, &models.CsvImportProfile{
+		Name:            "another",
+		Delimiter:       ",",
+		ArtistColumn:    "a",
+		TitleColumn:     "t",
+		TimestampColumn: "ts",
+		TimestampFormat: models.CsvTimestampUnix,
+		Timezone:        "UTC",
+		DurationUnit:    models.CsvDurationMilliseconds,
+	})
+	require.NoError(t, err)
+
+	profiles, err := store.GetCsvImportProfilesByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "another", profiles[0].Name)
+	assert.Equal(t, "notebook", profiles[1].Name)
+
+	got, err := store.GetCsvImportProfile(ctx, 1, "notebook")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, ";", got.Delimiter)
+
+	ok, err := store.DeleteCsvImportProfile(ctx, 1, "notebook")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.DeleteCsvImportProfile(ctx, 1, "notebook")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	got, err = store.GetCsvImportProfile(ctx, 1, "notebook")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,156 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// how many artists, albums or tracks the enrichment queries return at once
+const enrichmentPageSize = 20
+
+// artists, albums and tracks a task was tried on without filling anything in
+// are left out of its sweeps for this long
+const enrichmentRetryAfter = 30 * 24 * time.Hour
+
+func (d *Psql) GetEnrichmentProgress(ctx context.Context) ([]*models.EnrichmentProgress, error) {
+	rows, err := d.q.GetEnrichmentProgress(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetEnrichmentProgress: %w", err)
+	}
+	ret := make([]*models.EnrichmentProgress, len(rows))
+	for i, row := range rows {
+		ret[i] = &models.EnrichmentProgress{
+			Task:      models.EnrichmentTask(row.Task),
+			LastID:    row.LastID,
+			Processed: int(row.Processed),
+			Enriched:  int(row.Enriched),
+			StartedAt: row.StartedAt,
+			UpdatedAt: row.UpdatedAt,
+		}
+		if row.FinishedAt.Valid {
+			t := row.FinishedAt.Time
+			ret[i].FinishedAt = &t
+		}
+	}
+	return ret, nil
+}
+
+func (d *Psql) SaveEnrichmentProgress(ctx context.Context, p *models.EnrichmentProgress) error {
+	var finished pgtype.Timestamptz
+	if p.FinishedAt != nil {
+		finished = pgtype.Timestamptz{Time: *p.FinishedAt, Valid: true}
+	}
+	err := d.q.SaveEnrichmentProgress(ctx, repository.SaveEnrichmentProgressParams{
+		Task:       string(p.Task),
+		LastID:     p.LastID,
+		Processed:  int32(p.Processed),
+		Enriched:   int32(p.Enriched),
+		StartedAt:  p.StartedAt,
+		FinishedAt: finished,
+	})
+	if err != nil {
+		return fmt.Errorf("SaveEnrichmentProgress: %w", err)
+	}
+	return nil
+}
+
+// SaveEnrichmentAttempt records that the task was tried on the artist, album
+// or track with the id without filling anything in.
+func (d *Psql) SaveEnrichmentAttempt(ctx context.Context, task models.EnrichmentTask, id int32) error {
+	err := d.q.SaveEnrichmentAttempt(ctx, repository.SaveEnrichmentAttemptParams{
+		Task:     string(task),
+		EntityID: id,
+	})
+	if err != nil {
+		return fmt.Errorf("SaveEnrichmentAttempt: %w", err)
+	}
+	return nil
+}
+
+// TracksWithoutDuration returns the next tracks after from that have no
+// duration, and either have a MusicBrainz ID or were never looked up with a
+// MusicBrainz search. Tracks that were tried recently are left out.
+func (d *Psql) TracksWithoutDuration(ctx context.Context, from int32) ([]int32, error) {
+	ids, err := d.q.GetTracksWithoutDuration(ctx, repository.GetTracksWithoutDurationParams{
+		ID:          from,
+		Limit:       enrichmentPageSize,
+		AttemptedAt: time.Now().Add(-enrichmentRetryAfter),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("TracksWithoutDuration: %w", err)
+	}
+	return ids, nil
+}
+
+func (d *Psql) ArtistsWithoutMbzID(ctx context.Context, from int32) ([]db.ArtistWithoutMbzID, error) {
+	rows, err := d.q.GetArtistsWithoutMbzID(ctx, repository.GetArtistsWithoutMbzIDParams{
+		ID:    from,
+		Limit: enrichmentPageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ArtistsWithoutMbzID: %w", err)
+	}
+	ret := make([]db.ArtistWithoutMbzID, len(rows))
+	for i, row := range rows {
+		ret[i] = db.ArtistWithoutMbzID{ArtistID: row.ID, TrackID: row.TrackID}
+	}
+	return ret, nil
+}
+
+// ArtistsWithoutGenres returns the next artists after from that have a
+// MusicBrainz ID but no genres, leaving out those that were tried recently.
+func (d *Psql) ArtistsWithoutGenres(ctx context.Context, from int32) ([]*models.Artist, error) {
+	rows, err := d.q.GetArtistsWithoutGenres(ctx, repository.GetArtistsWithoutGenresParams{
+		ID:          from,
+		Limit:       enrichmentPageSize,
+		AttemptedAt: time.Now().Add(-enrichmentRetryAfter),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ArtistsWithoutGenres: %w", err)
+	}
+	ret := make([]*models.Artist, len(rows))
+	for i, row := range rows {
+		ret[i] = &models.Artist{ID: row.ID, MbzID: row.MusicBrainzID, Name: row.Name}
+	}
+	return ret, nil
+}
+
+// AlbumsWithoutGenres returns the next albums after from that have a
+// MusicBrainz ID but no genres, leaving out those that were tried recently.
+func (d *Psql) AlbumsWithoutGenres(ctx context.Context, from int32) ([]*models.Album, error) {
+	rows, err := d.q.GetReleasesWithoutGenres(ctx, repository.GetReleasesWithoutGenresParams{
+		ID:          from,
+		Limit:       enrichmentPageSize,
+		AttemptedAt: time.Now().Add(-enrichmentRetryAfter),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("AlbumsWithoutGenres: %w", err)
+	}
+	ret := make([]*models.Album, len(rows))
+	for i, row := range rows {
+		ret[i] = &models.Album{ID: row.ID, MbzID: row.MusicBrainzID, Title: row.Title}
+	}
+	return ret, nil
+}
+
+func (d *Psql) UpdateArtistGenres(ctx context.Context, id int32, genres []string) error {
+	err := d.q.UpdateArtistGenres(ctx, repository.UpdateArtistGenresParams{ID: id, Genres: genres})
+	if err != nil {
+		return fmt.Errorf("UpdateArtistGenres: %w", err)
+	}
+	return nil
+}
+
+func (d *Psql) UpdateAlbumGenres(ctx context.Context, id int32, genres []string) error {
+	err := d.q.UpdateReleaseGenres(ctx, repository.UpdateReleaseGenresParams{ID: id, Genres: genres})
+	if err != nil {
+		return fmt.Errorf("UpdateAlbumGenres: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestDataForEnrichment(t *testing.T) {
+	setupTestDataForImages(t)
+	ctx := context.Background()
+
+	err := store.Exec(ctx, `INSERT INTO artists (musicbrainz_id) VALUES (NULL)`)
+	require.NoError(t, err)
+	err = store.Exec(ctx,
+		`INSERT INTO artist_aliases (artist_id, alias, source, is_primary) VALUES (3, 'Artist Three', 'Testing', true)`)
+	require.NoError(t, err)
+
+	err = store.Exec(ctx,
+		`INSERT INTO tracks (musicbrainz_id, release_id, duration)
+			VALUES ('55555555-5555-5555-5555-555555555555', 1, 0),
+				   (NULL, 2, 0),
+				   (NULL, 2, 100)`)
+	require.NoError(t, err)
+	err = store.Exec(ctx,
+		`INSERT INTO track_aliases (track_id, alias, source, is_primary)
+			VALUES (1, 'Track One', 'Testing', true),
+				   (2, 'Track Two', 'Testing', true),
+				   (3, 'Track Three', 'Testing', true)`)
+	require.NoError(t, err)
+	err = store.Exec(ctx,
+		`INSERT INTO artist_tracks (artist_id, track_id) VALUES (1, 1), (3, 1), (2, 2), (3, 2), (2, 3)`)
+	require.NoError(t, err)
+}
+
+func TestEnrichmentQueries(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForEnrichment(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE enrichment_attempts`))
+	defer truncateTestData(t)
+
+	ids, err := store.TracksWithoutDuration(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, 2}, ids)
+	ids, err = store.TracksWithoutDuration(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{2}, ids)
+	// tracks without an MBID are left out once searched for
+	require.NoError(t, store.SaveMbzSearchAttempt(ctx, 2, 0.5))
+	ids, err = store.TracksWithoutDuration(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1}, ids)
+
+	artists, err := store.ArtistsWithoutMbzID(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, artists, 1)
+	assert.Equal(t, db.ArtistWithoutMbzID{ArtistID: 3, TrackID: 1}, artists[0], "expected the track with an MBID to be picked")
+
+	withoutGenres, err := store.ArtistsWithoutGenres(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, withoutGenres, 2, "expected artists without an MBID to be left out")
+	require.NoError(t, store.UpdateArtistGenres(ctx, 1, []string{"j-pop", "rock"}))
+	withoutGenres, err = store.ArtistsWithoutGenres(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, withoutGenres, 1)
+	assert.Equal(t, "Artist Two", withoutGenres[0].Name)
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"j-pop", "rock"}, artist.Genres)
+
+	albums, err := store.AlbumsWithoutGenres(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, albums, 1)
+	assert.Equal(t, "Album Two", albums[0].Title)
+	require.NoError(t, store.UpdateAlbumGenres(ctx, 2, []string{"pop"}))
+	albums, err = store.AlbumsWithoutGenres(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, albums, 1)
+	assert.Equal(t, "Album One", albums[0].Title)
+}
+
+func TestEnrichmentAttempts(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForEnrichment(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE enrichment_attempts`))
+	defer store.Exec(ctx, `TRUNCATE enrichment_attempts`)
+	defer truncateTestData(t)
+
+	require.NoError(t, store.SaveEnrichmentAttempt(ctx, models.EnrichmentTrackDurations, 1))
+	require.NoError(t, store.SaveEnrichmentAttempt(ctx, models.EnrichmentArtistGenres, 1))
+	require.NoError(t, store.SaveEnrichmentAttempt(ctx, models.EnrichmentAlbumGenres, 2))
+	// trying again is not an error
+	require.NoError(t, store.SaveEnrichmentAttempt(ctx, models.EnrichmentAlbumGenres, 2))
+
+	ids, err := store.TracksWithoutDuration(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{2}, ids)
+	artists, err := store.ArtistsWithoutGenres(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, artists, 1)
+	assert.Equal(t, "Artist Two", artists[0].Name)
+	albums, err := store.AlbumsWithoutGenres(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, albums, 1)
+	assert.Equal(t, "Album One", albums[0].Title)
+
+	// attempts of one task do not hold back the others
+	require.NoError(t, store.SaveEnrichmentAttempt(ctx, models.EnrichmentAlbumGenres, 1))
+	artists, err = store.ArtistsWithoutGenres(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, artists, 1)
+
+	// they are tried again once the attempt is old enough
+	require.NoError(t, store.Exec(ctx, `UPDATE enrichment_attempts SET attempted_at = now() - INTERVAL '31 days'`))
+	ids, err = store.TracksWithoutDuration(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, 2}, ids)
+	albums, err = store.AlbumsWithoutGenres(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, albums, 2)
+}
+
+func TestEnrichmentProgress(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, store.Exec(ctx, `TRUNCATE enrichment_progress`))
+	defer store.Exec(ctx, `TRUNCATE enrichment_progress`)
+
+	progress, err := store.GetEnrichmentProgress(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, progress)
+
+	started := time.Now().Truncate(time.Microsecond)
+	p := &models.EnrichmentProgress{
+		Task:      models.EnrichmentAlbumImages,
+		LastID:    4,
+		Processed: 4,
+		Enriched:  1,
+		StartedAt: started,
+	}
+	require.NoError(t, store.SaveEnrichmentProgress(ctx, p))
+	progress, err = store.GetEnrichmentProgress(ctx)
+	require.NoError(t, err)
+	require.Len(t, progress, 1)
+	assert.Equal(t, int32(4), progress[0].LastID)
+	assert.Nil(t, progress[0].FinishedAt)
+
+	finished := started.Add(time.Minute)
+	p.LastID = 9
+	p.Processed = 6
+	p.FinishedAt = &finished
+	require.NoError(t, store.SaveEnrichmentProgress(ctx, p))
+	progress, err = store.GetEnrichmentProgress(ctx)
+	require.NoError(t, err)
+	require.Len(t, progress, 1)
+	assert.Equal(t, models.EnrichmentAlbumImages, progress[0].Task)
+	assert.Equal(t, int32(9), progress[0].LastID)
+	assert.Equal(t, 6, progress[0].Processed)
+	assert.Equal(t, 1, progress[0].Enriched)
+	assert.True(t, started.Equal(progress[0].StartedAt))
+	require.NotNil(t, progress[0].FinishedAt)
+	assert.True(t, finished.Equal(*progress[0].FinishedAt))
+}
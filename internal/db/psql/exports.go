@@ -40,19 +40,24 @@ func (d *Psql) GetExportPage(ctx context.Context, opts db.GetExportPageOpts) ([]
 		}
 
 		ret[i] = &db.ExportItem{
-			TrackID:            row.TrackID,
-			ListenedAt:         row.ListenedAt,
-			UserID:             row.UserID,
-			Client:             row.Client,
-			TrackMbid:          row.TrackMbid,
-			TrackDuration:      row.TrackDuration,
-			TrackAliases:       trackAliases,
-			ReleaseID:          row.ReleaseID,
-			ReleaseMbid:        row.ReleaseMbid,
-			ReleaseImageSource: row.ReleaseImageSource.String,
-			VariousArtists:     row.VariousArtists,
-			ReleaseAliases:     albumAliases,
-			Artists:            artists,
+			TrackID:                 row.TrackID,
+			ListenedAt:              row.ListenedAt,
+			UserID:                  row.UserID,
+			Client:                  row.Client,
+			DurationMs:              row.DurationMs.Int32,
+			MediaPlayer:             row.MediaPlayer.String,
+			SubmissionClientVersion: row.SubmissionClientVersion.String,
+			Tags:                    row.Tags,
+			OriginURL:               row.OriginUrl.String,
+			TrackMbid:               row.TrackMbid,
+			TrackDuration:           row.TrackDuration,
+			TrackAliases:            trackAliases,
+			ReleaseID:               row.ReleaseID,
+			ReleaseMbid:             row.ReleaseMbid,
+			ReleaseImageSource:      row.ReleaseImageSource.String,
+			VariousArtists:          row.VariousArtists,
+			ReleaseAliases:          albumAliases,
+			Artists:                 artists,
 		}
 	}
 	return ret, nil
@@ -0,0 +1,175 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func importJobFromRow(row repository.ImportJob) (*models.ImportJob, error) {
+	j := &models.ImportJob{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		Format:    models.ImportFormat(row.Format),
+		Filename:  row.Filename,
+		Status:    models.ImportJobStatus(row.Status),
+		Size:      row.Size,
+		BytesRead: row.BytesRead,
+		Offset:    int(row.ItemOffset),
+		Imported:  int(row.Imported),
+		Skipped:   int(row.Skipped),
+		Failed:    int(row.Failed),
+		Errors:    row.Errors,
+		Error:     row.Error,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Options, &j.Options); err != nil {
+		return nil, fmt.Errorf("importJobFromRow: json.Unmarshal: %w", err)
+	}
+	if j.Errors == nil {
+		j.Errors = []string{}
+	}
+	if row.StartedAt.Valid {
+		t := row.StartedAt.Time
+		j.StartedAt = &t
+	}
+	if row.FinishedAt.Valid {
+		t := row.FinishedAt.Time
+		j.FinishedAt = &t
+	}
+	return j, nil
+}
+
+func (d *Psql) CreateImportJob(ctx context.Context, opts db.CreateImportJobOpts) (*models.ImportJob, error) {
+	options, err := json.Marshal(opts.Options)
+	if err != nil {
+		return nil, fmt.Errorf("CreateImportJob: json.Marshal: %w", err)
+	}
+	row, err := d.q.CreateImportJob(ctx, repository.CreateImportJobParams{
+		UserID:   opts.UserID,
+		Format:   string(opts.Format),
+		Filename: opts.Filename,
+		Options:  options,
+		Size:     opts.Size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CreateImportJob: %w", err)
+	}
+	j, err := importJobFromRow(row)
+	if err != nil {
+		return nil, fmt.Errorf("CreateImportJob: %w", err)
+	}
+	return j, nil
+}
+
+// GetImportJob returns nil, nil when there is no job with the id.
+func (d *Psql) GetImportJob(ctx context.Context, id int64) (*models.ImportJob, error) {
+	row, err := d.q.GetImportJob(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("GetImportJob: %w", err)
+	}
+	j, err := importJobFromRow(row)
+	if err != nil {
+		return nil, fmt.Errorf("GetImportJob: %w", err)
+	}
+	return j, nil
+}
+
+// GetImportJobsByUserID returns the user's latest jobs, newest first.
+func (d *Psql) GetImportJobsByUserID(ctx context.Context, userId int32, limit int) ([]*models.ImportJob, error) {
+	rows, err := d.q.GetImportJobsByUserID(ctx, repository.GetImportJobsByUserIDParams{
+		UserID: userId,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetImportJobsByUserID: %w", err)
+	}
+	jobs := make([]*models.ImportJob, len(rows))
+	for i, row := range rows {
+		jobs[i], err = importJobFromRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("GetImportJobsByUserID: %w", err)
+		}
+	}
+	return jobs, nil
+}
+
+// GetNextImportJob returns the job to run next: the oldest that was running
+// when the server stopped, else the oldest queued. Returns nil, nil when
+// there is none.
+func (d *Psql) GetNextImportJob(ctx context.Context) (*models.ImportJob, error) {
+	row, err := d.q.GetNextImportJob(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("GetNextImportJob: %w", err)
+	}
+	j, err := importJobFromRow(row)
+	if err != nil {
+		return nil, fmt.Errorf("GetNextImportJob: %w", err)
+	}
+	return j, nil
+}
+
+// StartImportJob marks the job as running, returning false when it was
+// canceled or has finished.
+func (d *Psql) StartImportJob(ctx context.Context, id int64) (bool, error) {
+	n, err := d.q.StartImportJob(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("StartImportJob: %w", err)
+	}
+	return n > 0, nil
+}
+
+// SaveImportJobProgress saves the progress of a running job, along with its
+// status and finish time. The status is kept when the job was canceled in
+// the meantime. Returns the status the job has after saving.
+func (d *Psql) SaveImportJobProgress(ctx context.Context, j *models.ImportJob) (models.ImportJobStatus, error) {
+	var finished pgtype.Timestamptz
+	if j.FinishedAt != nil {
+		finished = pgtype.Timestamptz{Time: *j.FinishedAt, Valid: true}
+	}
+	errs := j.Errors
+	if errs == nil {
+		errs = []string{}
+	}
+	status, err := d.q.SaveImportJobProgress(ctx, repository.SaveImportJobProgressParams{
+		ID:         j.ID,
+		BytesRead:  j.BytesRead,
+		ItemOffset: int32(j.Offset),
+		Imported:   int32(j.Imported),
+		Skipped:    int32(j.Skipped),
+		Failed:     int32(j.Failed),
+		Errors:     errs,
+		Error:      j.Error,
+		Status:     string(j.Status),
+		FinishedAt: finished,
+	})
+	if err != nil {
+		return "", fmt.Errorf("SaveImportJobProgress: %w", err)
+	}
+	return models.ImportJobStatus(status), nil
+}
+
+// CancelImportJob returns false when the user has no job with the id that is
+// queued or running.
+func (d *Psql) CancelImportJob(ctx context.Context, userId int32, id int64) (bool, error) {
+	n, err := d.q.CancelImportJob(ctx, repository.CancelImportJobParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if err != nil {
+		return false, fmt.Errorf("CancelImportJob: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,111 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func truncateTestDataForImportJobs(t *testing.T) {
+	err := store.Exec(context.Background(),
+		`TRUNCATE import_jobs RESTART IDENTITY`,
+	)
+	require.NoError(t, err)
+}
+
+func TestImportJobs(t *testing.T) {
+	ctx := context.Background()
+	truncateTestDataForImportJobs(t)
+	defer truncateTestDataForImportJobs(t)
+
+	first, err := store.CreateImportJob(ctx, db.CreateImportJobOpts{
+		UserID:   1,
+		Format:   models.ImportCsv,
+		Filename: "plays.csv",
+		Options:  models.ImportJobOptions{CsvProfile: "apple-music"},
+		Size:     1000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportJobQueued, first.Status)
+	assert.Equal(t, "apple-music", first.Options.CsvProfile)
+	assert.Equal(t, []string{}, first.Errors)
+	assert.Nil(t, first.StartedAt)
+	second, err := store.CreateImportJob(ctx, db.CreateImportJobOpts{
+		UserID:   1,
+		Format:   models.ImportSpotify,
+		Filename: "Streaming_History_Audio_2024.json",
+		Size:     2000,
+	})
+	require.NoError(t, err)
+
+	next, err := store.GetNextImportJob(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, first.ID, next.ID)
+
+	// a job that was running is resumed before older queued ones
+	ok, err := store.StartImportJob(ctx, second.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	next, err = store.GetNextImportJob(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, next.ID)
+	assert.NotNil(t, next.StartedAt)
+
+	next.BytesRead = 500
+	next.Offset = 10
+	next.Imported = 8
+	next.Skipped = 1
+	next.Failed = 1
+	next.Errors = []string{"item 4: invalid import item"}
+	status, err := store.SaveImportJobProgress(ctx, next)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportJobRunning, status)
+
+	got, err := store.GetImportJob(ctx, second.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.EqualValues(t, 500, got.BytesRead)
+	assert.Equal(t, 10, got.Offset)
+	assert.Equal(t, 8, got.Imported)
+	assert.Equal(t, []string{"item 4: invalid import item"}, got.Errors)
+
+	// a canceled job keeps its status when its progress is saved
+	ok, err = store.CancelImportJob(ctx, 1, second.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.CancelImportJob(ctx, 1, second.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	now := time.Now()
+	next.Offset = 12
+	next.Status = models.ImportJobSucceeded
+	next.FinishedAt = &now
+	status, err = store.SaveImportJobProgress(ctx, next)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportJobCanceled, status)
+	ok, err = store.StartImportJob(ctx, second.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	got, err = store.GetImportJob(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportJobCanceled, got.Status)
+	assert.Equal(t, 12, got.Offset)
+	assert.NotNil(t, got.FinishedAt)
+
+	jobs, err := store.GetImportJobsByUserID(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, second.ID, jobs[0].ID)
+	assert.Equal(t, first.ID, jobs[1].ID)
+
+	got, err = store.GetImportJob(ctx, 999)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
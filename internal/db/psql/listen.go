@@ -12,6 +12,8 @@ import (
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*db.PaginatedResponse[*models.Listen], error) {
@@ -49,6 +51,7 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 			Limit:        int32(opts.Limit),
 			Offset:       int32(offset),
 			ID:           int32(opts.TrackID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetListensPaginated: GetLastListensFromTrackPaginated: %w", err)
@@ -63,7 +66,16 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 					AlbumID: row.ReleaseID,
 					Album:   &row.ReleaseTitle,
 				},
-				Time: row.ListenedAt,
+				Time:                    row.ListenedAt,
+				DurationMs:              row.DurationMs.Int32,
+				MediaPlayer:             row.MediaPlayer.String,
+				SubmissionClientVersion: row.SubmissionClientVersion.String,
+				Tags:                    row.Tags,
+				OriginURL:               row.OriginUrl.String,
+				Overlap:                 row.Overlap,
+			}
+			if row.Client != nil {
+				t.Client = *row.Client
 			}
 			err = json.Unmarshal(row.Artists, &t.Track.Artists)
 			if err != nil {
@@ -75,6 +87,7 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
 			TrackID:      int32(opts.TrackID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetListensPaginated: CountListensFromTrack: %w", err)
@@ -88,6 +101,7 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 			Limit:        int32(opts.Limit),
 			Offset:       int32(offset),
 			ReleaseID:    int32(opts.AlbumID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetListensPaginated: GetLastListensFromReleasePaginated: %w", err)
@@ -102,7 +116,16 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 					AlbumID: row.ReleaseID,
 					Album:   &row.ReleaseTitle,
 				},
-				Time: row.ListenedAt,
+				Time:                    row.ListenedAt,
+				DurationMs:              row.DurationMs.Int32,
+				MediaPlayer:             row.MediaPlayer.String,
+				SubmissionClientVersion: row.SubmissionClientVersion.String,
+				Tags:                    row.Tags,
+				OriginURL:               row.OriginUrl.String,
+				Overlap:                 row.Overlap,
+			}
+			if row.Client != nil {
+				t.Client = *row.Client
 			}
 			err = json.Unmarshal(row.Artists, &t.Track.Artists)
 			if err != nil {
@@ -114,6 +137,7 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
 			ReleaseID:    int32(opts.AlbumID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetListensPaginated: CountListensFromRelease: %w", err)
@@ -127,6 +151,7 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 			Limit:        int32(opts.Limit),
 			Offset:       int32(offset),
 			ArtistID:     int32(opts.ArtistID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetListensPaginated: GetLastListensFromArtistPaginated: %w", err)
@@ -141,7 +166,16 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 					AlbumID: row.ReleaseID,
 					Album:   &row.ReleaseTitle,
 				},
-				Time: row.ListenedAt,
+				Time:                    row.ListenedAt,
+				DurationMs:              row.DurationMs.Int32,
+				MediaPlayer:             row.MediaPlayer.String,
+				SubmissionClientVersion: row.SubmissionClientVersion.String,
+				Tags:                    row.Tags,
+				OriginURL:               row.OriginUrl.String,
+				Overlap:                 row.Overlap,
+			}
+			if row.Client != nil {
+				t.Client = *row.Client
 			}
 			err = json.Unmarshal(row.Artists, &t.Track.Artists)
 			if err != nil {
@@ -153,6 +187,7 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
 			ArtistID:     int32(opts.ArtistID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetListensPaginated: CountListensFromArtist: %w", err)
@@ -165,6 +200,7 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 			ListenedAt_2: t2,
 			Limit:        int32(opts.Limit),
 			Offset:       int32(offset),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetListensPaginated: GetLastListensPaginated: %w", err)
@@ -179,7 +215,16 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 					AlbumID: row.ReleaseID,
 					Album:   &row.ReleaseTitle,
 				},
-				Time: row.ListenedAt,
+				Time:                    row.ListenedAt,
+				DurationMs:              row.DurationMs.Int32,
+				MediaPlayer:             row.MediaPlayer.String,
+				SubmissionClientVersion: row.SubmissionClientVersion.String,
+				Tags:                    row.Tags,
+				OriginURL:               row.OriginUrl.String,
+				Overlap:                 row.Overlap,
+			}
+			if row.Client != nil {
+				t.Client = *row.Client
 			}
 			err = json.Unmarshal(row.Artists, &t.Track.Artists)
 			if err != nil {
@@ -190,6 +235,7 @@ func (d *Psql) GetListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*
 		count, err = d.q.CountListens(ctx, repository.CountListensParams{
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetListensPaginated: CountListens: %w", err)
@@ -214,27 +260,228 @@ func (d *Psql) SaveListen(ctx context.Context, opts db.SaveListenOpts) error {
 	if opts.Time.IsZero() {
 		opts.Time = time.Now()
 	}
+	l.Debug().Msgf("Inserting listen for track with id %d at time %v into DB", opts.TrackID, opts.Time)
+	if opts.Match == nil {
+		return d.q.InsertListen(ctx, insertListenParams(opts))
+	}
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("SaveListen: BeginTx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := insertListen(ctx, d.q.WithTx(tx), opts); err != nil {
+		return fmt.Errorf("SaveListen: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("SaveListen: Commit: %w", err)
+	}
+	return nil
+}
+
+// insertListen inserts the listen along with how it was matched, when known.
+func insertListen(ctx context.Context, q *repository.Queries, opts db.SaveListenOpts) error {
+	if err := q.InsertListen(ctx, insertListenParams(opts)); err != nil {
+		return fmt.Errorf("insertListen: InsertListen: %w", err)
+	}
+	if opts.Match == nil {
+		return nil
+	}
+	err := q.InsertListenMatch(ctx, repository.InsertListenMatchParams{
+		UserID:           opts.UserID,
+		TrackID:          opts.TrackID,
+		ListenedAt:       opts.Time,
+		ArtistMethod:     string(opts.Match.Artist.Method),
+		ArtistConfidence: opts.Match.Artist.Confidence,
+		AlbumMethod:      string(opts.Match.Album.Method),
+		AlbumConfidence:  opts.Match.Album.Confidence,
+		TrackMethod:      string(opts.Match.Track.Method),
+		TrackConfidence:  opts.Match.Track.Confidence,
+		Confidence:       opts.Match.Confidence,
+	})
+	if err != nil {
+		return fmt.Errorf("insertListen: InsertListenMatch: %w", err)
+	}
+	return nil
+}
+
+func insertListenParams(opts db.SaveListenOpts) repository.InsertListenParams {
 	var client *string
 	if opts.Client != "" {
 		client = &opts.Client
 	}
-	l.Debug().Msgf("Inserting listen for track with id %d at time %v into DB", opts.TrackID, opts.Time)
-	return d.q.InsertListen(ctx, repository.InsertListenParams{
-		TrackID:    opts.TrackID,
-		ListenedAt: opts.Time,
-		UserID:     opts.UserID,
-		Client:     client,
-	})
+	return repository.InsertListenParams{
+		TrackID:                 opts.TrackID,
+		ListenedAt:              opts.Time,
+		UserID:                  opts.UserID,
+		Client:                  client,
+		DurationMs:              pgtype.Int4{Int32: opts.DurationMs, Valid: opts.DurationMs > 0},
+		MediaPlayer:             pgtype.Text{String: opts.MediaPlayer, Valid: opts.MediaPlayer != ""},
+		SubmissionClientVersion: pgtype.Text{String: opts.SubmissionClientVersion, Valid: opts.SubmissionClientVersion != ""},
+		Tags:                    opts.Tags,
+		OriginUrl:               pgtype.Text{String: opts.OriginURL, Valid: opts.OriginURL != ""},
+		Overlap:                 opts.Overlap,
+	}
 }
 
-func (d *Psql) DeleteListen(ctx context.Context, trackId int32, listenedAt time.Time) error {
+func (d *Psql) DeleteListen(ctx context.Context, trackId int32, listenedAt time.Time, userId int32) error {
 	l := logger.FromContext(ctx)
 	if trackId == 0 {
 		return errors.New("required parameter 'trackId' missing")
 	}
 	l.Debug().Msgf("Deleting listen from track %d at time %s from DB", trackId, listenedAt)
-	return d.q.DeleteListen(ctx, repository.DeleteListenParams{
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return fmt.Errorf("DeleteListen: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+	snapshot, err := qtx.GetListenSnapshot(ctx, repository.GetListenSnapshotParams{
+		UserID:     userId,
 		TrackID:    trackId,
 		ListenedAt: listenedAt,
 	})
+	if err != nil {
+		return fmt.Errorf("DeleteListen: GetListenSnapshot: %w", err)
+	}
+	err = insertAuditEntry(ctx, qtx, models.AuditDeleteListen, userId, map[string]any{
+		"track_id":    trackId,
+		"listened_at": listenedAt.Unix(),
+	}, snapshot)
+	if err != nil {
+		return fmt.Errorf("DeleteListen: %w", err)
+	}
+	err = qtx.DeleteListen(ctx, repository.DeleteListenParams{
+		TrackID:    trackId,
+		ListenedAt: listenedAt,
+		UserID:     userId,
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteListen: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func listenFilterRange(opts db.ListenFilterOpts) (time.Time, time.Time) {
+	to := opts.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	return opts.From, to
+}
+
+// GetTrackListenCounts returns how many of the filtered listens belong to each
+// track.
+func (d *Psql) GetTrackListenCounts(ctx context.Context, opts db.ListenFilterOpts) ([]db.TrackListenCount, error) {
+	from, to := listenFilterRange(opts)
+	rows, err := d.q.GetTrackListenCountsForReassociation(ctx, repository.GetTrackListenCountsForReassociationParams{
+		UserID:      opts.UserID,
+		PeriodStart: from,
+		PeriodEnd:   to,
+		Client:      opts.Client,
+		ArtistID:    opts.ArtistID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetTrackListenCounts: %w", err)
+	}
+	counts := make([]db.TrackListenCount, len(rows))
+	for i, row := range rows {
+		counts[i] = db.TrackListenCount{TrackID: row.TrackID, Listens: row.ListenCount}
+	}
+	return counts, nil
+}
+
+// MoveListens moves the filtered listens of one track to another. Listens that
+// would collide with a listen the user already has on the other track are left
+// in place.
+func (d *Psql) MoveListens(ctx context.Context, opts db.MoveListensOpts) (int64, error) {
+	if opts.FromTrackID == 0 || opts.ToTrackID == 0 {
+		return 0, errors.New("MoveListens: required parameter FromTrackID or ToTrackID missing")
+	}
+	from, to := listenFilterRange(opts.ListenFilterOpts)
+	n, err := d.q.UpdateTrackIdForFilteredListens(ctx, repository.UpdateTrackIdForFilteredListensParams{
+		NewTrackID:  opts.ToTrackID,
+		TrackID:     opts.FromTrackID,
+		UserID:      opts.UserID,
+		PeriodStart: from,
+		PeriodEnd:   to,
+		Client:      opts.Client,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("MoveListens: %w", err)
+	}
+	return n, nil
+}
+
+// ReassociateListens moves the filtered listens and rejected listens of each
+// track to its new track, then deletes tracks, albums and artists left without listens, recording both
+// in the audit log so that they can be undone. Returns how many listens were
+// moved by each move.
+func (d *Psql) ReassociateListens(ctx context.Context, opts db.ReassociateListensOpts) ([]int64, error) {
+	l := logger.FromContext(ctx)
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return nil, fmt.Errorf("ReassociateListens: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+
+	var tracks []int32
+	for _, m := range opts.Moves {
+		tracks = append(tracks, m.FromTrackID, m.ToTrackID)
+	}
+	inputs := map[string]any{
+		"user_id":   opts.UserID,
+		"client":    opts.Client,
+		"artist_id": opts.ArtistID,
+		"moves":     len(opts.Moves),
+	}
+	err = recordAudit(ctx, qtx, models.AuditReassociateListens, opts.UserID, inputs, auditEntities{Tracks: tracks})
+	if err != nil {
+		return nil, fmt.Errorf("ReassociateListens: %w", err)
+	}
+
+	from, to := listenFilterRange(opts.ListenFilterOpts)
+	moved := make([]int64, len(opts.Moves))
+	for i, m := range opts.Moves {
+		moved[i], err = qtx.UpdateTrackIdForFilteredListens(ctx, repository.UpdateTrackIdForFilteredListensParams{
+			NewTrackID:  m.ToTrackID,
+			TrackID:     m.FromTrackID,
+			UserID:      opts.UserID,
+			PeriodStart: from,
+			PeriodEnd:   to,
+			Client:      opts.Client,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ReassociateListens: UpdateTrackIdForFilteredListens: %w", err)
+		}
+		err = qtx.UpdateTrackIdForFilteredRejectedListens(ctx, repository.UpdateTrackIdForFilteredRejectedListensParams{
+			NewTrackID:  m.ToTrackID,
+			TrackID:     m.FromTrackID,
+			UserID:      opts.UserID,
+			PeriodStart: from,
+			PeriodEnd:   to,
+			Client:      opts.Client,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ReassociateListens: UpdateTrackIdForFilteredRejectedListens: %w", err)
+		}
+	}
+	if err := qtx.CleanOrphanedEntries(ctx); err != nil {
+		return nil, fmt.Errorf("ReassociateListens: CleanOrphanedEntries: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ReassociateListens: Commit: %w", err)
+	}
+	return moved, nil
+}
+
+// CleanOrphanedEntries deletes tracks without listens or rejected listens, then
+// albums without tracks and artists without tracks.
+func (d *Psql) CleanOrphanedEntries(ctx context.Context) error {
+	if err := d.q.CleanOrphanedEntries(ctx); err != nil {
+		return fmt.Errorf("CleanOrphanedEntries: %w", err)
+	}
+	return nil
 }
@@ -86,7 +86,7 @@ func TestListenActivity(t *testing.T) {
 	ctx := context.Background()
 
 	// Test for opts.Step = db.StepDay
-	activity, err := store.GetListenActivity(ctx, db.ListenActivityOpts{Step: db.StepDay})
+	activity, err := store.GetListenActivity(ctx, db.ListenActivityOpts{Step: db.StepDay, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, activity, db.DefaultRange)
 	assert.Equal(t, []int64{0, 0, 0, 2, 0, 0, 0, 0, 0, 2, 2, 0}, flattenListenCounts(activity))
@@ -104,7 +104,7 @@ func TestListenActivity(t *testing.T) {
 				   (1, 2, NOW() - INTERVAL '2 months')`)
 	require.NoError(t, err)
 
-	activity, err = store.GetListenActivity(ctx, db.ListenActivityOpts{Step: db.StepMonth, Range: 8})
+	activity, err = store.GetListenActivity(ctx, db.ListenActivityOpts{Step: db.StepMonth, Range: 8, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, activity, 8)
 	assert.Equal(t, []int64{0, 0, 0, 0, 1, 2, 2, 0}, flattenListenCounts(activity))
@@ -121,7 +121,7 @@ func TestListenActivity(t *testing.T) {
 				   (1, 2, NOW() - INTERVAL '3 years')`)
 	require.NoError(t, err)
 
-	activity, err = store.GetListenActivity(ctx, db.ListenActivityOpts{Step: db.StepYear})
+	activity, err = store.GetListenActivity(ctx, db.ListenActivityOpts{Step: db.StepYear, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, activity, db.DefaultRange)
 	assert.Equal(t, []int64{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 2, 0}, flattenListenCounts(activity))
@@ -136,9 +136,10 @@ func TestListenActivity(t *testing.T) {
 	require.NoError(t, err)
 
 	activity, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
-		Step:  db.StepDay,
-		Month: 3,
-		Year:  2024,
+		Step:   db.StepDay,
+		Month:  3,
+		Year:   2024,
+		UserID: 1,
 	})
 	require.NoError(t, err)
 	require.Len(t, activity, 31) // number of days in march
@@ -159,6 +160,7 @@ func TestListenActivity(t *testing.T) {
 	activity, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
 		Step:    db.StepDay,
 		AlbumID: 1, // Track 1 only
+		UserID:  1,
 	})
 	require.NoError(t, err)
 	require.Len(t, activity, db.DefaultRange)
@@ -167,6 +169,7 @@ func TestListenActivity(t *testing.T) {
 	activity, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
 		Step:    db.StepDay,
 		TrackID: 1, // Track 1 only
+		UserID:  1,
 	})
 	require.NoError(t, err)
 	require.Len(t, activity, db.DefaultRange)
@@ -175,6 +178,7 @@ func TestListenActivity(t *testing.T) {
 	activity, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
 		Step:     db.StepDay,
 		ArtistID: 2, // Should only include listens to Track 2
+		UserID:   1,
 	})
 	require.NoError(t, err)
 	require.Len(t, activity, db.DefaultRange)
@@ -182,31 +186,37 @@ func TestListenActivity(t *testing.T) {
 
 	// month without year is disallowed
 	_, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
-		Step:  db.StepDay,
-		Month: 5,
+		Step:   db.StepDay,
+		Month:  5,
+		UserID: 1,
 	})
 	require.Error(t, err)
 
 	// invalid options
 	_, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
-		Year: -10,
+		Year:   -10,
+		UserID: 1,
 	})
 	require.Error(t, err)
 	_, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
-		Year:  2025,
-		Month: -10,
+		Year:   2025,
+		Month:  -10,
+		UserID: 1,
 	})
 	require.Error(t, err)
 	_, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
-		Range: -1,
+		Range:  -1,
+		UserID: 1,
 	})
 	require.Error(t, err)
 	_, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
 		AlbumID: -1,
+		UserID:  1,
 	})
 	require.Error(t, err)
 	_, err = store.GetListenActivity(ctx, db.ListenActivityOpts{
 		ArtistID: -1,
+		UserID:   1,
 	})
 	require.Error(t, err)
 
@@ -0,0 +1,119 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+func listenMatchFromRow(row repository.ListenMatch) *models.ListenMatch {
+	return &models.ListenMatch{
+		Artist:     models.Match{Method: models.MatchMethod(row.ArtistMethod), Confidence: row.ArtistConfidence},
+		Album:      models.Match{Method: models.MatchMethod(row.AlbumMethod), Confidence: row.AlbumConfidence},
+		Track:      models.Match{Method: models.MatchMethod(row.TrackMethod), Confidence: row.TrackConfidence},
+		Confidence: row.Confidence,
+		Reviewed:   row.Reviewed,
+	}
+}
+
+// GetListenMatch returns nil, nil when it is not known how the listen was
+// matched, as for listens saved before matches were recorded.
+func (d *Psql) GetListenMatch(ctx context.Context, userId, trackId int32, listenedAt time.Time) (*models.ListenMatch, error) {
+	row, err := d.q.GetListenMatch(ctx, repository.GetListenMatchParams{
+		UserID:     userId,
+		TrackID:    trackId,
+		ListenedAt: listenedAt,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("GetListenMatch: %w", err)
+	}
+	return listenMatchFromRow(row), nil
+}
+
+// GetMatchInboxPaginated returns the listens that were matched with a lower
+// confidence than asked for and have not been reviewed, newest first.
+func (d *Psql) GetMatchInboxPaginated(ctx context.Context, opts db.GetMatchInboxOpts) (*db.PaginatedResponse[*models.Listen], error) {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultItemsPerPage
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	offset := (opts.Page - 1) * opts.Limit
+	rows, err := d.q.GetListenMatchInboxPaginated(ctx, repository.GetListenMatchInboxPaginatedParams{
+		UserID:     opts.UserID,
+		Confidence: opts.Below,
+		Limit:      int32(opts.Limit),
+		Offset:     int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetMatchInboxPaginated: GetListenMatchInboxPaginated: %w", err)
+	}
+	listens := make([]*models.Listen, len(rows))
+	for i, row := range rows {
+		listen := &models.Listen{
+			Time: row.ListenedAt,
+			Track: models.Track{
+				ID:      row.TrackID,
+				Title:   row.TrackTitle,
+				Image:   row.ReleaseImage,
+				AlbumID: row.ReleaseID,
+				Album:   &row.ReleaseTitle,
+			},
+			Match: listenMatchFromRow(repository.ListenMatch{
+				ArtistMethod:     row.ArtistMethod,
+				ArtistConfidence: row.ArtistConfidence,
+				AlbumMethod:      row.AlbumMethod,
+				AlbumConfidence:  row.AlbumConfidence,
+				TrackMethod:      row.TrackMethod,
+				TrackConfidence:  row.TrackConfidence,
+				Confidence:       row.Confidence,
+				Reviewed:         row.Reviewed,
+			}),
+		}
+		if row.Client != nil {
+			listen.Client = *row.Client
+		}
+		if err := json.Unmarshal(row.Artists, &listen.Track.Artists); err != nil {
+			return nil, fmt.Errorf("GetMatchInboxPaginated: Unmarshal: %w", err)
+		}
+		listens[i] = listen
+	}
+	count, err := d.q.CountListenMatchInbox(ctx, repository.CountListenMatchInboxParams{
+		UserID:     opts.UserID,
+		Confidence: opts.Below,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetMatchInboxPaginated: CountListenMatchInbox: %w", err)
+	}
+	return &db.PaginatedResponse[*models.Listen]{
+		Items:        listens,
+		TotalCount:   count,
+		ItemsPerPage: int32(opts.Limit),
+		HasNextPage:  int64(offset+len(listens)) < count,
+		CurrentPage:  int32(opts.Page),
+	}, nil
+}
+
+// ReviewListenMatch takes the listen out of the inbox. Returns false when the
+// user has no such listen with a recorded match.
+func (d *Psql) ReviewListenMatch(ctx context.Context, userId, trackId int32, listenedAt time.Time) (bool, error) {
+	n, err := d.q.MarkListenMatchReviewed(ctx, repository.MarkListenMatchReviewedParams{
+		UserID:     userId,
+		TrackID:    trackId,
+		ListenedAt: listenedAt,
+	})
+	if err != nil {
+		return false, fmt.Errorf("ReviewListenMatch: %w", err)
+	}
+	return n > 0, nil
+}
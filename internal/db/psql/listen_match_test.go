@@ -0,0 +1,82 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenMatches(t *testing.T) {
+	testDataForListens(t)
+	ctx := context.Background()
+
+	listenedAt := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	alias := models.Match{Method: models.MatchMethodAlias, Confidence: models.MatchMethodAlias.Confidence()}
+	created := models.Match{Method: models.MatchMethodCreated, Confidence: models.MatchMethodCreated.Confidence()}
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{
+		TrackID: 1, Time: listenedAt, UserID: 1, Client: "Navidrome",
+		Match: &models.ListenMatch{Artist: alias, Album: created, Track: created, Confidence: 0.25},
+	}))
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{
+		TrackID: 2, Time: listenedAt.Add(-time.Minute), UserID: 1,
+		Match: &models.ListenMatch{Artist: alias, Album: alias, Track: alias, Confidence: 0.9},
+	}))
+	// listens saved without a match are never in the inbox
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 2, Time: listenedAt.Add(-2 * time.Minute), UserID: 1}))
+
+	match, err := store.GetListenMatch(ctx, 1, 1, listenedAt)
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, models.MatchMethodAlias, match.Artist.Method)
+	assert.Equal(t, models.MatchMethodCreated, match.Album.Method)
+	assert.Equal(t, 0.25, match.Confidence)
+	assert.False(t, match.Reviewed)
+	match, err = store.GetListenMatch(ctx, 1, 2, listenedAt.Add(-2*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, match)
+
+	inbox, err := store.GetMatchInboxPaginated(ctx, db.GetMatchInboxOpts{UserID: 1, Below: 0.9})
+	require.NoError(t, err)
+	require.Len(t, inbox.Items, 1)
+	assert.EqualValues(t, 1, inbox.TotalCount)
+	assert.Equal(t, "Track One", inbox.Items[0].Track.Title)
+	assert.Equal(t, "Navidrome", inbox.Items[0].Client)
+	require.Len(t, inbox.Items[0].Track.Artists, 1)
+	assert.Equal(t, "Artist One", inbox.Items[0].Track.Artists[0].Name)
+	require.NotNil(t, inbox.Items[0].Match)
+	assert.Equal(t, models.MatchMethodCreated, inbox.Items[0].Match.Track.Method)
+
+	inbox, err = store.GetMatchInboxPaginated(ctx, db.GetMatchInboxOpts{UserID: 1, Below: 1})
+	require.NoError(t, err)
+	assert.Len(t, inbox.Items, 2)
+	inbox, err = store.GetMatchInboxPaginated(ctx, db.GetMatchInboxOpts{UserID: 2, Below: 1})
+	require.NoError(t, err)
+	assert.Empty(t, inbox.Items)
+
+	// the match follows the listen when it is moved to another track
+	_, err = store.MoveListens(ctx, db.MoveListensOpts{
+		ListenFilterOpts: db.ListenFilterOpts{UserID: 1, From: listenedAt, To: listenedAt},
+		FromTrackID:      1,
+		ToTrackID:        2,
+	})
+	require.NoError(t, err)
+	match, err = store.GetListenMatch(ctx, 1, 2, listenedAt)
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, 0.25, match.Confidence)
+
+	found, err := store.ReviewListenMatch(ctx, 2, 2, listenedAt)
+	require.NoError(t, err)
+	assert.False(t, found)
+	found, err = store.ReviewListenMatch(ctx, 1, 2, listenedAt)
+	require.NoError(t, err)
+	assert.True(t, found)
+	inbox, err = store.GetMatchInboxPaginated(ctx, db.GetMatchInboxOpts{UserID: 1, Below: 0.9})
+	require.NoError(t, err)
+	assert.Empty(t, inbox.Items)
+}
@@ -0,0 +1,197 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetListenPolicy returns the zero policy, which accepts every listen, when
+// the user has not set one.
+func (d *Psql) GetListenPolicy(ctx context.Context, userId int32) (*models.ListenPolicy, error) {
+	row, err := d.q.GetListenPolicy(ctx, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.ListenPolicy{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("GetListenPolicy: %w", err)
+	}
+	return &models.ListenPolicy{
+		MinPlayedFraction:      row.MinPlayedFraction,
+		MinPlayedSeconds:       row.MinPlayedSeconds,
+		DuplicateWindowSeconds: row.DuplicateWindowSeconds,
+		FlagOverlaps:           row.FlagOverlaps,
+		UpdatedAt:              row.UpdatedAt,
+	}, nil
+}
+
+func (d *Psql) SaveListenPolicy(ctx context.Context, opts db.SaveListenPolicyOpts) error {
+	err := d.q.UpsertListenPolicy(ctx, repository.UpsertListenPolicyParams{
+		UserID:                 opts.UserID,
+		MinPlayedFraction:      opts.MinPlayedFraction,
+		MinPlayedSeconds:       opts.MinPlayedSeconds,
+		DuplicateWindowSeconds: opts.DuplicateWindowSeconds,
+		FlagOverlaps:           opts.FlagOverlaps,
+	})
+	if err != nil {
+		return fmt.Errorf("SaveListenPolicy: %w", err)
+	}
+	return nil
+}
+
+// ListenOfTrackExists reports whether the user has another listen of the track
+// within the window around the given time. A listen at exactly the given time
+// is not counted, as saving it again changes nothing.
+func (d *Psql) ListenOfTrackExists(ctx context.Context, opts db.ListenOfTrackExistsOpts) (bool, error) {
+	exists, err := d.q.ListenOfTrackExistsInRange(ctx, repository.ListenOfTrackExistsInRangeParams{
+		UserID:      opts.UserID,
+		TrackID:     opts.TrackID,
+		PeriodStart: opts.Time.Add(-opts.Window),
+		PeriodEnd:   opts.Time.Add(opts.Window),
+		ListenedAt:  opts.Time,
+	})
+	if err != nil {
+		return false, fmt.Errorf("ListenOfTrackExists: %w", err)
+	}
+	return exists, nil
+}
+
+// GetListenBefore returns the time and track duration in seconds of the last
+// listen before the given time, or a zero time when there is none.
+func (d *Psql) GetListenBefore(ctx context.Context, userId int32, t time.Time) (time.Time, int32, error) {
+	row, err := d.q.GetListenBefore(ctx, repository.GetListenBeforeParams{
+		UserID:     userId,
+		ListenedAt: t,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, 0, nil
+	} else if err != nil {
+		return time.Time{}, 0, fmt.Errorf("GetListenBefore: %w", err)
+	}
+	return row.ListenedAt, row.Duration, nil
+}
+
+func (d *Psql) SaveRejectedListen(ctx context.Context, opts db.SaveRejectedListenOpts) error {
+	payload, err := json.Marshal(opts.Listen)
+	if err != nil {
+		return fmt.Errorf("SaveRejectedListen: Marshal: %w", err)
+	}
+	err = d.q.InsertRejectedListen(ctx, repository.InsertRejectedListenParams{
+		UserID:     opts.Listen.UserID,
+		TrackID:    opts.Listen.TrackID,
+		ListenedAt: opts.Listen.Time,
+		Reason:     string(opts.Reason),
+		Payload:    payload,
+	})
+	if err != nil {
+		return fmt.Errorf("SaveRejectedListen: %w", err)
+	}
+	return nil
+}
+
+func (d *Psql) GetRejectedListensPaginated(ctx context.Context, opts db.GetItemsOpts) (*db.PaginatedResponse[*models.RejectedListen], error) {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultItemsPerPage
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	offset := (opts.Page - 1) * opts.Limit
+	rows, err := d.q.GetRejectedListensPaginated(ctx, repository.GetRejectedListensPaginatedParams{
+		UserID: opts.UserID,
+		Limit:  int32(opts.Limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetRejectedListensPaginated: GetRejectedListensPaginated: %w", err)
+	}
+	listens := make([]*models.RejectedListen, len(rows))
+	for i, row := range rows {
+		var opts db.SaveListenOpts
+		if err := json.Unmarshal(row.Payload, &opts); err != nil {
+			return nil, fmt.Errorf("GetRejectedListensPaginated: Unmarshal: %w", err)
+		}
+		r := &models.RejectedListen{
+			ID:         row.ID,
+			Time:       row.ListenedAt,
+			Track:      models.Track{ID: row.TrackID, Title: row.TrackTitle},
+			Reason:     models.RejectedListenReason(row.Reason),
+			Client:     opts.Client,
+			DurationMs: opts.DurationMs,
+			CreatedAt:  row.CreatedAt,
+		}
+		if err := json.Unmarshal(row.Artists, &r.Track.Artists); err != nil {
+			return nil, fmt.Errorf("GetRejectedListensPaginated: Unmarshal: %w", err)
+		}
+		listens[i] = r
+	}
+	count, err := d.q.CountRejectedListens(ctx, opts.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("GetRejectedListensPaginated: CountRejectedListens: %w", err)
+	}
+	return &db.PaginatedResponse[*models.RejectedListen]{
+		Items:        listens,
+		TotalCount:   count,
+		ItemsPerPage: int32(opts.Limit),
+		HasNextPage:  int64(offset+len(listens)) < count,
+		CurrentPage:  int32(opts.Page),
+	}, nil
+}
+
+// AcceptRejectedListen saves a rejected listen as it was submitted and removes
+// it from the rejected listens. Returns false when the user has no such
+// rejected listen.
+func (d *Psql) AcceptRejectedListen(ctx context.Context, id int64, userId int32) (bool, error) {
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, fmt.Errorf("AcceptRejectedListen: BeginTx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+	row, err := qtx.GetRejectedListen(ctx, repository.GetRejectedListenParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("AcceptRejectedListen: GetRejectedListen: %w", err)
+	}
+	var opts db.SaveListenOpts
+	if err := json.Unmarshal(row.Payload, &opts); err != nil {
+		return false, fmt.Errorf("AcceptRejectedListen: Unmarshal: %w", err)
+	}
+	if err := insertListen(ctx, qtx, opts); err != nil {
+		return false, fmt.Errorf("AcceptRejectedListen: %w", err)
+	}
+	_, err = qtx.DeleteRejectedListen(ctx, repository.DeleteRejectedListenParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if err != nil {
+		return false, fmt.Errorf("AcceptRejectedListen: DeleteRejectedListen: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("AcceptRejectedListen: Commit: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteRejectedListen discards a rejected listen. Returns false when the user
+// has no such rejected listen.
+func (d *Psql) DeleteRejectedListen(ctx context.Context, id int64, userId int32) (bool, error) {
+	n, err := d.q.DeleteRejectedListen(ctx, repository.DeleteRejectedListenParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if err != nil {
+		return false, fmt.Errorf("DeleteRejectedListen: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,154 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenPolicy(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, store.Exec(ctx, `TRUNCATE listen_policies`))
+	defer store.Exec(ctx, `TRUNCATE listen_policies`)
+
+	// without a policy everything is accepted
+	policy, err := store.GetListenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, policy.MinPlayedFraction)
+	assert.Zero(t, policy.DuplicateWindowSeconds)
+	assert.False(t, policy.FlagOverlaps)
+
+	require.NoError(t, store.SaveListenPolicy(ctx, db.SaveListenPolicyOpts{
+		UserID:                 1,
+		MinPlayedFraction:      0.5,
+		MinPlayedSeconds:       240,
+		DuplicateWindowSeconds: 60,
+	}))
+	require.NoError(t, store.SaveListenPolicy(ctx, db.SaveListenPolicyOpts{
+		UserID:                 1,
+		MinPlayedFraction:      0.5,
+		MinPlayedSeconds:       240,
+		DuplicateWindowSeconds: 120,
+		FlagOverlaps:           true,
+	}))
+	policy, err = store.GetListenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, policy.MinPlayedFraction)
+	assert.EqualValues(t, 240, policy.MinPlayedSeconds)
+	assert.EqualValues(t, 120, policy.DuplicateWindowSeconds)
+	assert.True(t, policy.FlagOverlaps)
+}
+
+func TestListenPolicyLookups(t *testing.T) {
+	testDataForListens(t)
+	ctx := context.Background()
+	require.NoError(t, store.Exec(ctx, `UPDATE tracks SET duration = 200 WHERE id = 1`))
+
+	listenedAt := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 1, Time: listenedAt, UserID: 1}))
+
+	exists, err := store.ListenOfTrackExists(ctx, db.ListenOfTrackExistsOpts{
+		UserID: 1, TrackID: 1, Time: listenedAt.Add(30 * time.Second), Window: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = store.ListenOfTrackExists(ctx, db.ListenOfTrackExistsOpts{
+		UserID: 1, TrackID: 1, Time: listenedAt.Add(2 * time.Minute), Window: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.False(t, exists)
+	// the listen itself is not a duplicate of itself
+	exists, err = store.ListenOfTrackExists(ctx, db.ListenOfTrackExistsOpts{
+		UserID: 1, TrackID: 1, Time: listenedAt, Window: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = store.ListenOfTrackExists(ctx, db.ListenOfTrackExistsOpts{
+		UserID: 1, TrackID: 2, Time: listenedAt, Window: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	prev, duration, err := store.GetListenBefore(ctx, 1, listenedAt.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, listenedAt.Unix(), prev.Unix())
+	assert.EqualValues(t, 200, duration)
+	prev, _, err = store.GetListenBefore(ctx, 1, listenedAt)
+	require.NoError(t, err)
+	assert.True(t, prev.IsZero())
+}
+
+func TestRejectedListens(t *testing.T) {
+	testDataForListens(t)
+	ctx := context.Background()
+
+	listenedAt := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	require.NoError(t, store.SaveRejectedListen(ctx, db.SaveRejectedListenOpts{
+		Reason: models.RejectedListenTooShort,
+		Listen: db.SaveListenOpts{
+			TrackID:    1,
+			Time:       listenedAt,
+			UserID:     1,
+			Client:     "Navidrome",
+			DurationMs: 12000,
+			Tags:       []string{"rock"},
+		},
+	}))
+	require.NoError(t, store.SaveRejectedListen(ctx, db.SaveRejectedListenOpts{
+		Reason: models.RejectedListenDuplicate,
+		Listen: db.SaveListenOpts{TrackID: 2, Time: listenedAt.Add(-time.Minute), UserID: 1},
+	}))
+
+	resp, err := store.GetRejectedListensPaginated(ctx, db.GetItemsOpts{UserID: 1})
+	require.NoError(t, err)
+	rejected := resp.Items
+	require.Len(t, rejected, 2)
+	assert.EqualValues(t, 2, resp.TotalCount)
+	assert.False(t, resp.HasNextPage)
+	assert.Equal(t, models.RejectedListenTooShort, rejected[0].Reason)
+	assert.Equal(t, "Track One", rejected[0].Track.Title)
+	require.Len(t, rejected[0].Track.Artists, 1)
+	assert.Equal(t, "Artist One", rejected[0].Track.Artists[0].Name)
+	assert.Equal(t, "Navidrome", rejected[0].Client)
+	assert.EqualValues(t, 12000, rejected[0].DurationMs)
+	assert.Equal(t, models.RejectedListenDuplicate, rejected[1].Reason)
+
+	page, err := store.GetRejectedListensPaginated(ctx, db.GetItemsOpts{UserID: 1, Limit: 1, Page: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, rejected[1].ID, page.Items[0].ID)
+	assert.False(t, page.HasNextPage)
+
+	// other users cannot accept or delete them
+	found, err := store.AcceptRejectedListen(ctx, rejected[0].ID, 2)
+	require.NoError(t, err)
+	assert.False(t, found)
+	found, err = store.DeleteRejectedListen(ctx, rejected[1].ID, 2)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// accepting saves the listen as it was submitted
+	found, err = store.AcceptRejectedListen(ctx, rejected[0].ID, 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+	listens, err := store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, Page: 1, UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, listens.Items, 1)
+	assert.Equal(t, listenedAt.Unix(), listens.Items[0].Time.Unix())
+	assert.Equal(t, "Navidrome", listens.Items[0].Client)
+	assert.EqualValues(t, 12000, listens.Items[0].DurationMs)
+	assert.Equal(t, []string{"rock"}, listens.Items[0].Tags)
+
+	found, err = store.DeleteRejectedListen(ctx, rejected[1].ID, 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+	resp, err = store.GetRejectedListensPaginated(ctx, db.GetItemsOpts{UserID: 1})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Items)
+	assert.Zero(t, resp.TotalCount)
+}
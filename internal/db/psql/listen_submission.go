@@ -0,0 +1,176 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func listenSubmissionFromRow(row repository.ListenSubmission) *models.ListenSubmission {
+	return &models.ListenSubmission{
+		ID:            row.ID,
+		UserID:        row.UserID,
+		Payload:       row.Payload,
+		Status:        models.ListenSubmissionStatus(row.Status),
+		Attempts:      row.Attempts,
+		LastError:     row.LastError.String,
+		NextAttemptAt: row.NextAttemptAt,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}
+}
+
+func (d *Psql) SaveListenSubmission(ctx context.Context, opts db.SaveListenSubmissionOpts) (*models.ListenSubmission, error) {
+	row, err := d.q.InsertListenSubmission(ctx, repository.InsertListenSubmissionParams{
+		UserID:       opts.UserID,
+		Payload:      opts.Payload,
+		PartitionKey: opts.PartitionKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SaveListenSubmission: InsertListenSubmission: %w", err)
+	}
+	return listenSubmissionFromRow(row), nil
+}
+
+// SaveListenSubmissions saves all of the submissions or, when one cannot be
+// saved, none of them.
+func (d *Psql) SaveListenSubmissions(ctx context.Context, opts []db.SaveListenSubmissionOpts) error {
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("SaveListenSubmissions: BeginTx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+	for _, o := range opts {
+		_, err := qtx.InsertListenSubmission(ctx, repository.InsertListenSubmissionParams{
+			UserID:       o.UserID,
+			Payload:      o.Payload,
+			PartitionKey: o.PartitionKey,
+		})
+		if err != nil {
+			return fmt.Errorf("SaveListenSubmissions: InsertListenSubmission: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("SaveListenSubmissions: Commit: %w", err)
+	}
+	return nil
+}
+
+// ClaimListenSubmission marks the oldest pending submission that is due as
+// processing and returns it. Returns nil, nil when there is nothing to do.
+func (d *Psql) ClaimListenSubmission(ctx context.Context, opts db.ClaimListenSubmissionOpts) (*models.ListenSubmission, error) {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	row, err := d.q.ClaimListenSubmission(ctx, repository.ClaimListenSubmissionParams{
+		Workers: int32(opts.Workers),
+		Worker:  int32(opts.Worker),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("ClaimListenSubmission: %w", err)
+	}
+	return listenSubmissionFromRow(row), nil
+}
+
+func (d *Psql) FailListenSubmission(ctx context.Context, opts db.FailListenSubmissionOpts) error {
+	status := models.ListenSubmissionPending
+	if opts.Dead {
+		status = models.ListenSubmissionDead
+	}
+	err := d.q.FailListenSubmission(ctx, repository.FailListenSubmissionParams{
+		ID:            opts.ID,
+		Status:        string(status),
+		LastError:     pgtype.Text{String: opts.Error, Valid: opts.Error != ""},
+		NextAttemptAt: opts.RetryAt,
+	})
+	if err != nil {
+		return fmt.Errorf("FailListenSubmission: %w", err)
+	}
+	return nil
+}
+
+func (d *Psql) DeleteListenSubmission(ctx context.Context, id int64) error {
+	err := d.q.DeleteListenSubmission(ctx, id)
+	if err != nil {
+		return fmt.Errorf("DeleteListenSubmission: %w", err)
+	}
+	return nil
+}
+
+// ResetProcessingListenSubmissions puts submissions that were being processed
+// when the server stopped back in the queue.
+func (d *Psql) ResetProcessingListenSubmissions(ctx context.Context) (int64, error) {
+	n, err := d.q.ResetProcessingListenSubmissions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ResetProcessingListenSubmissions: %w", err)
+	}
+	return n, nil
+}
+
+func (d *Psql) GetDeadListenSubmissionsPaginated(ctx context.Context, opts db.GetItemsOpts) (*db.PaginatedResponse[*models.ListenSubmission], error) {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultItemsPerPage
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	offset := (opts.Page - 1) * opts.Limit
+	rows, err := d.q.GetDeadListenSubmissionsPaginated(ctx, repository.GetDeadListenSubmissionsPaginatedParams{
+		UserID: opts.UserID,
+		Limit:  int32(opts.Limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetDeadListenSubmissionsPaginated: GetDeadListenSubmissionsPaginated: %w", err)
+	}
+	items := make([]*models.ListenSubmission, len(rows))
+	for i, row := range rows {
+		items[i] = listenSubmissionFromRow(row)
+	}
+	count, err := d.q.CountDeadListenSubmissions(ctx, opts.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("GetDeadListenSubmissionsPaginated: CountDeadListenSubmissions: %w", err)
+	}
+	return &db.PaginatedResponse[*models.ListenSubmission]{
+		Items:        items,
+		TotalCount:   count,
+		ItemsPerPage: int32(opts.Limit),
+		HasNextPage:  int64(offset+len(items)) < count,
+		CurrentPage:  int32(opts.Page),
+	}, nil
+}
+
+// RequeueDeadListenSubmission moves a dead submission back into the queue with
+// a fresh set of attempts. Returns false when the user has no such submission.
+func (d *Psql) RequeueDeadListenSubmission(ctx context.Context, id int64, userId int32) (bool, error) {
+	n, err := d.q.RequeueDeadListenSubmission(ctx, repository.RequeueDeadListenSubmissionParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if err != nil {
+		return false, fmt.Errorf("RequeueDeadListenSubmission: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeleteDeadListenSubmission discards a dead submission. Returns false when
+// the user has no such submission.
+func (d *Psql) DeleteDeadListenSubmission(ctx context.Context, id int64, userId int32) (bool, error) {
+	n, err := d.q.DeleteDeadListenSubmission(ctx, repository.DeleteDeadListenSubmissionParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if err != nil {
+		return false, fmt.Errorf("DeleteDeadListenSubmission: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,161 @@
+package psql_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func truncateTestDataForListenSubmissions(t *testing.T) {
+	err := store.Exec(context.Background(),
+		`TRUNCATE listen_submissions RESTART IDENTITY`,
+	)
+	require.NoError(t, err)
+}
+
+func TestListenSubmissionLifecycle(t *testing.T) {
+	ctx := context.Background()
+	truncateTestDataForListenSubmissions(t)
+	defer truncateTestDataForListenSubmissions(t)
+
+	saved, err := store.SaveListenSubmission(ctx, db.SaveListenSubmissionOpts{
+		UserID:  1,
+		Payload: []byte(`{"Artist": "Artist One", "TrackTitle": "Track One"}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.ListenSubmissionPending, saved.Status)
+	assert.EqualValues(t, 0, saved.Attempts)
+
+	claimed, err := store.ClaimListenSubmission(ctx, db.ClaimListenSubmissionOpts{Workers: 1})
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, saved.ID, claimed.ID)
+	assert.Equal(t, models.ListenSubmissionProcessing, claimed.Status)
+	assert.EqualValues(t, 1, claimed.Attempts)
+
+	// nothing else is pending
+	none, err := store.ClaimListenSubmission(ctx, db.ClaimListenSubmissionOpts{Workers: 1})
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	// a retry is not claimed before it is due
+	err = store.FailListenSubmission(ctx, db.FailListenSubmissionOpts{
+		ID:      claimed.ID,
+		Error:   "temporary failure",
+		RetryAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	none, err = store.ClaimListenSubmission(ctx, db.ClaimListenSubmissionOpts{Workers: 1})
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	err = store.FailListenSubmission(ctx, db.FailListenSubmissionOpts{
+		ID:    claimed.ID,
+		Error: "permanent failure",
+		Dead:  true,
+	})
+	require.NoError(t, err)
+
+	dead, err := store.GetDeadListenSubmissionsPaginated(ctx, db.GetItemsOpts{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, dead.Items, 1)
+	assert.EqualValues(t, 1, dead.TotalCount)
+	assert.Equal(t, "permanent failure", dead.Items[0].LastError)
+
+	// dead letters belong to their user
+	ok, err := store.RequeueDeadListenSubmission(ctx, claimed.ID, 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.RequeueDeadListenSubmission(ctx, claimed.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	claimed, err = store.ClaimListenSubmission(ctx, db.ClaimListenSubmissionOpts{Workers: 1})
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.EqualValues(t, 1, claimed.Attempts)
+
+	// interrupted submissions are put back in the queue
+	n, err := store.ResetProcessingListenSubmissions(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+
+	claimed, err = store.ClaimListenSubmission(ctx, db.ClaimListenSubmissionOpts{Workers: 1})
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	require.NoError(t, store.DeleteListenSubmission(ctx, claimed.ID))
+
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM listen_submissions`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestClaimListenSubmissionPartitionsByArtist(t *testing.T) {
+	ctx := context.Background()
+	truncateTestDataForListenSubmissions(t)
+	defer truncateTestDataForListenSubmissions(t)
+
+	for _, artist := range []string{"Artist One", "artist one", "Artist Two", "Artist Three"} {
+		_, err := store.SaveListenSubmission(ctx, db.SaveListenSubmissionOpts{
+			UserID:       1,
+			Payload:      []byte(`{"Artist": "` + artist + `", "TrackTitle": "Track"}`),
+			PartitionKey: strings.ToLower(artist),
+		})
+		require.NoError(t, err)
+	}
+
+	// each submission is claimed by exactly one worker, and the same artist
+	// always goes to the same worker
+	workers := map[string][]int{}
+	claimed := 0
+	for worker := 0; worker < 3; worker++ {
+		for {
+			s, err := store.ClaimListenSubmission(ctx, db.ClaimListenSubmissionOpts{Workers: 3, Worker: worker})
+			require.NoError(t, err)
+			if s == nil {
+				break
+			}
+			claimed++
+			var payload struct{ Artist string }
+			require.NoError(t, json.Unmarshal(s.Payload, &payload))
+			artist := strings.ToLower(payload.Artist)
+			workers[artist] = append(workers[artist], worker)
+		}
+	}
+	assert.Equal(t, 4, claimed)
+	require.Len(t, workers["artist one"], 2)
+	assert.Equal(t, workers["artist one"][0], workers["artist one"][1])
+}
+
+func TestSaveListenSubmissions(t *testing.T) {
+	ctx := context.Background()
+	truncateTestDataForListenSubmissions(t)
+	defer truncateTestDataForListenSubmissions(t)
+
+	err := store.SaveListenSubmissions(ctx, []db.SaveListenSubmissionOpts{
+		{UserID: 1, Payload: []byte(`{"Artist": "Artist One", "TrackTitle": "Track One"}`)},
+		{UserID: 1, Payload: []byte(`{"Artist": "Artist Two", "TrackTitle": "Track Two"}`)},
+	})
+	require.NoError(t, err)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM listen_submissions`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// none of a batch is saved when one of it cannot be
+	err = store.SaveListenSubmissions(ctx, []db.SaveListenSubmissionOpts{
+		{UserID: 1, Payload: []byte(`{"Artist": "Artist Three", "TrackTitle": "Track Three"}`)},
+		{UserID: 1, Payload: []byte(`not json`)},
+	})
+	assert.Error(t, err)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listen_submissions`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
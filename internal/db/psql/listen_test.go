@@ -67,7 +67,7 @@ func TestGetListens(t *testing.T) {
 	ctx := context.Background()
 
 	// Test valid
-	resp, err := store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime})
+	resp, err := store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 10)
 	assert.Equal(t, int64(10), resp.TotalCount)
@@ -78,7 +78,7 @@ func TestGetListens(t *testing.T) {
 	assert.Equal(t, "Artist Three", resp.Items[1].Track.Artists[0].Name)
 
 	// Test pagination
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 1, Page: 2, Period: db.PeriodAllTime})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 1, Page: 2, Period: db.PeriodAllTime, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 1)
 	require.Len(t, resp.Items[0].Track.Artists, 1)
@@ -89,61 +89,61 @@ func TestGetListens(t *testing.T) {
 	assert.Equal(t, "Artist Three", resp.Items[0].Track.Artists[0].Name)
 
 	// Test page out of range
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 10, Page: 10, Period: db.PeriodAllTime})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 10, Page: 10, Period: db.PeriodAllTime, UserID: 1})
 	require.NoError(t, err)
 	assert.Empty(t, resp.Items)
 	assert.False(t, resp.HasNextPage)
 
 	// Test invalid inputs
-	_, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: -1, Page: 0})
+	_, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: -1, Page: 0, UserID: 1})
 	assert.Error(t, err)
 
-	_, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 1, Page: -1})
+	_, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Limit: 1, Page: -1, UserID: 1})
 	assert.Error(t, err)
 
 	// Test specify period
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodDay})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodDay, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 0) // empty
 	assert.Equal(t, int64(0), resp.TotalCount)
 	// should default to PeriodDay
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 0) // empty
 	assert.Equal(t, int64(0), resp.TotalCount)
 
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodWeek})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodWeek, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 1)
 	assert.Equal(t, int64(1), resp.TotalCount)
 
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodMonth})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodMonth, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 3)
 	assert.Equal(t, int64(3), resp.TotalCount)
 
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodYear})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodYear, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 6)
 	assert.Equal(t, int64(6), resp.TotalCount)
 
 	// Test filter by artists, releases, and tracks
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, ArtistID: 1})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, ArtistID: 1, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 4)
 	assert.Equal(t, int64(4), resp.TotalCount)
 
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, AlbumID: 2})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, AlbumID: 2, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 3)
 	assert.Equal(t, int64(3), resp.TotalCount)
 
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, TrackID: 3})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, TrackID: 3, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 2)
 	assert.Equal(t, int64(2), resp.TotalCount)
 	// when both artistID and albumID are specified, artist id is ignored
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, AlbumID: 2, ArtistID: 1})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, AlbumID: 2, ArtistID: 1, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 3)
 	assert.Equal(t, int64(3), resp.TotalCount)
@@ -152,18 +152,18 @@ func TestGetListens(t *testing.T) {
 
 	testDataAbsoluteListenTimes(t)
 
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Year: 2023})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Year: 2023, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 4)
 	assert.Equal(t, int64(4), resp.TotalCount)
 
-	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Month: 6, Year: 2024})
+	resp, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Month: 6, Year: 2024, UserID: 1})
 	require.NoError(t, err)
 	require.Len(t, resp.Items, 3)
 	assert.Equal(t, int64(3), resp.TotalCount)
 
 	// invalid, year required with month
-	_, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Month: 10})
+	_, err = store.GetListensPaginated(ctx, db.GetItemsOpts{Month: 10, UserID: 1})
 	require.Error(t, err)
 
 }
@@ -206,7 +206,7 @@ func TestDeleteListen(t *testing.T) {
 		VALUES (1, 1, to_timestamp(1749464138.0))`)
 	require.NoError(t, err)
 
-	err = store.DeleteListen(ctx, 1, time.Unix(1749464138, 0))
+	err = store.DeleteListen(ctx, 1, time.Unix(1749464138, 0), 1)
 	require.NoError(t, err)
 
 	exists, err := store.RowExists(ctx, `
@@ -217,3 +217,56 @@ func TestDeleteListen(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, exists, "expected listen to be deleted")
 }
+
+func TestSaveListenDetails(t *testing.T) {
+	testDataForListens(t)
+	ctx := context.Background()
+
+	listenedAt := time.Now().Add(-1 * time.Minute).Truncate(time.Second)
+	err := store.SaveListen(ctx, db.SaveListenOpts{
+		TrackID:                 1,
+		Time:                    listenedAt,
+		UserID:                  1,
+		Client:                  "Navidrome",
+		DurationMs:              183000,
+		MediaPlayer:             "Feishin",
+		SubmissionClientVersion: "0.12.3",
+		Tags:                    []string{"rock", "live"},
+		OriginURL:               "https://example.com/track/1",
+	})
+	require.NoError(t, err)
+	// details are optional
+	err = store.SaveListen(ctx, db.SaveListenOpts{
+		TrackID: 2,
+		Time:    listenedAt.Add(-1 * time.Minute),
+		UserID:  1,
+	})
+	require.NoError(t, err)
+
+	resp, err := store.GetListensPaginated(ctx, db.GetItemsOpts{Period: db.PeriodAllTime, Page: 1, UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 2)
+	listen := resp.Items[0]
+	assert.EqualValues(t, 1, listen.Track.ID)
+	assert.Equal(t, "Navidrome", listen.Client)
+	assert.EqualValues(t, 183000, listen.DurationMs)
+	assert.Equal(t, "Feishin", listen.MediaPlayer)
+	assert.Equal(t, "0.12.3", listen.SubmissionClientVersion)
+	assert.Equal(t, []string{"rock", "live"}, listen.Tags)
+	assert.Equal(t, "https://example.com/track/1", listen.OriginURL)
+
+	listen = resp.Items[1]
+	assert.Empty(t, listen.Client)
+	assert.Zero(t, listen.DurationMs)
+	assert.Empty(t, listen.MediaPlayer)
+	assert.Nil(t, listen.Tags)
+
+	// the same details are exported
+	items, err := store.GetExportPage(ctx, db.GetExportPageOpts{UserID: 1, ListenedAt: time.Unix(0, 0), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.EqualValues(t, 183000, items[1].DurationMs)
+	assert.Equal(t, "Feishin", items[1].MediaPlayer)
+	assert.Equal(t, []string{"rock", "live"}, items[1].Tags)
+	assert.Equal(t, "https://example.com/track/1", items[1].OriginURL)
+}
@@ -0,0 +1,61 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// GetMbzCacheEntry returns the cached response for the entity, or nil when
+// there is none or it expired.
+func (d *Psql) GetMbzCacheEntry(ctx context.Context, kind string, id uuid.UUID) (*models.MbzCacheEntry, error) {
+	row, err := d.q.GetMbzCacheEntry(ctx, repository.GetMbzCacheEntryParams{
+		Kind:          kind,
+		MusicBrainzID: id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("GetMbzCacheEntry: %w", err)
+	}
+	return &models.MbzCacheEntry{
+		Kind:      row.Kind,
+		MbzID:     row.MusicBrainzID,
+		Body:      row.Body,
+		FetchedAt: row.FetchedAt,
+		ExpiresAt: row.ExpiresAt,
+	}, nil
+}
+
+func (d *Psql) SaveMbzCacheEntry(ctx context.Context, e *models.MbzCacheEntry) error {
+	err := d.q.SaveMbzCacheEntry(ctx, repository.SaveMbzCacheEntryParams{
+		Kind:          e.Kind,
+		MusicBrainzID: e.MbzID,
+		Body:          e.Body,
+		ExpiresAt:     e.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("SaveMbzCacheEntry: %w", err)
+	}
+	return nil
+}
+
+// PurgeMbzCache deletes cached MusicBrainz responses, returning how many were
+// deleted.
+func (d *Psql) PurgeMbzCache(ctx context.Context, opts db.PurgeMbzCacheOpts) (int64, error) {
+	n, err := d.q.DeleteMbzCacheEntries(ctx, repository.DeleteMbzCacheEntriesParams{
+		Kind:        pgtype.Text{String: opts.Kind, Valid: opts.Kind != ""},
+		ExpiredOnly: opts.ExpiredOnly,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("PurgeMbzCache: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,59 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMbzCache(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, store.Exec(ctx, `TRUNCATE mbz_cache`))
+	defer store.Exec(ctx, `TRUNCATE mbz_cache`)
+
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	missing := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	body := []byte(`{"id": "00000000-0000-0000-0000-000000000001", "name": "ATARASHII GAKKO!"}`)
+
+	entry, err := store.GetMbzCacheEntry(ctx, "artist", id)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	require.NoError(t, store.SaveMbzCacheEntry(ctx, &models.MbzCacheEntry{
+		Kind: "artist", MbzID: id, Body: body, ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	require.NoError(t, store.SaveMbzCacheEntry(ctx, &models.MbzCacheEntry{
+		Kind: "artist", MbzID: missing, ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	require.NoError(t, store.SaveMbzCacheEntry(ctx, &models.MbzCacheEntry{
+		Kind: "release", MbzID: id, Body: []byte(`{}`), ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+
+	entry, err = store.GetMbzCacheEntry(ctx, "artist", id)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.JSONEq(t, string(body), string(entry.Body))
+	entry, err = store.GetMbzCacheEntry(ctx, "artist", missing)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Nil(t, entry.Body, "expected not found responses to have no body")
+	entry, err = store.GetMbzCacheEntry(ctx, "release", id)
+	require.NoError(t, err)
+	assert.Nil(t, entry, "expected expired responses to be left out")
+
+	n, err := store.PurgeMbzCache(ctx, db.PurgeMbzCacheOpts{ExpiredOnly: true})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	n, err = store.PurgeMbzCache(ctx, db.PurgeMbzCacheOpts{Kind: "release"})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = store.PurgeMbzCache(ctx, db.PurgeMbzCacheOpts{Kind: "artist"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+}
@@ -0,0 +1,31 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+)
+
+// GetTracksToSearch returns up to limit tracks without a MusicBrainz ID that
+// were never looked up with a MusicBrainz search.
+func (d *Psql) GetTracksToSearch(ctx context.Context, limit int32) ([]int32, error) {
+	ids, err := d.q.GetTracksToSearch(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetTracksToSearch: %w", err)
+	}
+	return ids, nil
+}
+
+// SaveMbzSearchAttempt records that the track was looked up with a
+// MusicBrainz search, and the score of the best result.
+func (d *Psql) SaveMbzSearchAttempt(ctx context.Context, trackId int32, score float64) error {
+	err := d.q.SaveMbzSearchAttempt(ctx, repository.SaveMbzSearchAttemptParams{
+		TrackID: trackId,
+		Score:   float32(score),
+	})
+	if err != nil {
+		return fmt.Errorf("SaveMbzSearchAttempt: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
 	"github.com/jackc/pgx/v5"
 )
@@ -19,6 +20,10 @@ func (d *Psql) MergeTracks(ctx context.Context, fromId, toId int32) error {
 	}
 	defer tx.Rollback(ctx)
 	qtx := d.q.WithTx(tx)
+	err = recordAudit(ctx, qtx, models.AuditMergeTracks, 0, map[string]any{"from_id": fromId, "to_id": toId}, auditEntities{Tracks: []int32{fromId, toId}})
+	if err != nil {
+		return fmt.Errorf("MergeTracks: %w", err)
+	}
 	from, err := qtx.GetTrack(ctx, fromId)
 	if err != nil {
 		return fmt.Errorf("MergeTracks: GetTrack: %w", err)
@@ -34,6 +39,13 @@ func (d *Psql) MergeTracks(ctx context.Context, fromId, toId int32) error {
 	if err != nil {
 		return fmt.Errorf("MergeTracks: UpdateTrackIdForListens: %w", err)
 	}
+	err = qtx.UpdateTrackIdForRejectedListens(ctx, repository.UpdateTrackIdForRejectedListensParams{
+		TrackID:   fromId,
+		TrackID_2: toId,
+	})
+	if err != nil {
+		return fmt.Errorf("MergeTracks: UpdateTrackIdForRejectedListens: %w", err)
+	}
 	if from.ReleaseID != to.ReleaseID {
 		// tracks are from different releases, track artist should be associated with to.release
 		artists, err := qtx.GetTrackArtists(ctx, fromId)
@@ -68,6 +80,10 @@ func (d *Psql) MergeAlbums(ctx context.Context, fromId, toId int32, replaceImage
 	}
 	defer tx.Rollback(ctx)
 	qtx := d.q.WithTx(tx)
+	err = recordAudit(ctx, qtx, models.AuditMergeAlbums, 0, map[string]any{"from_id": fromId, "to_id": toId, "replace_image": replaceImage}, auditEntities{Releases: []int32{fromId, toId}})
+	if err != nil {
+		return fmt.Errorf("MergeAlbums: %w", err)
+	}
 
 	fromArtists, err := qtx.GetReleaseArtists(ctx, fromId)
 	if err != nil {
@@ -124,6 +140,10 @@ func (d *Psql) MergeArtists(ctx context.Context, fromId, toId int32, replaceImag
 	}
 	defer tx.Rollback(ctx)
 	qtx := d.q.WithTx(tx)
+	err = recordAudit(ctx, qtx, models.AuditMergeArtists, 0, map[string]any{"from_id": fromId, "to_id": toId, "replace_image": replaceImage}, auditEntities{Artists: []int32{fromId, toId}})
+	if err != nil {
+		return fmt.Errorf("MergeArtists: %w", err)
+	}
 	err = qtx.DeleteConflictingArtistTracks(ctx, repository.DeleteConflictingArtistTracksParams{
 		ArtistID:   fromId,
 		ArtistID_2: toId,
@@ -0,0 +1,196 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+func (d *Psql) GetDuplicateCandidates(ctx context.Context, kind models.MergeSuggestionKind) ([]db.DuplicateCandidate, error) {
+	var candidates []db.DuplicateCandidate
+	switch kind {
+	case models.MergeSuggestionArtist:
+		rows, err := d.q.GetArtistDuplicateCandidates(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("GetDuplicateCandidates: GetArtistDuplicateCandidates: %w", err)
+		}
+		for _, row := range rows {
+			candidates = append(candidates, db.DuplicateCandidate{IDA: row.IDA, IDB: row.IDB, Similarity: row.Similarity})
+		}
+	case models.MergeSuggestionAlbum:
+		rows, err := d.q.GetReleaseDuplicateCandidates(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("GetDuplicateCandidates: GetReleaseDuplicateCandidates: %w", err)
+		}
+		for _, row := range rows {
+			candidates = append(candidates, db.DuplicateCandidate{IDA: row.IDA, IDB: row.IDB, Similarity: row.Similarity})
+		}
+	case models.MergeSuggestionTrack:
+		rows, err := d.q.GetTrackDuplicateCandidates(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("GetDuplicateCandidates: GetTrackDuplicateCandidates: %w", err)
+		}
+		for _, row := range rows {
+			candidates = append(candidates, db.DuplicateCandidate{IDA: row.IDA, IDB: row.IDB, Similarity: row.Similarity})
+		}
+	default:
+		return nil, fmt.Errorf("GetDuplicateCandidates: unknown kind '%s'", kind)
+	}
+	return candidates, nil
+}
+
+func (d *Psql) GetDuplicateDetails(ctx context.Context, kind models.MergeSuggestionKind, id int32) (*db.DuplicateDetails, error) {
+	switch kind {
+	case models.MergeSuggestionArtist:
+		row, err := d.q.GetArtistDuplicateDetails(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("GetDuplicateDetails: GetArtistDuplicateDetails: %w", err)
+		}
+		return &db.DuplicateDetails{
+			ID:          row.ID,
+			Name:        row.Name,
+			MbzID:       row.MusicBrainzID,
+			Aliases:     row.Aliases,
+			TrackTitles: row.TrackTitles,
+			ListenCount: row.ListenCount,
+		}, nil
+	case models.MergeSuggestionAlbum:
+		row, err := d.q.GetReleaseDuplicateDetails(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("GetDuplicateDetails: GetReleaseDuplicateDetails: %w", err)
+		}
+		return &db.DuplicateDetails{
+			ID:          row.ID,
+			Name:        row.Title,
+			MbzID:       row.MusicBrainzID,
+			Aliases:     row.Aliases,
+			TrackTitles: row.TrackTitles,
+			ListenCount: row.ListenCount,
+		}, nil
+	case models.MergeSuggestionTrack:
+		row, err := d.q.GetTrackDuplicateDetails(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("GetDuplicateDetails: GetTrackDuplicateDetails: %w", err)
+		}
+		return &db.DuplicateDetails{
+			ID:          row.ID,
+			Name:        row.Title,
+			MbzID:       row.MusicBrainzID,
+			Aliases:     row.Aliases,
+			Duration:    row.Duration,
+			ListenCount: row.ListenCount,
+		}, nil
+	default:
+		return nil, fmt.Errorf("GetDuplicateDetails: unknown kind '%s'", kind)
+	}
+}
+
+// SaveMergeSuggestion adds the suggestion or updates the one for the same
+// pair. Dismissed suggestions are left as they are.
+func (d *Psql) SaveMergeSuggestion(ctx context.Context, opts db.SaveMergeSuggestionOpts) error {
+	if opts.Reasons == nil {
+		opts.Reasons = []string{}
+	}
+	err := d.q.UpsertMergeSuggestion(ctx, repository.UpsertMergeSuggestionParams{
+		Kind:      string(opts.Kind),
+		FromID:    opts.FromID,
+		ToID:      opts.ToID,
+		Score:     opts.Score,
+		Reasons:   opts.Reasons,
+		UpdatedAt: opts.Time,
+	})
+	if err != nil {
+		return fmt.Errorf("SaveMergeSuggestion: %w", err)
+	}
+	return nil
+}
+
+// PruneMergeSuggestions removes suggestions of the kind that were not saved
+// again since before, unless they were dismissed, and suggestions of any kind
+// for entities that no longer exist.
+func (d *Psql) PruneMergeSuggestions(ctx context.Context, kind models.MergeSuggestionKind, before time.Time) error {
+	err := d.q.DeleteOutdatedMergeSuggestions(ctx, repository.DeleteOutdatedMergeSuggestionsParams{
+		Kind:      string(kind),
+		UpdatedAt: before,
+	})
+	if err != nil {
+		return fmt.Errorf("PruneMergeSuggestions: DeleteOutdatedMergeSuggestions: %w", err)
+	}
+	if err := d.q.DeleteOrphanedMergeSuggestions(ctx); err != nil {
+		return fmt.Errorf("PruneMergeSuggestions: DeleteOrphanedMergeSuggestions: %w", err)
+	}
+	return nil
+}
+
+// GetMergeSuggestions returns the suggestions with the highest scores first.
+func (d *Psql) GetMergeSuggestions(ctx context.Context, opts db.GetMergeSuggestionsOpts) ([]*models.MergeSuggestion, error) {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultItemsPerPage
+	}
+	rows, err := d.q.GetMergeSuggestions(ctx, repository.GetMergeSuggestionsParams{
+		Dismissed:  opts.Dismissed,
+		Kind:       string(opts.Kind),
+		LimitCount: int32(opts.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetMergeSuggestions: %w", err)
+	}
+	suggestions := make([]*models.MergeSuggestion, len(rows))
+	for i, row := range rows {
+		suggestions[i] = &models.MergeSuggestion{
+			ID:        row.ID,
+			Kind:      models.MergeSuggestionKind(row.Kind),
+			From:      models.MergeSuggestionItem{ID: row.FromID, Name: row.FromName},
+			To:        models.MergeSuggestionItem{ID: row.ToID, Name: row.ToName},
+			Score:     row.Score,
+			Reasons:   row.Reasons,
+			Dismissed: row.Dismissed,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return suggestions, nil
+}
+
+// GetMergeSuggestion returns nil, nil when there is no such suggestion. The
+// names of From and To are not filled in.
+func (d *Psql) GetMergeSuggestion(ctx context.Context, id int64) (*models.MergeSuggestion, error) {
+	row, err := d.q.GetMergeSuggestion(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("GetMergeSuggestion: %w", err)
+	}
+	return &models.MergeSuggestion{
+		ID:        row.ID,
+		Kind:      models.MergeSuggestionKind(row.Kind),
+		From:      models.MergeSuggestionItem{ID: row.FromID},
+		To:        models.MergeSuggestionItem{ID: row.ToID},
+		Score:     row.Score,
+		Reasons:   row.Reasons,
+		Dismissed: row.Dismissed,
+		CreatedAt: row.CreatedAt,
+	}, nil
+}
+
+// DismissMergeSuggestion keeps the suggestion from being listed or suggested
+// again. Returns false when there is no such suggestion.
+func (d *Psql) DismissMergeSuggestion(ctx context.Context, id int64) (bool, error) {
+	n, err := d.q.DismissMergeSuggestion(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("DismissMergeSuggestion: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (d *Psql) DeleteMergeSuggestion(ctx context.Context, id int64) error {
+	if err := d.q.DeleteMergeSuggestion(ctx, id); err != nil {
+		return fmt.Errorf("DeleteMergeSuggestion: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDataForMergeSuggestions(t *testing.T) {
+	truncateTestData(t)
+	ctx := context.Background()
+	require.NoError(t, store.Exec(ctx, `TRUNCATE merge_suggestions RESTART IDENTITY`))
+
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO artists (musicbrainz_id) VALUES (NULL), (NULL), (NULL)`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO artist_aliases (artist_id, alias, source, is_primary)
+			VALUES (1, 'Beyoncé', 'Testing', true),
+				   (2, 'Beyonce', 'Testing', true),
+				   (3, 'Radiohead', 'Testing', true)`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO releases (musicbrainz_id) VALUES (NULL), (NULL), (NULL)`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO release_aliases (release_id, alias, source, is_primary)
+			VALUES (1, 'Lemonade', 'Testing', true),
+				   (2, 'Lemonade.', 'Testing', true),
+				   (3, 'Kid A', 'Testing', true)`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO artist_releases (artist_id, release_id) VALUES (1, 1), (1, 2), (3, 3)`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO tracks (musicbrainz_id, release_id, duration) VALUES (NULL, 1, 200), (NULL, 2, 201), (NULL, 3, 230)`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO track_aliases (track_id, alias, source, is_primary)
+			VALUES (1, 'Formation', 'Testing', true),
+				   (2, 'Formation!', 'Testing', true),
+				   (3, 'Idioteque', 'Testing', true)`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO artist_tracks (artist_id, track_id) VALUES (1, 1), (1, 2), (3, 3)`))
+}
+
+func TestDuplicateCandidates(t *testing.T) {
+	testDataForMergeSuggestions(t)
+	ctx := context.Background()
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 1, Time: time.Now().Add(-time.Hour), UserID: 1}))
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 2, Time: time.Now().Add(-2 * time.Hour), UserID: 1}))
+
+	for _, kind := range []models.MergeSuggestionKind{
+		models.MergeSuggestionArtist,
+		models.MergeSuggestionAlbum,
+		models.MergeSuggestionTrack,
+	} {
+		candidates, err := store.GetDuplicateCandidates(ctx, kind)
+		require.NoError(t, err)
+		require.Len(t, candidates, 1, kind)
+		assert.EqualValues(t, 1, candidates[0].IDA)
+		assert.EqualValues(t, 2, candidates[0].IDB)
+		assert.Greater(t, candidates[0].Similarity, 0.3)
+	}
+
+	artist, err := store.GetDuplicateDetails(ctx, models.MergeSuggestionArtist, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Beyoncé", artist.Name)
+	assert.Equal(t, []string{"Beyoncé"}, artist.Aliases)
+	assert.ElementsMatch(t, []string{"Formation", "Formation!"}, artist.TrackTitles)
+	assert.EqualValues(t, 2, artist.ListenCount)
+	assert.Nil(t, artist.MbzID)
+
+	album, err := store.GetDuplicateDetails(ctx, models.MergeSuggestionAlbum, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Lemonade.", album.Name)
+	assert.Equal(t, []string{"Formation!"}, album.TrackTitles)
+	assert.EqualValues(t, 1, album.ListenCount)
+
+	track, err := store.GetDuplicateDetails(ctx, models.MergeSuggestionTrack, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "Idioteque", track.Name)
+	assert.EqualValues(t, 230, track.Duration)
+	assert.Empty(t, track.TrackTitles)
+	assert.Zero(t, track.ListenCount)
+}
+
+func TestMergeSuggestions(t *testing.T) {
+	testDataForMergeSuggestions(t)
+	ctx := context.Background()
+
+	first := time.Now().Add(-time.Hour).Truncate(time.Second)
+	save := func(kind models.MergeSuggestionKind, from, to int32, score float64, at time.Time) {
+		require.NoError(t, store.SaveMergeSuggestion(ctx, db.SaveMergeSuggestionOpts{
+			Kind:    kind,
+			FromID:  from,
+			ToID:    to,
+			Score:   score,
+			Reasons: []string{"similar_name"},
+			Time:    at,
+		}))
+	}
+	save(models.MergeSuggestionArtist, 2, 1, 0.9, first)
+	save(models.MergeSuggestionAlbum, 2, 1, 0.8, first)
+	save(models.MergeSuggestionTrack, 2, 1, 0.75, first)
+
+	suggestions, err := store.GetMergeSuggestions(ctx, db.GetMergeSuggestionsOpts{})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 3)
+	assert.Equal(t, models.MergeSuggestionArtist, suggestions[0].Kind)
+	assert.Equal(t, "Beyonce", suggestions[0].From.Name)
+	assert.Equal(t, "Beyoncé", suggestions[0].To.Name)
+	assert.Equal(t, []string{"similar_name"}, suggestions[0].Reasons)
+	assert.Equal(t, "Lemonade.", suggestions[1].From.Name)
+	assert.Equal(t, "Formation", suggestions[2].To.Name)
+
+	// saving the same pair the other way around updates the suggestion
+	save(models.MergeSuggestionAlbum, 1, 2, 0.95, first)
+	suggestions, err = store.GetMergeSuggestions(ctx, db.GetMergeSuggestionsOpts{Kind: models.MergeSuggestionAlbum})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.EqualValues(t, 1, suggestions[0].From.ID)
+	assert.EqualValues(t, 2, suggestions[0].To.ID)
+	assert.Equal(t, 0.95, suggestions[0].Score)
+
+	// dismissed suggestions are remembered
+	artistSuggestion, err := store.GetMergeSuggestions(ctx, db.GetMergeSuggestionsOpts{Kind: models.MergeSuggestionArtist})
+	require.NoError(t, err)
+	require.Len(t, artistSuggestion, 1)
+	found, err := store.DismissMergeSuggestion(ctx, artistSuggestion[0].ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	found, err = store.DismissMergeSuggestion(ctx, 12345)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	second := first.Add(30 * time.Minute)
+	save(models.MergeSuggestionArtist, 2, 1, 0.99, second)
+	suggestions, err = store.GetMergeSuggestions(ctx, db.GetMergeSuggestionsOpts{Kind: models.MergeSuggestionArtist})
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+	suggestions, err = store.GetMergeSuggestions(ctx, db.GetMergeSuggestionsOpts{Dismissed: true})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.True(t, suggestions[0].Dismissed)
+	assert.Equal(t, 0.9, suggestions[0].Score)
+
+	// suggestions not saved again are pruned, except dismissed ones
+	require.NoError(t, store.PruneMergeSuggestions(ctx, models.MergeSuggestionArtist, second.Add(time.Minute)))
+	require.NoError(t, store.PruneMergeSuggestions(ctx, models.MergeSuggestionAlbum, second))
+	suggestions, err = store.GetMergeSuggestions(ctx, db.GetMergeSuggestionsOpts{})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, models.MergeSuggestionTrack, suggestions[0].Kind)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM merge_suggestions WHERE dismissed`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	s, err := store.GetMergeSuggestion(ctx, suggestions[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	assert.Equal(t, models.MergeSuggestionTrack, s.Kind)
+	assert.EqualValues(t, 2, s.From.ID)
+	assert.EqualValues(t, 1, s.To.ID)
+
+	// suggestions for deleted entities are hidden, then pruned
+	require.NoError(t, store.DeleteTrack(ctx, 2))
+	suggestions, err = store.GetMergeSuggestions(ctx, db.GetMergeSuggestionsOpts{})
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+	require.NoError(t, store.PruneMergeSuggestions(ctx, models.MergeSuggestionTrack, first))
+	s, err = store.GetMergeSuggestion(ctx, s.ID)
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
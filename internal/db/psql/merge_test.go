@@ -119,6 +119,38 @@ func TestMergeTracks_SameRelease(t *testing.T) {
 	truncateTestData(t)
 }
 
+func TestMergeTracks_RejectedListens(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+
+	// track 4 only has a rejected listen
+	err := store.Exec(ctx, `DELETE FROM listens WHERE track_id = 4`)
+	require.NoError(t, err)
+	err = store.Exec(ctx,
+		`INSERT INTO rejected_listens (user_id, track_id, listened_at, reason, payload)
+			VALUES (1, 1, NOW() - INTERVAL '4 days', 'too_short', '{}'),
+				   (1, 4, NOW() - INTERVAL '5 days', 'too_short', '{}')`)
+	require.NoError(t, err)
+
+	err = store.MergeTracks(ctx, 1, 2)
+	require.NoError(t, err)
+
+	// rejected listens move with the listens of the merged track
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM rejected_listens WHERE track_id = 2`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected rejected listens to be merged into Track 2")
+
+	// tracks with only rejected listens are not cleaned up as orphans
+	exists, err := store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM tracks WHERE id = $1)`, 4)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected track with rejected listens to be kept")
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM rejected_listens WHERE track_id = 4`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "expected rejected listen to be kept")
+
+	truncateTestData(t)
+}
+
 func TestMergeAlbums(t *testing.T) {
 	ctx := context.Background()
 	setupTestDataForMerge(t)
@@ -0,0 +1,119 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func rewriteRuleFromRow(row repository.RewriteRule) *models.RewriteRule {
+	return &models.RewriteRule{
+		ID:          row.ID,
+		UserID:      row.UserID,
+		Position:    row.Position,
+		Enabled:     row.Enabled,
+		Field:       models.RewriteField(row.Field),
+		Pattern:     row.Pattern,
+		Action:      models.RewriteAction(row.Action),
+		Target:      models.RewriteField(row.Target.String),
+		Replacement: row.Replacement,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+func rewriteRulesFromRows(rows []repository.RewriteRule) []*models.RewriteRule {
+	rules := make([]*models.RewriteRule, len(rows))
+	for i, row := range rows {
+		rules[i] = rewriteRuleFromRow(row)
+	}
+	return rules
+}
+
+func rewriteTarget(target models.RewriteField) pgtype.Text {
+	return pgtype.Text{String: string(target), Valid: target != ""}
+}
+
+// GetRewriteRule returns nil, nil when the user has no such rule.
+func (d *Psql) GetRewriteRule(ctx context.Context, id, userId int32) (*models.RewriteRule, error) {
+	row, err := d.q.GetRewriteRule(ctx, repository.GetRewriteRuleParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("GetRewriteRule: %w", err)
+	}
+	return rewriteRuleFromRow(row), nil
+}
+
+func (d *Psql) GetRewriteRulesByUserID(ctx context.Context, userId int32) ([]*models.RewriteRule, error) {
+	rows, err := d.q.GetRewriteRulesByUserID(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("GetRewriteRulesByUserID: %w", err)
+	}
+	return rewriteRulesFromRows(rows), nil
+}
+
+func (d *Psql) GetEnabledRewriteRulesByUserID(ctx context.Context, userId int32) ([]*models.RewriteRule, error) {
+	rows, err := d.q.GetEnabledRewriteRulesByUserID(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("GetEnabledRewriteRulesByUserID: %w", err)
+	}
+	return rewriteRulesFromRows(rows), nil
+}
+
+func (d *Psql) SaveRewriteRule(ctx context.Context, opts db.SaveRewriteRuleOpts) (*models.RewriteRule, error) {
+	row, err := d.q.InsertRewriteRule(ctx, repository.InsertRewriteRuleParams{
+		UserID:      opts.UserID,
+		Position:    opts.Position,
+		Enabled:     opts.Enabled,
+		Field:       string(opts.Field),
+		Pattern:     opts.Pattern,
+		Action:      string(opts.Action),
+		Target:      rewriteTarget(opts.Target),
+		Replacement: opts.Replacement,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SaveRewriteRule: %w", err)
+	}
+	return rewriteRuleFromRow(row), nil
+}
+
+// UpdateRewriteRule returns false when the user has no such rule.
+func (d *Psql) UpdateRewriteRule(ctx context.Context, opts db.UpdateRewriteRuleOpts) (bool, error) {
+	n, err := d.q.UpdateRewriteRule(ctx, repository.UpdateRewriteRuleParams{
+		ID:          opts.ID,
+		UserID:      opts.UserID,
+		Position:    opts.Position,
+		Enabled:     opts.Enabled,
+		Field:       string(opts.Field),
+		Pattern:     opts.Pattern,
+		Action:      string(opts.Action),
+		Target:      rewriteTarget(opts.Target),
+		Replacement: opts.Replacement,
+	})
+	if err != nil {
+		return false, fmt.Errorf("UpdateRewriteRule: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeleteRewriteRule returns false when the user has no such rule.
+func (d *Psql) DeleteRewriteRule(ctx context.Context, id, userId int32) (bool, error) {
+	n, err := d.q.DeleteRewriteRule(ctx, repository.DeleteRewriteRuleParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if err != nil {
+		return false, fmt.Errorf("DeleteRewriteRule: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,93 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func truncateTestDataForRewriteRules(t *testing.T) {
+	err := store.Exec(context.Background(),
+		`TRUNCATE rewrite_rules RESTART IDENTITY`,
+	)
+	require.NoError(t, err)
+}
+
+func TestRewriteRules(t *testing.T) {
+	ctx := context.Background()
+	truncateTestDataForRewriteRules(t)
+	defer truncateTestDataForRewriteRules(t)
+
+	second, err := store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		UserID:   1,
+		Position: 2,
+		Enabled:  true,
+		Field:    models.RewriteFieldClient,
+		Pattern:  "^tape-deck$",
+		Action:   models.RewriteActionRemap,
+		Target:   models.RewriteFieldAlbum,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.RewriteFieldAlbum, second.Target)
+
+	first, err := store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		UserID:   1,
+		Position: 1,
+		Enabled:  false,
+		Field:    models.RewriteFieldArtist,
+		Pattern:  " - Topic$",
+		Action:   models.RewriteActionReplace,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, first.Target)
+
+	// rules are ordered by position
+	rules, err := store.GetRewriteRulesByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, first.ID, rules[0].ID)
+	assert.Equal(t, second.ID, rules[1].ID)
+
+	rules, err = store.GetEnabledRewriteRulesByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, second.ID, rules[0].ID)
+
+	ok, err := store.UpdateRewriteRule(ctx, db.UpdateRewriteRuleOpts{
+		ID:          first.ID,
+		UserID:      1,
+		Position:    3,
+		Enabled:     true,
+		Field:       models.RewriteFieldTitle,
+		Pattern:     `\(Official Video\)`,
+		Action:      models.RewriteActionReplace,
+		Replacement: "",
+	})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	rule, err := store.GetRewriteRule(ctx, first.ID, 1)
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	assert.Equal(t, models.RewriteFieldTitle, rule.Field)
+	assert.True(t, rule.Enabled)
+	assert.EqualValues(t, 3, rule.Position)
+
+	// rules belong to their user
+	rule, err = store.GetRewriteRule(ctx, first.ID, 2)
+	require.NoError(t, err)
+	assert.Nil(t, rule)
+	ok, err = store.DeleteRewriteRule(ctx, first.ID, 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.DeleteRewriteRule(ctx, first.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	rules, err = store.GetRewriteRulesByUserID(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, rules, 1)
+}
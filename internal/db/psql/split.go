@@ -0,0 +1,432 @@
+package psql
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// SplitArtist creates a new artist and moves the given aliases and tracks of
+// the artist to it. The new artist is added to the albums of the moved tracks
+// and, unless the tracks stay credited to both, the artist is taken off the
+// albums it has no tracks left on. Nothing is saved on a dry run.
+func (d *Psql) SplitArtist(ctx context.Context, opts db.SplitArtistOpts) (*db.SplitResult, error) {
+	l := logger.FromContext(ctx)
+	name := strings.TrimSpace(opts.Name)
+	aliases := unique(opts.Aliases)
+	trackIds := unique(opts.TrackIDs)
+	if name == "" {
+		return nil, fmt.Errorf("SplitArtist: %w: a name is required", db.ErrInvalidSplit)
+	}
+	if len(aliases) == 0 && len(trackIds) == 0 {
+		return nil, fmt.Errorf("SplitArtist: %w: no aliases or tracks to move", db.ErrInvalidSplit)
+	}
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return nil, fmt.Errorf("SplitArtist: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+
+	snapshot, err := qtx.GetCatalogSnapshot(ctx, repository.GetCatalogSnapshotParams{
+		ReleaseIds: []int32{},
+		ArtistIds:  []int32{opts.ID},
+		TrackIds:   []int32{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: GetCatalogSnapshot: %w", err)
+	}
+	artist, err := qtx.InsertArtist(ctx, repository.InsertArtistParams{})
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: InsertArtist: %w", err)
+	}
+	moved, err := qtx.MoveArtistAliases(ctx, repository.MoveArtistAliasesParams{
+		NewArtistID: artist.ID,
+		ArtistID:    opts.ID,
+		Aliases:     aliases,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: MoveArtistAliases: %w", err)
+	}
+	if moved != int64(len(aliases)) {
+		return nil, fmt.Errorf("SplitArtist: %w: only aliases of the artist other than its primary one can be moved", db.ErrInvalidSplit)
+	}
+	err = qtx.InsertArtistAlias(ctx, repository.InsertArtistAliasParams{
+		ArtistID:  artist.ID,
+		Alias:     name,
+		Source:    "Manual",
+		IsPrimary: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: InsertArtistAlias: %w", err)
+	}
+	err = qtx.SetArtistAliasPrimaryStatus(ctx, repository.SetArtistAliasPrimaryStatusParams{
+		IsPrimary: true,
+		ArtistID:  artist.ID,
+		Alias:     name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: SetArtistAliasPrimaryStatus: %w", err)
+	}
+
+	if opts.KeepCredits {
+		moved, err = qtx.CopyArtistTracks(ctx, repository.CopyArtistTracksParams{
+			NewArtistID: artist.ID,
+			ArtistID:    opts.ID,
+			TrackIds:    trackIds,
+		})
+	} else {
+		moved, err = qtx.MoveArtistTracks(ctx, repository.MoveArtistTracksParams{
+			NewArtistID: artist.ID,
+			ArtistID:    opts.ID,
+			TrackIds:    trackIds,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: %w", err)
+	}
+	if moved != int64(len(trackIds)) {
+		return nil, fmt.Errorf("SplitArtist: %w: only tracks of the artist can be moved", db.ErrInvalidSplit)
+	}
+	// the new artist is added to the albums before the artist is taken off, so
+	// that no album is left without artists and deleted
+	albumIds, err := qtx.AssociateArtistToTrackReleases(ctx, repository.AssociateArtistToTrackReleasesParams{
+		ArtistID: artist.ID,
+		TrackIds: trackIds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: AssociateArtistToTrackReleases: %w", err)
+	}
+	if !opts.KeepCredits {
+		err = qtx.DeleteArtistReleasesWithoutTracks(ctx, repository.DeleteArtistReleasesWithoutTracksParams{
+			ArtistID: opts.ID,
+			TrackIds: trackIds,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("SplitArtist: DeleteArtistReleasesWithoutTracks: %w", err)
+		}
+	}
+	listens, err := qtx.CountListensOfTracks(ctx, trackIds)
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: CountListensOfTracks: %w", err)
+	}
+
+	slices.Sort(albumIds)
+	result := &db.SplitResult{
+		DryRun:   opts.DryRun,
+		Name:     name,
+		Aliases:  aliases,
+		TrackIDs: trackIds,
+		AlbumIDs: albumIds,
+		Listens:  listens,
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	err = insertAuditEntry(ctx, qtx, models.AuditSplitArtist, 0, map[string]any{
+		"id":           opts.ID,
+		"name":         name,
+		"aliases":      aliases,
+		"track_ids":    trackIds,
+		"keep_credits": opts.KeepCredits,
+		"new_id":       artist.ID,
+	}, snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("SplitArtist: %w", err)
+	}
+	l.Info().Msgf("Split artist %d off artist %d", artist.ID, opts.ID)
+	result.NewID = artist.ID
+	return result, nil
+}
+
+// SplitAlbum creates a new album and moves the given aliases and tracks of the
+// album to it. The artists of the moved tracks are added to the new album, and
+// taken off the album when they have no tracks left on it. At least one track
+// has to stay on the album. Nothing is saved on a dry run.
+func (d *Psql) SplitAlbum(ctx context.Context, opts db.SplitAlbumOpts) (*db.SplitResult, error) {
+	l := logger.FromContext(ctx)
+	title := strings.TrimSpace(opts.Title)
+	aliases := unique(opts.Aliases)
+	trackIds := unique(opts.TrackIDs)
+	if title == "" {
+		return nil, fmt.Errorf("SplitAlbum: %w: a title is required", db.ErrInvalidSplit)
+	}
+	if len(trackIds) == 0 {
+		return nil, fmt.Errorf("SplitAlbum: %w: no tracks to move", db.ErrInvalidSplit)
+	}
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return nil, fmt.Errorf("SplitAlbum: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+
+	snapshot, err := qtx.GetCatalogSnapshot(ctx, repository.GetCatalogSnapshotParams{
+		ReleaseIds: []int32{opts.ID},
+		ArtistIds:  []int32{},
+		TrackIds:   []int32{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: GetCatalogSnapshot: %w", err)
+	}
+	from, err := qtx.GetRelease(ctx, opts.ID)
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: GetRelease: %w", err)
+	}
+	release, err := qtx.InsertRelease(ctx, repository.InsertReleaseParams{
+		VariousArtists: from.VariousArtists,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: InsertRelease: %w", err)
+	}
+	moved, err := qtx.MoveReleaseAliases(ctx, repository.MoveReleaseAliasesParams{
+		NewReleaseID: release.ID,
+		ReleaseID:    opts.ID,
+		Aliases:      aliases,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: MoveReleaseAliases: %w", err)
+	}
+	if moved != int64(len(aliases)) {
+		return nil, fmt.Errorf("SplitAlbum: %w: only aliases of the album other than its primary one can be moved", db.ErrInvalidSplit)
+	}
+	err = qtx.InsertReleaseAlias(ctx, repository.InsertReleaseAliasParams{
+		ReleaseID: release.ID,
+		Alias:     title,
+		Source:    "Manual",
+		IsPrimary: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: InsertReleaseAlias: %w", err)
+	}
+	err = qtx.SetReleaseAliasPrimaryStatus(ctx, repository.SetReleaseAliasPrimaryStatusParams{
+		IsPrimary: true,
+		ReleaseID: release.ID,
+		Alias:     title,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: SetReleaseAliasPrimaryStatus: %w", err)
+	}
+
+	moved, err = qtx.MoveTracksToRelease(ctx, repository.MoveTracksToReleaseParams{
+		NewReleaseID: release.ID,
+		ReleaseID:    opts.ID,
+		TrackIds:     trackIds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: MoveTracksToRelease: %w", err)
+	}
+	if moved != int64(len(trackIds)) {
+		return nil, fmt.Errorf("SplitAlbum: %w: only tracks on the album can be moved", db.ErrInvalidSplit)
+	}
+	left, err := qtx.CountTracksOfRelease(ctx, opts.ID)
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: CountTracksOfRelease: %w", err)
+	}
+	if left == 0 {
+		return nil, fmt.Errorf("SplitAlbum: %w: at least one track has to stay on the album", db.ErrInvalidSplit)
+	}
+	err = qtx.AssociateTrackArtistsToRelease(ctx, repository.AssociateTrackArtistsToReleaseParams{
+		NewReleaseID: release.ID,
+		ReleaseID:    opts.ID,
+		TrackIds:     trackIds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: AssociateTrackArtistsToRelease: %w", err)
+	}
+	err = qtx.DeleteReleaseArtistsWithoutTracks(ctx, repository.DeleteReleaseArtistsWithoutTracksParams{
+		ReleaseID: opts.ID,
+		TrackIds:  trackIds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: DeleteReleaseArtistsWithoutTracks: %w", err)
+	}
+	listens, err := qtx.CountListensOfTracks(ctx, trackIds)
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: CountListensOfTracks: %w", err)
+	}
+
+	result := &db.SplitResult{
+		DryRun:   opts.DryRun,
+		Name:     title,
+		Aliases:  aliases,
+		TrackIDs: trackIds,
+		Listens:  listens,
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	err = insertAuditEntry(ctx, qtx, models.AuditSplitAlbum, 0, map[string]any{
+		"id":        opts.ID,
+		"title":     title,
+		"aliases":   aliases,
+		"track_ids": trackIds,
+		"new_id":    release.ID,
+	}, snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("SplitAlbum: %w", err)
+	}
+	l.Info().Msgf("Split album %d off album %d", release.ID, opts.ID)
+	result.NewID = release.ID
+	return result, nil
+}
+
+// SplitTrack creates a new track on the same album and with the same artists,
+// and moves the given aliases and the user's filtered listens and rejected
+// listens of the track to it. Nothing is saved on a dry run.
+func (d *Psql) SplitTrack(ctx context.Context, opts db.SplitTrackOpts) (*db.SplitResult, error) {
+	l := logger.FromContext(ctx)
+	title := strings.TrimSpace(opts.Title)
+	aliases := unique(opts.Aliases)
+	if title == "" {
+		return nil, fmt.Errorf("SplitTrack: %w: a title is required", db.ErrInvalidSplit)
+	}
+	if opts.UserID == 0 {
+		return nil, fmt.Errorf("SplitTrack: required parameter 'UserID' missing")
+	}
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return nil, fmt.Errorf("SplitTrack: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+
+	snapshot, err := qtx.GetCatalogSnapshot(ctx, repository.GetCatalogSnapshotParams{
+		ReleaseIds: []int32{},
+		ArtistIds:  []int32{},
+		TrackIds:   []int32{opts.ID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: GetCatalogSnapshot: %w", err)
+	}
+	from, err := qtx.GetTrack(ctx, opts.ID)
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: GetTrack: %w", err)
+	}
+	track, err := qtx.InsertTrack(ctx, repository.InsertTrackParams{
+		ReleaseID: from.ReleaseID,
+		Duration:  from.Duration,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: InsertTrack: %w", err)
+	}
+	err = qtx.CopyTrackArtists(ctx, repository.CopyTrackArtistsParams{
+		NewTrackID: track.ID,
+		TrackID:    opts.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: CopyTrackArtists: %w", err)
+	}
+	moved, err := qtx.MoveTrackAliases(ctx, repository.MoveTrackAliasesParams{
+		NewTrackID: track.ID,
+		TrackID:    opts.ID,
+		Aliases:    aliases,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: MoveTrackAliases: %w", err)
+	}
+	if moved != int64(len(aliases)) {
+		return nil, fmt.Errorf("SplitTrack: %w: only aliases of the track other than its primary one can be moved", db.ErrInvalidSplit)
+	}
+	err = qtx.InsertTrackAlias(ctx, repository.InsertTrackAliasParams{
+		TrackID:   track.ID,
+		Alias:     title,
+		Source:    "Manual",
+		IsPrimary: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: InsertTrackAlias: %w", err)
+	}
+	err = qtx.SetTrackAliasPrimaryStatus(ctx, repository.SetTrackAliasPrimaryStatusParams{
+		IsPrimary: true,
+		TrackID:   track.ID,
+		Alias:     title,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: SetTrackAliasPrimaryStatus: %w", err)
+	}
+
+	periodStart, periodEnd := listenFilterRange(opts.ListenFilterOpts)
+	listens, err := qtx.UpdateTrackIdForFilteredListens(ctx, repository.UpdateTrackIdForFilteredListensParams{
+		NewTrackID:  track.ID,
+		TrackID:     opts.ID,
+		UserID:      opts.UserID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Client:      opts.Client,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: UpdateTrackIdForFilteredListens: %w", err)
+	}
+	if listens == 0 {
+		return nil, fmt.Errorf("SplitTrack: %w: no listens to move", db.ErrInvalidSplit)
+	}
+	err = qtx.UpdateTrackIdForFilteredRejectedListens(ctx, repository.UpdateTrackIdForFilteredRejectedListensParams{
+		NewTrackID:  track.ID,
+		TrackID:     opts.ID,
+		UserID:      opts.UserID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Client:      opts.Client,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: UpdateTrackIdForFilteredRejectedListens: %w", err)
+	}
+
+	result := &db.SplitResult{
+		DryRun:   opts.DryRun,
+		Name:     title,
+		Aliases:  aliases,
+		TrackIDs: []int32{},
+		Listens:  listens,
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	err = insertAuditEntry(ctx, qtx, models.AuditSplitTrack, opts.UserID, map[string]any{
+		"id":      opts.ID,
+		"title":   title,
+		"aliases": aliases,
+		"from":    periodStart.Unix(),
+		"to":      periodEnd.Unix(),
+		"client":  opts.Client,
+		"new_id":  track.ID,
+	}, snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("SplitTrack: %w", err)
+	}
+	l.Info().Msgf("Split track %d off track %d", track.ID, opts.ID)
+	result.NewID = track.ID
+	return result, nil
+}
+
+// unique returns the values sorted and without duplicates, never nil.
+func unique[T cmp.Ordered](values []T) []T {
+	out := slices.Clone(values)
+	slices.Sort(out)
+	out = slices.Compact(out)
+	if out == nil {
+		return []T{}
+	}
+	return out
+}
@@ -0,0 +1,212 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func undoLatestAuditEntry(t *testing.T, action models.AuditAction) {
+	ctx := context.Background()
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, action, entries[0].Action)
+	found, err := store.UndoAuditEntry(ctx, entries[0].ID, 1)
+	require.NoError(t, err)
+	require.True(t, found)
+}
+
+func TestSplitArtist(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO artist_aliases (artist_id, alias, source, is_primary) VALUES (1, 'Artist Three', 'Testing', false)`))
+
+	opts := db.SplitArtistOpts{
+		ID:       1,
+		Name:     "Artist Three",
+		Aliases:  []string{"Artist Three"},
+		TrackIDs: []int32{4},
+		DryRun:   true,
+	}
+	result, err := store.SplitArtist(ctx, opts)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Zero(t, result.NewID)
+	assert.Equal(t, []int32{3}, result.AlbumIDs)
+	assert.EqualValues(t, 1, result.Listens)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM artists`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "expected nothing to be saved on a dry run")
+
+	opts.DryRun = false
+	result, err = store.SplitArtist(ctx, opts)
+	require.NoError(t, err)
+	require.NotZero(t, result.NewID)
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: result.NewID})
+	require.NoError(t, err)
+	assert.Equal(t, "Artist Three", artist.Name)
+	exists, err := store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_tracks WHERE artist_id = $1 AND track_id = 4)`, result.NewID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = $1 AND release_id = 3)`, result.NewID)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected new artist to be added to the album of the moved track")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 3)`)
+	require.NoError(t, err)
+	assert.False(t, exists, "expected artist to be taken off the album it has no tracks left on")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 1)`)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	undoLatestAuditEntry(t, models.AuditSplitArtist)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artists`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "expected split off artist to be deleted")
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artist_aliases WHERE artist_id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "expected moved alias to be back")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 3)`)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_tracks WHERE artist_id = 1 AND track_id = 4)`)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// tracks of two artists saved as one stay credited to both
+	result, err = store.SplitArtist(ctx, db.SplitArtistOpts{ID: 1, Name: "Artist Four", TrackIDs: []int32{1}, KeepCredits: true})
+	require.NoError(t, err)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM artist_tracks WHERE track_id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 1)`)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	truncateTestData(t)
+}
+
+func TestSplitArtist_Invalid(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+
+	_, err := store.SplitArtist(ctx, db.SplitArtistOpts{ID: 1, Name: "Artist Three"})
+	assert.ErrorIs(t, err, db.ErrInvalidSplit, "expected error when nothing is moved")
+	_, err = store.SplitArtist(ctx, db.SplitArtistOpts{ID: 1, Name: "Artist Three", TrackIDs: []int32{2}})
+	assert.ErrorIs(t, err, db.ErrInvalidSplit, "expected error when moving a track of another artist")
+	_, err = store.SplitArtist(ctx, db.SplitArtistOpts{ID: 1, Name: "Artist Three", Aliases: []string{"Artist One"}})
+	assert.ErrorIs(t, err, db.ErrInvalidSplit, "expected error when moving the primary alias")
+	_, err = store.SplitArtist(ctx, db.SplitArtistOpts{ID: 1, Name: " ", TrackIDs: []int32{4}})
+	assert.ErrorIs(t, err, db.ErrInvalidSplit, "expected error without a name")
+
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM artists`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	truncateTestData(t)
+}
+
+func TestSplitAlbum(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+
+	result, err := store.SplitAlbum(ctx, db.SplitAlbumOpts{ID: 1, Title: "Album One (Disc 2)", TrackIDs: []int32{3}})
+	require.NoError(t, err)
+	require.NotZero(t, result.NewID)
+	assert.EqualValues(t, 1, result.Listens)
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: result.NewID})
+	require.NoError(t, err)
+	assert.Equal(t, "Album One (Disc 2)", album.Title)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM tracks WHERE release_id = $1`, result.NewID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	exists, err := store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = $1)`, result.NewID)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected artist of the moved track to be added to the new album")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 1)`)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected artist to stay on the album it still has tracks on")
+
+	// the last track cannot be moved
+	_, err = store.SplitAlbum(ctx, db.SplitAlbumOpts{ID: 1, Title: "Album One (Disc 3)", TrackIDs: []int32{1}})
+	assert.ErrorIs(t, err, db.ErrInvalidSplit)
+	_, err = store.SplitAlbum(ctx, db.SplitAlbumOpts{ID: 1, Title: "Album One (Disc 3)", TrackIDs: []int32{2}})
+	assert.ErrorIs(t, err, db.ErrInvalidSplit)
+
+	undoLatestAuditEntry(t, models.AuditSplitAlbum)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM tracks WHERE release_id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM releases`)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	truncateTestData(t)
+}
+
+func TestSplitTrack(t *testing.T) {
+	ctx := context.Background()
+	setupTestDataForMerge(t)
+	require.NoError(t, store.Exec(ctx, `TRUNCATE audit_log RESTART IDENTITY`))
+
+	listenedAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 1, Time: listenedAt, UserID: 1, Client: "Navidrome"}))
+
+	_, err := store.SplitTrack(ctx, db.SplitTrackOpts{
+		ListenFilterOpts: db.ListenFilterOpts{UserID: 1, Client: "Jellyfin"},
+		ID:               1,
+		Title:            "Track One (Live)",
+	})
+	assert.ErrorIs(t, err, db.ErrInvalidSplit, "expected error when no listens are moved")
+
+	result, err := store.SplitTrack(ctx, db.SplitTrackOpts{
+		ListenFilterOpts: db.ListenFilterOpts{UserID: 1, Client: "Navidrome"},
+		ID:               1,
+		Title:            "Track One (Live)",
+	})
+	require.NoError(t, err)
+	require.NotZero(t, result.NewID)
+	assert.EqualValues(t, 1, result.Listens)
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: result.NewID})
+	require.NoError(t, err)
+	assert.Equal(t, "Track One (Live)", track.Title)
+	assert.EqualValues(t, 1, track.AlbumID)
+	require.Len(t, track.Artists, 1)
+	assert.EqualValues(t, 1, track.Artists[0].ID)
+	count, err := store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// only the user whose listens were moved sees and undoes the split
+	require.NoError(t, store.Exec(ctx,
+		`INSERT INTO users (username, password) VALUES ('second_user', DECODE('abc123', 'hex'))`))
+	entries, err := store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 2})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	entries, err = store.GetAuditLog(ctx, db.GetAuditLogOpts{UserID: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	found, err := store.UndoAuditEntry(ctx, entries[0].ID, 2)
+	require.NoError(t, err)
+	assert.False(t, found)
+	require.NoError(t, store.Exec(ctx, `DELETE FROM users WHERE id <> 1`))
+	require.NoError(t, store.Exec(ctx, `ALTER SEQUENCE users_id_seq RESTART WITH 2`))
+
+	undoLatestAuditEntry(t, models.AuditSplitTrack)
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "expected moved listen to be back")
+	count, err = store.Count(ctx, `SELECT COUNT(*) FROM tracks`)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	truncateTestData(t)
+}
@@ -42,6 +42,7 @@ func (d *Psql) GetTopAlbumsPaginated(ctx context.Context, opts db.GetItemsOpts)
 			Offset:       int32(offset),
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetTopAlbumsPaginated: GetTopReleasesFromArtist: %w", err)
@@ -81,6 +82,7 @@ func (d *Psql) GetTopAlbumsPaginated(ctx context.Context, opts db.GetItemsOpts)
 			ListenedAt_2: t2,
 			Limit:        int32(opts.Limit),
 			Offset:       int32(offset),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetTopAlbumsPaginated: GetTopReleasesPaginated: %w", err)
@@ -112,6 +114,7 @@ func (d *Psql) GetTopAlbumsPaginated(ctx context.Context, opts db.GetItemsOpts)
 		count, err = d.q.CountTopReleases(ctx, repository.CountTopReleasesParams{
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetTopAlbumsPaginated: CountTopReleases: %w", err)
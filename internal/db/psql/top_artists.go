@@ -34,6 +34,7 @@ func (d *Psql) GetTopArtistsPaginated(ctx context.Context, opts db.GetItemsOpts)
 		ListenedAt_2: t2,
 		Limit:        int32(opts.Limit),
 		Offset:       int32(offset),
+		UserID:       opts.UserID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetTopArtistsPaginated: GetTopArtistsPaginated: %w", err)
@@ -56,6 +57,7 @@ func (d *Psql) GetTopArtistsPaginated(ctx context.Context, opts db.GetItemsOpts)
 	count, err := d.q.CountTopArtists(ctx, repository.CountTopArtistsParams{
 		ListenedAt:   t1,
 		ListenedAt_2: t2,
+		UserID:       opts.UserID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetTopArtistsPaginated: CountTopArtists: %w", err)
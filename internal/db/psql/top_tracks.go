@@ -39,6 +39,7 @@ func (d *Psql) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts)
 			Limit:        int32(opts.Limit),
 			Offset:       int32(offset),
 			ReleaseID:    int32(opts.AlbumID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetTopTracksPaginated: GetTopTracksInReleasePaginated: %w", err)
@@ -66,6 +67,7 @@ func (d *Psql) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts)
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
 			ReleaseID:    int32(opts.AlbumID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, err
@@ -79,6 +81,7 @@ func (d *Psql) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts)
 			Limit:        int32(opts.Limit),
 			Offset:       int32(offset),
 			ArtistID:     int32(opts.ArtistID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetTopTracksPaginated: GetTopTracksByArtistPaginated: %w", err)
@@ -106,6 +109,7 @@ func (d *Psql) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts)
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
 			ArtistID:     int32(opts.ArtistID),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetTopTracksPaginated: CountTopTracksByArtist: %w", err)
@@ -118,6 +122,7 @@ func (d *Psql) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts)
 			ListenedAt_2: t2,
 			Limit:        int32(opts.Limit),
 			Offset:       int32(offset),
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetTopTracksPaginated: GetTopTracksPaginated: %w", err)
@@ -144,6 +149,7 @@ func (d *Psql) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts)
 		count, err = d.q.CountTopTracks(ctx, repository.CountTopTracksParams{
 			ListenedAt:   t1,
 			ListenedAt_2: t2,
+			UserID:       opts.UserID,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("GetTopTracksPaginated: CountTopTracks: %w", err)
@@ -90,6 +90,7 @@ func (d *Psql) GetTrack(ctx context.Context, opts db.GetTrackOpts) (*models.Trac
 		ListenedAt:   time.Unix(0, 0),
 		ListenedAt_2: time.Now(),
 		TrackID:      track.ID,
+		UserID:       opts.UserID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetTrack: CountListensFromTrack: %w", err)
@@ -98,12 +99,16 @@ func (d *Psql) GetTrack(ctx context.Context, opts db.GetTrackOpts) (*models.Trac
 	seconds, err := d.CountTimeListenedToItem(ctx, db.TimeListenedOpts{
 		Period:  db.PeriodAllTime,
 		TrackID: track.ID,
+		UserID:  opts.UserID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetTrack: CountTimeListenedToItem: %w", err)
 	}
 
-	firstListen, err := d.q.GetFirstListenFromTrack(ctx, track.ID)
+	firstListen, err := d.q.GetFirstListenFromTrack(ctx, repository.GetFirstListenFromTrackParams{
+		ID:     track.ID,
+		UserID: opts.UserID,
+	})
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("GetAlbum: GetFirstListenFromRelease: %w", err)
 	}
@@ -182,6 +187,11 @@ func (d *Psql) SaveTrack(ctx context.Context, opts db.SaveTrackOpts) (*models.Tr
 	}, nil
 }
 
+// UpdateTrack changes the fields of the track that are set. When the track is
+// moved to another album or its artists are replaced, the artists are added to
+// the album the track is on and taken off the albums they have no tracks left
+// on, and the album the track leaves is deleted once it has no tracks. Such
+// edits are recorded in the audit log so that they can be undone.
 func (d *Psql) UpdateTrack(ctx context.Context, opts db.UpdateTrackOpts) error {
 	l := logger.FromContext(ctx)
 	if opts.ID == 0 {
@@ -194,9 +204,31 @@ func (d *Psql) UpdateTrack(ctx context.Context, opts db.UpdateTrackOpts) error {
 	}
 	defer tx.Rollback(ctx)
 	qtx := d.q.WithTx(tx)
+	if opts.AlbumID != 0 || len(opts.ArtistIDs) > 0 {
+		// the new artists are recorded too, so that the credits they are
+		// given are taken back on undo
+		err = recordAudit(ctx, qtx, models.AuditEditTrack, 0, map[string]any{
+			"track_id":   opts.ID,
+			"album_id":   opts.AlbumID,
+			"artist_ids": opts.ArtistIDs,
+		}, auditEntities{
+			Artists:  opts.ArtistIDs,
+			Releases: []int32{opts.AlbumID},
+			Tracks:   []int32{opts.ID},
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: %w", err)
+		}
+	}
 	if opts.MusicBrainzID != uuid.Nil {
 		l.Debug().Msgf("Updating MusicBrainz ID for track %d", opts.ID)
-		err := qtx.UpdateTrackMbzID(ctx, repository.UpdateTrackMbzIDParams{
+		existing, err := qtx.GetTrackByMbzID(ctx, &opts.MusicBrainzID)
+		if err == nil && existing.ID != opts.ID {
+			return fmt.Errorf("UpdateTrack: %w: track %d", db.ErrMbzIDInUse, existing.ID)
+		} else if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("UpdateTrack: GetTrackByMbzID: %w", err)
+		}
+		err = qtx.UpdateTrackMbzID(ctx, repository.UpdateTrackMbzIDParams{
 			ID:            opts.ID,
 			MusicBrainzID: &opts.MusicBrainzID,
 		})
@@ -214,6 +246,108 @@ func (d *Psql) UpdateTrack(ctx context.Context, opts db.UpdateTrackOpts) error {
 			return fmt.Errorf("UpdateTrack: UpdateTrackDuration: %w", err)
 		}
 	}
+	if opts.AlbumID == 0 && len(opts.ArtistIDs) == 0 {
+		return tx.Commit(ctx)
+	}
+
+	track, err := qtx.GetTrack(ctx, opts.ID)
+	if err != nil {
+		return fmt.Errorf("UpdateTrack: GetTrack: %w", err)
+	}
+	releaseId := track.ReleaseID
+	artistIds, err := qtx.GetTrackArtistIDs(ctx, opts.ID)
+	if err != nil {
+		return fmt.Errorf("UpdateTrack: GetTrackArtistIDs: %w", err)
+	}
+	if opts.AlbumID != 0 && opts.AlbumID != releaseId {
+		l.Debug().Msgf("Moving track %d to release %d", opts.ID, opts.AlbumID)
+		if _, err := qtx.GetRelease(ctx, opts.AlbumID); errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("UpdateTrack: %w: album %d does not exist", db.ErrInvalidEdit, opts.AlbumID)
+		} else if err != nil {
+			return fmt.Errorf("UpdateTrack: GetRelease: %w", err)
+		}
+		err = qtx.UpdateTrackRelease(ctx, repository.UpdateTrackReleaseParams{
+			ID:        opts.ID,
+			ReleaseID: opts.AlbumID,
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: UpdateTrackRelease: %w", err)
+		}
+		err = qtx.AssociateTrackArtistsToRelease(ctx, repository.AssociateTrackArtistsToReleaseParams{
+			NewReleaseID: opts.AlbumID,
+			ReleaseID:    releaseId,
+			TrackIds:     []int32{opts.ID},
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: AssociateTrackArtistsToRelease: %w", err)
+		}
+		remaining, err := qtx.CountTracksOfRelease(ctx, releaseId)
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: CountTracksOfRelease: %w", err)
+		}
+		if remaining == 0 {
+			l.Debug().Msgf("Deleting release %d that has no tracks left", releaseId)
+			err = qtx.DeleteRelease(ctx, releaseId)
+		} else {
+			err = qtx.DeleteUncreditedReleaseArtists(ctx, repository.DeleteUncreditedReleaseArtistsParams{
+				ReleaseID: releaseId,
+				ArtistIds: artistIds,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: %w", err)
+		}
+		releaseId = opts.AlbumID
+	}
+	if len(opts.ArtistIDs) > 0 {
+		l.Debug().Msgf("Replacing artists of track %d", opts.ID)
+		newIds := unique(opts.ArtistIDs)
+		count, err := qtx.CountArtistsByIDs(ctx, newIds)
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: CountArtistsByIDs: %w", err)
+		}
+		if count != int64(len(newIds)) {
+			return fmt.Errorf("UpdateTrack: %w: not all artists exist", db.ErrInvalidEdit)
+		}
+		// artists are added before the old ones are removed, so that the album
+		// is never left without artists and deleted
+		err = qtx.AddArtistsToTrack(ctx, repository.AddArtistsToTrackParams{
+			TrackID:   opts.ID,
+			ArtistIds: newIds,
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: AddArtistsToTrack: %w", err)
+		}
+		err = qtx.DeleteTrackArtistsExcept(ctx, repository.DeleteTrackArtistsExceptParams{
+			TrackID:   opts.ID,
+			ArtistIds: newIds,
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: DeleteTrackArtistsExcept: %w", err)
+		}
+		err = qtx.EnsureTrackPrimaryArtist(ctx, repository.EnsureTrackPrimaryArtistParams{
+			TrackID:  opts.ID,
+			ArtistID: opts.ArtistIDs[0],
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: EnsureTrackPrimaryArtist: %w", err)
+		}
+		err = qtx.AssociateTrackArtistsToRelease(ctx, repository.AssociateTrackArtistsToReleaseParams{
+			NewReleaseID: releaseId,
+			ReleaseID:    releaseId,
+			TrackIds:     []int32{opts.ID},
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: AssociateTrackArtistsToRelease: %w", err)
+		}
+		err = qtx.DeleteUncreditedReleaseArtists(ctx, repository.DeleteUncreditedReleaseArtistsParams{
+			ReleaseID: releaseId,
+			ArtistIds: artistIds,
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateTrack: DeleteUncreditedReleaseArtists: %w", err)
+		}
+	}
 	return tx.Commit(ctx)
 }
 
@@ -254,8 +388,25 @@ func (d *Psql) SaveTrackAliases(ctx context.Context, id int32, aliases []string,
 	return tx.Commit(ctx)
 }
 
+// DeleteTrack deletes the track along with what depends on it, recording it in the
+// audit log first.
 func (d *Psql) DeleteTrack(ctx context.Context, id int32) error {
-	return d.q.DeleteTrack(ctx, id)
+	l := logger.FromContext(ctx)
+	tx, err := d.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		l.Err(err).Msg("Failed to begin transaction")
+		return fmt.Errorf("DeleteTrack: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := d.q.WithTx(tx)
+	err = recordAudit(ctx, qtx, models.AuditDeleteTrack, 0, map[string]any{"id": id}, auditEntities{Tracks: []int32{id}})
+	if err != nil {
+		return fmt.Errorf("DeleteTrack: %w", err)
+	}
+	if err := qtx.DeleteTrack(ctx, id); err != nil {
+		return fmt.Errorf("DeleteTrack: %w", err)
+	}
+	return tx.Commit(ctx)
 }
 
 func (d *Psql) DeleteTrackAlias(ctx context.Context, id int32, alias string) error {
@@ -74,7 +74,7 @@ func TestGetTrack(t *testing.T) {
 	ctx := context.Background()
 
 	// Test GetTrack by ID
-	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, int32(1), track.ID)
 	assert.Equal(t, "Track One", track.Title)
@@ -82,7 +82,7 @@ func TestGetTrack(t *testing.T) {
 	assert.EqualValues(t, 100, track.TimeListened)
 
 	// Test GetTrack by MusicBrainzID
-	track, err = store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: uuid.MustParse("22222222-2222-2222-2222-222222222222")})
+	track, err = store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: uuid.MustParse("22222222-2222-2222-2222-222222222222"), UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, int32(2), track.ID)
 	assert.Equal(t, "Track Two", track.Title)
@@ -92,6 +92,7 @@ func TestGetTrack(t *testing.T) {
 	track, err = store.GetTrack(ctx, db.GetTrackOpts{
 		Title:     "Track One",
 		ArtistIDs: []int32{1},
+		UserID:    1,
 	})
 	require.NoError(t, err)
 	assert.Equal(t, int32(1), track.ID)
@@ -166,7 +167,7 @@ func TestUpdateTrack(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify the update
-	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	require.Equal(t, newMbzID, *track.MbzID)
 	require.EqualValues(t, newDuration, track.Duration)
@@ -188,6 +189,60 @@ func TestUpdateTrack(t *testing.T) {
 	assert.NoError(t, err) // No update should occur
 }
 
+func TestUpdateTrack_AlbumAndArtists(t *testing.T) {
+	setupTestDataForMerge(t)
+	ctx := context.Background()
+
+	// track 4 is the only track of album 3
+	err := store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 4, AlbumID: 2})
+	require.NoError(t, err)
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 4})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, track.AlbumID)
+	exists, err := store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 2)`)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected artist of the track to be added to the new album")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM releases WHERE id = 3)`)
+	require.NoError(t, err)
+	assert.False(t, exists, "expected album without tracks to be deleted")
+
+	err = store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 3, ArtistIDs: []int32{2}})
+	require.NoError(t, err)
+	track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 3})
+	require.NoError(t, err)
+	require.Len(t, track.Artists, 1)
+	assert.EqualValues(t, 2, track.Artists[0].ID)
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_tracks WHERE artist_id = 2 AND track_id = 3 AND is_primary)`)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected the new artist to become the primary artist")
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 2 AND release_id = 1)`)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 1)`)
+	require.NoError(t, err)
+	assert.True(t, exists, "expected artist to stay on the album it still has tracks on")
+
+	err = store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 1, ArtistIDs: []int32{2}})
+	require.NoError(t, err)
+	exists, err = store.RowExists(ctx, `SELECT EXISTS (SELECT 1 FROM artist_releases WHERE artist_id = 1 AND release_id = 1)`)
+	require.NoError(t, err)
+	assert.False(t, exists, "expected artist to be taken off the album it has no tracks left on")
+
+	err = store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 1, AlbumID: 99})
+	assert.ErrorIs(t, err, db.ErrInvalidEdit)
+	err = store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 1, ArtistIDs: []int32{2, 99}})
+	assert.ErrorIs(t, err, db.ErrInvalidEdit)
+
+	mbzID := uuid.MustParse("77777777-7777-7777-7777-777777777777")
+	require.NoError(t, store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 1, MusicBrainzID: mbzID}))
+	// setting the same id again is not a conflict
+	require.NoError(t, store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 1, MusicBrainzID: mbzID}))
+	err = store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: 2, MusicBrainzID: mbzID})
+	assert.ErrorIs(t, err, db.ErrMbzIDInUse)
+
+	truncateTestData(t)
+}
+
 func TestTrackAliases(t *testing.T) {
 	testDataForTracks(t)
 	ctx := context.Background()
@@ -200,7 +255,7 @@ func TestTrackAliases(t *testing.T) {
 
 	err = store.SetPrimaryTrackAlias(ctx, 1, "Alias One")
 	require.NoError(t, err)
-	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, "Alias One", track.Title)
 
@@ -210,7 +265,7 @@ func TestTrackAliases(t *testing.T) {
 	// Ensure primary alias cannot be deleted
 	err = store.DeleteTrackAlias(ctx, track.ID, "Alias One")
 	require.NoError(t, err) // shouldn't error when nothing is deleted
-	track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 1, UserID: 1})
 	require.NoError(t, err)
 	assert.Equal(t, "Alias One", track.Title)
 
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"time"
 
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
@@ -15,11 +16,83 @@ const (
 	InformationSourceUserProvided InformationSource = "User"
 )
 
+// ErrNotUndoable is returned when undoing an audit entry that was already
+// undone, or that later entries still depend on
+var ErrNotUndoable = errors.New("only the latest operation that was not undone can be undone")
+
+// ErrUndoConflict is returned when undoing an audit entry would overwrite
+// changes made to its artists, albums or tracks since
+var ErrUndoConflict = errors.New("the operation cannot be undone, what it changed was changed again since")
+
+// ErrAuditSnapshotTooLarge is returned when an operation could change too many
+// rows to be recorded in the audit log, and is not made
+var ErrAuditSnapshotTooLarge = errors.New("the operation changes too much to be recorded in the audit log")
+
+// ErrInvalidSplit is returned when a split would move nothing, or something
+// the artist, album or track does not have
+var ErrInvalidSplit = errors.New("invalid split")
+
+// ErrMbzIDInUse is returned when setting a MusicBrainz ID that another artist,
+// album or track already has
+var ErrMbzIDInUse = errors.New("musicbrainz id is already in use")
+
+// ErrInvalidEdit is returned when an edit refers to an album or artists that
+// do not exist
+var ErrInvalidEdit = errors.New("invalid edit")
+
 type ListenActivityItem struct {
 	Start   time.Time `json:"start_time"`
 	Listens int64     `json:"listens"`
 }
 
+type TrackListenCount struct {
+	TrackID int32
+	Listens int64
+}
+
+// a DuplicateCandidate is a pair of artists, albums or tracks with similar
+// names, IDA being the lower id
+type DuplicateCandidate struct {
+	IDA        int32
+	IDB        int32
+	Similarity float64
+}
+
+// DuplicateDetails is what duplicate candidates are compared by. TrackTitles
+// is empty for tracks and Duration is zero for artists and albums.
+type DuplicateDetails struct {
+	ID          int32
+	Name        string
+	MbzID       *uuid.UUID
+	Aliases     []string
+	TrackTitles []string
+	Duration    int32
+	ListenCount int64
+}
+
+// SplitResult is what a split moved, or would move on a dry run, to the new
+// artist, album or track.
+type SplitResult struct {
+	DryRun bool `json:"dry_run"`
+	// only set when not a dry run
+	NewID    int32    `json:"new_id,omitempty"`
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases"`
+	TrackIDs []int32  `json:"track_ids"`
+	// the albums an artist split off is added to
+	AlbumIDs []int32 `json:"album_ids,omitempty"`
+	Listens  int64   `json:"listens"`
+}
+
+// ArtistWithoutMbzID is an artist without a MusicBrainz ID, and the track of
+// theirs most likely to identify them: one with a MusicBrainz ID, or else one
+// that was never looked up with a MusicBrainz search. TrackID is zero when
+// there is none.
+type ArtistWithoutMbzID struct {
+	ArtistID int32
+	TrackID  int32
+}
+
 type PaginatedResponse[T any] struct {
 	Items        []T   `json:"items"`
 	TotalCount   int64 `json:"total_record_count"`
@@ -29,18 +102,23 @@ type PaginatedResponse[T any] struct {
 }
 
 type ExportItem struct {
-	ListenedAt         time.Time
-	UserID             int32
-	Client             *string
-	TrackID            int32
-	TrackMbid          *uuid.UUID
-	TrackDuration      int32
-	TrackAliases       []models.Alias
-	ReleaseID          int32
-	ReleaseMbid        *uuid.UUID
-	ReleaseImage       *uuid.UUID
-	ReleaseImageSource string
-	VariousArtists     bool
-	ReleaseAliases     []models.Alias
-	Artists            []models.ArtistWithFullAliases
+	ListenedAt              time.Time
+	UserID                  int32
+	Client                  *string
+	DurationMs              int32
+	MediaPlayer             string
+	SubmissionClientVersion string
+	Tags                    []string
+	OriginURL               string
+	TrackID                 int32
+	TrackMbid               *uuid.UUID
+	TrackDuration           int32
+	TrackAliases            []models.Alias
+	ReleaseID               int32
+	ReleaseMbid             *uuid.UUID
+	ReleaseImage            *uuid.UUID
+	ReleaseImageSource      string
+	VariousArtists          bool
+	ReleaseAliases          []models.Alias
+	Artists                 []models.ArtistWithFullAliases
 }
@@ -0,0 +1,218 @@
+// Package dedupe finds artists, albums and tracks that are likely duplicates
+// of each other and saves them as merge suggestions. Candidate pairs are those
+// with similar or equal normalized aliases, which are then scored by their
+// MusicBrainz IDs, shared tracks and durations. Dismissed suggestions are
+// remembered and never suggested again.
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+// the first scan waits this long after startup, to leave the database to
+// imports and ingestion first
+const startDelay = time.Minute
+
+var waker utils.Waker
+
+// Wake makes the analyzer scan for duplicates now instead of at the next
+// interval.
+func Wake() {
+	waker.Wake()
+}
+
+var running atomic.Bool
+
+// Running reports whether the analyzer was started.
+func Running() bool {
+	return running.Load()
+}
+
+type Analyzer struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start scans for duplicates in the background every interval, and whenever
+// woken up.
+func Start(ctx context.Context, store db.DB, interval time.Duration) *Analyzer {
+	ctx, cancel := context.WithCancel(ctx)
+	a := &Analyzer{cancel: cancel}
+	running.Store(true)
+	a.wg.Add(1)
+	go a.run(ctx, store, interval)
+	logger.FromContext(ctx).Info().Msgf("Dedupe: Scanning for duplicates every %v", interval)
+	return a
+}
+
+// Stop stops the analyzer, interrupting a scan in progress.
+func (a *Analyzer) Stop() {
+	a.cancel()
+	a.wg.Wait()
+	running.Store(false)
+}
+
+func (a *Analyzer) run(ctx context.Context, store db.DB, interval time.Duration) {
+	defer a.wg.Done()
+	l := logger.FromContext(ctx)
+	next := time.After(startDelay)
+	for {
+		wake := waker.C()
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-next:
+		}
+		n, err := Analyze(ctx, store)
+		if err != nil && ctx.Err() == nil {
+			l.Err(err).Msg("Dedupe: Failed to scan for duplicates")
+		} else if err == nil {
+			l.Info().Msgf("Dedupe: Found %d likely duplicates", n)
+		}
+		next = time.After(interval)
+	}
+}
+
+// Analyze scores every candidate pair of artists, albums and tracks and saves
+// those scoring at least MinScore as merge suggestions. Suggestions that no
+// longer score high enough are removed. Returns the number of suggestions
+// saved.
+func Analyze(ctx context.Context, store db.DB) (int, error) {
+	total := 0
+	for _, kind := range []models.MergeSuggestionKind{
+		models.MergeSuggestionArtist,
+		models.MergeSuggestionAlbum,
+		models.MergeSuggestionTrack,
+	} {
+		n, err := analyzeKind(ctx, store, kind)
+		if err != nil {
+			return total, fmt.Errorf("Analyze: %w", err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func analyzeKind(ctx context.Context, store db.DB, kind models.MergeSuggestionKind) (int, error) {
+	l := logger.FromContext(ctx)
+	// timestamps are stored with microsecond precision
+	start := time.Now().Truncate(time.Microsecond)
+
+	candidates, err := store.GetDuplicateCandidates(ctx, kind)
+	if err != nil {
+		return 0, fmt.Errorf("analyzeKind: %w", err)
+	}
+	l.Debug().Msgf("Dedupe: Scoring %d %s candidate pairs", len(candidates), kind)
+
+	details := make(map[int32]*db.DuplicateDetails)
+	getDetails := func(id int32) (*db.DuplicateDetails, error) {
+		if d, ok := details[id]; ok {
+			return d, nil
+		}
+		d, err := store.GetDuplicateDetails(ctx, kind, id)
+		if err != nil {
+			return nil, err
+		}
+		details[id] = d
+		return d, nil
+	}
+
+	saved := 0
+	for _, c := range candidates {
+		if err := ctx.Err(); err != nil {
+			return saved, fmt.Errorf("analyzeKind: %w", err)
+		}
+		a, err := getDetails(c.IDA)
+		if err != nil {
+			return saved, fmt.Errorf("analyzeKind: %w", err)
+		}
+		b, err := getDetails(c.IDB)
+		if err != nil {
+			return saved, fmt.Errorf("analyzeKind: %w", err)
+		}
+		score, reasons := Score(kind, a, b, c.Similarity)
+		if score < MinScore {
+			continue
+		}
+		from, to := mergeDirection(a, b)
+		err = store.SaveMergeSuggestion(ctx, db.SaveMergeSuggestionOpts{
+			Kind:    kind,
+			FromID:  from.ID,
+			ToID:    to.ID,
+			Score:   score,
+			Reasons: reasons,
+			Time:    start,
+		})
+		if err != nil {
+			return saved, fmt.Errorf("analyzeKind: %w", err)
+		}
+		saved++
+	}
+
+	if err := store.PruneMergeSuggestions(ctx, kind, start); err != nil {
+		return saved, fmt.Errorf("analyzeKind: %w", err)
+	}
+	return saved, nil
+}
+
+// ErrInvalidKeep is returned when accepting a suggestion asks to keep an
+// entity that is not part of it
+var ErrInvalidKeep = errors.New("the entity to keep must be one of the pair")
+
+type AcceptOpts struct {
+	ID int64
+	// the id of the entity to keep, the suggested one when zero
+	Keep         int32
+	ReplaceImage bool
+}
+
+// Accept merges the pair of the suggestion and removes the suggestion.
+// Returns false when there is no such suggestion.
+func Accept(ctx context.Context, store db.DB, opts AcceptOpts) (bool, error) {
+	s, err := store.GetMergeSuggestion(ctx, opts.ID)
+	if err != nil {
+		return false, fmt.Errorf("Accept: %w", err)
+	}
+	if s == nil {
+		return false, nil
+	}
+	from, to := s.From.ID, s.To.ID
+	switch opts.Keep {
+	case 0, to:
+	case from:
+		from, to = to, from
+	default:
+		return false, fmt.Errorf("Accept: %w", ErrInvalidKeep)
+	}
+
+	switch s.Kind {
+	case models.MergeSuggestionArtist:
+		err = store.MergeArtists(ctx, from, to, opts.ReplaceImage)
+	case models.MergeSuggestionAlbum:
+		err = store.MergeAlbums(ctx, from, to, opts.ReplaceImage)
+	case models.MergeSuggestionTrack:
+		err = store.MergeTracks(ctx, from, to)
+	default:
+		err = fmt.Errorf("unknown kind '%s'", s.Kind)
+	}
+	if err != nil {
+		return false, fmt.Errorf("Accept: %w", err)
+	}
+	logger.FromContext(ctx).Info().Msgf("Dedupe: Merged %s %d into %d", s.Kind, from, to)
+
+	if err := store.DeleteMergeSuggestion(ctx, s.ID); err != nil {
+		return false, fmt.Errorf("Accept: %w", err)
+	}
+	return true, nil
+}
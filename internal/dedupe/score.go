@@ -0,0 +1,169 @@
+package dedupe
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/gosimple/unidecode"
+)
+
+// reasons a pair was scored the way it was
+const (
+	ReasonSimilarName        = "similar_name"
+	ReasonSameNormalizedName = "same_normalized_name"
+	ReasonSameMbzID          = "same_musicbrainz_id"
+	ReasonDifferentMbzID     = "different_musicbrainz_id"
+	ReasonSharedTracks       = "shared_tracks"
+	ReasonSimilarDuration    = "similar_duration"
+	ReasonDifferentDuration  = "different_duration"
+)
+
+// MinScore is the lowest score a pair is suggested with
+const MinScore = 0.7
+
+const (
+	// the trigram similarity pg_trgm considers similar by default
+	similarNameThreshold = 0.3
+	// durations of duplicate tracks are at most this many seconds apart, and
+	// those of different ones usually more than differentDuration
+	similarDuration   = 3
+	differentDuration = 15
+	// see trackOverlap
+	sharedTracksForFullOverlap = 3
+)
+
+// Normalize lowercases the name and removes diacritics, punctuation and
+// spaces, so that "Beyoncé", "beyonce" and "BEYONCÉ!" are the same.
+func Normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r == '&':
+			b.WriteString("and")
+		case r < unicode.MaxASCII:
+			if unicode.IsLetter(r) || unicode.IsNumber(r) {
+				b.WriteRune(r)
+			}
+		case unicode.Is(unicode.Latin, r):
+			for _, c := range strings.ToLower(unidecode.Unidecode(string(r))) {
+				if unicode.IsLetter(c) || unicode.IsNumber(c) {
+					b.WriteRune(c)
+				}
+			}
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Score returns how likely a and b are duplicates of each other, from 0 to 1,
+// and why. similarity is the trigram similarity of their closest aliases.
+func Score(kind models.MergeSuggestionKind, a, b *db.DuplicateDetails, similarity float64) (float64, []string) {
+	score := similarity
+	var reasons []string
+	if similarity >= similarNameThreshold {
+		reasons = append(reasons, ReasonSimilarName)
+	}
+	if sameNormalizedName(a, b) {
+		score = max(score, 0.9)
+		reasons = append(reasons, ReasonSameNormalizedName)
+	}
+
+	if kind != models.MergeSuggestionTrack {
+		if overlap := trackOverlap(a.TrackTitles, b.TrackTitles); overlap > 0 {
+			score += (1 - score) * overlap
+			reasons = append(reasons, ReasonSharedTracks)
+		}
+	} else if a.Duration > 0 && b.Duration > 0 {
+		diff := a.Duration - b.Duration
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= similarDuration {
+			score += (1 - score) * 0.25
+			reasons = append(reasons, ReasonSimilarDuration)
+		} else if diff > differentDuration {
+			score *= 0.7
+			reasons = append(reasons, ReasonDifferentDuration)
+		}
+	}
+
+	if a.MbzID != nil && b.MbzID != nil {
+		if *a.MbzID == *b.MbzID {
+			score = 1
+			reasons = append(reasons, ReasonSameMbzID)
+		} else {
+			// both were identified by MusicBrainz as different entities
+			score *= 0.5
+			reasons = append(reasons, ReasonDifferentMbzID)
+		}
+	}
+	return min(score, 1), reasons
+}
+
+func sameNormalizedName(a, b *db.DuplicateDetails) bool {
+	names := make(map[string]bool)
+	for _, name := range append([]string{a.Name}, a.Aliases...) {
+		if n := Normalize(name); n != "" {
+			names[n] = true
+		}
+	}
+	for _, name := range append([]string{b.Name}, b.Aliases...) {
+		if names[Normalize(name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// trackOverlap is the fraction of the shorter track list that is also in the
+// other one, comparing normalized titles. Fewer than sharedTracksForFullOverlap
+// shared tracks count for less, so that a single common title like "Intro"
+// does not make a pair look like duplicates.
+func trackOverlap(a, b []string) float64 {
+	setA := normalizedSet(a)
+	setB := normalizedSet(b)
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range setA {
+		if setB[t] {
+			shared++
+		}
+	}
+	overlap := float64(shared) / float64(min(len(setA), len(setB)))
+	return overlap * min(1, float64(shared)/sharedTracksForFullOverlap)
+}
+
+func normalizedSet(titles []string) map[string]bool {
+	set := make(map[string]bool, len(titles))
+	for _, t := range titles {
+		if n := Normalize(t); n != "" {
+			set[n] = true
+		}
+	}
+	return set
+}
+
+// mergeDirection picks which of the pair is kept: the one identified by
+// MusicBrainz, then the one with more listens, then the older one.
+func mergeDirection(a, b *db.DuplicateDetails) (from, to *db.DuplicateDetails) {
+	switch {
+	case a.MbzID != nil && b.MbzID == nil:
+		return b, a
+	case b.MbzID != nil && a.MbzID == nil:
+		return a, b
+	case b.ListenCount > a.ListenCount:
+		return a, b
+	case a.ListenCount > b.ListenCount:
+		return b, a
+	case a.ID < b.ID:
+		return b, a
+	default:
+		return a, b
+	}
+}
@@ -0,0 +1,81 @@
+package dedupe_test
+
+import (
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/dedupe"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "beyonce", dedupe.Normalize("Beyoncé"))
+	assert.Equal(t, "beyonce", dedupe.Normalize("BEYONCÉ!"))
+	assert.Equal(t, "acdc", dedupe.Normalize("AC/DC"))
+	assert.Equal(t, "simonandgarfunkel", dedupe.Normalize("Simon & Garfunkel"))
+	assert.Equal(t, "simonandgarfunkel", dedupe.Normalize("Simon and Garfunkel"))
+	assert.Equal(t, "motorhead", dedupe.Normalize("Motörhead"))
+	assert.Equal(t, "ヨルシカ", dedupe.Normalize("ヨルシカ "))
+	assert.Empty(t, dedupe.Normalize("!!!"))
+}
+
+func TestScore(t *testing.T) {
+	a := &db.DuplicateDetails{ID: 1, Name: "Beyoncé", Aliases: []string{"Beyoncé"}}
+	b := &db.DuplicateDetails{ID: 2, Name: "Beyonce", Aliases: []string{"Beyonce"}}
+	score, reasons := dedupe.Score(models.MergeSuggestionArtist, a, b, 0.5)
+	assert.GreaterOrEqual(t, score, dedupe.MinScore)
+	assert.Contains(t, reasons, dedupe.ReasonSimilarName)
+	assert.Contains(t, reasons, dedupe.ReasonSameNormalizedName)
+
+	// identified as different artists by MusicBrainz
+	id1, id2 := uuid.New(), uuid.New()
+	a.MbzID, b.MbzID = &id1, &id2
+	score, reasons = dedupe.Score(models.MergeSuggestionArtist, a, b, 0.5)
+	assert.Less(t, score, dedupe.MinScore)
+	assert.Contains(t, reasons, dedupe.ReasonDifferentMbzID)
+
+	b.MbzID = &id1
+	score, reasons = dedupe.Score(models.MergeSuggestionArtist, a, b, 0.5)
+	assert.Equal(t, 1.0, score)
+	assert.Contains(t, reasons, dedupe.ReasonSameMbzID)
+}
+
+func TestScore_SharedTracks(t *testing.T) {
+	a := &db.DuplicateDetails{ID: 1, Name: "AG! Calling", TrackTitles: []string{"Tokyo Calling", "Pineapple Kryptonite", "Forever Sisters", "Otona Blue"}}
+	b := &db.DuplicateDetails{ID: 2, Name: "AG! Calling (Deluxe)", TrackTitles: []string{"Tokyo Calling", "Pineapple Kryptonite", "Forever Sisters"}}
+	score, reasons := dedupe.Score(models.MergeSuggestionAlbum, a, b, 0.6)
+	assert.Equal(t, 1.0, score)
+	assert.Contains(t, reasons, dedupe.ReasonSharedTracks)
+
+	// a single common title counts for less
+	b.TrackTitles = []string{"Intro"}
+	a.TrackTitles = []string{"Intro", "Outro"}
+	score, _ = dedupe.Score(models.MergeSuggestionAlbum, a, b, 0.4)
+	assert.Less(t, score, dedupe.MinScore)
+
+	b.TrackTitles = []string{"Something Else"}
+	score, reasons = dedupe.Score(models.MergeSuggestionAlbum, a, b, 0.6)
+	assert.Equal(t, 0.6, score)
+	assert.NotContains(t, reasons, dedupe.ReasonSharedTracks)
+}
+
+func TestScore_Duration(t *testing.T) {
+	a := &db.DuplicateDetails{ID: 1, Name: "Tokyo Calling", Duration: 200}
+	b := &db.DuplicateDetails{ID: 2, Name: "Tokyo Calling (Live)", Duration: 202}
+	score, reasons := dedupe.Score(models.MergeSuggestionTrack, a, b, 0.7)
+	assert.Greater(t, score, 0.7)
+	assert.Contains(t, reasons, dedupe.ReasonSimilarDuration)
+
+	b.Duration = 260
+	score, reasons = dedupe.Score(models.MergeSuggestionTrack, a, b, 0.7)
+	assert.Less(t, score, dedupe.MinScore)
+	assert.Contains(t, reasons, dedupe.ReasonDifferentDuration)
+
+	// unknown durations are not compared
+	b.Duration = 0
+	score, reasons = dedupe.Score(models.MergeSuggestionTrack, a, b, 0.7)
+	assert.Equal(t, 0.7, score)
+	assert.Equal(t, []string{dedupe.ReasonSimilarName}, reasons)
+}
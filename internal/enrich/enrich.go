@@ -0,0 +1,232 @@
+// Package enrich fills in metadata that was missing when listens were
+// ingested: durations of tracks, MusicBrainz IDs of artists, album images and
+// genres of artists and albums. A worker sweeps each task in turn, saving its
+// progress after every artist, album or track, so that a sweep resumes where
+// it left off after a restart. MusicBrainz and the image providers are called
+// through their clients, so their rate limits apply.
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/SaturnX-Dev/Beat-Scrobble/queue"
+)
+
+const (
+	// the first sweep waits this long after startup, to leave MusicBrainz to
+	// imports and ingestion first
+	startDelay = time.Minute
+	// how long to wait before trying again after a sweep failed
+	retryDelay = 10 * time.Minute
+)
+
+var waker utils.Waker
+
+// Wake makes the worker sweep every task now, including those that finished
+// less than an interval ago.
+func Wake() {
+	waker.Wake()
+}
+
+// Current is the artist, album or track the worker is enriching.
+type Current struct {
+	Task models.EnrichmentTask `json:"task"`
+	ID   int32                 `json:"id"`
+	Name string                `json:"name,omitempty"`
+}
+
+var (
+	currentMu sync.Mutex
+	current   *Current
+	running   bool
+)
+
+func setCurrent(c *Current) {
+	currentMu.Lock()
+	current = c
+	currentMu.Unlock()
+}
+
+// Working returns what the worker is enriching, nil when it is idle.
+func Working() *Current {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	return current
+}
+
+// Running reports whether the worker was started.
+func Running() bool {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	return running
+}
+
+type Worker struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start sweeps for missing metadata in the background, waiting interval after
+// each task finishes before sweeping it again. Tasks that need MusicBrainz are
+// skipped when mbzc is nil.
+func Start(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, interval time.Duration) *Worker {
+	ctx, cancel := context.WithCancel(queue.WithPriority(ctx, queue.PriorityBackground))
+	w := &Worker{cancel: cancel}
+	currentMu.Lock()
+	running = true
+	currentMu.Unlock()
+	w.wg.Add(1)
+	go w.run(ctx, store, mbzc, interval)
+	logger.FromContext(ctx).Info().Msgf("Enrich: Sweeping for missing metadata every %v", interval)
+	return w
+}
+
+// Stop stops the worker, interrupting a sweep in progress. The sweep resumes
+// from where it stopped the next time the worker is started.
+func (w *Worker) Stop() {
+	w.cancel()
+	w.wg.Wait()
+	currentMu.Lock()
+	running = false
+	currentMu.Unlock()
+}
+
+func (w *Worker) run(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, interval time.Duration) {
+	defer w.wg.Done()
+	l := logger.FromContext(ctx)
+	next := time.After(startDelay)
+	for {
+		wake := waker.C()
+		force := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+			force = true
+		case <-next:
+		}
+		wait, err := Sweep(ctx, store, mbzc, interval, force)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			l.Err(err).Msg("Enrich: Sweep failed")
+			wait = retryDelay
+		}
+		next = time.After(wait)
+	}
+}
+
+// Sweep runs every task that is due, continuing those that were interrupted.
+// When force is set, tasks that finished less than interval ago are swept
+// again too. Returns how long until the next task is due.
+func Sweep(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, interval time.Duration, force bool) (time.Duration, error) {
+	saved, err := store.GetEnrichmentProgress(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Sweep: %w", err)
+	}
+	progress := make(map[models.EnrichmentTask]*models.EnrichmentProgress, len(saved))
+	for _, p := range saved {
+		progress[p.Task] = p
+	}
+
+	var enabled []*models.EnrichmentProgress
+	for _, t := range tasks {
+		if !t.enabled(mbzc) {
+			continue
+		}
+		p := progress[t.kind]
+		if force || due(p, interval, time.Now()) {
+			if p == nil || p.FinishedAt != nil {
+				p = &models.EnrichmentProgress{Task: t.kind, StartedAt: time.Now()}
+			}
+			if err := runTask(ctx, store, mbzc, t, p); err != nil {
+				return 0, fmt.Errorf("Sweep: %w", err)
+			}
+		}
+		enabled = append(enabled, p)
+	}
+	return untilDue(enabled, interval, time.Now()), nil
+}
+
+// due reports whether the task with the progress should be swept: when it
+// never was, its sweep was interrupted, or it finished at least interval ago.
+func due(p *models.EnrichmentProgress, interval time.Duration, now time.Time) bool {
+	return p == nil || p.FinishedAt == nil || !now.Before(p.FinishedAt.Add(interval))
+}
+
+// untilDue returns how long until the first of the tasks with the progress is
+// due, at most interval.
+func untilDue(progress []*models.EnrichmentProgress, interval time.Duration, now time.Time) time.Duration {
+	wait := interval
+	for _, p := range progress {
+		if due(p, interval, now) {
+			return 0
+		}
+		wait = min(wait, p.FinishedAt.Add(interval).Sub(now))
+	}
+	return wait
+}
+
+// runTask enriches what the task finds from where its progress left off,
+// saving the progress after each one. Failing to enrich one is logged and
+// does not stop the sweep.
+func runTask(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, t task, p *models.EnrichmentProgress) error {
+	l := logger.FromContext(ctx)
+	defer setCurrent(nil)
+	if p.LastID > 0 {
+		l.Info().Msgf("Enrich: Resuming %s after id %d", t.kind, p.LastID)
+	} else {
+		l.Debug().Msgf("Enrich: Starting %s", t.kind)
+	}
+	for {
+		items, err := t.next(ctx, store, mbzc, p.LastID)
+		if err != nil {
+			return fmt.Errorf("runTask: %w", err)
+		}
+		if len(items) == 0 {
+			break
+		}
+		for _, it := range items {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("runTask: %w", err)
+			}
+			setCurrent(&Current{Task: t.kind, ID: it.id, Name: it.name})
+			enriched, err := it.enrich(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return fmt.Errorf("runTask: %w", ctx.Err())
+				}
+				l.Warn().Err(err).Msgf("Enrich: Failed %s for id %d", t.kind, it.id)
+			}
+			if !enriched && t.recordAttempts {
+				if err := store.SaveEnrichmentAttempt(ctx, t.kind, it.id); err != nil {
+					return fmt.Errorf("runTask: %w", err)
+				}
+			}
+			p.LastID = it.id
+			p.Processed++
+			if enriched {
+				p.Enriched++
+			}
+			if err := store.SaveEnrichmentProgress(ctx, p); err != nil {
+				return fmt.Errorf("runTask: %w", err)
+			}
+		}
+	}
+	now := time.Now()
+	p.FinishedAt = &now
+	if err := store.SaveEnrichmentProgress(ctx, p); err != nil {
+		return fmt.Errorf("runTask: %w", err)
+	}
+	l.Info().Msgf("Enrich: Finished %s, enriched %d of %d", t.kind, p.Enriched, p.Processed)
+	return nil
+}
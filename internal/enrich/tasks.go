@@ -0,0 +1,263 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/images"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/google/uuid"
+)
+
+// credited artists of a recording are attached to the artist of the track
+// whose name is at least this similar
+const minArtistSimilarity = 0.85
+
+// an item is an artist, album or track to enrich. enrich returns whether
+// anything was filled in.
+type item struct {
+	id     int32
+	name   string
+	enrich func(ctx context.Context) (bool, error)
+}
+
+type task struct {
+	kind    models.EnrichmentTask
+	enabled func(mbzc mbz.MusicBrainzCaller) bool
+	// returns the next items after the id, none when the sweep is done
+	next func(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, from int32) ([]item, error)
+	// whether items that were not enriched are recorded, so that next leaves
+	// them out for a while instead of trying them again every sweep
+	recordAttempts bool
+}
+
+func mbzEnabled(mbzc mbz.MusicBrainzCaller) bool {
+	return mbzc != nil
+}
+
+func imagesEnabled(mbz.MusicBrainzCaller) bool {
+	return images.Enabled()
+}
+
+// tasks in the order they are swept. Artist and track MusicBrainz IDs are
+// filled in first, as album images and genres are found by them.
+var tasks = []task{
+	{kind: models.EnrichmentTrackDurations, enabled: mbzEnabled, next: tracksWithoutDuration, recordAttempts: true},
+	{kind: models.EnrichmentArtistMbzIDs, enabled: mbzEnabled, next: artistsWithoutMbzID},
+	{kind: models.EnrichmentAlbumImages, enabled: imagesEnabled, next: albumsWithoutImages},
+	{kind: models.EnrichmentArtistGenres, enabled: mbzEnabled, next: artistsWithoutGenres, recordAttempts: true},
+	{kind: models.EnrichmentAlbumGenres, enabled: mbzEnabled, next: albumsWithoutGenres, recordAttempts: true},
+}
+
+func tracksWithoutDuration(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, from int32) ([]item, error) {
+	ids, err := store.TracksWithoutDuration(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("tracksWithoutDuration: %w", err)
+	}
+	items := make([]item, len(ids))
+	for i, id := range ids {
+		items[i] = item{id: id, enrich: func(ctx context.Context) (bool, error) {
+			return enrichTrackDuration(ctx, store, mbzc, id)
+		}}
+	}
+	return items, nil
+}
+
+// enrichTrackDuration sets the duration of the track to the length of its
+// recording. A track without a MusicBrainz ID is searched for first, when
+// searching is enabled.
+func enrichTrackDuration(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, id int32) (bool, error) {
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: id})
+	if err != nil {
+		return false, fmt.Errorf("enrichTrackDuration: %w", err)
+	}
+	if track.MbzID == nil || *track.MbzID == uuid.Nil {
+		if !catalog.MbzSearchEnabled(mbzc) {
+			return false, nil
+		}
+		matched, err := catalog.AttachMbzIDs(ctx, store, mbzc, id)
+		if err != nil || !matched {
+			return false, err
+		}
+		track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: id})
+		if err != nil {
+			return false, fmt.Errorf("enrichTrackDuration: %w", err)
+		}
+	}
+	recording, err := mbzc.GetTrack(ctx, *track.MbzID)
+	if err != nil {
+		return false, fmt.Errorf("enrichTrackDuration: %w", err)
+	}
+	if recording.LengthMs <= 0 {
+		return false, nil
+	}
+	err = store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: id, Duration: int32(recording.LengthMs / 1000)})
+	if err != nil {
+		return false, fmt.Errorf("enrichTrackDuration: %w", err)
+	}
+	logger.FromContext(ctx).Debug().Msgf("Enrich: Set duration of track '%s' to %ds", track.Title, recording.LengthMs/1000)
+	return true, nil
+}
+
+func artistsWithoutMbzID(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, from int32) ([]item, error) {
+	artists, err := store.ArtistsWithoutMbzID(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("artistsWithoutMbzID: %w", err)
+	}
+	items := make([]item, len(artists))
+	for i, a := range artists {
+		items[i] = item{id: a.ArtistID, enrich: func(ctx context.Context) (bool, error) {
+			return enrichArtistMbzID(ctx, store, mbzc, a)
+		}}
+	}
+	return items, nil
+}
+
+// enrichArtistMbzID finds the artist's ID among the credits of the recording
+// of one of their tracks. When none of their tracks has a MusicBrainz ID, one
+// is searched for, when searching is enabled.
+func enrichArtistMbzID(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, a db.ArtistWithoutMbzID) (bool, error) {
+	if a.TrackID == 0 {
+		return false, nil
+	}
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: a.TrackID})
+	if err != nil {
+		return false, fmt.Errorf("enrichArtistMbzID: %w", err)
+	}
+	if track.MbzID != nil && *track.MbzID != uuid.Nil {
+		recording, err := mbzc.GetTrack(ctx, *track.MbzID)
+		if err != nil {
+			return false, fmt.Errorf("enrichArtistMbzID: %w", err)
+		}
+		err = catalog.AttachArtistMbzIDs(ctx, store, track.Artists, recording.ArtistCredit, minArtistSimilarity)
+		if err != nil {
+			return false, fmt.Errorf("enrichArtistMbzID: %w", err)
+		}
+	} else if catalog.MbzSearchEnabled(mbzc) {
+		if _, err := catalog.AttachMbzIDs(ctx, store, mbzc, track.ID); err != nil {
+			return false, fmt.Errorf("enrichArtistMbzID: %w", err)
+		}
+	} else {
+		return false, nil
+	}
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: a.ArtistID})
+	if err != nil {
+		return false, fmt.Errorf("enrichArtistMbzID: %w", err)
+	}
+	return artist.MbzID != nil && *artist.MbzID != uuid.Nil, nil
+}
+
+func albumsWithoutImages(ctx context.Context, store db.DB, _ mbz.MusicBrainzCaller, from int32) ([]item, error) {
+	albums, err := store.AlbumsWithoutImages(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("albumsWithoutImages: %w", err)
+	}
+	items := make([]item, len(albums))
+	for i, album := range albums {
+		items[i] = item{id: album.ID, name: album.Title, enrich: func(ctx context.Context) (bool, error) {
+			return enrichAlbumImage(ctx, store, album)
+		}}
+	}
+	return items, nil
+}
+
+func enrichAlbumImage(ctx context.Context, store db.DB, album *models.Album) (bool, error) {
+	// the image providers search by the first artist
+	if len(album.Artists) == 0 {
+		return false, nil
+	}
+	imgUrl, err := images.GetAlbumImage(ctx, images.AlbumImageOpts{
+		Artists:      utils.FlattenSimpleArtistNames(album.Artists),
+		Album:        album.Title,
+		ReleaseMbzID: album.MbzID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("enrichAlbumImage: %w", err)
+	}
+	if imgUrl == "" {
+		return false, nil
+	}
+	imgid := uuid.New()
+	if err := catalog.DownloadAndCacheImage(ctx, imgid, imgUrl, catalog.ImageSourceSize()); err != nil {
+		return false, fmt.Errorf("enrichAlbumImage: %w", err)
+	}
+	err = store.UpdateAlbum(ctx, db.UpdateAlbumOpts{ID: album.ID, Image: imgid, ImageSrc: imgUrl})
+	if err != nil {
+		return false, fmt.Errorf("enrichAlbumImage: %w", err)
+	}
+	logger.FromContext(ctx).Debug().Msgf("Enrich: Found image for album '%s'", album.Title)
+	return true, nil
+}
+
+func artistsWithoutGenres(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, from int32) ([]item, error) {
+	artists, err := store.ArtistsWithoutGenres(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("artistsWithoutGenres: %w", err)
+	}
+	items := make([]item, len(artists))
+	for i, artist := range artists {
+		items[i] = item{id: artist.ID, name: artist.Name, enrich: func(ctx context.Context) (bool, error) {
+			return enrichArtistGenres(ctx, store, mbzc, artist)
+		}}
+	}
+	return items, nil
+}
+
+func enrichArtistGenres(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, artist *models.Artist) (bool, error) {
+	a, err := mbzc.GetArtist(ctx, *artist.MbzID)
+	if err != nil {
+		return false, fmt.Errorf("enrichArtistGenres: %w", err)
+	}
+	genres := mbz.GenreNames(a.Genres)
+	if len(genres) == 0 {
+		return false, nil
+	}
+	if err := store.UpdateArtistGenres(ctx, artist.ID, genres); err != nil {
+		return false, fmt.Errorf("enrichArtistGenres: %w", err)
+	}
+	return true, nil
+}
+
+func albumsWithoutGenres(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, from int32) ([]item, error) {
+	albums, err := store.AlbumsWithoutGenres(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("albumsWithoutGenres: %w", err)
+	}
+	items := make([]item, len(albums))
+	for i, album := range albums {
+		items[i] = item{id: album.ID, name: album.Title, enrich: func(ctx context.Context) (bool, error) {
+			return enrichAlbumGenres(ctx, store, mbzc, album)
+		}}
+	}
+	return items, nil
+}
+
+// enrichAlbumGenres sets the genres of the album to those of its release, or
+// those of its release group when it has none, as genres are mostly voted on
+// release groups.
+func enrichAlbumGenres(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, album *models.Album) (bool, error) {
+	release, err := mbzc.GetRelease(ctx, *album.MbzID)
+	if err != nil {
+		return false, fmt.Errorf("enrichAlbumGenres: %w", err)
+	}
+	genres := mbz.GenreNames(release.Genres)
+	if rgid, err := uuid.Parse(release.ReleaseGroup.ID); len(genres) == 0 && err == nil {
+		rg, err := mbzc.GetReleaseGroup(ctx, rgid)
+		if err != nil {
+			return false, fmt.Errorf("enrichAlbumGenres: %w", err)
+		}
+		genres = mbz.GenreNames(rg.Genres)
+	}
+	if len(genres) == 0 {
+		return false, nil
+	}
+	if err := store.UpdateAlbumGenres(ctx, album.ID, genres); err != nil {
+		return false, fmt.Errorf("enrichAlbumGenres: %w", err)
+	}
+	return true, nil
+}
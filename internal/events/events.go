@@ -0,0 +1,91 @@
+// Package events broadcasts user activity, such as now playing changes and newly
+// saved listens, to in-process subscribers like the live activity stream.
+package events
+
+import (
+	"sync"
+)
+
+type Type string
+
+const (
+	// published with no data when the now playing entry expires
+	TypeNowPlaying Type = "now_playing"
+	TypeListen     Type = "listen"
+)
+
+type Event struct {
+	Type   Type
+	UserID int32
+	Data   interface{}
+}
+
+// how many events can be waiting for a subscriber before new ones are dropped
+const subscriberBuffer = 16
+
+type subscriber struct {
+	userID int32
+	ch     chan Event
+}
+
+type Broker struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]*subscriber
+}
+
+var Bus *Broker
+
+func init() {
+	Bus = NewBroker()
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subs: make(map[int]*subscriber),
+	}
+}
+
+// Subscribe returns a channel receiving every event published for userID, or
+// for all users when userID is 0. The returned function must be called to
+// release the subscription.
+func (b *Broker) Subscribe(userID int32) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	sub := &subscriber{
+		userID: userID,
+		ch:     make(chan Event, subscriberBuffer),
+	}
+	b.subs[id] = sub
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs, id)
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish delivers an event to all interested subscribers. It never blocks; a
+// subscriber that is not keeping up misses the event instead of stalling the
+// listen pipeline.
+func (b *Broker) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subs {
+		if sub.userID != 0 && sub.userID != e.UserID {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+		}
+	}
+}
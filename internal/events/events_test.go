@@ -0,0 +1,43 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishFiltersByUser(t *testing.T) {
+	b := events.NewBroker()
+
+	user1, unsub1 := b.Subscribe(1)
+	defer unsub1()
+	all, unsubAll := b.Subscribe(0)
+	defer unsubAll()
+
+	b.Publish(events.Event{Type: events.TypeListen, UserID: 2})
+	b.Publish(events.Event{Type: events.TypeNowPlaying, UserID: 1})
+
+	e := <-user1
+	assert.Equal(t, events.TypeNowPlaying, e.Type)
+	assert.Empty(t, user1)
+
+	require.Len(t, all, 2)
+	assert.Equal(t, int32(2), (<-all).UserID)
+	assert.Equal(t, int32(1), (<-all).UserID)
+}
+
+func TestPublishDoesNotBlock(t *testing.T) {
+	b := events.NewBroker()
+	_, unsub := b.Subscribe(1)
+
+	for i := 0; i < 100; i++ {
+		b.Publish(events.Event{Type: events.TypeListen, UserID: 1})
+	}
+
+	unsub()
+	// unsubscribing twice is safe
+	unsub()
+	b.Publish(events.Event{Type: events.TypeListen, UserID: 1})
+}
@@ -22,10 +22,16 @@ type BeatScrobbleExport struct {
 	Listens     []BeatScrobbleListen   `json:"listens"`
 }
 type BeatScrobbleListen struct {
-	ListenedAt time.Time            `json:"listened_at"`
-	Track      BeatScrobbleTrack    `json:"track"`
-	Album      BeatScrobbleAlbum    `json:"album"`
-	Artists    []BeatScrobbleArtist `json:"artists"`
+	ListenedAt              time.Time            `json:"listened_at"`
+	Client                  string               `json:"client,omitempty"`
+	DurationMs              int32                `json:"duration_ms,omitempty"`
+	MediaPlayer             string               `json:"media_player,omitempty"`
+	SubmissionClientVersion string               `json:"submission_client_version,omitempty"`
+	Tags                    []string             `json:"tags,omitempty"`
+	OriginURL               string               `json:"origin_url,omitempty"`
+	Track                   BeatScrobbleTrack    `json:"track"`
+	Album                   BeatScrobbleAlbum    `json:"album"`
+	Artists                 []BeatScrobbleArtist `json:"artists"`
 }
 type BeatScrobbleTrack struct {
 	MBID     *uuid.UUID     `json:"mbid"`
@@ -143,7 +149,12 @@ func ExportData(ctx context.Context, user *models.User, store db.DB, mode string
 
 func convertToExportFormat(item *db.ExportItem) *BeatScrobbleListen {
 	ret := &BeatScrobbleListen{
-		ListenedAt: item.ListenedAt.UTC(),
+		ListenedAt:              item.ListenedAt.UTC(),
+		DurationMs:              item.DurationMs,
+		MediaPlayer:             item.MediaPlayer,
+		SubmissionClientVersion: item.SubmissionClientVersion,
+		Tags:                    item.Tags,
+		OriginURL:               item.OriginURL,
 		Track: BeatScrobbleTrack{
 			MBID:     item.TrackMbid,
 			Duration: int(item.TrackDuration),
@@ -156,6 +167,9 @@ func convertToExportFormat(item *db.ExportItem) *BeatScrobbleListen {
 			Aliases:        item.ReleaseAliases,
 		},
 	}
+	if item.Client != nil {
+		ret.Client = *item.Client
+	}
 	for i := range item.Artists {
 		ret.Artists = append(ret.Artists, BeatScrobbleArtist{
 			IsPrimary: item.Artists[i].IsPrimary,
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -53,7 +52,7 @@ func NewDeezerClient() *DeezerClient {
 	ret := new(DeezerClient)
 	ret.url = deezerBaseUrl
 	ret.userAgent = cfg.UserAgent()
-	ret.requestQueue = queue.NewRequestQueue(5, 5)
+	ret.requestQueue = queue.New(queue.Options{Name: "Deezer", RPS: 5, Burst: 5})
 	return ret
 }
 
@@ -66,24 +65,14 @@ func (c *DeezerClient) queue(ctx context.Context, req *http.Request) ([]byte, er
 	req.Header.Set("User-Agent", c.userAgent)
 	req.Header.Set("Accept", "application/json")
 
-	resultChan := c.requestQueue.Enqueue(func(client *http.Client, done chan<- queue.RequestResult) {
-		resp, err := client.Do(req)
-		if err != nil {
-			l.Debug().Err(err).Str("url", req.RequestURI).Msg("Failed to contact ImageSrc")
-			done <- queue.RequestResult{Err: err}
-			return
-		} else if resp.StatusCode >= 300 || resp.StatusCode < 200 {
-			err = fmt.Errorf("recieved non-ok status from Deezer: %s", resp.Status)
-			done <- queue.RequestResult{Body: nil, Err: err}
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		done <- queue.RequestResult{Body: body, Err: err}
-	})
-
-	result := <-resultChan
-	return result.Body, result.Err
+	resp, err := c.requestQueue.Do(ctx, req)
+	if err != nil {
+		l.Debug().Err(err).Str("url", req.URL.String()).Msg("Failed to contact ImageSrc")
+		return nil, err
+	} else if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+		return nil, fmt.Errorf("recieved non-ok status from Deezer: %s", resp.Status)
+	}
+	return resp.Body, nil
 }
 
 func (c *DeezerClient) getEntity(ctx context.Context, endpoint string, result any) error {
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/queue"
 	"github.com/google/uuid"
 )
 
@@ -58,8 +59,31 @@ func Initialize(opts ImageSourceOpts) {
 	})
 }
 
+// Enabled reports whether any image provider is enabled.
+func Enabled() bool {
+	return imgsrc.caaEnabled || imgsrc.deezerEnabled || imgsrc.subsonicEnabled
+}
+
 func Shutdown() {
-	imgsrc.deezerC.Shutdown()
+	if imgsrc.deezerC != nil {
+		imgsrc.deezerC.Shutdown()
+	}
+	if imgsrc.subsonicC != nil {
+		imgsrc.subsonicC.requestQueue.Shutdown()
+	}
+}
+
+// QueueStats returns the stats of the request queue of every enabled image
+// provider that has one, by provider name.
+func QueueStats() map[string]queue.Stats {
+	stats := make(map[string]queue.Stats)
+	if imgsrc.deezerC != nil {
+		stats["deezer"] = imgsrc.deezerC.requestQueue.Stats()
+	}
+	if imgsrc.subsonicC != nil {
+		stats["subsonic"] = imgsrc.subsonicC.requestQueue.Stats()
+	}
+	return stats
 }
 
 func GetArtistImage(ctx context.Context, opts ArtistImageOpts) (string, error) {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 
@@ -53,7 +52,7 @@ func NewSubsonicClient() *SubsonicClient {
 	ret.url = cfg.SubsonicUrl()
 	ret.userAgent = cfg.UserAgent()
 	ret.authParams = cfg.SubsonicParams()
-	ret.requestQueue = queue.NewRequestQueue(5, 5)
+	ret.requestQueue = queue.New(queue.Options{Name: "Subsonic", RPS: 5, Burst: 5})
 	return ret
 }
 
@@ -62,24 +61,14 @@ func (c *SubsonicClient) queue(ctx context.Context, req *http.Request) ([]byte,
 	req.Header.Set("User-Agent", c.userAgent)
 	req.Header.Set("Accept", "application/json")
 
-	resultChan := c.requestQueue.Enqueue(func(client *http.Client, done chan<- queue.RequestResult) {
-		resp, err := client.Do(req)
-		if err != nil {
-			l.Debug().Err(err).Str("url", req.RequestURI).Msg("Failed to contact ImageSrc")
-			done <- queue.RequestResult{Err: err}
-			return
-		} else if resp.StatusCode >= 300 || resp.StatusCode < 200 {
-			err = fmt.Errorf("recieved non-ok status from Subsonic: %s", resp.Status)
-			done <- queue.RequestResult{Body: nil, Err: err}
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		done <- queue.RequestResult{Body: body, Err: err}
-	})
-
-	result := <-resultChan
-	return result.Body, result.Err
+	resp, err := c.requestQueue.Do(ctx, req)
+	if err != nil {
+		l.Debug().Err(err).Str("url", req.URL.String()).Msg("Failed to contact ImageSrc")
+		return nil, err
+	} else if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+		return nil, fmt.Errorf("recieved non-ok status from Subsonic: %s", resp.Status)
+	}
+	return resp.Body, nil
 }
 
 func (c *SubsonicClient) getEntity(ctx context.Context, endpoint string, result any) error {
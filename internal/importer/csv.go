@@ -0,0 +1,411 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/google/uuid"
+)
+
+// BuiltinCsvProfiles returns the profiles for the exports of streaming
+// services, which cannot be changed or deleted.
+func BuiltinCsvProfiles() []models.CsvImportProfile {
+	return []models.CsvImportProfile{
+		{
+			// "Apple Music Play Activity.csv" from Apple's Data and Privacy
+			// export, which logs every play event, finished or not. Plays
+			// skipped early end with PLAY_END too.
+			Name:            "apple-music",
+			Builtin:         true,
+			Delimiter:       ",",
+			ArtistColumn:    "Artist Name",
+			TitleColumn:     "Song Name",
+			AlbumColumn:     "Album Name",
+			TimestampColumn: "Event Start Timestamp",
+			TimestampFormat: models.CsvTimestampRFC3339,
+			Timezone:        "UTC",
+			DurationColumn:  "Media Duration In Milliseconds",
+			DurationUnit:    models.CsvDurationMilliseconds,
+			FilterColumn:    "Event Type",
+			FilterValue:     "PLAY_END",
+			PlayedColumn:    "Play Duration Milliseconds",
+		},
+	}
+}
+
+// IsBuiltinCsvProfile reports whether the name is taken by a built-in profile.
+func IsBuiltinCsvProfile(name string) bool {
+	for _, p := range BuiltinCsvProfiles() {
+		if strings.EqualFold(p.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// CsvProfiles returns the profiles saved by the user, followed by the
+// built-in ones.
+func CsvProfiles(ctx context.Context, store db.DB, userId int32) ([]models.CsvImportProfile, error) {
+	saved, err := store.GetCsvImportProfilesByUserID(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("CsvProfiles: %w", err)
+	}
+	profiles := make([]models.CsvImportProfile, 0, len(saved))
+	for _, p := range saved {
+		profiles = append(profiles, *p)
+	}
+	return append(profiles, BuiltinCsvProfiles()...), nil
+}
+
+// ValidateCsvProfile checks that the profile names the required columns, and
+// that its delimiter, timestamp format, timezone and duration unit are valid.
+func ValidateCsvProfile(p models.CsvImportProfile) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name is required")
+	}
+	if p.ArtistColumn == "" || p.TitleColumn == "" || p.TimestampColumn == "" {
+		return errors.New("artist, title and timestamp columns are required")
+	}
+	if p.Delimiter != "" {
+		if r, size := utf8.DecodeRuneInString(p.Delimiter); size != len(p.Delimiter) || r == '"' || r == '\n' || r == '\r' {
+			return errors.New("delimiter must be a single character other than a quote or newline")
+		}
+	}
+	switch p.TimestampFormat {
+	case models.CsvTimestampUnix, models.CsvTimestampUnixMs, models.CsvTimestampRFC3339:
+	case "":
+		return errors.New("timestamp format is required")
+	default:
+		// a layout that does not include the year cannot place a listen
+		ref := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+		t, err := time.Parse(p.TimestampFormat, ref.Format(p.TimestampFormat))
+		if err != nil || t.Year() != ref.Year() {
+			return errors.New("timestamp format must be unix, unix_ms, rfc3339 or a Go time layout including the year")
+		}
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return errors.New("timezone must be an IANA timezone name")
+	}
+	switch p.DurationUnit {
+	case "", models.CsvDurationSeconds, models.CsvDurationMilliseconds:
+	default:
+		return errors.New("duration unit must be s or ms")
+	}
+	if (p.FilterColumn == "") != (p.FilterValue == "") {
+		return errors.New("filter column and filter value must be set together")
+	}
+	return nil
+}
+
+var ErrNoCsvProfile = errors.New("no CSV import profile matches the columns of the file")
+
+// CsvListen is a listen read from a row of a CSV file.
+type CsvListen struct {
+	Artist   string
+	Title    string
+	Album    string
+	Time     time.Time
+	Duration int32 // in seconds
+	PlayedMs int32 // how long it was played for, 0 when unknown
+	MbzID    uuid.UUID
+}
+
+// CsvReader reads listens from a CSV file by the columns of a profile.
+type CsvReader struct {
+	r       *csv.Reader
+	profile models.CsvImportProfile
+	loc     *time.Location
+	// index of each column of the profile, -1 for unmapped optional columns
+	artist, title, album, timestamp, duration, mbzID, filter, played int
+	// rows left out by the filter of the profile
+	Filtered int
+	// rows that could not be parsed
+	Invalid int
+}
+
+// NewCsvReader reads the header of the CSV file, using the first of the
+// profiles whose columns it has all of. Returns ErrNoCsvProfile when none
+// match.
+func NewCsvReader(r io.Reader, profiles ...models.CsvImportProfile) (*CsvReader, error) {
+	br := bufio.NewReader(r)
+	line, err := br.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return nil, fmt.Errorf("NewCsvReader: %w", ErrNoCsvProfile)
+	}
+	line = strings.TrimPrefix(line, "\ufeff")
+	for _, p := range profiles {
+		header, err := newCsvReader(strings.NewReader(line), p).Read()
+		if err != nil {
+			continue
+		}
+		c := &CsvReader{r: newCsvReader(br, p), profile: p}
+		if !c.mapColumns(header) {
+			continue
+		}
+		c.loc, err = time.LoadLocation(p.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("NewCsvReader: %w", err)
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("NewCsvReader: %w", ErrNoCsvProfile)
+}
+
+func newCsvReader(r io.Reader, p models.CsvImportProfile) *csv.Reader {
+	cr := csv.NewReader(r)
+	if p.Delimiter != "" {
+		cr.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+	}
+	// hand-kept files often have ragged rows
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+	return cr
+}
+
+// mapColumns finds the columns of the profile in the header, returning false
+// when one is missing.
+func (c *CsvReader) mapColumns(header []string) bool {
+	find := func(name string) int {
+		if name == "" {
+			return -1
+		}
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(name)) {
+				return i
+			}
+		}
+		return -2
+	}
+	p := c.profile
+	c.artist, c.title, c.album = find(p.ArtistColumn), find(p.TitleColumn), find(p.AlbumColumn)
+	c.timestamp, c.duration = find(p.TimestampColumn), find(p.DurationColumn)
+	c.mbzID, c.filter, c.played = find(p.MbzIDColumn), find(p.FilterColumn), find(p.PlayedColumn)
+	for _, i := range []int{c.artist, c.title, c.album, c.timestamp, c.duration, c.mbzID, c.filter, c.played} {
+		if i == -2 {
+			return false
+		}
+	}
+	return true
+}
+
+// Profile returns the profile the file is read with.
+func (c *CsvReader) Profile() models.CsvImportProfile {
+	return c.profile
+}
+
+// Next returns the next listen, skipping rows that are filtered out or
+// invalid. Returns io.EOF after the last row.
+func (c *CsvReader) Next() (*CsvListen, error) {
+	for {
+		listen, err := c.read()
+		if errors.Is(err, errCsvFiltered) {
+			c.Filtered++
+			continue
+		} else if errors.Is(err, ErrInvalidItem) {
+			c.Invalid++
+			continue
+		}
+		return listen, err
+	}
+}
+
+var errCsvFiltered = errors.New("row left out by the filter of the profile")
+
+// read reads the next row. Rows left out by the filter of the profile return
+// errCsvFiltered, and rows that cannot be parsed an error wrapping
+// ErrInvalidItem. Returns io.EOF after the last row.
+func (c *CsvReader) read() (*CsvListen, error) {
+	row, err := c.r.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	} else if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidItem, err)
+		}
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	col := func(i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	if c.filter >= 0 && !strings.EqualFold(col(c.filter), c.profile.FilterValue) {
+		return nil, errCsvFiltered
+	}
+	listen := &CsvListen{
+		Artist: col(c.artist),
+		Title:  col(c.title),
+		Album:  col(c.album),
+	}
+	if listen.Artist == "" || listen.Title == "" {
+		return nil, fmt.Errorf("%w: missing artist or title", ErrInvalidItem)
+	}
+	listen.Time, err = parseCsvTime(col(c.timestamp), c.profile.TimestampFormat, c.loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: timestamp %q", ErrInvalidItem, col(c.timestamp))
+	}
+	durationMs := c.milliseconds(col(c.duration))
+	listen.Duration = int32(durationMs / 1000)
+	listen.PlayedMs = int32(c.milliseconds(col(c.played)))
+	if listen.PlayedMs > 0 && !playedEnough(listen.PlayedMs, durationMs) {
+		return nil, errCsvFiltered
+	}
+	if id, err := uuid.Parse(col(c.mbzID)); err == nil {
+		listen.MbzID = id
+	}
+	return listen, nil
+}
+
+// milliseconds returns the duration in the unit of the profile as
+// milliseconds, 0 when it is empty or not a positive number.
+func (c *CsvReader) milliseconds(s string) int64 {
+	d, err := strconv.ParseFloat(s, 64)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	if c.profile.DurationUnit == models.CsvDurationMilliseconds {
+		return int64(d)
+	}
+	return int64(d * 1000)
+}
+
+// playedEnough reports whether a play is long enough to be scrobbled: half
+// the track, or four minutes of a longer one. Plays of tracks of unknown
+// length need to last 30 seconds.
+func playedEnough(playedMs int32, durationMs int64) bool {
+	if durationMs <= 0 {
+		return playedMs >= 30*1000
+	}
+	return int64(playedMs) >= min(durationMs/2, 4*60*1000)
+}
+
+func parseCsvTime(s, format string, loc *time.Location) (time.Time, error) {
+	switch format {
+	case models.CsvTimestampUnix, models.CsvTimestampUnixMs:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == models.CsvTimestampUnixMs {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	case models.CsvTimestampRFC3339:
+		return time.Parse(time.RFC3339, s)
+	default:
+		return time.ParseInLocation(format, s, loc)
+	}
+}
+
+// ImportCsvFile imports a CSV file from the import directory with the first
+// of the default user's profiles, or the built-in ones, that matches its
+// columns.
+func ImportCsvFile(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, filename string) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msgf("Beginning CSV import on file: %s", filename)
+	file, err := os.Open(path.Join(cfg.ConfigDir(), "import", filename))
+	if err != nil {
+		l.Err(err).Msgf("Failed to read import file: %s", filename)
+		return fmt.Errorf("ImportCsvFile: %w", err)
+	}
+	defer file.Close()
+	profiles, err := CsvProfiles(ctx, store, 1)
+	if err != nil {
+		return fmt.Errorf("ImportCsvFile: %w", err)
+	}
+	r, err := NewCsvReader(file, profiles...)
+	if err != nil {
+		return fmt.Errorf("ImportCsvFile: %w", err)
+	}
+	l.Info().Msgf("Importing %s with CSV profile '%s'", filename, r.Profile().Name)
+	count, err := ImportCsv(ctx, store, mbzc, r, 1)
+	if err != nil {
+		return fmt.Errorf("ImportCsvFile: %w", err)
+	}
+	return finishImport(ctx, filename, count)
+}
+
+// ImportCsv submits the listens read by r for the user, returning how many
+// were imported.
+func ImportCsv(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, r *CsvReader, userId int32) (int, error) {
+	l := logger.FromContext(ctx)
+	var throttleFunc = func() {}
+	if ms := cfg.ThrottleImportMs(); ms > 0 {
+		throttleFunc = func() {
+			time.Sleep(time.Duration(ms) * time.Millisecond)
+		}
+	}
+	client := "csv:" + r.Profile().Name
+	count := 0
+	for {
+		listen, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return count, fmt.Errorf("ImportCsv: %w", err)
+		}
+		opts, ok := csvListen(ctx, client, listen)
+		if !ok {
+			continue
+		}
+		opts.MbzCaller = mbzc
+		opts.UserID = userId
+		err = catalog.SubmitListen(ctx, store, opts)
+		if err != nil {
+			l.Err(err).Msg("Failed to import CSV row")
+			return count, fmt.Errorf("ImportCsv: %w", err)
+		}
+		count++
+		throttleFunc()
+	}
+	if r.Filtered > 0 || r.Invalid > 0 {
+		l.Info().Msgf("Ignored %d filtered and %d invalid CSV rows", r.Filtered, r.Invalid)
+	}
+	return count, nil
+}
+
+// csvListen returns the listen read from a row, and false when it is outside
+// of the import window. The user is set by the caller.
+func csvListen(ctx context.Context, client string, listen *CsvListen) (catalog.SubmitListenOpts, bool) {
+	l := logger.FromContext(ctx)
+	if !inImportTimeWindow(listen.Time) {
+		l.Debug().Msgf("Skipping import due to import time rules")
+		return catalog.SubmitListenOpts{}, false
+	}
+	album := listen.Album
+	if album == "" {
+		album = listen.Title
+	}
+	return catalog.SubmitListenOpts{
+		Artist:         listen.Artist,
+		ArtistNames:    []string{listen.Artist},
+		TrackTitle:     listen.Title,
+		RecordingMbzID: listen.MbzID,
+		ReleaseTitle:   album,
+		Duration:       listen.Duration,
+		DurationMs:     listen.PlayedMs,
+		Client:         client,
+		Time:           listen.Time,
+		SkipCacheImage: !cfg.FetchImagesDuringImport(),
+		SkipMbzSearch:  true,
+	}, true
+}
@@ -0,0 +1,121 @@
+package importer_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importer"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, r *importer.CsvReader) []*importer.CsvListen {
+	var listens []*importer.CsvListen
+	for {
+		listen, err := r.Next()
+		if err == io.EOF {
+			return listens
+		}
+		require.NoError(t, err)
+		listens = append(listens, listen)
+	}
+}
+
+func TestCsvReader_AppleMusic(t *testing.T) {
+	input := "\ufeffAlbum Name,Artist Name,Event Start Timestamp,Event Type,Media Duration In Milliseconds,Play Duration Milliseconds,Song Name\n" +
+		"Lux Prima,Karen O & Danger Mouse,2021-03-04T05:06:07.123Z,PLAY_END,291000,250000,Ministry\n" +
+		"Lux Prima,Karen O & Danger Mouse,2021-03-04T05:11:00.000Z,PLAY_START,291000,0,Woke Up Lonely\n" +
+		"Lux Prima,Karen O & Danger Mouse,2021-03-04T05:11:00.000Z,PLAY_END,291000,12000,Woke Up Lonely\n" +
+		",Karen O & Danger Mouse,,PLAY_END,291000,291000,Redeemer\n"
+
+	r, err := importer.NewCsvReader(strings.NewReader(input), importer.BuiltinCsvProfiles()...)
+	require.NoError(t, err)
+	assert.Equal(t, "apple-music", r.Profile().Name)
+
+	listens := readAll(t, r)
+	require.Len(t, listens, 1)
+	assert.Equal(t, "Karen O & Danger Mouse", listens[0].Artist)
+	assert.Equal(t, "Ministry", listens[0].Title)
+	assert.Equal(t, "Lux Prima", listens[0].Album)
+	assert.EqualValues(t, 291, listens[0].Duration)
+	assert.EqualValues(t, 250000, listens[0].PlayedMs)
+	assert.True(t, time.Date(2021, 3, 4, 5, 6, 7, 123000000, time.UTC).Equal(listens[0].Time))
+	// the start, and the end of the play skipped after 12 seconds
+	assert.Equal(t, 2, r.Filtered)
+	// no timestamp
+	assert.Equal(t, 1, r.Invalid)
+}
+
+func TestCsvReader_CustomProfile(t *testing.T) {
+	profile := models.CsvImportProfile{
+		Name:            "notebook",
+		Delimiter:       ";",
+		ArtistColumn:    "artist",
+		TitleColumn:     "song",
+		TimestampColumn: "played",
+		TimestampFormat: "02/01/2006 15:04",
+		Timezone:        "Europe/Berlin",
+		DurationColumn:  "length",
+		DurationUnit:    models.CsvDurationSeconds,
+		MbzIDColumn:     "mbid",
+	}
+	require.NoError(t, importer.ValidateCsvProfile(profile))
+	input := "Played;Artist;Song;Length;MBID\n" +
+		"24/12/2023 20:15;Wham!;Last Christmas;262;3e6c4cf8-5bdf-4a6e-a5e5-5d0d2e8e9f9a\n" +
+		"25/12/2023 09:00;Mariah Carey;All I Want for Christmas Is You;;\n"
+
+	r, err := importer.NewCsvReader(strings.NewReader(input), append(importer.BuiltinCsvProfiles(), profile)...)
+	require.NoError(t, err)
+	assert.Equal(t, "notebook", r.Profile().Name)
+
+	listens := readAll(t, r)
+	require.Len(t, listens, 2)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	assert.True(t, time.Date(2023, 12, 24, 20, 15, 0, 0, berlin).Equal(listens[0].Time))
+	assert.EqualValues(t, 262, listens[0].Duration)
+	assert.Equal(t, uuid.MustParse("3e6c4cf8-5bdf-4a6e-a5e5-5d0d2e8e9f9a"), listens[0].MbzID)
+	assert.Empty(t, listens[1].Album)
+	assert.Zero(t, listens[1].Duration)
+	assert.Equal(t, uuid.Nil, listens[1].MbzID)
+}
+
+func TestCsvReader_NoProfile(t *testing.T) {
+	_, err := importer.NewCsvReader(strings.NewReader("a,b,c\n1,2,3\n"), importer.BuiltinCsvProfiles()...)
+	assert.ErrorIs(t, err, importer.ErrNoCsvProfile)
+	_, err = importer.NewCsvReader(strings.NewReader(""), importer.BuiltinCsvProfiles()...)
+	assert.ErrorIs(t, err, importer.ErrNoCsvProfile)
+}
+
+func TestValidateCsvProfile(t *testing.T) {
+	valid := models.CsvImportProfile{
+		Name:            "valid",
+		ArtistColumn:    "artist",
+		TitleColumn:     "title",
+		TimestampColumn: "time",
+		TimestampFormat: models.CsvTimestampUnix,
+	}
+	require.NoError(t, importer.ValidateCsvProfile(valid))
+	for _, p := range importer.BuiltinCsvProfiles() {
+		assert.NoError(t, importer.ValidateCsvProfile(p), p.Name)
+	}
+
+	for name, change := range map[string]func(p *models.CsvImportProfile){
+		"no title":        func(p *models.CsvImportProfile) { p.TitleColumn = "" },
+		"long delimiter":  func(p *models.CsvImportProfile) { p.Delimiter = ";;" },
+		"quote delimiter": func(p *models.CsvImportProfile) { p.Delimiter = `"` },
+		"no format":       func(p *models.CsvImportProfile) { p.TimestampFormat = "" },
+		"no year":         func(p *models.CsvImportProfile) { p.TimestampFormat = "15:04" },
+		"bad timezone":    func(p *models.CsvImportProfile) { p.Timezone = "Mars/Olympus_Mons" },
+		"bad unit":        func(p *models.CsvImportProfile) { p.DurationUnit = "min" },
+		"half filter":     func(p *models.CsvImportProfile) { p.FilterColumn = "type" },
+	} {
+		p := valid
+		change(&p)
+		assert.Error(t, importer.ValidateCsvProfile(p), name)
+	}
+}
@@ -80,130 +80,151 @@ func importBeatScrobbleData(ctx context.Context, store db.DB, r io.Reader) error
 	count := 0
 
 	for i := range data.Listens {
-		// use this for save/get mbid for all artist/album/track
-		var mbid uuid.UUID
-
-		artistIds := make([]int32, 0)
-		for _, ia := range data.Listens[i].Artists {
-			mbid = uuid.Nil
-			if ia.MBID != nil {
-				mbid = *ia.MBID
-			}
-			artist, err := store.GetArtist(ctx, db.GetArtistOpts{
-				MusicBrainzID: mbid,
-				Name:          getPrimaryAliasFromAliasSlice(ia.Aliases),
-			})
-			if errors.Is(err, pgx.ErrNoRows) {
-				var imgid = uuid.Nil
-				// not a perfect way to check if the image url is an actual source vs manual upload but
-				// im like 99% sure it will work perfectly
-				if strings.HasPrefix(ia.ImageUrl, "http") {
-					imgid = uuid.New()
-				}
-				// save artist
-				artist, err := store.SaveArtist(ctx, db.SaveArtistOpts{
-					Name:          getPrimaryAliasFromAliasSlice(ia.Aliases),
-					Image:         imgid,
-					ImageSrc:      ia.ImageUrl,
-					MusicBrainzID: mbid,
-					Aliases:       utils.FlattenAliases(ia.Aliases),
-				})
-				if err != nil {
-					return fmt.Errorf("ImportBeatScrobbleFile: %w", err)
-				}
-				artistIds = append(artistIds, artist.ID)
-			} else if err != nil {
-				return fmt.Errorf("ImportBeatScrobbleFile: %w", err)
-			} else {
-				artistIds = append(artistIds, artist.ID)
-			}
+		err := saveBeatScrobbleListen(ctx, store, &data.Listens[i], 1)
+		if err != nil {
+			return fmt.Errorf("importBeatScrobbleData: %w", err)
 		}
-		// call associate album
-		albumId := int32(0)
+		count++
+	}
+
+	l.Info().Msgf("importBeatScrobbleData: Finished importing %d listens", count)
+	return nil
+}
+
+// saveBeatScrobbleListen saves the listen for the user, along with the
+// artists, album and track of the export that are not saved yet.
+func saveBeatScrobbleListen(ctx context.Context, store db.DB, listen *export.BeatScrobbleListen, userId int32) error {
+	l := logger.FromContext(ctx)
+	// use this for save/get mbid for all artist/album/track
+	var mbid uuid.UUID
+
+	artistIds := make([]int32, 0)
+	for _, ia := range listen.Artists {
 		mbid = uuid.Nil
-		if data.Listens[i].Album.MBID != nil {
-			mbid = *data.Listens[i].Album.MBID
+		if ia.MBID != nil {
+			mbid = *ia.MBID
 		}
-		album, err := store.GetAlbum(ctx, db.GetAlbumOpts{
+		artist, err := store.GetArtist(ctx, db.GetArtistOpts{
 			MusicBrainzID: mbid,
-			Title:         getPrimaryAliasFromAliasSlice(data.Listens[i].Album.Aliases),
-			ArtistID:      artistIds[0],
+			Name:          getPrimaryAliasFromAliasSlice(ia.Aliases),
 		})
 		if errors.Is(err, pgx.ErrNoRows) {
 			var imgid = uuid.Nil
 			// not a perfect way to check if the image url is an actual source vs manual upload but
 			// im like 99% sure it will work perfectly
-			if strings.HasPrefix(data.Listens[i].Album.ImageUrl, "http") {
+			if strings.HasPrefix(ia.ImageUrl, "http") {
 				imgid = uuid.New()
 			}
-			// save album
-			album, err = store.SaveAlbum(ctx, db.SaveAlbumOpts{
-				Title:          getPrimaryAliasFromAliasSlice(data.Listens[i].Album.Aliases),
-				Image:          imgid,
-				ImageSrc:       data.Listens[i].Album.ImageUrl,
-				MusicBrainzID:  mbid,
-				Aliases:        utils.FlattenAliases(data.Listens[i].Album.Aliases),
-				ArtistIDs:      artistIds,
-				VariousArtists: data.Listens[i].Album.VariousArtists,
+			// save artist
+			artist, err := store.SaveArtist(ctx, db.SaveArtistOpts{
+				Name:          getPrimaryAliasFromAliasSlice(ia.Aliases),
+				Image:         imgid,
+				ImageSrc:      ia.ImageUrl,
+				MusicBrainzID: mbid,
+				Aliases:       utils.FlattenAliases(ia.Aliases),
 			})
 			if err != nil {
-				return fmt.Errorf("ImportBeatScrobbleFile: %w", err)
+				return fmt.Errorf("saveBeatScrobbleListen: %w", err)
 			}
-			albumId = album.ID
+			artistIds = append(artistIds, artist.ID)
 		} else if err != nil {
-			return fmt.Errorf("ImportBeatScrobbleFile: %w", err)
+			return fmt.Errorf("saveBeatScrobbleListen: %w", err)
 		} else {
-			albumId = album.ID
+			artistIds = append(artistIds, artist.ID)
 		}
-
-		// call associate track
-		mbid = uuid.Nil
-		if data.Listens[i].Track.MBID != nil {
-			mbid = *data.Listens[i].Track.MBID
+	}
+	if len(artistIds) == 0 {
+		return fmt.Errorf("saveBeatScrobbleListen: %w: listen has no artists", ErrInvalidItem)
+	}
+	// call associate album
+	albumId := int32(0)
+	mbid = uuid.Nil
+	if listen.Album.MBID != nil {
+		mbid = *listen.Album.MBID
+	}
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{
+		MusicBrainzID: mbid,
+		Title:         getPrimaryAliasFromAliasSlice(listen.Album.Aliases),
+		ArtistID:      artistIds[0],
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		var imgid = uuid.Nil
+		// not a perfect way to check if the image url is an actual source vs manual upload but
+		// im like 99% sure it will work perfectly
+		if strings.HasPrefix(listen.Album.ImageUrl, "http") {
+			imgid = uuid.New()
 		}
-		track, err := store.GetTrack(ctx, db.GetTrackOpts{
-			MusicBrainzID: mbid,
-			Title:         getPrimaryAliasFromAliasSlice(data.Listens[i].Track.Aliases),
-			ArtistIDs:     artistIds,
+		// save album
+		album, err = store.SaveAlbum(ctx, db.SaveAlbumOpts{
+			Title:          getPrimaryAliasFromAliasSlice(listen.Album.Aliases),
+			Image:          imgid,
+			ImageSrc:       listen.Album.ImageUrl,
+			MusicBrainzID:  mbid,
+			Aliases:        utils.FlattenAliases(listen.Album.Aliases),
+			ArtistIDs:      artistIds,
+			VariousArtists: listen.Album.VariousArtists,
 		})
-		if errors.Is(err, pgx.ErrNoRows) {
-			// save track
-			track, err = store.SaveTrack(ctx, db.SaveTrackOpts{
-				Title:          getPrimaryAliasFromAliasSlice(data.Listens[i].Track.Aliases),
-				RecordingMbzID: mbid,
-				Duration:       int32(data.Listens[i].Track.Duration),
-				ArtistIDs:      artistIds,
-				AlbumID:        albumId,
-			})
-			if err != nil {
-				return fmt.Errorf("ImportBeatScrobbleFile: %w", err)
-			}
-			// save track aliases
-			err = store.SaveTrackAliases(ctx, track.ID, utils.FlattenAliases(data.Listens[i].Track.Aliases), "Import")
-			if err != nil {
-				return fmt.Errorf("ImportBeatScrobbleFile: %w", err)
-			}
-		} else if err != nil {
-			return fmt.Errorf("ImportBeatScrobbleFile: %w", err)
+		if err != nil {
+			return fmt.Errorf("saveBeatScrobbleListen: %w", err)
 		}
+		albumId = album.ID
+	} else if err != nil {
+		return fmt.Errorf("saveBeatScrobbleListen: %w", err)
+	} else {
+		albumId = album.ID
+	}
 
-		// save listen
-		err = store.SaveListen(ctx, db.SaveListenOpts{
-			TrackID: track.ID,
-			Time:    data.Listens[i].ListenedAt,
-			UserID:  1,
+	// call associate track
+	mbid = uuid.Nil
+	if listen.Track.MBID != nil {
+		mbid = *listen.Track.MBID
+	}
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{
+		MusicBrainzID: mbid,
+		Title:         getPrimaryAliasFromAliasSlice(listen.Track.Aliases),
+		ArtistIDs:     artistIds,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// save track
+		track, err = store.SaveTrack(ctx, db.SaveTrackOpts{
+			Title:          getPrimaryAliasFromAliasSlice(listen.Track.Aliases),
+			RecordingMbzID: mbid,
+			Duration:       int32(listen.Track.Duration),
+			ArtistIDs:      artistIds,
+			AlbumID:        albumId,
 		})
 		if err != nil {
-			return fmt.Errorf("ImportBeatScrobbleFile: %w", err)
+			return fmt.Errorf("saveBeatScrobbleListen: %w", err)
+		}
+		// save track aliases
+		err = store.SaveTrackAliases(ctx, track.ID, utils.FlattenAliases(listen.Track.Aliases), "Import")
+		if err != nil {
+			return fmt.Errorf("saveBeatScrobbleListen: %w", err)
 		}
+	} else if err != nil {
+		return fmt.Errorf("saveBeatScrobbleListen: %w", err)
+	}
 
-		l.Info().Msgf("ImportBeatScrobbleFile: Imported listen for track %s", track.Title)
-		count++
+	// save listen
+	err = store.SaveListen(ctx, db.SaveListenOpts{
+		TrackID:                 track.ID,
+		Time:                    listen.ListenedAt,
+		UserID:                  userId,
+		Client:                  listen.Client,
+		DurationMs:              listen.DurationMs,
+		MediaPlayer:             listen.MediaPlayer,
+		SubmissionClientVersion: listen.SubmissionClientVersion,
+		Tags:                    listen.Tags,
+		OriginURL:               listen.OriginURL,
+	})
+	if err != nil {
+		return fmt.Errorf("saveBeatScrobbleListen: %w", err)
 	}
 
-	l.Info().Msgf("importBeatScrobbleData: Finished importing %d listens", count)
+	l.Info().Msgf("ImportBeatScrobbleFile: Imported listen for track %s", track.Title)
 	return nil
 }
+
 func getPrimaryAliasFromAliasSlice(aliases []models.Alias) string {
 	for _, a := range aliases {
 		if a.Primary {
@@ -64,62 +64,11 @@ func ImportLastFMFile(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCall
 	count := 0
 	for _, item := range export {
 		for _, track := range item.Track {
-			album := track.Album.Text
-			if album == "" {
-				album = track.Name
-			}
-			if track.Name == "" || track.Artist.Text == "" {
-				l.Debug().Msg("Skipping invalid LastFM import item")
+			opts, ok := lastFMListen(ctx, track)
+			if !ok {
 				continue
 			}
-			albumMbzID, err := uuid.Parse(track.Album.MBID)
-			if err != nil {
-				albumMbzID = uuid.Nil
-			}
-			artistMbzID, err := uuid.Parse(track.Artist.MBID)
-			if err != nil {
-				artistMbzID = uuid.Nil
-			}
-			trackMbzID, err := uuid.Parse(track.MBID)
-			if err != nil {
-				trackMbzID = uuid.Nil
-			}
-			var ts time.Time
-			unix, err := strconv.ParseInt(track.Date.Unix, 10, 64)
-			if err != nil {
-				ts, err = time.Parse("02 Jan 2006, 15:04", track.Date.Text)
-				if err != nil {
-					l.Err(err).Msg("Could not parse time from listen activity, skipping...")
-					continue
-				}
-			} else {
-				ts = time.Unix(unix, 0).UTC()
-			}
-			if !inImportTimeWindow(ts) {
-				l.Debug().Msgf("Skipping import due to import time rules")
-				continue
-			}
-
-			var artistMbidMap []catalog.ArtistMbidMap
-			if artistMbzID != uuid.Nil {
-				artistMbidMap = append(artistMbidMap, catalog.ArtistMbidMap{Artist: track.Artist.Text, Mbid: artistMbzID})
-			}
-
-			opts := catalog.SubmitListenOpts{
-				MbzCaller:          mbzc,
-				Artist:             track.Artist.Text,
-				ArtistNames:        []string{track.Artist.Text},
-				ArtistMbzIDs:       []uuid.UUID{artistMbzID},
-				TrackTitle:         track.Name,
-				RecordingMbzID:     trackMbzID,
-				ReleaseTitle:       album,
-				ReleaseMbzID:       albumMbzID,
-				ArtistMbidMappings: artistMbidMap,
-				Client:             "lastfm",
-				Time:               ts,
-				UserID:             1,
-				SkipCacheImage:     !cfg.FetchImagesDuringImport(),
-			}
+			opts.MbzCaller = mbzc
 			err = catalog.SubmitListen(ctx, store, opts)
 			if err != nil {
 				l.Err(err).Msg("Failed to import LastFM playback item")
@@ -131,3 +80,66 @@ func ImportLastFMFile(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCall
 	}
 	return finishImport(ctx, filename, count)
 }
+
+// lastFMListen returns the listen of the track, and false when it has no
+// name or artist, its time cannot be parsed or is outside of the import
+// window.
+func lastFMListen(ctx context.Context, track LastFMTrack) (catalog.SubmitListenOpts, bool) {
+	l := logger.FromContext(ctx)
+	album := track.Album.Text
+	if album == "" {
+		album = track.Name
+	}
+	if track.Name == "" || track.Artist.Text == "" {
+		l.Debug().Msg("Skipping invalid LastFM import item")
+		return catalog.SubmitListenOpts{}, false
+	}
+	albumMbzID, err := uuid.Parse(track.Album.MBID)
+	if err != nil {
+		albumMbzID = uuid.Nil
+	}
+	artistMbzID, err := uuid.Parse(track.Artist.MBID)
+	if err != nil {
+		artistMbzID = uuid.Nil
+	}
+	trackMbzID, err := uuid.Parse(track.MBID)
+	if err != nil {
+		trackMbzID = uuid.Nil
+	}
+	var ts time.Time
+	unix, err := strconv.ParseInt(track.Date.Unix, 10, 64)
+	if err != nil {
+		ts, err = time.Parse("02 Jan 2006, 15:04", track.Date.Text)
+		if err != nil {
+			l.Err(err).Msg("Could not parse time from listen activity, skipping...")
+			return catalog.SubmitListenOpts{}, false
+		}
+	} else {
+		ts = time.Unix(unix, 0).UTC()
+	}
+	if !inImportTimeWindow(ts) {
+		l.Debug().Msgf("Skipping import due to import time rules")
+		return catalog.SubmitListenOpts{}, false
+	}
+
+	var artistMbidMap []catalog.ArtistMbidMap
+	if artistMbzID != uuid.Nil {
+		artistMbidMap = append(artistMbidMap, catalog.ArtistMbidMap{Artist: track.Artist.Text, Mbid: artistMbzID})
+	}
+
+	return catalog.SubmitListenOpts{
+		Artist:             track.Artist.Text,
+		ArtistNames:        []string{track.Artist.Text},
+		ArtistMbzIDs:       []uuid.UUID{artistMbzID},
+		TrackTitle:         track.Name,
+		RecordingMbzID:     trackMbzID,
+		ReleaseTitle:       album,
+		ReleaseMbzID:       albumMbzID,
+		ArtistMbidMappings: artistMbidMap,
+		Client:             "lastfm",
+		Time:               ts,
+		UserID:             1,
+		SkipCacheImage:     !cfg.FetchImagesDuringImport(),
+		SkipMbzSearch:      true,
+	}, true
+}
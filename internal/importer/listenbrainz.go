@@ -12,12 +12,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/SaturnX-Dev/Beat-Scrobble/engine/handlers"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
 	"github.com/google/uuid"
 )
@@ -72,77 +72,17 @@ func ImportListenBrainzFile(ctx context.Context, store db.DB, mbzc mbz.MusicBrai
 	count := 0
 	for scanner.Scan() {
 		line := scanner.Bytes()
-		payload := new(handlers.LbzSubmitListenPayload)
+		payload := new(models.LbzSubmitListenPayload)
 		err := json.Unmarshal(line, payload)
 		if err != nil {
 			fmt.Println("Error unmarshaling JSON:", err)
 			continue
 		}
-		ts := time.Unix(payload.ListenedAt, 0)
-		if !inImportTimeWindow(ts) {
-			l.Debug().Msgf("Skipping import due to import time rules")
+		opts, ok := listenBrainzListen(ctx, payload)
+		if !ok {
 			continue
 		}
-		artistMbzIDs, err := utils.ParseUUIDSlice(payload.TrackMeta.AdditionalInfo.ArtistMBIDs)
-		if err != nil {
-			l.Debug().Err(err).Msg("Failed to parse one or more uuids")
-		}
-		rgMbzID, err := uuid.Parse(payload.TrackMeta.AdditionalInfo.ReleaseGroupMBID)
-		if err != nil {
-			rgMbzID = uuid.Nil
-		}
-		releaseMbzID, err := uuid.Parse(payload.TrackMeta.AdditionalInfo.ReleaseMBID)
-		if err != nil {
-			releaseMbzID = uuid.Nil
-		}
-		recordingMbzID, err := uuid.Parse(payload.TrackMeta.AdditionalInfo.RecordingMBID)
-		if err != nil {
-			recordingMbzID = uuid.Nil
-		}
-
-		var client string
-		if payload.TrackMeta.AdditionalInfo.MediaPlayer != "" {
-			client = payload.TrackMeta.AdditionalInfo.MediaPlayer
-		} else if payload.TrackMeta.AdditionalInfo.SubmissionClient != "" {
-			client = payload.TrackMeta.AdditionalInfo.SubmissionClient
-		}
-
-		var duration int32
-		if payload.TrackMeta.AdditionalInfo.Duration != 0 {
-			duration = payload.TrackMeta.AdditionalInfo.Duration
-		} else if payload.TrackMeta.AdditionalInfo.DurationMs != 0 {
-			duration = payload.TrackMeta.AdditionalInfo.DurationMs / 1000
-		}
-
-		var artistMbidMap []catalog.ArtistMbidMap
-		for _, a := range payload.TrackMeta.MBIDMapping.Artists {
-			if a.ArtistMBID == "" || a.ArtistName == "" {
-				continue
-			}
-			mbid, err := uuid.Parse(a.ArtistMBID)
-			if err != nil {
-				l.Err(err).Msgf("LbzSubmitListenHandler: Failed to parse UUID for artist '%s'", a.ArtistName)
-			}
-			artistMbidMap = append(artistMbidMap, catalog.ArtistMbidMap{Artist: a.ArtistName, Mbid: mbid})
-		}
-
-		opts := catalog.SubmitListenOpts{
-			MbzCaller:          mbzc,
-			ArtistNames:        payload.TrackMeta.AdditionalInfo.ArtistNames,
-			Artist:             payload.TrackMeta.ArtistName,
-			ArtistMbzIDs:       artistMbzIDs,
-			TrackTitle:         payload.TrackMeta.TrackName,
-			RecordingMbzID:     recordingMbzID,
-			ReleaseTitle:       payload.TrackMeta.ReleaseName,
-			ReleaseMbzID:       releaseMbzID,
-			ReleaseGroupMbzID:  rgMbzID,
-			ArtistMbidMappings: artistMbidMap,
-			Duration:           duration,
-			Time:               ts,
-			UserID:             1,
-			Client:             client,
-			SkipCacheImage:     !cfg.FetchImagesDuringImport(),
-		}
+		opts.MbzCaller = mbzc
 		err = catalog.SubmitListen(ctx, store, opts)
 		if err != nil {
 			l.Err(err).Msg("Failed to import LastFM playback item")
@@ -154,3 +94,74 @@ func ImportListenBrainzFile(ctx context.Context, store db.DB, mbzc mbz.MusicBrai
 	l.Info().Msgf("Finished importing %s; imported %d items", filename, count)
 	return nil
 }
+
+// listenBrainzListen returns the listen of the payload, and false when it is
+// outside of the import window.
+func listenBrainzListen(ctx context.Context, payload *models.LbzSubmitListenPayload) (catalog.SubmitListenOpts, bool) {
+	l := logger.FromContext(ctx)
+	ts := time.Unix(payload.ListenedAt, 0)
+	if !inImportTimeWindow(ts) {
+		l.Debug().Msgf("Skipping import due to import time rules")
+		return catalog.SubmitListenOpts{}, false
+	}
+	artistMbzIDs, err := utils.ParseUUIDSlice(payload.TrackMeta.AdditionalInfo.ArtistMBIDs)
+	if err != nil {
+		l.Debug().Err(err).Msg("Failed to parse one or more uuids")
+	}
+	rgMbzID, err := uuid.Parse(payload.TrackMeta.AdditionalInfo.ReleaseGroupMBID)
+	if err != nil {
+		rgMbzID = uuid.Nil
+	}
+	releaseMbzID, err := uuid.Parse(payload.TrackMeta.AdditionalInfo.ReleaseMBID)
+	if err != nil {
+		releaseMbzID = uuid.Nil
+	}
+	recordingMbzID, err := uuid.Parse(payload.TrackMeta.AdditionalInfo.RecordingMBID)
+	if err != nil {
+		recordingMbzID = uuid.Nil
+	}
+
+	var client string
+	if payload.TrackMeta.AdditionalInfo.MediaPlayer != "" {
+		client = payload.TrackMeta.AdditionalInfo.MediaPlayer
+	} else if payload.TrackMeta.AdditionalInfo.SubmissionClient != "" {
+		client = payload.TrackMeta.AdditionalInfo.SubmissionClient
+	}
+
+	var duration int32
+	if payload.TrackMeta.AdditionalInfo.Duration != 0 {
+		duration = payload.TrackMeta.AdditionalInfo.Duration
+	} else if payload.TrackMeta.AdditionalInfo.DurationMs != 0 {
+		duration = payload.TrackMeta.AdditionalInfo.DurationMs / 1000
+	}
+
+	var artistMbidMap []catalog.ArtistMbidMap
+	for _, a := range payload.TrackMeta.MBIDMapping.Artists {
+		if a.ArtistMBID == "" || a.ArtistName == "" {
+			continue
+		}
+		mbid, err := uuid.Parse(a.ArtistMBID)
+		if err != nil {
+			l.Err(err).Msgf("LbzSubmitListenHandler: Failed to parse UUID for artist '%s'", a.ArtistName)
+		}
+		artistMbidMap = append(artistMbidMap, catalog.ArtistMbidMap{Artist: a.ArtistName, Mbid: mbid})
+	}
+
+	return catalog.SubmitListenOpts{
+		ArtistNames:        payload.TrackMeta.AdditionalInfo.ArtistNames,
+		Artist:             payload.TrackMeta.ArtistName,
+		ArtistMbzIDs:       artistMbzIDs,
+		TrackTitle:         payload.TrackMeta.TrackName,
+		RecordingMbzID:     recordingMbzID,
+		ReleaseTitle:       payload.TrackMeta.ReleaseName,
+		ReleaseMbzID:       releaseMbzID,
+		ReleaseGroupMbzID:  rgMbzID,
+		ArtistMbidMappings: artistMbidMap,
+		Duration:           duration,
+		Time:               ts,
+		UserID:             1,
+		Client:             client,
+		SkipCacheImage:     !cfg.FetchImagesDuringImport(),
+		SkipMbzSearch:      true,
+	}, true
+}
@@ -53,35 +53,11 @@ func ImportMalojaFile(ctx context.Context, store db.DB, filename string) error {
 		return fmt.Errorf("ImportMalojaFile: %w", err)
 	}
 	for _, item := range export.Scrobbles {
-		martists := make([]string, 0)
-		// Maloja has a tendency to have the the artist order ['feature', 'main \u2022 feature'], so
-		// here we try to turn that artist array into ['main', 'feature']
-		item.Track.Artists = utils.MoveFirstMatchToFront(item.Track.Artists, " \u2022 ")
-		for _, an := range item.Track.Artists {
-			ans := strings.Split(an, " \u2022 ")
-			martists = append(martists, ans...)
-		}
-		artists := utils.UniqueIgnoringCase(martists)
-		if len(item.Track.Artists) < 1 || item.Track.Title == "" {
-			l.Debug().Msg("Skipping invalid maloja import item")
-			continue
-		}
-		ts := time.Unix(item.Time, 0)
-		if !inImportTimeWindow(ts) {
-			l.Debug().Msgf("Skipping import due to import time rules")
+		opts, ok := malojaListen(ctx, item)
+		if !ok {
 			continue
 		}
-		opts := catalog.SubmitListenOpts{
-			MbzCaller:      &mbz.MusicBrainzClient{},
-			Artist:         item.Track.Artists[0],
-			ArtistNames:    artists,
-			TrackTitle:     item.Track.Title,
-			ReleaseTitle:   item.Track.Album.Title,
-			Time:           ts.Local(),
-			Client:         "maloja",
-			UserID:         1,
-			SkipCacheImage: !cfg.FetchImagesDuringImport(),
-		}
+		opts.MbzCaller = &mbz.MusicBrainzClient{}
 		err = catalog.SubmitListen(ctx, store, opts)
 		if err != nil {
 			l.Err(err).Msg("Failed to import maloja playback item")
@@ -91,3 +67,38 @@ func ImportMalojaFile(ctx context.Context, store db.DB, filename string) error {
 	}
 	return finishImport(ctx, filename, len(export.Scrobbles))
 }
+
+// malojaListen returns the listen of the scrobble, and false when it has no
+// artist or title or is outside of the import window.
+func malojaListen(ctx context.Context, item MalojaExportItem) (catalog.SubmitListenOpts, bool) {
+	l := logger.FromContext(ctx)
+	martists := make([]string, 0)
+	// Maloja has a tendency to have the the artist order ['feature', 'main \u2022 feature'], so
+	// here we try to turn that artist array into ['main', 'feature']
+	item.Track.Artists = utils.MoveFirstMatchToFront(item.Track.Artists, " \u2022 ")
+	for _, an := range item.Track.Artists {
+		ans := strings.Split(an, " \u2022 ")
+		martists = append(martists, ans...)
+	}
+	artists := utils.UniqueIgnoringCase(martists)
+	if len(item.Track.Artists) < 1 || item.Track.Title == "" {
+		l.Debug().Msg("Skipping invalid maloja import item")
+		return catalog.SubmitListenOpts{}, false
+	}
+	ts := time.Unix(item.Time, 0)
+	if !inImportTimeWindow(ts) {
+		l.Debug().Msgf("Skipping import due to import time rules")
+		return catalog.SubmitListenOpts{}, false
+	}
+	return catalog.SubmitListenOpts{
+		Artist:         item.Track.Artists[0],
+		ArtistNames:    artists,
+		TrackTitle:     item.Track.Title,
+		ReleaseTitle:   item.Track.Album.Title,
+		Time:           ts.Local(),
+		Client:         "maloja",
+		UserID:         1,
+		SkipCacheImage: !cfg.FetchImagesDuringImport(),
+		SkipMbzSearch:  true,
+	}, true
+}
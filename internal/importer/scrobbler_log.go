@@ -0,0 +1,255 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/google/uuid"
+)
+
+// ScrobblerLog is an Audioscrobbler .scrobbler.log file, as written by
+// Rockbox and other portable players.
+type ScrobblerLog struct {
+	Client string
+	// listens rated L, in the order they were logged
+	Entries []ScrobblerLogEntry
+	// entries rated S, which were skipped on the player
+	Skipped int
+	// lines that could not be parsed
+	Invalid int
+}
+
+type ScrobblerLogEntry struct {
+	Artist   string
+	Album    string
+	Title    string
+	Duration int32 // in seconds
+	Time     time.Time
+	MbzID    uuid.UUID // of the recording, when the player knew it
+}
+
+var ErrNotScrobblerLog = errors.New("not an Audioscrobbler log: missing #AUDIOSCROBBLER header")
+
+// columns of a log entry, the last one being optional
+const (
+	logColArtist = iota
+	logColAlbum
+	logColTitle
+	logColTrackNum
+	logColDuration
+	logColRating
+	logColTimestamp
+	logColMbzID
+)
+
+// ParseScrobblerLog parses a .scrobbler.log. Players that do not know their
+// timezone write #TZ/UNKNOWN and log their local wall clock time as if it
+// were UTC; those times are taken to be in loc.
+func ParseScrobblerLog(r io.Reader, loc *time.Location) (*ScrobblerLog, error) {
+	lr := newScrobblerLogReader(r, loc)
+	log := &ScrobblerLog{}
+	for {
+		entry, err := lr.next()
+		if err == io.EOF {
+			break
+		} else if errors.Is(err, errSkippedEntry) {
+			log.Skipped++
+			continue
+		} else if errors.Is(err, ErrInvalidItem) {
+			log.Invalid++
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("ParseScrobblerLog: %w", err)
+		}
+		log.Entries = append(log.Entries, *entry)
+	}
+	log.Client = lr.client
+	return log, nil
+}
+
+var errSkippedEntry = errors.New("entry was skipped on the player")
+
+// scrobblerLogReader reads the entries of a .scrobbler.log one line at a time.
+type scrobblerLogReader struct {
+	scanner    *bufio.Scanner
+	loc        *time.Location
+	client     string
+	tzKnown    bool
+	headerSeen bool
+}
+
+func newScrobblerLogReader(r io.Reader, loc *time.Location) *scrobblerLogReader {
+	return &scrobblerLogReader{scanner: bufio.NewScanner(r), loc: loc, tzKnown: true}
+}
+
+// next returns the next entry rated L, reading past the headers before it.
+// Entries rated S return errSkippedEntry, and lines that cannot be parsed an
+// error wrapping ErrInvalidItem. Returns io.EOF after the last line.
+func (r *scrobblerLogReader) next() (*ScrobblerLogEntry, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimRight(r.scanner.Text(), "\r")
+		if !r.headerSeen {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			key, value, _ := strings.Cut(line[1:], "/")
+			switch key {
+			case "AUDIOSCROBBLER":
+				r.headerSeen = true
+			case "TZ":
+				r.tzKnown = value != "UNKNOWN"
+			case "CLIENT":
+				r.client = strings.TrimSpace(value)
+			}
+			continue
+		}
+		if !r.headerSeen {
+			return nil, ErrNotScrobblerLog
+		}
+		return r.parseEntry(line)
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !r.headerSeen {
+		return nil, ErrNotScrobblerLog
+	}
+	return nil, io.EOF
+}
+
+func (r *scrobblerLogReader) parseEntry(line string) (*ScrobblerLogEntry, error) {
+	cols := strings.Split(line, "\t")
+	if len(cols) <= logColTimestamp || cols[logColArtist] == "" || cols[logColTitle] == "" {
+		return nil, fmt.Errorf("%w: missing artist, title or timestamp", ErrInvalidItem)
+	}
+	unix, err := strconv.ParseInt(cols[logColTimestamp], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: timestamp %q", ErrInvalidItem, cols[logColTimestamp])
+	}
+	switch cols[logColRating] {
+	case "L":
+	case "S":
+		return nil, errSkippedEntry
+	default:
+		return nil, fmt.Errorf("%w: rating %q", ErrInvalidItem, cols[logColRating])
+	}
+	ts := time.Unix(unix, 0).UTC()
+	if !r.tzKnown {
+		ts = time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, r.loc)
+	}
+	duration, _ := strconv.Atoi(cols[logColDuration])
+	entry := &ScrobblerLogEntry{
+		Artist:   cols[logColArtist],
+		Album:    cols[logColAlbum],
+		Title:    cols[logColTitle],
+		Duration: int32(duration),
+		Time:     ts,
+	}
+	if len(cols) > logColMbzID {
+		if id, err := uuid.Parse(cols[logColMbzID]); err == nil {
+			entry.MbzID = id
+		}
+	}
+	return entry, nil
+}
+
+// ImportScrobblerLogFile imports a .scrobbler.log from the import directory,
+// taking times in logs without a timezone to be in the server's.
+func ImportScrobblerLogFile(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, filename string) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msgf("Beginning scrobbler.log import on file: %s", filename)
+	file, err := os.Open(path.Join(cfg.ConfigDir(), "import", filename))
+	if err != nil {
+		l.Err(err).Msgf("Failed to read import file: %s", filename)
+		return fmt.Errorf("ImportScrobblerLogFile: %w", err)
+	}
+	defer file.Close()
+	log, err := ParseScrobblerLog(file, time.Local)
+	if err != nil {
+		return fmt.Errorf("ImportScrobblerLogFile: %w", err)
+	}
+	count, err := ImportScrobblerLog(ctx, store, mbzc, log, 1)
+	if err != nil {
+		return fmt.Errorf("ImportScrobblerLogFile: %w", err)
+	}
+	return finishImport(ctx, filename, count)
+}
+
+// ImportScrobblerLog submits the listens of the log for the user, returning
+// how many were imported.
+func ImportScrobblerLog(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, log *ScrobblerLog, userId int32) (int, error) {
+	l := logger.FromContext(ctx)
+	if log.Skipped > 0 || log.Invalid > 0 {
+		l.Info().Msgf("Ignoring %d skipped and %d invalid scrobbler.log entries", log.Skipped, log.Invalid)
+	}
+	var throttleFunc = func() {}
+	if ms := cfg.ThrottleImportMs(); ms > 0 {
+		throttleFunc = func() {
+			time.Sleep(time.Duration(ms) * time.Millisecond)
+		}
+	}
+	client := log.Client
+	if client == "" {
+		client = "scrobbler.log"
+	}
+	count := 0
+	for _, entry := range log.Entries {
+		opts, ok := scrobblerLogListen(ctx, client, entry)
+		if !ok {
+			continue
+		}
+		opts.MbzCaller = mbzc
+		opts.UserID = userId
+		err := catalog.SubmitListen(ctx, store, opts)
+		if err != nil {
+			l.Err(err).Msg("Failed to import scrobbler.log entry")
+			return count, fmt.Errorf("ImportScrobblerLog: %w", err)
+		}
+		count++
+		throttleFunc()
+	}
+	return count, nil
+}
+
+// scrobblerLogListen returns the listen of the entry, and false when it is
+// outside of the import window.
+func scrobblerLogListen(ctx context.Context, client string, entry ScrobblerLogEntry) (catalog.SubmitListenOpts, bool) {
+	l := logger.FromContext(ctx)
+	if !inImportTimeWindow(entry.Time) {
+		l.Debug().Msgf("Skipping import due to import time rules")
+		return catalog.SubmitListenOpts{}, false
+	}
+	album := entry.Album
+	if album == "" {
+		album = entry.Title
+	}
+	return catalog.SubmitListenOpts{
+		Artist:         entry.Artist,
+		ArtistNames:    []string{entry.Artist},
+		TrackTitle:     entry.Title,
+		RecordingMbzID: entry.MbzID,
+		ReleaseTitle:   album,
+		Duration:       entry.Duration,
+		Client:         client,
+		Time:           entry.Time,
+		UserID:         1,
+		SkipCacheImage: !cfg.FetchImagesDuringImport(),
+		SkipMbzSearch:  true,
+	}, true
+}
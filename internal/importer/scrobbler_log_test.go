@@ -0,0 +1,63 @@
+package importer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importer"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScrobblerLog(t *testing.T) {
+	input := "#AUDIOSCROBBLER/1.1\r\n" +
+		"#TZ/UTC\r\n" +
+		"#CLIENT/Rockbox ipodvideo $Revision$\r\n" +
+		"Artist\tAlbum\tTitle\t1\t215\tL\t1700000000\t3e6c4cf8-5bdf-4a6e-a5e5-5d0d2e8e9f9a\r\n" +
+		"Artist\tAlbum\tSkipped\t2\t200\tS\t1700000300\t\r\n" +
+		"Artist\t\tNo Album\t\t180\tL\t1700000600\r\n" +
+		"Artist\tAlbum\tBroken\t3\t180\tL\tyesterday\t\r\n"
+
+	log, err := importer.ParseScrobblerLog(strings.NewReader(input), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, "Rockbox ipodvideo $Revision$", log.Client)
+	assert.Equal(t, 1, log.Skipped)
+	assert.Equal(t, 1, log.Invalid)
+	require.Len(t, log.Entries, 2)
+
+	assert.Equal(t, importer.ScrobblerLogEntry{
+		Artist:   "Artist",
+		Album:    "Album",
+		Title:    "Title",
+		Duration: 215,
+		Time:     time.Unix(1700000000, 0).UTC(),
+		MbzID:    uuid.MustParse("3e6c4cf8-5bdf-4a6e-a5e5-5d0d2e8e9f9a"),
+	}, log.Entries[0])
+	assert.Equal(t, "No Album", log.Entries[1].Title)
+	assert.Empty(t, log.Entries[1].Album)
+	assert.Equal(t, uuid.Nil, log.Entries[1].MbzID)
+}
+
+func TestParseScrobblerLog_UnknownTZ(t *testing.T) {
+	input := "#AUDIOSCROBBLER/1.1\n" +
+		"#TZ/UNKNOWN\n" +
+		"Artist\tAlbum\tTitle\t1\t215\tL\t1700000000\n"
+
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	log, err := importer.ParseScrobblerLog(strings.NewReader(input), loc)
+	require.NoError(t, err)
+	require.Len(t, log.Entries, 1)
+	// 2023-11-14 22:13:20 on the player's clock, five hours behind UTC
+	assert.True(t, time.Date(2023, 11, 14, 22, 13, 20, 0, loc).Equal(log.Entries[0].Time))
+	assert.True(t, time.Unix(1700000000, 0).Add(5*time.Hour).Equal(log.Entries[0].Time))
+}
+
+func TestParseScrobblerLog_NotALog(t *testing.T) {
+	_, err := importer.ParseScrobblerLog(strings.NewReader("artist\ttitle\n"), time.UTC)
+	assert.ErrorIs(t, err, importer.ErrNotScrobblerLog)
+	_, err = importer.ParseScrobblerLog(strings.NewReader(""), time.UTC)
+	assert.ErrorIs(t, err, importer.ErrNotScrobblerLog)
+}
@@ -0,0 +1,446 @@
+package importer
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/export"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+)
+
+// ErrInvalidItem is wrapped by the errors of items of import files that
+// cannot be read. Reading goes on with the next item.
+var ErrInvalidItem = errors.New("invalid import item")
+
+// DetectFormat returns the format of an import file by its name, as exports
+// of each service are named, and false when the name is not one of them.
+func DetectFormat(filename string) (models.ImportFormat, bool) {
+	switch {
+	case strings.Contains(filename, "Streaming_History_Audio"):
+		return models.ImportSpotify, true
+	case strings.Contains(filename, "maloja"):
+		return models.ImportMaloja, true
+	case strings.Contains(filename, "recenttracks"):
+		return models.ImportLastFM, true
+	case strings.Contains(filename, "listenbrainz"):
+		return models.ImportListenBrainz, true
+	case strings.Contains(filename, "watch-history") && strings.HasSuffix(filename, ".json"):
+		return models.ImportYouTubeMusic, true
+	case strings.HasSuffix(filename, "scrobbler.log"):
+		return models.ImportScrobblerLog, true
+	case strings.HasSuffix(strings.ToLower(filename), ".csv"):
+		return models.ImportCsv, true
+	case strings.Contains(filename, "beat_scrobble") || strings.Contains(filename, "beat-scrobble") || strings.Contains(filename, "koito"):
+		return models.ImportBeatScrobble, true
+	}
+	return "", false
+}
+
+// Listen is a listen read from an import file by a Source.
+type Listen struct {
+	// the listen, submitted like a scrobble
+	Opts catalog.SubmitListenOpts
+	// set instead for listens of Beat Scrobble exports, which are saved with
+	// the artists, album and track of the export
+	beatScrobble *export.BeatScrobbleListen
+}
+
+// Submit saves the listen for the user.
+func (l *Listen) Submit(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, userId int32) error {
+	if l.beatScrobble != nil {
+		return saveBeatScrobbleListen(ctx, store, l.beatScrobble, userId)
+	}
+	opts := l.Opts
+	opts.MbzCaller = mbzc
+	opts.UserID = userId
+	return catalog.SubmitListen(ctx, store, opts)
+}
+
+// Source reads the items of an import file one at a time, so that an import
+// can be checkpointed by how many items it has read, and resumed by reading
+// past as many again.
+type Source interface {
+	// Next reads the next item, returning its listen, nil when it is not one
+	// to import, or io.EOF after the last item.
+	Next() (*Listen, error)
+	// BytesRead is how much of Size has been read so far.
+	BytesRead() int64
+	// Size is how many bytes there are to read, which for zip files is the
+	// uncompressed size of the files in them that are read.
+	Size() int64
+	Close() error
+}
+
+type SourceOpts struct {
+	Format models.ImportFormat
+	// the profiles to read csv files with; the first that matches the
+	// columns of the file is used
+	CsvProfiles []models.CsvImportProfile
+	// the timezone of scrobbler.log files that do not know theirs, the
+	// server's when nil
+	Location *time.Location
+}
+
+// OpenSource opens the import file at name to be read as opts.Format. The
+// file is checked to be of the format as far as that can be done without
+// reading its items.
+func OpenSource(ctx context.Context, name string, opts SourceOpts) (Source, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("OpenSource: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("OpenSource: %w", err)
+	}
+	cr := &countingReader{r: f}
+	s := &source{
+		size:      info.Size(),
+		bytesRead: func() int64 { return cr.n },
+		closers:   []io.Closer{f},
+	}
+	switch opts.Format {
+	case models.ImportSpotify:
+		s.next, err = jsonListens(ctx, cr, "", spotifyListen)
+	case models.ImportMaloja:
+		s.next, err = jsonListens(ctx, cr, "scrobbles", malojaListen)
+	case models.ImportYouTubeMusic:
+		s.next, err = jsonListens(ctx, cr, "", youTubeMusicListen)
+	case models.ImportLastFM:
+		s.next, err = lastFMListens(ctx, cr)
+	case models.ImportListenBrainz:
+		err = s.readListenBrainz(ctx, f, cr)
+	case models.ImportScrobblerLog:
+		s.next = scrobblerLogListens(ctx, cr, opts.Location)
+	case models.ImportCsv:
+		s.next, err = csvListens(ctx, cr, opts.CsvProfiles)
+	case models.ImportBeatScrobble:
+		s.next, err = beatScrobbleListens(cr)
+	default:
+		err = fmt.Errorf("unknown import format '%s'", opts.Format)
+	}
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("OpenSource: %w", err)
+	}
+	return s, nil
+}
+
+// source is a Source reading its items with next.
+type source struct {
+	next      func() (*Listen, error)
+	bytesRead func() int64
+	size      int64
+	closers   []io.Closer
+}
+
+func (s *source) Next() (*Listen, error) {
+	return s.next()
+}
+
+func (s *source) BytesRead() int64 {
+	return s.bytesRead()
+}
+
+func (s *source) Size() int64 {
+	return s.size
+}
+
+func (s *source) Close() error {
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		errs = append(errs, s.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func toListen(opts catalog.SubmitListenOpts, ok bool) (*Listen, error) {
+	if !ok {
+		return nil, nil
+	}
+	return &Listen{Opts: opts}, nil
+}
+
+// jsonListens reads the listens of the array that is the file, or that is
+// the value of key in the object that is the file.
+func jsonListens[T any](ctx context.Context, r io.Reader, key string, listen func(context.Context, T) (catalog.SubmitListenOpts, bool)) (func() (*Listen, error), error) {
+	dec := json.NewDecoder(r)
+	if err := seekJSONArray(dec, key, nil); err != nil {
+		return nil, err
+	}
+	items := jsonArray[T](dec)
+	return func() (*Listen, error) {
+		item, err := items()
+		if err != nil {
+			return nil, err
+		}
+		return toListen(listen(ctx, item))
+	}, nil
+}
+
+// seekJSONArray reads dec up to the start of the array that is the value of
+// key in the object at the top of the JSON, or that is at the top itself
+// when key is empty. Values of other keys are decoded into fields when it
+// has them, and skipped otherwise.
+func seekJSONArray(dec *json.Decoder, key string, fields map[string]any) error {
+	if key != "" {
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for {
+			if !dec.More() {
+				return fmt.Errorf("file has no '%s'", key)
+			}
+			t, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if t == key {
+				break
+			}
+			target, ok := fields[t.(string)]
+			if !ok {
+				target = new(json.RawMessage)
+			}
+			if err := dec.Decode(target); err != nil {
+				return err
+			}
+		}
+	}
+	return expectDelim(dec, '[')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected '%v' but found '%v'", want, t)
+	}
+	return nil
+}
+
+// jsonArray returns a func decoding the next item of the array dec is in,
+// which returns io.EOF after the last one.
+func jsonArray[T any](dec *json.Decoder) func() (T, error) {
+	done := false
+	return func() (T, error) {
+		var item T
+		if done {
+			return item, io.EOF
+		}
+		if !dec.More() {
+			done = true
+			// a file cut short ends with an error rather than the array
+			if err := expectDelim(dec, ']'); err != nil {
+				return item, err
+			}
+			return item, io.EOF
+		}
+		if err := dec.Decode(&item); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return item, fmt.Errorf("%w: %w", ErrInvalidItem, err)
+			}
+			return item, err
+		}
+		return item, nil
+	}
+}
+
+// lastFMListens reads the tracks of each page of the export in turn.
+func lastFMListens(ctx context.Context, r io.Reader) (func() (*Listen, error), error) {
+	dec := json.NewDecoder(r)
+	if err := seekJSONArray(dec, "", nil); err != nil {
+		return nil, err
+	}
+	pages := jsonArray[LastFMExportPage](dec)
+	var tracks []LastFMTrack
+	return func() (*Listen, error) {
+		for len(tracks) == 0 {
+			page, err := pages()
+			if err != nil {
+				return nil, err
+			}
+			tracks = page.Track
+		}
+		track := tracks[0]
+		tracks = tracks[1:]
+		return toListen(lastFMListen(ctx, track))
+	}, nil
+}
+
+// the maximum length of a line of a ListenBrainz export
+const maxListenBrainzLine = 1 << 20
+
+// readListenBrainz reads the listens of a ListenBrainz export zip, or of one
+// of the .jsonl files in it.
+func (s *source) readListenBrainz(ctx context.Context, f *os.File, r io.Reader) error {
+	magic := make([]byte, 4)
+	if _, err := f.ReadAt(magic, 0); err != nil && err != io.EOF {
+		return err
+	}
+	if !bytes.Equal(magic, []byte("PK\x03\x04")) {
+		s.next = listenBrainzLines(ctx, r)
+		return nil
+	}
+
+	zr, err := zip.NewReader(f, s.size)
+	if err != nil {
+		return err
+	}
+	var files []*zip.File
+	s.size = 0
+	for _, zf := range zr.File {
+		if !zf.FileInfo().IsDir() && strings.HasPrefix(zf.Name, "listens/") && strings.HasSuffix(zf.Name, ".jsonl") {
+			files = append(files, zf)
+			s.size += int64(zf.UncompressedSize64)
+		}
+	}
+	var done int64
+	var cr *countingReader
+	var next func() (*Listen, error)
+	s.bytesRead = func() int64 {
+		if cr == nil {
+			return done
+		}
+		return done + cr.n
+	}
+	s.next = func() (*Listen, error) {
+		for {
+			if next != nil {
+				listen, err := next()
+				if err != io.EOF {
+					return listen, err
+				}
+				done += cr.n
+				cr, next = nil, nil
+				last := len(s.closers) - 1
+				s.closers[last].Close()
+				s.closers = s.closers[:last]
+			}
+			if len(files) == 0 {
+				return nil, io.EOF
+			}
+			rc, err := files[0].Open()
+			if err != nil {
+				return nil, err
+			}
+			files = files[1:]
+			s.closers = append(s.closers, rc)
+			cr = &countingReader{r: rc}
+			next = listenBrainzLines(ctx, cr)
+		}
+	}
+	return nil
+}
+
+// listenBrainzLines reads a listen from each line of a .jsonl file.
+func listenBrainzLines(ctx context.Context, r io.Reader) func() (*Listen, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxListenBrainzLine)
+	return func() (*Listen, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			return nil, nil
+		}
+		payload := new(models.LbzSubmitListenPayload)
+		if err := json.Unmarshal(line, payload); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidItem, err)
+		}
+		return toListen(listenBrainzListen(ctx, payload))
+	}
+}
+
+// scrobblerLogListens reads a listen from each entry of a .scrobbler.log.
+// Entries that were skipped on the player are not listens.
+func scrobblerLogListens(ctx context.Context, r io.Reader, loc *time.Location) func() (*Listen, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	lr := newScrobblerLogReader(r, loc)
+	return func() (*Listen, error) {
+		entry, err := lr.next()
+		if errors.Is(err, errSkippedEntry) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		client := lr.client
+		if client == "" {
+			client = "scrobbler.log"
+		}
+		return toListen(scrobblerLogListen(ctx, client, *entry))
+	}
+}
+
+// csvListens reads a listen from each row of a csv file. Rows left out by the
+// filter of the profile are not listens.
+func csvListens(ctx context.Context, r io.Reader, profiles []models.CsvImportProfile) (func() (*Listen, error), error) {
+	cr, err := NewCsvReader(r, profiles...)
+	if err != nil {
+		return nil, err
+	}
+	client := "csv:" + cr.Profile().Name
+	return func() (*Listen, error) {
+		listen, err := cr.read()
+		if errors.Is(err, errCsvFiltered) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return toListen(csvListen(ctx, client, listen))
+	}, nil
+}
+
+// beatScrobbleListens reads the listens of a Beat Scrobble or Koito export.
+// The preferences and theme of the export are not restored.
+func beatScrobbleListens(r io.Reader) (func() (*Listen, error), error) {
+	dec := json.NewDecoder(r)
+	var version string
+	if err := seekJSONArray(dec, "listens", map[string]any{"version": &version}); err != nil {
+		return nil, err
+	}
+	if version != "1" && version != "2" {
+		return nil, fmt.Errorf("unsupported version: %s", version)
+	}
+	items := jsonArray[export.BeatScrobbleListen](dec)
+	return func() (*Listen, error) {
+		item, err := items()
+		if err != nil {
+			return nil, err
+		}
+		return &Listen{beatScrobble: &item}, nil
+	}, nil
+}
@@ -0,0 +1,127 @@
+package importer_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importer"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	env := map[string]string{
+		cfg.DATABASE_URL_ENV: "postgres://unused",
+	}
+	if err := cfg.Load(func(k string) string { return env[k] }, "test"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestDetectFormat(t *testing.T) {
+	for filename, want := range map[string]models.ImportFormat{
+		"Streaming_History_Audio_2023.json":   models.ImportSpotify,
+		"maloja_export.json":                  models.ImportMaloja,
+		"recenttracks-shoko2-1749776100.json": models.ImportLastFM,
+		"listenbrainz_shoko1_1749780844.zip":  models.ImportListenBrainz,
+		"watch-history.json":                  models.ImportYouTubeMusic,
+		".scrobbler.log":                      models.ImportScrobblerLog,
+		"Apple Music Play Activity.CSV":       models.ImportCsv,
+		"beat_scrobble_export_test.json":      models.ImportBeatScrobble,
+		"web_import_1749780844_koito.json":    models.ImportBeatScrobble,
+	} {
+		format, ok := importer.DetectFormat(filename)
+		assert.True(t, ok, filename)
+		assert.Equal(t, want, format, filename)
+	}
+	_, ok := importer.DetectFormat("watch-history.html")
+	assert.False(t, ok)
+}
+
+func readSource(t *testing.T, src importer.Source) (listens []*importer.Listen, invalid int) {
+	for {
+		listen, err := src.Next()
+		if err == io.EOF {
+			return listens, invalid
+		} else if err != nil {
+			require.ErrorIs(t, err, importer.ErrInvalidItem)
+			invalid++
+			continue
+		}
+		listens = append(listens, listen)
+	}
+}
+
+func TestOpenSource_ListenBrainzLines(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "listenbrainz_listens.jsonl")
+	input := `{"listened_at": 1700000000, "track_metadata": {"artist_name": "Artist", "track_name": "Title"}}` + "\n" +
+		"\n" +
+		`{"listened_at": "yesterday"` + "\n" +
+		`{"listened_at": 1700000300, "track_metadata": {"artist_name": "Artist", "track_name": "Other"}}` + "\n"
+	require.NoError(t, os.WriteFile(name, []byte(input), 0644))
+
+	src, err := importer.OpenSource(context.Background(), name, importer.SourceOpts{Format: models.ImportListenBrainz})
+	require.NoError(t, err)
+	defer src.Close()
+
+	listens, invalid := readSource(t, src)
+	assert.Equal(t, 1, invalid)
+	require.Len(t, listens, 3)
+	assert.Nil(t, listens[1])
+	assert.Equal(t, "Title", listens[0].Opts.TrackTitle)
+	assert.Equal(t, "Other", listens[2].Opts.TrackTitle)
+	assert.Equal(t, src.Size(), src.BytesRead())
+}
+
+func TestOpenSource_Resume(t *testing.T) {
+	ctx := context.Background()
+	name := path.Join("..", "..", "test_assets", "maloja_import_test.json")
+	opts := importer.SourceOpts{Format: models.ImportMaloja}
+
+	src, err := importer.OpenSource(ctx, name, opts)
+	require.NoError(t, err)
+	all, invalid := readSource(t, src)
+	src.Close()
+	assert.Zero(t, invalid)
+	require.Len(t, all, 38)
+
+	// reading past as many items as were read before gets the rest
+	src, err = importer.OpenSource(ctx, name, opts)
+	require.NoError(t, err)
+	defer src.Close()
+	for range 30 {
+		_, err := src.Next()
+		require.NoError(t, err)
+	}
+	assert.Less(t, src.BytesRead(), src.Size())
+	rest, _ := readSource(t, src)
+	require.Len(t, rest, 8)
+	assert.Equal(t, all[30].Opts, rest[0].Opts)
+}
+
+func TestOpenSource_WrongFormat(t *testing.T) {
+	name := path.Join("..", "..", "test_assets", "Streaming_History_Audio_spotify_import_test.json")
+	_, err := importer.OpenSource(context.Background(), name, importer.SourceOpts{Format: models.ImportBeatScrobble})
+	assert.Error(t, err)
+	_, err = importer.OpenSource(context.Background(), name, importer.SourceOpts{Format: "mp3"})
+	assert.Error(t, err)
+}
+
+func TestOpenSource_Truncated(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "maloja_export.json")
+	require.NoError(t, os.WriteFile(name, []byte(`{"scrobbles": [`), 0644))
+
+	src, err := importer.OpenSource(context.Background(), name, importer.SourceOpts{Format: models.ImportMaloja})
+	require.NoError(t, err)
+	defer src.Close()
+	_, err = src.Next()
+	assert.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
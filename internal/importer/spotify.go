@@ -46,29 +46,11 @@ func ImportSpotifyFile(ctx context.Context, store db.DB, filename string) error
 	}
 
 	for _, item := range export {
-		if item.ReasonEnd != "trackdone" {
+		opts, ok := spotifyListen(ctx, item)
+		if !ok {
 			continue
 		}
-		if !inImportTimeWindow(item.Timestamp) {
-			l.Debug().Msgf("Skipping import due to import time rules")
-			continue
-		}
-		dur := item.MsPlayed
-		if item.TrackName == "" || item.ArtistName == "" {
-			l.Debug().Msg("Skipping non-track item")
-			continue
-		}
-		opts := catalog.SubmitListenOpts{
-			MbzCaller:      &mbz.MusicBrainzClient{},
-			Artist:         item.ArtistName,
-			TrackTitle:     item.TrackName,
-			ReleaseTitle:   item.AlbumName,
-			Duration:       dur / 1000,
-			Time:           item.Timestamp,
-			Client:         "spotify",
-			UserID:         1,
-			SkipCacheImage: !cfg.FetchImagesDuringImport(),
-		}
+		opts.MbzCaller = &mbz.MusicBrainzClient{}
 		err = catalog.SubmitListen(ctx, store, opts)
 		if err != nil {
 			l.Err(err).Msg("Failed to import spotify playback item")
@@ -78,3 +60,31 @@ func ImportSpotifyFile(ctx context.Context, store db.DB, filename string) error
 	}
 	return finishImport(ctx, filename, len(export))
 }
+
+// spotifyListen returns the listen of the item, and false when it is not a
+// track played to the end or is outside of the import window.
+func spotifyListen(ctx context.Context, item SpotifyExportItem) (catalog.SubmitListenOpts, bool) {
+	l := logger.FromContext(ctx)
+	if item.ReasonEnd != "trackdone" {
+		return catalog.SubmitListenOpts{}, false
+	}
+	if !inImportTimeWindow(item.Timestamp) {
+		l.Debug().Msgf("Skipping import due to import time rules")
+		return catalog.SubmitListenOpts{}, false
+	}
+	if item.TrackName == "" || item.ArtistName == "" {
+		l.Debug().Msg("Skipping non-track item")
+		return catalog.SubmitListenOpts{}, false
+	}
+	return catalog.SubmitListenOpts{
+		Artist:         item.ArtistName,
+		TrackTitle:     item.TrackName,
+		ReleaseTitle:   item.AlbumName,
+		Duration:       item.MsPlayed / 1000,
+		Time:           item.Timestamp,
+		Client:         "spotify",
+		UserID:         1,
+		SkipCacheImage: !cfg.FetchImagesDuringImport(),
+		SkipMbzSearch:  true,
+	}, true
+}
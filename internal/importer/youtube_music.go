@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+)
+
+// YouTubeHistoryItem is an entry of the watch-history.json of a Google
+// Takeout export, which holds both YouTube and YouTube Music history.
+type YouTubeHistoryItem struct {
+	Header   string `json:"header"`
+	Title    string `json:"title"`
+	TitleUrl string `json:"titleUrl"`
+	// the channel that uploaded the video
+	Subtitles []YouTubeHistorySubtitle `json:"subtitles"`
+	Time      time.Time                `json:"time"`
+	Products  []string                 `json:"products"`
+}
+
+type YouTubeHistorySubtitle struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+const youTubeMusic = "YouTube Music"
+
+var (
+	// suffixes of the names of channels that upload for an artist
+	youTubeChannelSuffixes = []string{" - Topic", "VEVO", " Official"}
+	// decorations of the titles of music videos
+	youTubeTitleNoise = regexp.MustCompile(`(?i)\s*[\(\[](?:official\s+)?(?:music\s+|lyrics?\s+|hd\s+|4k\s+)?(?:video|audio|lyrics?|visuali[sz]er|mv)[\)\]]`)
+)
+
+// ParseYouTubeMusicItem returns the artist and title of the history entry,
+// and false when it is not a YouTube Music entry or does not name either.
+// Titles of videos uploaded by artists' own channels are often "Artist -
+// Title", in which case the artist is taken from the title.
+func ParseYouTubeMusicItem(item YouTubeHistoryItem) (artist string, title string, ok bool) {
+	if item.Header != youTubeMusic && !slices.Contains(item.Products, youTubeMusic) {
+		return "", "", false
+	}
+	// entries of removed or private videos have no channel
+	if len(item.Subtitles) == 0 {
+		return "", "", false
+	}
+	title = strings.TrimPrefix(item.Title, "Watched ")
+	channel := item.Subtitles[0].Name
+	artist = channel
+	topic := false
+	for _, suffix := range youTubeChannelSuffixes {
+		if trimmed, found := strings.CutSuffix(artist, suffix); found && trimmed != "" {
+			artist = strings.TrimSpace(trimmed)
+			topic = suffix == " - Topic"
+			break
+		}
+	}
+	if !topic {
+		title = youTubeTitleNoise.ReplaceAllString(title, "")
+		if before, after, found := strings.Cut(title, " - "); found && before != "" && after != "" {
+			artist, title = strings.TrimSpace(before), strings.TrimSpace(after)
+		}
+	}
+	title = strings.TrimSpace(title)
+	if artist == "" || title == "" || strings.HasPrefix(title, "https://") {
+		return "", "", false
+	}
+	return artist, title, true
+}
+
+func ImportYouTubeMusicFile(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, filename string) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msgf("Beginning YouTube Music import on file: %s", filename)
+	file, err := os.Open(path.Join(cfg.ConfigDir(), "import", filename))
+	if err != nil {
+		l.Err(err).Msgf("Failed to read import file: %s", filename)
+		return fmt.Errorf("ImportYouTubeMusicFile: %w", err)
+	}
+	defer file.Close()
+	var throttleFunc = func() {}
+	if ms := cfg.ThrottleImportMs(); ms > 0 {
+		throttleFunc = func() {
+			time.Sleep(time.Duration(ms) * time.Millisecond)
+		}
+	}
+	export := make([]YouTubeHistoryItem, 0)
+	err = json.NewDecoder(file).Decode(&export)
+	if err != nil {
+		return fmt.Errorf("ImportYouTubeMusicFile: %w", err)
+	}
+
+	count := 0
+	for _, item := range export {
+		opts, ok := youTubeMusicListen(ctx, item)
+		if !ok {
+			continue
+		}
+		opts.MbzCaller = mbzc
+		err = catalog.SubmitListen(ctx, store, opts)
+		if err != nil {
+			l.Err(err).Msg("Failed to import YouTube Music history item")
+			return fmt.Errorf("ImportYouTubeMusicFile: %w", err)
+		}
+		count++
+		throttleFunc()
+	}
+	return finishImport(ctx, filename, count)
+}
+
+// youTubeMusicListen returns the listen of the history entry, and false when
+// it is not one or is outside of the import window.
+func youTubeMusicListen(ctx context.Context, item YouTubeHistoryItem) (catalog.SubmitListenOpts, bool) {
+	l := logger.FromContext(ctx)
+	artist, title, ok := ParseYouTubeMusicItem(item)
+	if !ok {
+		return catalog.SubmitListenOpts{}, false
+	}
+	if !inImportTimeWindow(item.Time) {
+		l.Debug().Msgf("Skipping import due to import time rules")
+		return catalog.SubmitListenOpts{}, false
+	}
+	return catalog.SubmitListenOpts{
+		Artist:         artist,
+		ArtistNames:    catalog.ParseArtists(artist, title, cfg.ArtistSeparators()),
+		TrackTitle:     title,
+		ReleaseTitle:   title,
+		Client:         "youtube_music",
+		Time:           item.Time,
+		UserID:         1,
+		SkipCacheImage: !cfg.FetchImagesDuringImport(),
+		SkipMbzSearch:  true,
+	}, true
+}
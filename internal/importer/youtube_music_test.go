@@ -0,0 +1,53 @@
+package importer_test
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseYouTubeMusicItem(t *testing.T) {
+	input, err := os.ReadFile(path.Join("..", "..", "test_assets", "watch-history.json"))
+	require.NoError(t, err)
+	var items []importer.YouTubeHistoryItem
+	require.NoError(t, json.Unmarshal(input, &items))
+	require.Len(t, items, 4)
+
+	// uploaded by the artist's topic channel
+	artist, title, ok := importer.ParseYouTubeMusicItem(items[0])
+	require.True(t, ok)
+	assert.Equal(t, "Danger Mouse", artist)
+	assert.Equal(t, "Ministry", title)
+
+	// not music
+	_, _, ok = importer.ParseYouTubeMusicItem(items[1])
+	assert.False(t, ok)
+
+	// a music video, named after the artist
+	artist, title, ok = importer.ParseYouTubeMusicItem(items[2])
+	require.True(t, ok)
+	assert.Equal(t, "Danger Mouse", artist)
+	assert.Equal(t, "Ministry", title)
+
+	// removed video
+	_, _, ok = importer.ParseYouTubeMusicItem(items[3])
+	assert.False(t, ok)
+}
+
+func TestParseYouTubeMusicItem_Products(t *testing.T) {
+	item := importer.YouTubeHistoryItem{
+		Header:    "YouTube",
+		Title:     "Watched Hold On",
+		Subtitles: []importer.YouTubeHistorySubtitle{{Name: "Chord Overstreet"}},
+		Products:  []string{"YouTube Music"},
+	}
+	artist, title, ok := importer.ParseYouTubeMusicItem(item)
+	require.True(t, ok)
+	assert.Equal(t, "Chord Overstreet", artist)
+	assert.Equal(t, "Hold On", title)
+}
@@ -0,0 +1,514 @@
+// Package importjob runs imports of uploaded files in the background, one job
+// at a time. The file of a job is kept in Dir until the job finishes. A job
+// saves how many items of its file it has read as it goes, so that one
+// interrupted by a restart resumes from there, and its progress can be
+// watched while it runs. Jobs can be canceled while queued or running.
+package importjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/importer"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+	"github.com/SaturnX-Dev/Beat-Scrobble/queue"
+)
+
+const (
+	// progress is saved after this many items or this long, whichever comes
+	// first
+	checkpointItems    = 100
+	checkpointInterval = time.Second
+	// how many of the latest errors of failed items a job keeps
+	maxErrors = 50
+	// a job fails when this many listens in a row could not be saved, as
+	// the rest most likely cannot be either
+	maxFailedInARow = 25
+	// how often to look for queued jobs when not woken
+	pollInterval = time.Minute
+)
+
+var (
+	ErrUnknownFormat = errors.New("the format of the file could not be detected from its name")
+	ErrInvalidFile   = errors.New("the file is not a valid file of its format")
+	// returned when the csv import profile of the job does not exist
+	ErrCsvProfileNotFound = errors.New("csv import profile not found")
+)
+
+// errCanceled is the cause of the context of a job that was canceled.
+var errCanceled = errors.New("import job canceled")
+
+// Dir is where the files of jobs are kept until they finish.
+func Dir() string {
+	return path.Join(cfg.ConfigDir(), "import_jobs")
+}
+
+func jobFile(id int64) string {
+	return path.Join(Dir(), strconv.FormatInt(id, 10))
+}
+
+var waker utils.Waker
+
+// Wake makes the worker look for queued jobs now.
+func Wake() {
+	waker.Wake()
+}
+
+var (
+	cancelsMu sync.Mutex
+	// cancel the jobs being run, by their id
+	cancels = make(map[int64]context.CancelCauseFunc)
+)
+
+func setCancel(id int64, cancel context.CancelCauseFunc) {
+	cancelsMu.Lock()
+	defer cancelsMu.Unlock()
+	if cancel == nil {
+		delete(cancels, id)
+	} else {
+		cancels[id] = cancel
+	}
+}
+
+var (
+	watchMu  sync.Mutex
+	watchers = make(map[int64]map[chan models.ImportJob]struct{})
+)
+
+// Watch returns a channel that receives the job each time its progress is
+// saved, the last time when it finishes. A watcher that falls behind only
+// receives the latest. The returned func stops watching.
+func Watch(id int64) (<-chan models.ImportJob, func()) {
+	ch := make(chan models.ImportJob, 1)
+	watchMu.Lock()
+	if watchers[id] == nil {
+		watchers[id] = make(map[chan models.ImportJob]struct{})
+	}
+	watchers[id][ch] = struct{}{}
+	watchMu.Unlock()
+	return ch, func() {
+		watchMu.Lock()
+		delete(watchers[id], ch)
+		if len(watchers[id]) == 0 {
+			delete(watchers, id)
+		}
+		watchMu.Unlock()
+	}
+}
+
+func publish(job *models.ImportJob) {
+	j := *job
+	j.Errors = slices.Clone(job.Errors)
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	for ch := range watchers[job.ID] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- j
+	}
+}
+
+type CreateOpts struct {
+	UserID int32
+	// detected from Filename when empty
+	Format   models.ImportFormat
+	Filename string
+	// the profile of csv files is the user's first that matches the columns
+	// of the file when CsvProfile is empty
+	Options models.ImportJobOptions
+	File    io.Reader
+}
+
+// Create saves the file of a new job and queues it. Returns ErrUnknownFormat
+// when no format is given and it cannot be detected, and ErrInvalidFile when
+// the file cannot be read as its format.
+func Create(ctx context.Context, store db.DB, opts CreateOpts) (*models.ImportJob, error) {
+	if opts.Format == "" {
+		format, ok := importer.DetectFormat(opts.Filename)
+		if !ok {
+			return nil, fmt.Errorf("Create: %w", ErrUnknownFormat)
+		}
+		opts.Format = format
+	}
+	if opts.Options.Timezone != "" {
+		if _, err := time.LoadLocation(opts.Options.Timezone); err != nil {
+			return nil, fmt.Errorf("Create: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(Dir(), 0744); err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	tmp, err := os.CreateTemp(Dir(), "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	// does nothing once the file is renamed to that of the job
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, opts.File)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+
+	if opts.Format == models.ImportCsv {
+		profile, err := csvProfile(ctx, store, opts.UserID, tmp.Name(), opts.Options.CsvProfile)
+		if errors.Is(err, ErrCsvProfileNotFound) {
+			return nil, fmt.Errorf("Create: %w", err)
+		} else if err != nil {
+			return nil, fmt.Errorf("Create: %w: %w", ErrInvalidFile, err)
+		}
+		opts.Options.CsvProfile = profile
+	}
+	// reading the first item finds files that are not of the format but could
+	// only be told apart by their items
+	src, err := openSource(ctx, store, tmp.Name(), opts.UserID, opts.Format, opts.Options)
+	if err != nil {
+		return nil, fmt.Errorf("Create: %w: %w", ErrInvalidFile, err)
+	}
+	_, err = src.Next()
+	size := src.Size()
+	src.Close()
+	if err != nil && err != io.EOF && !errors.Is(err, importer.ErrInvalidItem) {
+		return nil, fmt.Errorf("Create: %w: %w", ErrInvalidFile, err)
+	}
+
+	job, err := store.CreateImportJob(ctx, db.CreateImportJobOpts{
+		UserID:   opts.UserID,
+		Format:   opts.Format,
+		Filename: opts.Filename,
+		Options:  opts.Options,
+		Size:     size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), jobFile(job.ID)); err != nil {
+		// the job fails for its missing file when it is run
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	logger.FromContext(ctx).Info().Msgf("ImportJob: Queued job %d to import %s file %s", job.ID, job.Format, job.Filename)
+	Wake()
+	return job, nil
+}
+
+// csvProfiles returns the user's csv import profiles, only the one named
+// name when it is not empty.
+func csvProfiles(ctx context.Context, store db.DB, userId int32, name string) ([]models.CsvImportProfile, error) {
+	profiles, err := importer.CsvProfiles(ctx, store, userId)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return profiles, nil
+	}
+	for _, p := range profiles {
+		if strings.EqualFold(p.Name, name) {
+			return []models.CsvImportProfile{p}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrCsvProfileNotFound, name)
+}
+
+// csvProfile returns the name of the profile the csv file at filename is read
+// with.
+func csvProfile(ctx context.Context, store db.DB, userId int32, filename string, name string) (string, error) {
+	profiles, err := csvProfiles(ctx, store, userId, name)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	r, err := importer.NewCsvReader(f, profiles...)
+	if err != nil {
+		return "", err
+	}
+	return r.Profile().Name, nil
+}
+
+func openSource(ctx context.Context, store db.DB, filename string, userId int32, format models.ImportFormat, options models.ImportJobOptions) (importer.Source, error) {
+	opts := importer.SourceOpts{Format: format}
+	if options.Timezone != "" {
+		loc, err := time.LoadLocation(options.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		opts.Location = loc
+	}
+	if format == models.ImportCsv {
+		profiles, err := csvProfiles(ctx, store, userId, options.CsvProfile)
+		if err != nil {
+			return nil, err
+		}
+		opts.CsvProfiles = profiles
+	}
+	return importer.OpenSource(ctx, filename, opts)
+}
+
+// Cancel cancels the user's job, returning false when the user has no job
+// with the id that is queued or running. A running job stops after the item
+// it is on.
+func Cancel(ctx context.Context, store db.DB, userId int32, id int64) (bool, error) {
+	ok, err := store.CancelImportJob(ctx, userId, id)
+	if err != nil {
+		return false, fmt.Errorf("Cancel: %w", err)
+	}
+	if !ok {
+		return false, nil
+	}
+	cancelsMu.Lock()
+	cancel := cancels[id]
+	cancelsMu.Unlock()
+	if cancel != nil {
+		// the job removes its file once it stops
+		cancel(errCanceled)
+		return true, nil
+	}
+	os.Remove(jobFile(id))
+	job, err := store.GetImportJob(ctx, id)
+	if err != nil {
+		return true, fmt.Errorf("Cancel: %w", err)
+	}
+	if job != nil {
+		publish(job)
+	}
+	return true, nil
+}
+
+type Worker struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start runs queued jobs in the background, resuming first those that were
+// running when the server stopped.
+func Start(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller) *Worker {
+	ctx, cancel := context.WithCancel(queue.WithPriority(ctx, queue.PriorityBackground))
+	w := &Worker{cancel: cancel}
+	w.wg.Add(1)
+	go w.run(ctx, store, mbzc)
+	return w
+}
+
+// Stop stops the worker, interrupting the job it is running. The job resumes
+// from its last checkpoint the next time the worker is started.
+func (w *Worker) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+func (w *Worker) run(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller) {
+	defer w.wg.Done()
+	l := logger.FromContext(ctx)
+	for {
+		wake := waker.C()
+		job, err := store.GetNextImportJob(ctx)
+		if err == nil && job != nil {
+			err = Run(ctx, store, mbzc, job)
+			if err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			l.Err(err).Msg("ImportJob: Failed to run import jobs")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Run runs the job from its offset until it finishes or is canceled. When ctx
+// is done first, the job is left running to be resumed from its last
+// checkpoint.
+func Run(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, job *models.ImportJob) error {
+	l := logger.FromContext(ctx)
+
+	// the job can be canceled as soon as it is started
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	setCancel(job.ID, cancel)
+	defer setCancel(job.ID, nil)
+
+	ok, err := store.StartImportJob(ctx, job.ID)
+	if err != nil {
+		return fmt.Errorf("Run: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
+	job.Status = models.ImportJobRunning
+	publish(job)
+
+	src, err := openSource(ctx, store, jobFile(job.ID), job.UserID, job.Format, job.Options)
+	if err != nil {
+		return finish(ctx, store, job, err)
+	}
+	defer src.Close()
+
+	if job.Offset > 0 {
+		l.Info().Msgf("ImportJob: Resuming job %d after %d items", job.ID, job.Offset)
+		for i := 0; i < job.Offset; i++ {
+			_, err := src.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil && !errors.Is(err, importer.ErrInvalidItem) {
+				return finish(ctx, store, job, err)
+			}
+		}
+	} else {
+		l.Info().Msgf("ImportJob: Starting job %d to import %s file %s", job.ID, job.Format, job.Filename)
+	}
+
+	throttle := time.Duration(cfg.ThrottleImportMs()) * time.Millisecond
+	checkpoint := time.Now()
+	sinceCheckpoint := 0
+	failedInARow := 0
+	for ctx.Err() == nil {
+		listen, err := src.Next()
+		if err == io.EOF {
+			return finish(ctx, store, job, nil)
+		}
+		switch {
+		case errors.Is(err, importer.ErrInvalidItem):
+			job.Failed++
+			addError(job, err)
+		case err != nil:
+			return finish(ctx, store, job, err)
+		case listen == nil:
+			job.Skipped++
+		default:
+			err := listen.Submit(ctx, store, mbzc, job.UserID)
+			if err != nil && ctx.Err() != nil {
+				// the item is read again when the job is resumed
+				continue
+			}
+			if err != nil {
+				l.Err(err).Msgf("ImportJob: Failed to save item %d of job %d", job.Offset+1, job.ID)
+				job.Failed++
+				addError(job, err)
+				failedInARow++
+				if failedInARow >= maxFailedInARow {
+					job.Offset++
+					return finish(ctx, store, job, fmt.Errorf("%d listens in a row could not be saved: %w", failedInARow, err))
+				}
+			} else {
+				job.Imported++
+				failedInARow = 0
+			}
+			if throttle > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(throttle):
+				}
+			}
+		}
+		job.Offset++
+		job.BytesRead = src.BytesRead()
+
+		sinceCheckpoint++
+		if sinceCheckpoint >= checkpointItems || time.Since(checkpoint) >= checkpointInterval {
+			status, err := store.SaveImportJobProgress(ctx, job)
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				return fmt.Errorf("Run: %w", err)
+			}
+			if status == models.ImportJobCanceled {
+				cancel(errCanceled)
+				break
+			}
+			publish(job)
+			checkpoint = time.Now()
+			sinceCheckpoint = 0
+		}
+	}
+
+	// save how far the job got even though ctx is done
+	sctx, scancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer scancel()
+	status, err := store.SaveImportJobProgress(sctx, job)
+	if err != nil {
+		return fmt.Errorf("Run: %w", err)
+	}
+	if context.Cause(ctx) != errCanceled {
+		l.Info().Msgf("ImportJob: Interrupted job %d after %d items", job.ID, job.Offset)
+		return ctx.Err()
+	}
+	job.Status = status
+	if job.FinishedAt == nil {
+		now := time.Now()
+		job.FinishedAt = &now
+	}
+	l.Info().Msgf("ImportJob: Canceled job %d after %d items", job.ID, job.Offset)
+	os.Remove(jobFile(job.ID))
+	publish(job)
+	return nil
+}
+
+// finish saves the job as succeeded, or as failed for err, and removes its
+// file.
+func finish(ctx context.Context, store db.DB, job *models.ImportJob, err error) error {
+	l := logger.FromContext(ctx)
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		l.Err(err).Msgf("ImportJob: Job %d failed", job.ID)
+		job.Status = models.ImportJobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = models.ImportJobSucceeded
+		job.BytesRead = job.Size
+	}
+	status, serr := store.SaveImportJobProgress(ctx, job)
+	if serr != nil {
+		return fmt.Errorf("finish: %w", serr)
+	}
+	job.Status = status
+	if status == models.ImportJobSucceeded {
+		l.Info().Msgf("ImportJob: Finished job %d: %d imported, %d skipped, %d failed", job.ID, job.Imported, job.Skipped, job.Failed)
+	}
+	os.Remove(jobFile(job.ID))
+	publish(job)
+	return nil
+}
+
+// addError keeps why an item failed, dropping the oldest beyond maxErrors.
+func addError(job *models.ImportJob, err error) {
+	job.Errors = append(job.Errors, fmt.Sprintf("item %d: %v", job.Offset+1, err))
+	if len(job.Errors) > maxErrors {
+		job.Errors = slices.Delete(job.Errors, 0, len(job.Errors)-maxErrors)
+	}
+}
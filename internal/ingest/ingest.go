@@ -0,0 +1,214 @@
+// Package ingest persists listen submissions to an outbox table before they are
+// processed, so scrobbling clients are acknowledged immediately and no listen is
+// lost if the server stops mid-request. A pool of workers drains the outbox in
+// the background, retrying failed submissions with exponential backoff until
+// they are moved to the dead-letter list.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/utils"
+)
+
+const (
+	// submissions that fail this many times are moved to the dead-letter list
+	maxAttempts    = 8
+	initialBackoff = 30 * time.Second
+	maxBackoff     = time.Hour
+	// workers poll the outbox this often when they are not woken up, so retries
+	// are picked up once they are due
+	pollInterval = 5 * time.Second
+)
+
+var waker utils.Waker
+
+// Wake notifies all workers that new submissions are waiting.
+func Wake() {
+	waker.Wake()
+}
+
+// Enqueue stores the submission in the outbox and wakes the workers. The
+// MusicBrainz caller in opts is not persisted; the pool's caller is used when
+// the submission is processed.
+func Enqueue(ctx context.Context, store db.DB, opts catalog.SubmitListenOpts) error {
+	payload, err := json.Marshal(opts)
+	if err != nil {
+		return fmt.Errorf("Enqueue: %w", err)
+	}
+	_, err = store.SaveListenSubmission(ctx, db.SaveListenSubmissionOpts{
+		UserID:       opts.UserID,
+		Payload:      payload,
+		PartitionKey: strings.ToLower(opts.Artist),
+	})
+	if err != nil {
+		return fmt.Errorf("Enqueue: %w", err)
+	}
+	Wake()
+	return nil
+}
+
+// EnqueueAll stores all of the submissions in the outbox, or none of them when
+// one cannot be stored, so that a client retrying a batch does not submit part
+// of it twice.
+func EnqueueAll(ctx context.Context, store db.DB, opts []catalog.SubmitListenOpts) error {
+	subs := make([]db.SaveListenSubmissionOpts, len(opts))
+	for i, o := range opts {
+		payload, err := json.Marshal(o)
+		if err != nil {
+			return fmt.Errorf("EnqueueAll: %w", err)
+		}
+		subs[i] = db.SaveListenSubmissionOpts{
+			UserID:       o.UserID,
+			Payload:      payload,
+			PartitionKey: strings.ToLower(o.Artist),
+		}
+	}
+	if err := store.SaveListenSubmissions(ctx, subs); err != nil {
+		return fmt.Errorf("EnqueueAll: %w", err)
+	}
+	Wake()
+	return nil
+}
+
+type Pool struct {
+	store   db.DB
+	mbzc    mbz.MusicBrainzCaller
+	workers int
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// Start requeues any submissions left processing by a previous run and starts
+// the workers. Submissions are partitioned between workers by artist name, so
+// that two workers never create the same new artist at the same time.
+func Start(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, workers int) *Pool {
+	l := logger.FromContext(ctx)
+	if workers < 1 {
+		workers = 1
+	}
+	n, err := store.ResetProcessingListenSubmissions(ctx)
+	if err != nil {
+		l.Err(err).Msg("Ingest: Failed to requeue interrupted submissions")
+	} else if n > 0 {
+		l.Info().Msgf("Ingest: Requeued %d interrupted submissions", n)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Pool{
+		store:   store,
+		mbzc:    mbzc,
+		workers: workers,
+		cancel:  cancel,
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx, i)
+	}
+	l.Info().Msgf("Ingest: Started %d workers", workers)
+	return p
+}
+
+// Stop signals the workers to stop and waits for the submissions currently
+// being processed to finish.
+func (p *Pool) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Pool) work(ctx context.Context, worker int) {
+	defer p.wg.Done()
+	l := logger.FromContext(ctx)
+	for {
+		wake := waker.C()
+		s, err := p.store.ClaimListenSubmission(ctx, db.ClaimListenSubmissionOpts{
+			Workers: p.workers,
+			Worker:  worker,
+		})
+		if err != nil && ctx.Err() == nil {
+			l.Err(err).Msg("Ingest: Failed to claim submission")
+		}
+		if s != nil {
+			// a submission that has been claimed is always finished, even when
+			// the pool is stopping
+			p.process(context.WithoutCancel(ctx), s)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (p *Pool) process(ctx context.Context, s *models.ListenSubmission) {
+	l := logger.FromContext(ctx)
+
+	var opts catalog.SubmitListenOpts
+	if err := json.Unmarshal(s.Payload, &opts); err != nil {
+		l.Err(err).Msgf("Ingest: Submission %d has an invalid payload", s.ID)
+		p.fail(ctx, s, err, true)
+		return
+	}
+	opts.MbzCaller = p.mbzc
+	opts.UserID = s.UserID
+
+	if err := submit(ctx, p.store, opts); err != nil {
+		dead := s.Attempts >= maxAttempts
+		if dead {
+			l.Err(err).Msgf("Ingest: Submission %d failed %d times; moving to dead letters", s.ID, s.Attempts)
+		} else {
+			l.Warn().Err(err).Msgf("Ingest: Submission %d failed; retrying", s.ID)
+		}
+		p.fail(ctx, s, err, dead)
+		return
+	}
+	if err := p.store.DeleteListenSubmission(ctx, s.ID); err != nil {
+		l.Err(err).Msgf("Ingest: Failed to delete processed submission %d", s.ID)
+	}
+}
+
+func submit(ctx context.Context, store db.DB, opts catalog.SubmitListenOpts) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return catalog.SubmitListen(ctx, store, opts)
+}
+
+func (p *Pool) fail(ctx context.Context, s *models.ListenSubmission, cause error, dead bool) {
+	err := p.store.FailListenSubmission(ctx, db.FailListenSubmissionOpts{
+		ID:      s.ID,
+		Error:   cause.Error(),
+		RetryAt: time.Now().Add(backoff(s.Attempts)),
+		Dead:    dead,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Err(err).Msgf("Ingest: Failed to update submission %d", s.ID)
+	}
+}
+
+// backoff doubles the delay with every attempt, up to maxBackoff.
+func backoff(attempts int32) time.Duration {
+	d := initialBackoff
+	for i := int32(1); i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
@@ -11,11 +11,13 @@ import (
 )
 
 type MusicBrainzArtist struct {
+	ID       string                   `json:"id"`
 	Name     string                   `json:"name"`
 	SortName string                   `json:"sort_name"`
 	Gender   string                   `json:"gender"`
 	Area     MusicBrainzArea          `json:"area"`
 	Aliases  []MusicBrainzArtistAlias `json:"aliases"`
+	Genres   []MusicBrainzGenre       `json:"genres"`
 }
 type MusicBrainzArtistAlias struct {
 	Name    string `json:"name"`
@@ -23,13 +25,13 @@ type MusicBrainzArtistAlias struct {
 	Primary bool   `json:"primary"`
 }
 
-const artistAliasFmtStr = "%s/ws/2/artist/%s?inc=aliases"
+const artistAliasFmtStr = "%s/ws/2/artist/%s?inc=aliases+genres"
 
-func (c *MusicBrainzClient) getArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error) {
+func (c *MusicBrainzClient) GetArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error) {
 	mbzArtist := new(MusicBrainzArtist)
-	err := c.getEntity(ctx, artistAliasFmtStr, id, mbzArtist)
+	err := c.getEntity(ctx, CacheKindArtist, artistAliasFmtStr, id, mbzArtist)
 	if err != nil {
-		return nil, fmt.Errorf("getArtist: %w", err)
+		return nil, fmt.Errorf("GetArtist: %w", err)
 	}
 	return mbzArtist, nil
 }
@@ -37,7 +39,7 @@ func (c *MusicBrainzClient) getArtist(ctx context.Context, id uuid.UUID) (*Music
 // Returns the artist name at index 0, and all primary aliases after.
 func (c *MusicBrainzClient) GetArtistPrimaryAliases(ctx context.Context, id uuid.UUID) ([]string, error) {
 	l := logger.FromContext(ctx)
-	artist, err := c.getArtist(ctx, id)
+	artist, err := c.GetArtist(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("GetArtistPrimaryAliases: %w", err)
 	}
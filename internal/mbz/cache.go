@@ -0,0 +1,75 @@
+package mbz
+
+import (
+	"context"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/google/uuid"
+)
+
+// kinds of entities whose lookups are cached
+const (
+	CacheKindArtist       = "artist"
+	CacheKindRelease      = "release"
+	CacheKindReleaseGroup = "release-group"
+	CacheKindRecording    = "recording"
+)
+
+var CacheKinds = []string{CacheKindArtist, CacheKindRelease, CacheKindReleaseGroup, CacheKindRecording}
+
+// entities MusicBrainz did not find are looked up again after this long at
+// most, in case they were just added
+const notFoundTTL = 24 * time.Hour
+
+// Cache stores responses of MusicBrainz lookups by the kind and ID of the
+// entity. GetMbzCacheEntry returns nil when there is no response that did not
+// expire yet.
+type Cache interface {
+	GetMbzCacheEntry(ctx context.Context, kind string, id uuid.UUID) (*models.MbzCacheEntry, error)
+	SaveMbzCacheEntry(ctx context.Context, e *models.MbzCacheEntry) error
+}
+
+// UseCache makes the client answer lookups of artists, releases, release
+// groups and recordings from the cache, saving responses to it for ttl. Not
+// found responses are saved for a day at most. Searches are not cached.
+func (c *MusicBrainzClient) UseCache(cache Cache, ttl time.Duration) {
+	c.cache = cache
+	c.cacheTTL = ttl
+}
+
+// getCached returns the cached response for the entity, or nil. Failing to
+// read the cache is only logged, so that the entity is requested instead.
+func (c *MusicBrainzClient) getCached(ctx context.Context, kind string, id uuid.UUID) *models.MbzCacheEntry {
+	if c.cache == nil || c.cacheTTL <= 0 {
+		return nil
+	}
+	entry, err := c.cache.GetMbzCacheEntry(ctx, kind, id)
+	if err != nil {
+		logger.FromContext(ctx).Err(err).Msgf("Failed to read cached MusicBrainz response for %s %s", kind, id)
+		return nil
+	}
+	return entry
+}
+
+// saveCached caches the response for the entity, body being nil when it was
+// not found.
+func (c *MusicBrainzClient) saveCached(ctx context.Context, kind string, id uuid.UUID, body []byte) {
+	if c.cache == nil || c.cacheTTL <= 0 {
+		return
+	}
+	ttl := c.cacheTTL
+	if body == nil {
+		ttl = min(ttl, notFoundTTL)
+	}
+	err := c.cache.SaveMbzCacheEntry(ctx, &models.MbzCacheEntry{
+		Kind:      kind,
+		MbzID:     id,
+		Body:      body,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		logger.FromContext(ctx).Err(err).Msgf("Failed to cache MusicBrainz response for %s %s", kind, id)
+	}
+}
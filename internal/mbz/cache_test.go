@@ -0,0 +1,120 @@
+package mbz_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	knownArtist = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	requests    atomic.Int32
+)
+
+func TestMain(m *testing.M) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !strings.HasPrefix(r.URL.Path, "/ws/2/artist/"+knownArtist.String()) {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"id": "00000000-0000-0000-0000-000000000001", "name": "ATARASHII GAKKO!"}`)
+	}))
+	env := map[string]string{
+		cfg.DATABASE_URL_ENV:           "postgres://unused",
+		cfg.MUSICBRAINZ_URL_ENV:        server.URL,
+		cfg.MUSICBRAINZ_RATE_LIMIT_ENV: "100",
+	}
+	if err := cfg.Load(func(k string) string { return env[k] }, "test"); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
+type memCache struct {
+	mu      sync.Mutex
+	entries map[string]*models.MbzCacheEntry
+}
+
+func (c *memCache) GetMbzCacheEntry(ctx context.Context, kind string, id uuid.UUID) (*models.MbzCacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[kind+id.String()]
+	if e == nil || !time.Now().Before(e.ExpiresAt) {
+		return nil, nil
+	}
+	return e, nil
+}
+
+func (c *memCache) SaveMbzCacheEntry(ctx context.Context, e *models.MbzCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[e.Kind+e.MbzID.String()] = e
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	cache := &memCache{entries: make(map[string]*models.MbzCacheEntry)}
+	c := mbz.NewMusicBrainzClient()
+	defer c.Shutdown()
+	c.UseCache(cache, time.Hour)
+	requests.Store(0)
+
+	for range 2 {
+		artist, err := c.GetArtist(ctx, knownArtist)
+		require.NoError(t, err)
+		assert.Equal(t, "ATARASHII GAKKO!", artist.Name)
+	}
+	assert.EqualValues(t, 1, requests.Load(), "expected the second lookup to be answered from the cache")
+
+	unknown := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	for range 2 {
+		_, err := c.GetArtist(ctx, unknown)
+		assert.ErrorIs(t, err, mbz.ErrNotFound)
+	}
+	assert.EqualValues(t, 2, requests.Load(), "expected the not found response to be cached")
+	entry, _ := cache.GetMbzCacheEntry(ctx, mbz.CacheKindArtist, unknown)
+	require.NotNil(t, entry)
+	assert.Nil(t, entry.Body)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), entry.ExpiresAt, time.Minute)
+
+	// expired responses are requested again
+	cache.entries[mbz.CacheKindArtist+knownArtist.String()].ExpiresAt = time.Now().Add(-time.Second)
+	_, err := c.GetArtist(ctx, knownArtist)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, requests.Load())
+
+	// other kinds do not share entries
+	_, err = c.GetRelease(ctx, knownArtist)
+	assert.ErrorIs(t, err, mbz.ErrNotFound)
+	assert.EqualValues(t, 4, requests.Load())
+}
+
+func TestCache_Disabled(t *testing.T) {
+	c := mbz.NewMusicBrainzClient()
+	defer c.Shutdown()
+	requests.Store(0)
+
+	for range 2 {
+		_, err := c.GetArtist(context.Background(), knownArtist)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, requests.Load())
+}
@@ -4,9 +4,11 @@ package mbz
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/cfg"
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
@@ -19,26 +21,56 @@ type MusicBrainzArea struct {
 	Iso3166_1Codes []string `json:"iso-3166-1-codes"`
 }
 
+// a MusicBrainzGenre is a genre tagged on an artist or release, Count being
+// the number of votes for it
+type MusicBrainzGenre struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// GenreNames returns the names of the genres, the most voted first.
+func GenreNames(genres []MusicBrainzGenre) []string {
+	sorted := slices.Clone(genres)
+	slices.SortStableFunc(sorted, func(a, b MusicBrainzGenre) int { return b.Count - a.Count })
+	names := make([]string, len(sorted))
+	for i, g := range sorted {
+		names[i] = g.Name
+	}
+	return names
+}
+
 type MusicBrainzClient struct {
 	url          string
 	userAgent    string
 	requestQueue *queue.RequestQueue
+	cache        Cache
+	cacheTTL     time.Duration
 }
 
 type MusicBrainzCaller interface {
+	GetArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error)
 	GetArtistPrimaryAliases(ctx context.Context, id uuid.UUID) ([]string, error)
 	GetReleaseTitles(ctx context.Context, RGID uuid.UUID) ([]string, error)
 	GetTrack(ctx context.Context, id uuid.UUID) (*MusicBrainzTrack, error)
 	GetReleaseGroup(ctx context.Context, id uuid.UUID) (*MusicBrainzReleaseGroup, error)
 	GetRelease(ctx context.Context, id uuid.UUID) (*MusicBrainzRelease, error)
+	SearchRecordings(ctx context.Context, q SearchQuery) ([]MusicBrainzRecording, error)
+	SearchReleases(ctx context.Context, q SearchQuery) ([]MusicBrainzRelease, error)
 	Shutdown()
 }
 
+// ErrNotFound is returned when MusicBrainz has no entity with the ID
+var ErrNotFound = errors.New("not found on MusicBrainz")
+
 func NewMusicBrainzClient() *MusicBrainzClient {
 	ret := new(MusicBrainzClient)
 	ret.url = cfg.MusicBrainzUrl()
 	ret.userAgent = cfg.UserAgent()
-	ret.requestQueue = queue.NewRequestQueue(cfg.MusicBrainzRateLimit(), cfg.MusicBrainzRateLimit())
+	ret.requestQueue = queue.New(queue.Options{
+		Name:  "MusicBrainz",
+		RPS:   cfg.MusicBrainzRateLimit(),
+		Burst: cfg.MusicBrainzRateLimit(),
+	})
 	return ret
 }
 
@@ -46,27 +78,75 @@ func (c *MusicBrainzClient) Shutdown() {
 	c.requestQueue.Shutdown()
 }
 
-func (c *MusicBrainzClient) getEntity(ctx context.Context, fmtStr string, id uuid.UUID, result any) error {
+// QueueStats returns the stats of the queue requests to MusicBrainz wait in.
+func (c *MusicBrainzClient) QueueStats() queue.Stats {
+	return c.requestQueue.Stats()
+}
+
+// getEntity looks up the entity of the kind, answering from the cache when
+// it has a response that did not expire yet.
+func (c *MusicBrainzClient) getEntity(ctx context.Context, kind string, fmtStr string, id uuid.UUID, result any) error {
+	l := logger.FromContext(ctx)
+	if entry := c.getCached(ctx, kind, id); entry != nil {
+		if entry.Body == nil {
+			return fmt.Errorf("getEntity: %w", ErrNotFound)
+		}
+		if err := json.Unmarshal(entry.Body, result); err == nil {
+			l.Debug().Msgf("Using cached MusicBrainz response for %s %s", kind, id)
+			return nil
+		}
+		l.Warn().Msgf("Failed to unmarshal cached MusicBrainz response for %s %s; requesting it again", kind, id)
+	}
+
+	body, err := c.get(ctx, fmt.Sprintf(fmtStr, c.url, id.String()))
+	if errors.Is(err, ErrNotFound) {
+		c.saveCached(ctx, kind, id, nil)
+		return fmt.Errorf("getEntity: %w", err)
+	} else if err != nil {
+		return fmt.Errorf("getEntity: %w", err)
+	}
+	if err := unmarshal(ctx, body, result); err != nil {
+		return fmt.Errorf("getEntity: %w", err)
+	}
+	c.saveCached(ctx, kind, id, body)
+	return nil
+}
+
+func (c *MusicBrainzClient) getJSON(ctx context.Context, url string, result any) error {
+	body, err := c.get(ctx, url)
+	if err != nil {
+		return fmt.Errorf("getJSON: %w", err)
+	}
+	if err := unmarshal(ctx, body, result); err != nil {
+		return fmt.Errorf("getJSON: %w", err)
+	}
+	return nil
+}
+
+func (c *MusicBrainzClient) get(ctx context.Context, url string) ([]byte, error) {
 	l := logger.FromContext(ctx)
-	url := fmt.Sprintf(fmtStr, c.url, id.String())
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		l.Err(err).Msg("Failed to build MusicBrainz request")
-		return fmt.Errorf("getEntity: %w", err)
+		return nil, fmt.Errorf("get: %w", err)
 	}
 	l.Debug().Msg("Adding MusicBrainz request to queue")
 	body, err := c.queue(ctx, req)
 	if err != nil {
-		l.Err(err).Msg("MusicBrainz request failed")
-		return fmt.Errorf("getEntity: %w", err)
+		if !errors.Is(err, ErrNotFound) {
+			l.Err(err).Msg("MusicBrainz request failed")
+		}
+		return nil, fmt.Errorf("get: %w", err)
 	}
+	return body, nil
+}
 
-	err = json.Unmarshal(body, result)
+func unmarshal(ctx context.Context, body []byte, result any) error {
+	err := json.Unmarshal(body, result)
 	if err != nil {
-		l.Err(err).Str("body", string(body)).Msg("Failed to unmarshal MusicBrainz response body")
-		return fmt.Errorf("getEntity: %w", err)
+		logger.FromContext(ctx).Err(err).Str("body", string(body)).Msg("Failed to unmarshal MusicBrainz response body")
+		return fmt.Errorf("unmarshal: %w", err)
 	}
-
 	return nil
 }
 
@@ -75,22 +155,15 @@ func (c *MusicBrainzClient) queue(ctx context.Context, req *http.Request) ([]byt
 	req.Header.Set("User-Agent", c.userAgent)
 	req.Header.Set("Accept", "application/json")
 
-	resultChan := c.requestQueue.Enqueue(func(client *http.Client, done chan<- queue.RequestResult) {
-		resp, err := client.Do(req)
-		if err != nil {
-			l.Err(err).Str("url", req.RequestURI).Msg("Failed to contact MusicBrainz")
-			done <- queue.RequestResult{Err: err}
-			return
-		} else if resp.StatusCode >= 300 || resp.StatusCode < 200 {
-			err = fmt.Errorf("recieved non-ok status from MusicBrainz: %s", resp.Status)
-			done <- queue.RequestResult{Body: nil, Err: err}
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		done <- queue.RequestResult{Body: body, Err: err}
-	})
-
-	result := <-resultChan
-	return result.Body, result.Err
+	resp, err := c.requestQueue.Do(ctx, req)
+	if err != nil {
+		l.Err(err).Str("url", req.URL.String()).Msg("Failed to contact MusicBrainz")
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	} else if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+		return nil, fmt.Errorf("recieved non-ok status from MusicBrainz: %s", resp.Status)
+	}
+	return resp.Body, nil
 }
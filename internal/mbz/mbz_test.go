@@ -0,0 +1,18 @@
+package mbz_test
+
+import (
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenreNames(t *testing.T) {
+	genres := []mbz.MusicBrainzGenre{
+		{Name: "j-pop", Count: 2},
+		{Name: "rock", Count: 5},
+		{Name: "dance", Count: 2},
+	}
+	assert.Equal(t, []string{"rock", "j-pop", "dance"}, mbz.GenreNames(genres))
+	assert.Empty(t, mbz.GenreNames(nil))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -15,6 +16,8 @@ type MbzMockCaller struct {
 	ReleaseGroups map[uuid.UUID]*MusicBrainzReleaseGroup
 	Releases      map[uuid.UUID]*MusicBrainzRelease
 	Tracks        map[uuid.UUID]*MusicBrainzTrack
+	// returned by every search, leaving it to scoring to pick one
+	Recordings []MusicBrainzRecording
 }
 
 func (m *MbzMockCaller) GetReleaseGroup(ctx context.Context, id uuid.UUID) (*MusicBrainzReleaseGroup, error) {
@@ -56,6 +59,14 @@ func (m *MbzMockCaller) GetTrack(ctx context.Context, id uuid.UUID) (*MusicBrain
 	return track, nil
 }
 
+func (m *MbzMockCaller) GetArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error) {
+	artist, exists := m.Artists[id]
+	if !exists {
+		return nil, fmt.Errorf("artist with ID %s not found", id)
+	}
+	return artist, nil
+}
+
 func (m *MbzMockCaller) GetArtistPrimaryAliases(ctx context.Context, id uuid.UUID) ([]string, error) {
 	artist, exists := m.Artists[id]
 	if !exists {
@@ -70,6 +81,21 @@ func (m *MbzMockCaller) GetArtistPrimaryAliases(ctx context.Context, id uuid.UUI
 	return ss, nil
 }
 
+func (m *MbzMockCaller) SearchRecordings(ctx context.Context, q SearchQuery) ([]MusicBrainzRecording, error) {
+	return m.Recordings, nil
+}
+
+func (m *MbzMockCaller) SearchReleases(ctx context.Context, q SearchQuery) ([]MusicBrainzRelease, error) {
+	releases := make([]MusicBrainzRelease, 0, len(m.Releases))
+	for id, release := range m.Releases {
+		r := *release
+		r.ID = id.String()
+		releases = append(releases, r)
+	}
+	slices.SortFunc(releases, func(a, b MusicBrainzRelease) int { return strings.Compare(a.ID, b.ID) })
+	return releases, nil
+}
+
 func (m *MbzMockCaller) Shutdown() {}
 
 type MbzErrorCaller struct{}
@@ -90,8 +116,20 @@ func (m *MbzErrorCaller) GetTrack(ctx context.Context, id uuid.UUID) (*MusicBrai
 	return nil, fmt.Errorf("error: GetTrack not implemented")
 }
 
+func (m *MbzErrorCaller) GetArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error) {
+	return nil, fmt.Errorf("error: GetArtist not implemented")
+}
+
 func (m *MbzErrorCaller) GetArtistPrimaryAliases(ctx context.Context, id uuid.UUID) ([]string, error) {
 	return nil, fmt.Errorf("error: GetArtistPrimaryAliases not implemented")
 }
 
+func (m *MbzErrorCaller) SearchRecordings(ctx context.Context, q SearchQuery) ([]MusicBrainzRecording, error) {
+	return nil, fmt.Errorf("error: SearchRecordings not implemented")
+}
+
+func (m *MbzErrorCaller) SearchReleases(ctx context.Context, q SearchQuery) ([]MusicBrainzRelease, error) {
+	return nil, fmt.Errorf("error: SearchReleases not implemented")
+}
+
 func (m *MbzErrorCaller) Shutdown() {}
@@ -13,29 +13,39 @@ type MusicBrainzReleaseGroup struct {
 	Type         string                    `json:"primary_type"`
 	ArtistCredit []MusicBrainzArtistCredit `json:"artist-credit"`
 	Releases     []MusicBrainzRelease      `json:"releases"`
+	Genres       []MusicBrainzGenre        `json:"genres"`
 }
 type MusicBrainzRelease struct {
-	Title              string                    `json:"title"`
-	ID                 string                    `json:"id"`
-	ArtistCredit       []MusicBrainzArtistCredit `json:"artist-credit"`
-	Status             string                    `json:"status"`
-	TextRepresentation TextRepresentation        `json:"text-representation"`
+	Title              string                     `json:"title"`
+	ID                 string                     `json:"id"`
+	ArtistCredit       []MusicBrainzArtistCredit  `json:"artist-credit"`
+	Status             string                     `json:"status"`
+	TextRepresentation TextRepresentation         `json:"text-representation"`
+	Genres             []MusicBrainzGenre         `json:"genres"`
+	ReleaseGroup       MusicBrainzReleaseGroupRef `json:"release-group"`
+	// only set in search results
+	Score int `json:"score"`
+}
+type MusicBrainzReleaseGroupRef struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
 }
 type MusicBrainzArtistCredit struct {
-	Artist MusicBrainzArtist `json:"artist"`
-	Name   string            `json:"name"`
+	Artist     MusicBrainzArtist `json:"artist"`
+	Name       string            `json:"name"`
+	JoinPhrase string            `json:"joinphrase"`
 }
 type TextRepresentation struct {
 	Language string `json:"language"`
 	Script   string `json:"script"`
 }
 
-const releaseGroupFmtStr = "%s/ws/2/release-group/%s?inc=releases+artists"
-const releaseFmtStr = "%s/ws/2/release/%s?inc=artists"
+const releaseGroupFmtStr = "%s/ws/2/release-group/%s?inc=releases+artists+genres"
+const releaseFmtStr = "%s/ws/2/release/%s?inc=artists+genres+release-groups"
 
 func (c *MusicBrainzClient) GetReleaseGroup(ctx context.Context, id uuid.UUID) (*MusicBrainzReleaseGroup, error) {
 	mbzRG := new(MusicBrainzReleaseGroup)
-	err := c.getEntity(ctx, releaseGroupFmtStr, id, mbzRG)
+	err := c.getEntity(ctx, CacheKindReleaseGroup, releaseGroupFmtStr, id, mbzRG)
 	if err != nil {
 		return nil, fmt.Errorf("GetReleaseGroup: %w", err)
 	}
@@ -44,7 +54,7 @@ func (c *MusicBrainzClient) GetReleaseGroup(ctx context.Context, id uuid.UUID) (
 
 func (c *MusicBrainzClient) GetRelease(ctx context.Context, id uuid.UUID) (*MusicBrainzRelease, error) {
 	mbzRelease := new(MusicBrainzRelease)
-	err := c.getEntity(ctx, releaseFmtStr, id, mbzRelease)
+	err := c.getEntity(ctx, CacheKindRelease, releaseFmtStr, id, mbzRelease)
 	if err != nil {
 		return nil, fmt.Errorf("GetRelease: %w", err)
 	}
@@ -0,0 +1,225 @@
+package mbz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gosimple/unidecode"
+)
+
+type MusicBrainzRecording struct {
+	ID           string                    `json:"id"`
+	Title        string                    `json:"title"`
+	LengthMs     int                       `json:"length"`
+	Score        int                       `json:"score"`
+	ArtistCredit []MusicBrainzArtistCredit `json:"artist-credit"`
+	Releases     []MusicBrainzRelease      `json:"releases"`
+}
+
+// SearchQuery is what a listen is looked up on MusicBrainz by. Release is
+// optional for recording searches, and Duration is only used for scoring.
+type SearchQuery struct {
+	Artist      string
+	ArtistNames []string
+	Title       string
+	Release     string
+	Duration    int32 // in seconds
+}
+
+const searchLimit = 10
+
+const (
+	recordingSearchFmtStr = "%s/ws/2/recording?query=%s&limit=%d"
+	releaseSearchFmtStr   = "%s/ws/2/release?query=%s&limit=%d"
+)
+
+// SearchRecordings searches MusicBrainz for recordings with the title by the
+// artist, optionally on the release. Results are ordered by MusicBrainz's own
+// score, use ScoreRecording to compare them with the query.
+func (c *MusicBrainzClient) SearchRecordings(ctx context.Context, q SearchQuery) ([]MusicBrainzRecording, error) {
+	terms := []string{luceneTerm("recording", q.Title), luceneTerm("artist", q.Artist)}
+	if q.Release != "" {
+		terms = append(terms, luceneTerm("release", q.Release))
+	}
+	var result struct {
+		Recordings []MusicBrainzRecording `json:"recordings"`
+	}
+	err := c.getJSON(ctx, fmt.Sprintf(recordingSearchFmtStr, c.url, url.QueryEscape(strings.Join(terms, " AND ")), searchLimit), &result)
+	if err != nil {
+		return nil, fmt.Errorf("SearchRecordings: %w", err)
+	}
+	return result.Recordings, nil
+}
+
+// SearchReleases searches MusicBrainz for releases with the title of
+// q.Release by the artist.
+func (c *MusicBrainzClient) SearchReleases(ctx context.Context, q SearchQuery) ([]MusicBrainzRelease, error) {
+	query := luceneTerm("release", q.Release) + " AND " + luceneTerm("artist", q.Artist)
+	var result struct {
+		Releases []MusicBrainzRelease `json:"releases"`
+	}
+	err := c.getJSON(ctx, fmt.Sprintf(releaseSearchFmtStr, c.url, url.QueryEscape(query), searchLimit), &result)
+	if err != nil {
+		return nil, fmt.Errorf("SearchReleases: %w", err)
+	}
+	return result.Releases, nil
+}
+
+// luceneTerm quotes the value as a phrase for the field.
+func luceneTerm(field, value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return field + `:"` + value + `"`
+}
+
+// weights of the parts of a recording's score
+const (
+	titleWeight    = 0.45
+	artistWeight   = 0.35
+	durationWeight = 0.2
+	// durations further apart than this many seconds score zero
+	maxDurationDiff = 30
+)
+
+// ScoreRecording returns how well the recording matches the query, from 0 to
+// 1, by title similarity, artist credit and duration. The duration is left
+// out when either one is unknown.
+func ScoreRecording(r *MusicBrainzRecording, q SearchQuery) float64 {
+	title := Similarity(q.Title, r.Title)
+	artist := scoreArtistCredit(r.ArtistCredit, q)
+	if q.Duration <= 0 || r.LengthMs <= 0 {
+		return (titleWeight*title + artistWeight*artist) / (titleWeight + artistWeight)
+	}
+	diff := float64(q.Duration) - float64(r.LengthMs)/1000
+	if diff < 0 {
+		diff = -diff
+	}
+	duration := max(0, 1-diff/maxDurationDiff)
+	return titleWeight*title + artistWeight*artist + durationWeight*duration
+}
+
+// ScoreRelease returns how well the release matches the title of q.Release
+// and the artist, from 0 to 1.
+func ScoreRelease(r *MusicBrainzRelease, q SearchQuery) float64 {
+	title := Similarity(q.Release, r.Title)
+	artist := scoreArtistCredit(r.ArtistCredit, q)
+	return (titleWeight*title + artistWeight*artist) / (titleWeight + artistWeight)
+}
+
+// scoreArtistCredit compares the full credit with the artist string, and
+// every credited artist with the closest of the artist names, taking
+// whichever is better.
+func scoreArtistCredit(credit []MusicBrainzArtistCredit, q SearchQuery) float64 {
+	if len(credit) == 0 {
+		return 0
+	}
+	var full strings.Builder
+	for _, c := range credit {
+		full.WriteString(c.Name)
+		full.WriteString(c.JoinPhrase)
+	}
+	score := Similarity(q.Artist, full.String())
+
+	names := q.ArtistNames
+	if len(names) == 0 {
+		names = []string{q.Artist}
+	}
+	var sum float64
+	for _, c := range credit {
+		best := 0.0
+		for _, name := range names {
+			best = max(best, Similarity(name, c.Name), Similarity(name, c.Artist.Name))
+		}
+		sum += best
+	}
+	return max(score, sum/float64(len(credit)))
+}
+
+// Similarity compares two names by their edit distance after lowercasing and
+// removing diacritics, punctuation and spaces, from 0 to 1.
+func Similarity(a, b string) float64 {
+	ra := []rune(normalize(a))
+	rb := []rune(normalize(b))
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	longest := max(len(ra), len(rb))
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r >= unicode.MaxASCII && unicode.Is(unicode.Latin, r) {
+			for _, c := range strings.ToLower(unidecode.Unidecode(string(r))) {
+				if unicode.IsLetter(c) || unicode.IsNumber(c) {
+					b.WriteRune(c)
+				}
+			}
+		} else if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// BestRecording returns the recording scoring highest against the query, and
+// its score. Returns nil when there are none.
+func BestRecording(recordings []MusicBrainzRecording, q SearchQuery) (*MusicBrainzRecording, float64) {
+	var best *MusicBrainzRecording
+	bestScore := 0.0
+	for i := range recordings {
+		if score := ScoreRecording(&recordings[i], q); best == nil || score > bestScore {
+			best, bestScore = &recordings[i], score
+		}
+	}
+	return best, bestScore
+}
+
+// BestRelease returns the release scoring highest against the query, and its
+// score, preferring official releases when scores are tied. Returns nil when
+// there are none.
+func BestRelease(releases []MusicBrainzRelease, q SearchQuery) (*MusicBrainzRelease, float64) {
+	sorted := slices.Clone(releases)
+	// stable, so that MusicBrainz's order is kept otherwise
+	slices.SortStableFunc(sorted, func(a, b MusicBrainzRelease) int {
+		if (a.Status == "Official") == (b.Status == "Official") {
+			return 0
+		} else if a.Status == "Official" {
+			return -1
+		}
+		return 1
+	})
+	var best *MusicBrainzRelease
+	bestScore := 0.0
+	for i := range sorted {
+		if score := ScoreRelease(&sorted[i], q); best == nil || score > bestScore {
+			best, bestScore = &sorted[i], score
+		}
+	}
+	return best, bestScore
+}
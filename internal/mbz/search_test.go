@@ -0,0 +1,77 @@
+package mbz_test
+
+import (
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, mbz.Similarity("Beyoncé", "BEYONCE!"))
+	assert.Equal(t, 1.0, mbz.Similarity("", ""))
+	assert.Zero(t, mbz.Similarity("abc", ""))
+	assert.InDelta(t, 0.8, mbz.Similarity("Tokyo Calling", "Tokyo Callin"), 0.15)
+}
+
+func credit(names ...string) []mbz.MusicBrainzArtistCredit {
+	c := make([]mbz.MusicBrainzArtistCredit, len(names))
+	for i, name := range names {
+		c[i] = mbz.MusicBrainzArtistCredit{Name: name, Artist: mbz.MusicBrainzArtist{Name: name}}
+		if i < len(names)-1 {
+			c[i].JoinPhrase = " & "
+		}
+	}
+	return c
+}
+
+func TestScoreRecording(t *testing.T) {
+	q := mbz.SearchQuery{
+		Artist:      "ATARASHII GAKKO!",
+		ArtistNames: []string{"ATARASHII GAKKO!"},
+		Title:       "Tokyo Calling",
+		Duration:    200,
+	}
+	exact := mbz.MusicBrainzRecording{Title: "Tokyo Calling", LengthMs: 201000, ArtistCredit: credit("ATARASHII GAKKO!")}
+	otherArtist := mbz.MusicBrainzRecording{Title: "Tokyo Calling", LengthMs: 200000, ArtistCredit: credit("Someone Else")}
+	otherLength := mbz.MusicBrainzRecording{Title: "Tokyo Calling", LengthMs: 320000, ArtistCredit: credit("ATARASHII GAKKO!")}
+	noLength := mbz.MusicBrainzRecording{Title: "Tokyo Calling", ArtistCredit: credit("ATARASHII GAKKO!")}
+
+	assert.Greater(t, mbz.ScoreRecording(&exact, q), 0.95)
+	assert.Less(t, mbz.ScoreRecording(&otherArtist, q), 0.85)
+	assert.Less(t, mbz.ScoreRecording(&otherLength, q), 0.85)
+	// an unknown duration neither helps nor hurts
+	assert.Equal(t, 1.0, mbz.ScoreRecording(&noLength, q))
+
+	best, score := mbz.BestRecording([]mbz.MusicBrainzRecording{otherArtist, otherLength, exact}, q)
+	assert.Equal(t, exact.LengthMs, best.LengthMs)
+	assert.Equal(t, mbz.ScoreRecording(&exact, q), score)
+
+	best, _ = mbz.BestRecording(nil, q)
+	assert.Nil(t, best)
+}
+
+func TestScoreRecording_SeveralArtists(t *testing.T) {
+	q := mbz.SearchQuery{
+		Artist:      "Rat Tally feat. Madeline Kenney",
+		ArtistNames: []string{"Rat Tally", "Madeline Kenney"},
+		Title:       "In My Car",
+	}
+	r := mbz.MusicBrainzRecording{Title: "In My Car", ArtistCredit: credit("Rat Tally", "Madeline Kenney")}
+	assert.Equal(t, 1.0, mbz.ScoreRecording(&r, q))
+}
+
+func TestBestRelease(t *testing.T) {
+	q := mbz.SearchQuery{
+		Artist:  "ATARASHII GAKKO!",
+		Release: "AG! Calling",
+	}
+	releases := []mbz.MusicBrainzRelease{
+		{ID: "bootleg", Title: "AG! Calling", Status: "Bootleg", ArtistCredit: credit("ATARASHII GAKKO!")},
+		{ID: "official", Title: "AG! Calling", Status: "Official", ArtistCredit: credit("ATARASHII GAKKO!")},
+		{ID: "other", Title: "Pineapple Kryptonite", Status: "Official", ArtistCredit: credit("ATARASHII GAKKO!")},
+	}
+	best, score := mbz.BestRelease(releases, q)
+	assert.Equal(t, "official", best.ID)
+	assert.Equal(t, 1.0, score)
+}
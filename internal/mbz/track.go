@@ -8,16 +8,17 @@ import (
 )
 
 type MusicBrainzTrack struct {
-	Title    string `json:"title"`
-	LengthMs int    `json:"length"`
+	Title        string                    `json:"title"`
+	LengthMs     int                       `json:"length"`
+	ArtistCredit []MusicBrainzArtistCredit `json:"artist-credit"`
 }
 
-const recordingFmtStr = "%s/ws/2/recording/%s"
+const recordingFmtStr = "%s/ws/2/recording/%s?inc=artists"
 
 // Returns the artist name at index 0, and all primary aliases after.
 func (c *MusicBrainzClient) GetTrack(ctx context.Context, id uuid.UUID) (*MusicBrainzTrack, error) {
 	track := new(MusicBrainzTrack)
-	err := c.getEntity(ctx, recordingFmtStr, id, track)
+	err := c.getEntity(ctx, CacheKindRecording, recordingFmtStr, id, track)
 	if err != nil {
 		return nil, fmt.Errorf("GetTrack: %w", err)
 	}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditMergeArtists AuditAction = "merge_artists"
+	AuditMergeAlbums  AuditAction = "merge_albums"
+	AuditMergeTracks  AuditAction = "merge_tracks"
+	AuditSplitArtist  AuditAction = "split_artist"
+	AuditSplitAlbum   AuditAction = "split_album"
+	AuditSplitTrack   AuditAction = "split_track"
+	AuditDeleteArtist AuditAction = "delete_artist"
+	AuditDeleteAlbum  AuditAction = "delete_album"
+	AuditDeleteTrack  AuditAction = "delete_track"
+	AuditDeleteListen AuditAction = "delete_listen"
+
+	AuditReassociateListens AuditAction = "reassociate_listens"
+	AuditEditTrack          AuditAction = "edit_track"
+)
+
+// AuditRecorded counts the rows an audit entry keeps to restore on undo
+type AuditRecorded struct {
+	Artists     int32 `json:"artists"`
+	Albums      int32 `json:"albums"`
+	Tracks      int32 `json:"tracks"`
+	Listens     int32 `json:"listens"`
+	Aliases     int32 `json:"aliases"`
+	ArtistLinks int32 `json:"artist_links"`
+}
+
+// an AuditEntry is a merge, split, delete, reassociation or track edit that was made, with what it was given.
+// Entries can only be undone newest first.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Action    AuditAction     `json:"action"`
+	Inputs    json.RawMessage `json:"inputs"`
+	Recorded  AuditRecorded   `json:"recorded"`
+	Undoable  bool            `json:"undoable"`
+	CreatedAt time.Time       `json:"created_at"`
+	UndoneAt  *time.Time      `json:"undone_at"`
+}
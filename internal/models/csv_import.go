@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+type CsvDurationUnit string
+
+const (
+	CsvDurationSeconds      CsvDurationUnit = "s"
+	CsvDurationMilliseconds CsvDurationUnit = "ms"
+)
+
+// Timestamp formats besides Go time layouts
+const (
+	CsvTimestampUnix    = "unix"
+	CsvTimestampUnixMs  = "unix_ms"
+	CsvTimestampRFC3339 = "rfc3339"
+)
+
+// a CsvImportProfile maps the columns of a CSV file, by their header, to the
+// fields of a listen. Optional columns are empty when the file has none.
+type CsvImportProfile struct {
+	ID      int32  `json:"id,omitempty"`
+	Name    string `json:"name"`
+	Builtin bool   `json:"builtin"`
+	// one character, a comma when empty
+	Delimiter       string `json:"delimiter"`
+	ArtistColumn    string `json:"artist_column"`
+	TitleColumn     string `json:"title_column"`
+	AlbumColumn     string `json:"album_column"`
+	TimestampColumn string `json:"timestamp_column"`
+	// unix, unix_ms, rfc3339 or a Go time layout
+	TimestampFormat string `json:"timestamp_format"`
+	// IANA name of the timezone of timestamps that do not include one
+	Timezone       string          `json:"timezone"`
+	DurationColumn string          `json:"duration_column"`
+	DurationUnit   CsvDurationUnit `json:"duration_unit"`
+	MbzIDColumn    string          `json:"mbid_column"`
+	// when set, only rows whose filter column has the filter value are listens
+	FilterColumn string `json:"filter_column"`
+	FilterValue  string `json:"filter_value"`
+	// how long each row was played for, in the duration unit. Rows played
+	// for too short to be scrobbled are not listens.
+	PlayedColumn string    `json:"played_column"`
+	CreatedAt    time.Time `json:"created_at,omitzero"`
+	UpdatedAt    time.Time `json:"updated_at,omitzero"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type EnrichmentTask string
+
+const (
+	EnrichmentTrackDurations EnrichmentTask = "track_durations"
+	EnrichmentAlbumImages    EnrichmentTask = "album_images"
+	EnrichmentArtistMbzIDs   EnrichmentTask = "artist_mbids"
+	EnrichmentArtistGenres   EnrichmentTask = "artist_genres"
+	EnrichmentAlbumGenres    EnrichmentTask = "album_genres"
+)
+
+// EnrichmentProgress is how far a task of the enrichment worker got in its
+// latest sweep. FinishedAt is nil while the sweep is in progress.
+type EnrichmentProgress struct {
+	Task EnrichmentTask `json:"task"`
+	// the id of the last artist, album or track handled
+	LastID int32 `json:"last_id"`
+	// how many were handled, and how many of those were enriched
+	Processed  int        `json:"processed"`
+	Enriched   int        `json:"enriched"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
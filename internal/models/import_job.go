@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// ImportFormat is the kind of export an import file is.
+type ImportFormat string
+
+const (
+	ImportSpotify      ImportFormat = "spotify"
+	ImportMaloja       ImportFormat = "maloja"
+	ImportLastFM       ImportFormat = "lastfm"
+	ImportListenBrainz ImportFormat = "listenbrainz"
+	ImportYouTubeMusic ImportFormat = "youtube_music"
+	ImportScrobblerLog ImportFormat = "scrobbler_log"
+	ImportCsv          ImportFormat = "csv"
+	ImportBeatScrobble ImportFormat = "beat_scrobble"
+)
+
+type ImportJobStatus string
+
+const (
+	ImportJobQueued    ImportJobStatus = "queued"
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobSucceeded ImportJobStatus = "succeeded"
+	ImportJobFailed    ImportJobStatus = "failed"
+	ImportJobCanceled  ImportJobStatus = "canceled"
+)
+
+// Done reports whether a job with the status has stopped for good.
+func (s ImportJobStatus) Done() bool {
+	return s == ImportJobSucceeded || s == ImportJobFailed || s == ImportJobCanceled
+}
+
+// ImportJobOptions are how the file of an import job is read, for the formats
+// that need more than the file itself.
+type ImportJobOptions struct {
+	// the CSV import profile that matched the columns of a csv file
+	CsvProfile string `json:"csv_profile,omitempty"`
+	// IANA name of the timezone of scrobbler.log files that do not know theirs
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// an ImportJob is an import of an uploaded file, run in the background. Offset
+// is how many items of the file were read, saved as the job runs so that it
+// resumes from there after a restart.
+type ImportJob struct {
+	ID       int64            `json:"id"`
+	UserID   int32            `json:"-"`
+	Format   ImportFormat     `json:"format"`
+	Filename string           `json:"filename"`
+	Options  ImportJobOptions `json:"options"`
+	Status   ImportJobStatus  `json:"status"`
+	// how many bytes of the file there are to read, and were read so far
+	Size      int64 `json:"size"`
+	BytesRead int64 `json:"bytes_read"`
+	Offset    int   `json:"offset"`
+	// of the items read, how many were saved as listens, were not listens or
+	// fell outside of the import window, and could not be read or saved
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	Failed   int `json:"failed"`
+	// why the latest items that failed did
+	Errors []string `json:"errors"`
+	// why the job failed, when it did
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
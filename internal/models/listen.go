@@ -8,4 +8,15 @@ import (
 type Listen struct {
 	Time  time.Time `json:"time"`
 	Track Track     `json:"track"`
+	// What the client told us about the listen, empty when it sent nothing
+	Client                  string   `json:"client,omitempty"`
+	DurationMs              int32    `json:"duration_ms,omitempty"` // how long the track was played for
+	MediaPlayer             string   `json:"media_player,omitempty"`
+	SubmissionClientVersion string   `json:"submission_client_version,omitempty"`
+	Tags                    []string `json:"tags,omitempty"`
+	OriginURL               string   `json:"origin_url,omitempty"`
+	// set when the listen started before the previous one could have ended
+	Overlap bool `json:"overlap,omitempty"`
+	// how the listen was matched, only set where it is asked for
+	Match *ListenMatch `json:"match,omitempty"`
 }
@@ -0,0 +1,49 @@
+package models
+
+// MatchMethod is how a listen was associated with an artist, album or track
+type MatchMethod string
+
+const (
+	// matched or created by MusicBrainz ID
+	MatchMethodMBID MatchMethod = "mbid"
+	// matched an existing entity by the exact name or title submitted
+	MatchMethodAlias MatchMethod = "alias"
+	// matched an existing entity by a name or title other than the one
+	// submitted, such as an artist split out of the artist string or an album
+	// guessed from the track title
+	MatchMethodFuzzy MatchMethod = "fuzzy"
+	// nothing matched and a new entity was created
+	MatchMethodCreated MatchMethod = "created"
+)
+
+// Confidence is how sure a match made with the method is, from 0 to 1
+func (m MatchMethod) Confidence() float64 {
+	switch m {
+	case MatchMethodMBID:
+		return 1
+	case MatchMethodAlias:
+		return 0.9
+	case MatchMethodFuzzy:
+		return 0.5
+	case MatchMethodCreated:
+		return 0.25
+	default:
+		return 0
+	}
+}
+
+type Match struct {
+	Method     MatchMethod `json:"method"`
+	Confidence float64     `json:"confidence"`
+}
+
+// a ListenMatch records how a listen was associated with its artists, album
+// and track
+type ListenMatch struct {
+	Artist Match `json:"artist"`
+	Album  Match `json:"album"`
+	Track  Match `json:"track"`
+	// the lowest confidence of the three
+	Confidence float64 `json:"confidence"`
+	Reviewed   bool    `json:"reviewed"`
+}
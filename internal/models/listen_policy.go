@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// a ListenPolicy decides which submitted listens are saved for a user. Zero
+// values disable a check, so the zero policy accepts every listen.
+type ListenPolicy struct {
+	// a listen must be played for at least this fraction of the track, or for
+	// MinPlayedSeconds, whichever is set and met first
+	MinPlayedFraction float64 `json:"min_played_fraction"`
+	MinPlayedSeconds  int32   `json:"min_played_seconds"`
+	// a listen of the same track within this many seconds of another is a duplicate
+	DuplicateWindowSeconds int32 `json:"duplicate_window_seconds"`
+	// flags listens that start before the previous listen could have ended
+	FlagOverlaps bool      `json:"flag_overlaps"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type RejectedListenReason string
+
+const (
+	RejectedListenTooShort  RejectedListenReason = "too_short"
+	RejectedListenDuplicate RejectedListenReason = "duplicate"
+)
+
+// a RejectedListen is a listen the user's policy turned away, kept so it can
+// be reviewed and saved anyway
+type RejectedListen struct {
+	ID         int64                `json:"id"`
+	Time       time.Time            `json:"time"`
+	Track      Track                `json:"track"`
+	Reason     RejectedListenReason `json:"reason"`
+	Client     string               `json:"client,omitempty"`
+	DurationMs int32                `json:"duration_ms,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
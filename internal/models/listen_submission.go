@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ListenSubmissionStatus string
+
+const (
+	ListenSubmissionPending    ListenSubmissionStatus = "pending"
+	ListenSubmissionProcessing ListenSubmissionStatus = "processing"
+	ListenSubmissionDead       ListenSubmissionStatus = "dead"
+)
+
+// a ListenSubmission is a submitted listen waiting in the ingest outbox to be
+// associated with an artist, album and track and saved
+type ListenSubmission struct {
+	ID            int64                  `json:"id"`
+	UserID        int32                  `json:"user_id"`
+	Payload       json.RawMessage        `json:"payload"`
+	Status        ListenSubmissionStatus `json:"status"`
+	Attempts      int32                  `json:"attempts"`
+	LastError     string                 `json:"last_error,omitempty"`
+	NextAttemptAt time.Time              `json:"next_attempt_at"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// a MbzCacheEntry is the response of a MusicBrainz lookup of an artist,
+// release, release group or recording. Body is nil when MusicBrainz answered
+// that it does not exist.
+type MbzCacheEntry struct {
+	Kind      string
+	MbzID     uuid.UUID
+	Body      []byte
+	FetchedAt time.Time
+	ExpiresAt time.Time
+}
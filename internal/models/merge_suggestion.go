@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type MergeSuggestionKind string
+
+const (
+	MergeSuggestionArtist MergeSuggestionKind = "artist"
+	MergeSuggestionAlbum  MergeSuggestionKind = "album"
+	MergeSuggestionTrack  MergeSuggestionKind = "track"
+)
+
+type MergeSuggestionItem struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+// a MergeSuggestion is a pair of artists, albums or tracks that look like
+// duplicates of each other. Accepting it merges From into To.
+type MergeSuggestion struct {
+	ID   int64               `json:"id"`
+	Kind MergeSuggestionKind `json:"kind"`
+	From MergeSuggestionItem `json:"from"`
+	To   MergeSuggestionItem `json:"to"`
+	// how likely the pair is a duplicate, from 0 to 1
+	Score     float64   `json:"score"`
+	Reasons   []string  `json:"reasons"`
+	Dismissed bool      `json:"dismissed"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// RewriteField is a listen field that a rewrite rule can match on or change
+type RewriteField string
+
+const (
+	RewriteFieldArtist RewriteField = "artist"
+	RewriteFieldTitle  RewriteField = "title"
+	RewriteFieldAlbum  RewriteField = "album"
+	RewriteFieldClient RewriteField = "client"
+)
+
+type RewriteAction string
+
+const (
+	// replaces every match of the pattern in the field with the replacement
+	RewriteActionReplace RewriteAction = "replace"
+	// splits the artist field into multiple artists on every match of the pattern
+	RewriteActionSplit RewriteAction = "split"
+	// discards the listen when the pattern matches
+	RewriteActionDrop RewriteAction = "drop"
+	// sets the target field to the replacement when the pattern matches
+	RewriteActionRemap RewriteAction = "remap"
+)
+
+// a RewriteRule changes submitted listens before they are associated with an
+// artist, album and track. Rules are applied in position order.
+type RewriteRule struct {
+	ID          int32         `json:"id"`
+	UserID      int32         `json:"user_id"`
+	Position    int32         `json:"position"`
+	Enabled     bool          `json:"enabled"`
+	Field       RewriteField  `json:"field"`
+	Pattern     string        `json:"pattern"`
+	Action      RewriteAction `json:"action"`
+	Target      RewriteField  `json:"target,omitempty"`
+	Replacement string        `json:"replacement"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,203 @@
+package mpd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPort = "6600"
+
+// Conn is a connection to an MPD server speaking its text protocol.
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	// protocol version sent by the server when connecting
+	Version string
+}
+
+type Status struct {
+	State    string // play, pause or stop
+	SongID   string
+	Elapsed  time.Duration
+	Duration time.Duration
+}
+
+type Song struct {
+	ID               string
+	File             string
+	Artists          []string
+	AlbumArtist      string
+	Title            string
+	Album            string
+	Duration         time.Duration
+	RecordingMBID    string
+	ReleaseMBID      string
+	ReleaseGroupMBID string
+	ArtistMBIDs      []string
+}
+
+// Dial connects to the server and sends the password when one is given. An
+// address starting with a slash is a unix socket, and the default port is used
+// when the address has none.
+func Dial(ctx context.Context, address, password string) (*Conn, error) {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	} else if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultPort)
+	}
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("Dial: %w", err)
+	}
+	c := &Conn{conn: nc, r: bufio.NewReader(nc)}
+	greeting, err := c.readLine()
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("Dial: %w", err)
+	}
+	version, ok := strings.CutPrefix(greeting, "OK MPD ")
+	if !ok {
+		nc.Close()
+		return nil, fmt.Errorf("Dial: unexpected greeting '%s'", greeting)
+	}
+	c.Version = version
+	if password != "" {
+		if _, err := c.command("password " + quote(password)); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("Dial: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) Status() (Status, error) {
+	pairs, err := c.command("status")
+	if err != nil {
+		return Status{}, fmt.Errorf("Status: %w", err)
+	}
+	var status Status
+	for _, p := range pairs {
+		switch p[0] {
+		case "state":
+			status.State = p[1]
+		case "songid":
+			status.SongID = p[1]
+		case "elapsed":
+			status.Elapsed = parseSeconds(p[1])
+		case "duration":
+			status.Duration = parseSeconds(p[1])
+		}
+	}
+	return status, nil
+}
+
+// CurrentSong returns the song that is playing or paused, or nil when there is
+// none.
+func (c *Conn) CurrentSong() (*Song, error) {
+	pairs, err := c.command("currentsong")
+	if err != nil {
+		return nil, fmt.Errorf("CurrentSong: %w", err)
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	song := new(Song)
+	for _, p := range pairs {
+		switch p[0] {
+		case "Id":
+			song.ID = p[1]
+		case "file":
+			song.File = p[1]
+		case "Artist":
+			song.Artists = append(song.Artists, p[1])
+		case "AlbumArtist":
+			song.AlbumArtist = p[1]
+		case "Title":
+			song.Title = p[1]
+		case "Album":
+			song.Album = p[1]
+		case "duration":
+			song.Duration = parseSeconds(p[1])
+		case "Time":
+			if song.Duration == 0 {
+				song.Duration = parseSeconds(p[1])
+			}
+		case "MUSICBRAINZ_TRACKID":
+			song.RecordingMBID = p[1]
+		case "MUSICBRAINZ_ALBUMID":
+			song.ReleaseMBID = p[1]
+		case "MUSICBRAINZ_RELEASEGROUPID":
+			song.ReleaseGroupMBID = p[1]
+		case "MUSICBRAINZ_ARTISTID":
+			song.ArtistMBIDs = append(song.ArtistMBIDs, p[1])
+		}
+	}
+	return song, nil
+}
+
+// IdlePlayer blocks until the server reports a change of the player state,
+// such as a new song starting, playback being paused or seeking.
+func (c *Conn) IdlePlayer() error {
+	if _, err := c.command("idle player"); err != nil {
+		return fmt.Errorf("IdlePlayer: %w", err)
+	}
+	return nil
+}
+
+// command sends a command and returns the key value pairs of the response.
+func (c *Conn) command(cmd string) ([][2]string, error) {
+	if _, err := c.conn.Write([]byte(cmd + "\n")); err != nil {
+		return nil, err
+	}
+	var pairs [][2]string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "OK" {
+			return pairs, nil
+		}
+		if strings.HasPrefix(line, "ACK ") {
+			return nil, errors.New(line)
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("unexpected response '%s'", line)
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+}
+
+func (c *Conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func parseSeconds(s string) time.Duration {
+	secs, err := strconv.ParseFloat(s, 64)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
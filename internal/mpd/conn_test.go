@@ -0,0 +1,104 @@
+package mpd_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMPD answers commands with the given responses, in order
+func fakeMPD(t *testing.T, responses map[string]string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("OK MPD 0.23.5\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			resp, ok := responses[strings.TrimSpace(line)]
+			if !ok {
+				resp = "ACK [5@0] {} unknown command\n"
+			}
+			conn.Write([]byte(resp))
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestConn(t *testing.T) {
+	addr := fakeMPD(t, map[string]string{
+		`password "se\"cret"`: "OK\n",
+		"status":              "volume: 100\nstate: play\nsongid: 12\nelapsed: 61.250\nduration: 241.560\nOK\n",
+		"currentsong": "file: music/track.flac\nArtist: ネクライトーキー\nArtist: Guest\nTitle: こんがらがった！\nAlbum: ONE!\n" +
+			"Time: 242\nduration: 241.560\nMUSICBRAINZ_TRACKID: 8eec4f3f-a059-4217-aad1-fbf82e33e756\n" +
+			"MUSICBRAINZ_ARTISTID: 1262ab85-308b-46e7-b0b5-91fef8e46b62\nId: 12\nOK\n",
+		"idle player": "changed: player\nOK\n",
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := mpd.Dial(ctx, addr, `se"cret`)
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, "0.23.5", c.Version)
+
+	status, err := c.Status()
+	require.NoError(t, err)
+	assert.Equal(t, "play", status.State)
+	assert.Equal(t, "12", status.SongID)
+	assert.Equal(t, 61250*time.Millisecond, status.Elapsed)
+	assert.Equal(t, 241560*time.Millisecond, status.Duration)
+
+	song, err := c.CurrentSong()
+	require.NoError(t, err)
+	require.NotNil(t, song)
+	assert.Equal(t, "12", song.ID)
+	assert.Equal(t, []string{"ネクライトーキー", "Guest"}, song.Artists)
+	assert.Equal(t, "こんがらがった！", song.Title)
+	assert.Equal(t, "ONE!", song.Album)
+	assert.Equal(t, 241560*time.Millisecond, song.Duration)
+	assert.Equal(t, "8eec4f3f-a059-4217-aad1-fbf82e33e756", song.RecordingMBID)
+	assert.Equal(t, []string{"1262ab85-308b-46e7-b0b5-91fef8e46b62"}, song.ArtistMBIDs)
+
+	require.NoError(t, c.IdlePlayer())
+}
+
+func TestConnErrors(t *testing.T) {
+	addr := fakeMPD(t, map[string]string{
+		"currentsong": "OK\n",
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// a wrong password fails the connection
+	_, err := mpd.Dial(ctx, addr, "wrong")
+	assert.Error(t, err)
+
+	addr = fakeMPD(t, map[string]string{
+		"currentsong": "OK\n",
+	})
+	c, err := mpd.Dial(ctx, addr, "")
+	require.NoError(t, err)
+	defer c.Close()
+	song, err := c.CurrentSong()
+	require.NoError(t, err)
+	assert.Nil(t, song, "nothing is playing")
+	_, err = c.Status()
+	assert.Error(t, err)
+}
@@ -0,0 +1,195 @@
+// Package mpd watches an MPD server and scrobbles what it plays, so no separate
+// scrobbling daemon is needed. Songs that start playing are set as now playing
+// right away, and songs that were played long enough are queued as listens the
+// same way ListenBrainz submissions are.
+package mpd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/catalog"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/db"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/ingest"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
+	"github.com/google/uuid"
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+type Options struct {
+	Address  string
+	Password string
+	// the user listens are saved for
+	UserID int32
+}
+
+type Watcher struct {
+	store  db.DB
+	mbzc   mbz.MusicBrainzCaller
+	opts   Options
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start connects to MPD in the background, reconnecting with increasing delays
+// whenever the connection is lost, for example when MPD restarts.
+func Start(ctx context.Context, store db.DB, mbzc mbz.MusicBrainzCaller, opts Options) *Watcher {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &Watcher{
+		store:  store,
+		mbzc:   mbzc,
+		opts:   opts,
+		cancel: cancel,
+	}
+	w.wg.Add(1)
+	go w.run(ctx)
+	return w
+}
+
+// Stop closes the connection, saving the song being played when it already
+// counts as a listen.
+func (w *Watcher) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer w.wg.Done()
+	l := logger.FromContext(ctx)
+	delay := minReconnectDelay
+	for {
+		connected, err := w.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = minReconnectDelay
+		}
+		l.Warn().Err(err).Msgf("MPD: Connection to %s lost, reconnecting in %s", w.opts.Address, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// watch follows the player until the connection fails. Returns whether the
+// connection was established.
+func (w *Watcher) watch(ctx context.Context) (bool, error) {
+	l := logger.FromContext(ctx)
+
+	c, err := Dial(ctx, w.opts.Address, w.opts.Password)
+	if err != nil {
+		return false, fmt.Errorf("watch: %w", err)
+	}
+	defer c.Close()
+	// closing the connection unblocks the idle command when stopping
+	stop := context.AfterFunc(ctx, func() { c.Close() })
+	defer stop()
+
+	l.Info().Msgf("MPD: Connected to %s (protocol %s)", w.opts.Address, c.Version)
+
+	var tracker Tracker
+	defer func() {
+		if play := tracker.Flush(time.Now()); play != nil {
+			w.submitListen(context.WithoutCancel(ctx), c.Version, play)
+		}
+	}()
+	for {
+		status, err := c.Status()
+		if err != nil {
+			return true, fmt.Errorf("watch: %w", err)
+		}
+		song, err := c.CurrentSong()
+		if err != nil {
+			return true, fmt.Errorf("watch: %w", err)
+		}
+		started, finished := tracker.Update(status, song, time.Now())
+		if finished != nil {
+			w.submitListen(ctx, c.Version, finished)
+		}
+		if started != nil {
+			w.submitNowPlaying(ctx, c.Version, started)
+		}
+		if err := c.IdlePlayer(); err != nil {
+			return true, fmt.Errorf("watch: %w", err)
+		}
+	}
+}
+
+func (w *Watcher) submitNowPlaying(ctx context.Context, version string, song *Song) {
+	l := logger.FromContext(ctx)
+	opts, ok := w.submitOpts(version, song)
+	if !ok {
+		l.Debug().Msgf("MPD: Skipping song '%s' without artist or title", song.File)
+		return
+	}
+	opts.Time = time.Now()
+	opts.IsNowPlaying = true
+	opts.SkipSaveListen = true
+	if err := catalog.SubmitListen(ctx, w.store, opts); err != nil {
+		l.Err(err).Msg("MPD: Failed to submit now playing")
+	}
+}
+
+func (w *Watcher) submitListen(ctx context.Context, version string, play *Play) {
+	l := logger.FromContext(ctx)
+	opts, ok := w.submitOpts(version, &play.Song)
+	if !ok {
+		return
+	}
+	opts.Time = play.Started
+	opts.DurationMs = int32(play.Played.Milliseconds())
+	if err := ingest.Enqueue(ctx, w.store, opts); err != nil {
+		l.Err(err).Msg("MPD: Failed to queue listen")
+		return
+	}
+	l.Debug().Msgf("MPD: Queued listen for '%s'", play.Song.Title)
+}
+
+func (w *Watcher) submitOpts(version string, song *Song) (catalog.SubmitListenOpts, bool) {
+	artists := song.Artists
+	if len(artists) == 0 && song.AlbumArtist != "" {
+		artists = []string{song.AlbumArtist}
+	}
+	if len(artists) == 0 || song.Title == "" {
+		return catalog.SubmitListenOpts{}, false
+	}
+	opts := catalog.SubmitListenOpts{
+		MbzCaller:               w.mbzc,
+		Artist:                  strings.Join(artists, ", "),
+		ArtistNames:             artists,
+		TrackTitle:              song.Title,
+		ReleaseTitle:            song.Album,
+		Duration:                int32(song.Duration.Seconds()),
+		UserID:                  w.opts.UserID,
+		Client:                  "mpd",
+		MediaPlayer:             "MPD",
+		SubmissionClientVersion: version,
+	}
+	if id, err := uuid.Parse(song.RecordingMBID); err == nil {
+		opts.RecordingMbzID = id
+	}
+	if id, err := uuid.Parse(song.ReleaseMBID); err == nil {
+		opts.ReleaseMbzID = id
+	}
+	if id, err := uuid.Parse(song.ReleaseGroupMBID); err == nil {
+		opts.ReleaseGroupMbzID = id
+	}
+	for _, s := range song.ArtistMBIDs {
+		if id, err := uuid.Parse(s); err == nil {
+			opts.ArtistMbzIDs = append(opts.ArtistMbzIDs, id)
+		}
+	}
+	return opts, true
+}
@@ -0,0 +1,101 @@
+package mpd
+
+import (
+	"time"
+)
+
+const (
+	// a song counts as a listen once it has been played for half its length or
+	// four minutes, whichever comes first, like Last.fm and ListenBrainz expect
+	maxListenThreshold = 4 * time.Minute
+	// songs shorter than this are never listens
+	minSongDuration = 30 * time.Second
+	// a song that goes back to its start after being played this long is
+	// counted as played again
+	restartWindow = 5 * time.Second
+)
+
+// Play is a song that was played long enough to be saved as a listen.
+type Play struct {
+	Song    Song
+	Started time.Time
+	Played  time.Duration
+}
+
+// Tracker follows the player state reported by MPD and works out which songs
+// started playing and which were listened to. Only time spent playing counts,
+// so pausing or leaving a song paused does not make it a listen.
+type Tracker struct {
+	current    *Song
+	started    time.Time
+	played     time.Duration
+	playing    bool
+	lastUpdate time.Time
+}
+
+// Update records the player state at the given time. It returns the song when
+// one started or resumed playing, and the previous song when it has been
+// played long enough to be a listen.
+func (t *Tracker) Update(status Status, song *Song, now time.Time) (*Song, *Play) {
+	if t.playing {
+		t.played += now.Sub(t.lastUpdate)
+	}
+	t.lastUpdate = now
+
+	active := status.State == "play" || status.State == "pause"
+	changed := !active || song == nil || t.current == nil || song.ID != t.current.ID
+	restarted := !changed && status.Elapsed < restartWindow && t.played >= restartWindow
+
+	var started *Song
+	var finished *Play
+	if changed || restarted {
+		finished = t.finish()
+		t.current = nil
+		if active && song != nil {
+			s := *song
+			if status.Duration > 0 {
+				s.Duration = status.Duration
+			}
+			t.current = &s
+			t.started = now.Add(-status.Elapsed)
+			if status.State == "play" {
+				started = t.current
+			}
+		}
+	} else if !t.playing && status.State == "play" {
+		// resumed after a pause
+		started = t.current
+	}
+	t.playing = t.current != nil && status.State == "play"
+	return started, finished
+}
+
+// Flush ends the current song, as when the connection to MPD is lost, and
+// returns it when it has been played long enough to be a listen.
+func (t *Tracker) Flush(now time.Time) *Play {
+	if t.playing {
+		t.played += now.Sub(t.lastUpdate)
+	}
+	play := t.finish()
+	t.current = nil
+	t.playing = false
+	return play
+}
+
+func (t *Tracker) finish() *Play {
+	defer func() { t.played = 0 }()
+	if t.current == nil {
+		return nil
+	}
+	if t.current.Duration > 0 && t.current.Duration < minSongDuration {
+		return nil
+	}
+	threshold := maxListenThreshold
+	if t.current.Duration > 0 {
+		threshold = min(t.current.Duration/2, maxListenThreshold)
+	}
+	if t.played < threshold {
+		return nil
+	}
+	return &Play{Song: *t.current, Started: t.started, Played: t.played}
+}
@@ -0,0 +1,82 @@
+package mpd_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mpd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func song(id string, duration time.Duration) *mpd.Song {
+	return &mpd.Song{ID: id, Artists: []string{"Artist"}, Title: "Title " + id, Duration: duration}
+}
+
+func TestTrackerHalfDuration(t *testing.T) {
+	var tr mpd.Tracker
+	start := time.Unix(1700000000, 0)
+	a := song("1", 3*time.Minute)
+
+	started, finished := tr.Update(mpd.Status{State: "play", SongID: "1"}, a, start)
+	require.NotNil(t, started)
+	assert.Equal(t, "1", started.ID)
+	assert.Nil(t, finished)
+
+	// skipped after a minute, less than half of the song
+	started, finished = tr.Update(mpd.Status{State: "play", SongID: "2"}, song("2", 3*time.Minute), start.Add(time.Minute))
+	require.NotNil(t, started)
+	assert.Nil(t, finished)
+
+	// the second song is played for more than half its length
+	started, finished = tr.Update(mpd.Status{State: "play", SongID: "3"}, song("3", 3*time.Minute), start.Add(time.Minute+91*time.Second))
+	require.NotNil(t, started)
+	require.NotNil(t, finished)
+	assert.Equal(t, "2", finished.Song.ID)
+	assert.Equal(t, start.Add(time.Minute), finished.Started)
+	assert.Equal(t, 91*time.Second, finished.Played)
+}
+
+func TestTrackerFourMinutes(t *testing.T) {
+	var tr mpd.Tracker
+	start := time.Unix(1700000000, 0)
+	tr.Update(mpd.Status{State: "play", SongID: "1"}, song("1", 20*time.Minute), start)
+	_, finished := tr.Update(mpd.Status{State: "stop"}, nil, start.Add(4*time.Minute))
+	require.NotNil(t, finished)
+	assert.Equal(t, 4*time.Minute, finished.Played)
+
+	// without a known duration, four minutes are needed
+	tr.Update(mpd.Status{State: "play", SongID: "2"}, song("2", 0), start)
+	assert.Nil(t, tr.Flush(start.Add(3*time.Minute)))
+}
+
+func TestTrackerPause(t *testing.T) {
+	var tr mpd.Tracker
+	start := time.Unix(1700000000, 0)
+	a := song("1", 4*time.Minute)
+	tr.Update(mpd.Status{State: "play", SongID: "1"}, a, start)
+
+	started, finished := tr.Update(mpd.Status{State: "pause", SongID: "1", Elapsed: time.Minute}, a, start.Add(time.Minute))
+	assert.Nil(t, started)
+	assert.Nil(t, finished)
+
+	// time spent paused does not count
+	started, _ = tr.Update(mpd.Status{State: "play", SongID: "1", Elapsed: time.Minute}, a, start.Add(time.Hour))
+	require.NotNil(t, started, "resuming sets the song as now playing again")
+	assert.Nil(t, tr.Flush(start.Add(time.Hour+time.Minute/2)))
+}
+
+func TestTrackerRepeatAndShortSongs(t *testing.T) {
+	var tr mpd.Tracker
+	start := time.Unix(1700000000, 0)
+	a := song("1", 2*time.Minute)
+	tr.Update(mpd.Status{State: "play", SongID: "1"}, a, start)
+	// the same song starts over when repeating
+	started, finished := tr.Update(mpd.Status{State: "play", SongID: "1", Elapsed: time.Second}, a, start.Add(2*time.Minute+time.Second))
+	require.NotNil(t, started)
+	require.NotNil(t, finished)
+	assert.Equal(t, "1", finished.Song.ID)
+
+	tr.Update(mpd.Status{State: "play", SongID: "2"}, song("2", 20*time.Second), start)
+	assert.Nil(t, tr.Flush(start.Add(20*time.Second)))
+}
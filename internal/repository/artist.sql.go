@@ -18,15 +18,17 @@ SELECT COUNT(DISTINCT at.artist_id) AS total_count
 FROM listens l
 JOIN artist_tracks at ON l.track_id = at.track_id
 WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $3
 `
 
 type CountTopArtistsParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
+	UserID       int32
 }
 
 func (q *Queries) CountTopArtists(ctx context.Context, arg CountTopArtistsParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countTopArtists, arg.ListenedAt, arg.ListenedAt_2)
+	row := q.db.QueryRow(ctx, countTopArtists, arg.ListenedAt, arg.ListenedAt_2, arg.UserID)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -305,6 +307,7 @@ JOIN tracks t ON l.track_id = t.id
 JOIN artist_tracks at ON at.track_id = t.id
 JOIN artists_with_name a ON a.id = at.artist_id
 WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $5
 GROUP BY a.id, a.name, a.musicbrainz_id, a.image, a.genres, a.bio, a.popularity, a.spotify_id, a.followers
 ORDER BY listen_count DESC, a.id
 LIMIT $3 OFFSET $4
@@ -315,6 +318,7 @@ type GetTopArtistsPaginatedParams struct {
 	ListenedAt_2 time.Time
 	Limit        int32
 	Offset       int32
+	UserID       int32
 }
 
 type GetTopArtistsPaginatedRow struct {
@@ -336,6 +340,7 @@ func (q *Queries) GetTopArtistsPaginated(ctx context.Context, arg GetTopArtistsP
 		arg.ListenedAt_2,
 		arg.Limit,
 		arg.Offset,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -12,7 +12,8 @@ import (
 const cleanOrphanedEntries = `-- name: CleanOrphanedEntries :exec
 DO $$
 BEGIN
-  DELETE FROM tracks WHERE id NOT IN (SELECT l.track_id FROM listens l);
+  DELETE FROM tracks WHERE id NOT IN (SELECT l.track_id FROM listens l)
+    AND id NOT IN (SELECT r.track_id FROM rejected_listens r);
   DELETE FROM releases WHERE id NOT IN (SELECT t.release_id FROM tracks t);
   DELETE FROM artists WHERE id NOT IN (SELECT at.artist_id FROM artist_tracks at);
 END $$
@@ -17,15 +17,17 @@ const countListens = `-- name: CountListens :one
 SELECT COUNT(*) AS total_count
 FROM listens l
 WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $3
 `
 
 type CountListensParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
+	UserID       int32
 }
 
 func (q *Queries) CountListens(ctx context.Context, arg CountListensParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countListens, arg.ListenedAt, arg.ListenedAt_2)
+	row := q.db.QueryRow(ctx, countListens, arg.ListenedAt, arg.ListenedAt_2, arg.UserID)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -37,16 +39,23 @@ FROM listens l
 JOIN artist_tracks at ON l.track_id = at.track_id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND at.artist_id = $3
+  AND l.user_id = $4
 `
 
 type CountListensFromArtistParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
 	ArtistID     int32
+	UserID       int32
 }
 
 func (q *Queries) CountListensFromArtist(ctx context.Context, arg CountListensFromArtistParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countListensFromArtist, arg.ListenedAt, arg.ListenedAt_2, arg.ArtistID)
+	row := q.db.QueryRow(ctx, countListensFromArtist,
+		arg.ListenedAt,
+		arg.ListenedAt_2,
+		arg.ArtistID,
+		arg.UserID,
+	)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -58,16 +67,23 @@ FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND t.release_id = $3
+  AND l.user_id = $4
 `
 
 type CountListensFromReleaseParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
 	ReleaseID    int32
+	UserID       int32
 }
 
 func (q *Queries) CountListensFromRelease(ctx context.Context, arg CountListensFromReleaseParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countListensFromRelease, arg.ListenedAt, arg.ListenedAt_2, arg.ReleaseID)
+	row := q.db.QueryRow(ctx, countListensFromRelease,
+		arg.ListenedAt,
+		arg.ListenedAt_2,
+		arg.ReleaseID,
+		arg.UserID,
+	)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -78,16 +94,23 @@ SELECT COUNT(*) AS total_count
 FROM listens l
 WHERE l.listened_at BETWEEN $1 AND $2
   AND l.track_id = $3
+  AND l.user_id = $4
 `
 
 type CountListensFromTrackParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
 	TrackID      int32
+	UserID       int32
 }
 
 func (q *Queries) CountListensFromTrack(ctx context.Context, arg CountListensFromTrackParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countListensFromTrack, arg.ListenedAt, arg.ListenedAt_2, arg.TrackID)
+	row := q.db.QueryRow(ctx, countListensFromTrack,
+		arg.ListenedAt,
+		arg.ListenedAt_2,
+		arg.TrackID,
+		arg.UserID,
+	)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -98,15 +121,17 @@ SELECT COALESCE(SUM(t.duration), 0)::BIGINT AS seconds_listened
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $3
 `
 
 type CountTimeListenedParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
+	UserID       int32
 }
 
 func (q *Queries) CountTimeListened(ctx context.Context, arg CountTimeListenedParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countTimeListened, arg.ListenedAt, arg.ListenedAt_2)
+	row := q.db.QueryRow(ctx, countTimeListened, arg.ListenedAt, arg.ListenedAt_2, arg.UserID)
 	var seconds_listened int64
 	err := row.Scan(&seconds_listened)
 	return seconds_listened, err
@@ -119,16 +144,23 @@ JOIN tracks t ON l.track_id = t.id
 JOIN artist_tracks at ON t.id = at.track_id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND at.artist_id = $3
+  AND l.user_id = $4
 `
 
 type CountTimeListenedToArtistParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
 	ArtistID     int32
+	UserID       int32
 }
 
 func (q *Queries) CountTimeListenedToArtist(ctx context.Context, arg CountTimeListenedToArtistParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countTimeListenedToArtist, arg.ListenedAt, arg.ListenedAt_2, arg.ArtistID)
+	row := q.db.QueryRow(ctx, countTimeListenedToArtist,
+		arg.ListenedAt,
+		arg.ListenedAt_2,
+		arg.ArtistID,
+		arg.UserID,
+	)
 	var seconds_listened int64
 	err := row.Scan(&seconds_listened)
 	return seconds_listened, err
@@ -140,16 +172,23 @@ FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND t.release_id = $3
+  AND l.user_id = $4
 `
 
 type CountTimeListenedToReleaseParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
 	ReleaseID    int32
+	UserID       int32
 }
 
 func (q *Queries) CountTimeListenedToRelease(ctx context.Context, arg CountTimeListenedToReleaseParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countTimeListenedToRelease, arg.ListenedAt, arg.ListenedAt_2, arg.ReleaseID)
+	row := q.db.QueryRow(ctx, countTimeListenedToRelease,
+		arg.ListenedAt,
+		arg.ListenedAt_2,
+		arg.ReleaseID,
+		arg.UserID,
+	)
 	var seconds_listened int64
 	err := row.Scan(&seconds_listened)
 	return seconds_listened, err
@@ -161,105 +200,149 @@ FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND t.id = $3
+  AND l.user_id = $4
 `
 
 type CountTimeListenedToTrackParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
 	ID           int32
+	UserID       int32
 }
 
 func (q *Queries) CountTimeListenedToTrack(ctx context.Context, arg CountTimeListenedToTrackParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countTimeListenedToTrack, arg.ListenedAt, arg.ListenedAt_2, arg.ID)
+	row := q.db.QueryRow(ctx, countTimeListenedToTrack,
+		arg.ListenedAt,
+		arg.ListenedAt_2,
+		arg.ID,
+		arg.UserID,
+	)
 	var seconds_listened int64
 	err := row.Scan(&seconds_listened)
 	return seconds_listened, err
 }
 
 const deleteListen = `-- name: DeleteListen :exec
-DELETE FROM listens WHERE track_id = $1 AND listened_at = $2
+DELETE FROM listens WHERE track_id = $1 AND listened_at = $2 AND user_id = $3
 `
 
 type DeleteListenParams struct {
 	TrackID    int32
 	ListenedAt time.Time
+	UserID     int32
 }
 
 func (q *Queries) DeleteListen(ctx context.Context, arg DeleteListenParams) error {
-	_, err := q.db.Exec(ctx, deleteListen, arg.TrackID, arg.ListenedAt)
+	_, err := q.db.Exec(ctx, deleteListen, arg.TrackID, arg.ListenedAt, arg.UserID)
 	return err
 }
 
 const getFirstListenFromArtist = `-- name: GetFirstListenFromArtist :one
 SELECT 
-  l.track_id, l.listened_at, l.client, l.user_id
+  l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap
 FROM listens l
 JOIN tracks_with_title t ON l.track_id = t.id
 JOIN artist_tracks at ON t.id = at.track_id 
 WHERE at.artist_id = $1
+  AND l.user_id = $2
 ORDER BY l.listened_at ASC
 LIMIT 1
 `
 
-func (q *Queries) GetFirstListenFromArtist(ctx context.Context, artistID int32) (Listen, error) {
-	row := q.db.QueryRow(ctx, getFirstListenFromArtist, artistID)
+type GetFirstListenFromArtistParams struct {
+	ArtistID int32
+	UserID   int32
+}
+
+func (q *Queries) GetFirstListenFromArtist(ctx context.Context, arg GetFirstListenFromArtistParams) (Listen, error) {
+	row := q.db.QueryRow(ctx, getFirstListenFromArtist, arg.ArtistID, arg.UserID)
 	var i Listen
 	err := row.Scan(
 		&i.TrackID,
 		&i.ListenedAt,
 		&i.Client,
 		&i.UserID,
+		&i.DurationMs,
+		&i.MediaPlayer,
+		&i.SubmissionClientVersion,
+		&i.Tags,
+		&i.OriginUrl,
+		&i.Overlap,
 	)
 	return i, err
 }
 
 const getFirstListenFromRelease = `-- name: GetFirstListenFromRelease :one
 SELECT 
-  l.track_id, l.listened_at, l.client, l.user_id
+  l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE t.release_id = $1
+  AND l.user_id = $2
 ORDER BY l.listened_at ASC
 LIMIT 1
 `
 
-func (q *Queries) GetFirstListenFromRelease(ctx context.Context, releaseID int32) (Listen, error) {
-	row := q.db.QueryRow(ctx, getFirstListenFromRelease, releaseID)
+type GetFirstListenFromReleaseParams struct {
+	ReleaseID int32
+	UserID    int32
+}
+
+func (q *Queries) GetFirstListenFromRelease(ctx context.Context, arg GetFirstListenFromReleaseParams) (Listen, error) {
+	row := q.db.QueryRow(ctx, getFirstListenFromRelease, arg.ReleaseID, arg.UserID)
 	var i Listen
 	err := row.Scan(
 		&i.TrackID,
 		&i.ListenedAt,
 		&i.Client,
 		&i.UserID,
+		&i.DurationMs,
+		&i.MediaPlayer,
+		&i.SubmissionClientVersion,
+		&i.Tags,
+		&i.OriginUrl,
+		&i.Overlap,
 	)
 	return i, err
 }
 
 const getFirstListenFromTrack = `-- name: GetFirstListenFromTrack :one
 SELECT 
-  l.track_id, l.listened_at, l.client, l.user_id
+  l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap
 FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE t.id = $1
+  AND l.user_id = $2
 ORDER BY l.listened_at ASC
 LIMIT 1
 `
 
-func (q *Queries) GetFirstListenFromTrack(ctx context.Context, id int32) (Listen, error) {
-	row := q.db.QueryRow(ctx, getFirstListenFromTrack, id)
+type GetFirstListenFromTrackParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetFirstListenFromTrack(ctx context.Context, arg GetFirstListenFromTrackParams) (Listen, error) {
+	row := q.db.QueryRow(ctx, getFirstListenFromTrack, arg.ID, arg.UserID)
 	var i Listen
 	err := row.Scan(
 		&i.TrackID,
 		&i.ListenedAt,
 		&i.Client,
 		&i.UserID,
+		&i.DurationMs,
+		&i.MediaPlayer,
+		&i.SubmissionClientVersion,
+		&i.Tags,
+		&i.OriginUrl,
+		&i.Overlap,
 	)
 	return i, err
 }
 
 const getLastListensFromArtistPaginated = `-- name: GetLastListensFromArtistPaginated :many
 SELECT 
-  l.track_id, l.listened_at, l.client, l.user_id,
+  l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap,
   t.title AS track_title,
   t.release_id AS release_id,
   r.image AS release_image,
@@ -271,6 +354,7 @@ JOIN releases_with_title r ON t.release_id = r.id
 JOIN artist_tracks at ON t.id = at.track_id 
 WHERE at.artist_id = $5
   AND l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $6
 ORDER BY l.listened_at DESC
 LIMIT $3 OFFSET $4
 `
@@ -281,18 +365,25 @@ type GetLastListensFromArtistPaginatedParams struct {
 	Limit        int32
 	Offset       int32
 	ArtistID     int32
+	UserID       int32
 }
 
 type GetLastListensFromArtistPaginatedRow struct {
-	TrackID      int32
-	ListenedAt   time.Time
-	Client       *string
-	UserID       int32
-	TrackTitle   string
-	ReleaseID    int32
-	ReleaseImage *uuid.UUID
-	ReleaseTitle string
-	Artists      []byte
+	TrackID                 int32
+	ListenedAt              time.Time
+	Client                  *string
+	UserID                  int32
+	DurationMs              pgtype.Int4
+	MediaPlayer             pgtype.Text
+	SubmissionClientVersion pgtype.Text
+	Tags                    []string
+	OriginUrl               pgtype.Text
+	Overlap                 bool
+	TrackTitle              string
+	ReleaseID               int32
+	ReleaseImage            *uuid.UUID
+	ReleaseTitle            string
+	Artists                 []byte
 }
 
 func (q *Queries) GetLastListensFromArtistPaginated(ctx context.Context, arg GetLastListensFromArtistPaginatedParams) ([]GetLastListensFromArtistPaginatedRow, error) {
@@ -302,6 +393,7 @@ func (q *Queries) GetLastListensFromArtistPaginated(ctx context.Context, arg Get
 		arg.Limit,
 		arg.Offset,
 		arg.ArtistID,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -315,6 +407,12 @@ func (q *Queries) GetLastListensFromArtistPaginated(ctx context.Context, arg Get
 			&i.ListenedAt,
 			&i.Client,
 			&i.UserID,
+			&i.DurationMs,
+			&i.MediaPlayer,
+			&i.SubmissionClientVersion,
+			&i.Tags,
+			&i.OriginUrl,
+			&i.Overlap,
 			&i.TrackTitle,
 			&i.ReleaseID,
 			&i.ReleaseImage,
@@ -333,7 +431,7 @@ func (q *Queries) GetLastListensFromArtistPaginated(ctx context.Context, arg Get
 
 const getLastListensFromReleasePaginated = `-- name: GetLastListensFromReleasePaginated :many
 SELECT 
-  l.track_id, l.listened_at, l.client, l.user_id,
+  l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap,
   t.title AS track_title,
   t.release_id AS release_id,
   r.image AS release_image,
@@ -344,6 +442,7 @@ JOIN tracks_with_title t ON l.track_id = t.id
 JOIN releases_with_title r ON t.release_id = r.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND t.release_id = $5
+  AND l.user_id = $6
 ORDER BY l.listened_at DESC
 LIMIT $3 OFFSET $4
 `
@@ -354,18 +453,25 @@ type GetLastListensFromReleasePaginatedParams struct {
 	Limit        int32
 	Offset       int32
 	ReleaseID    int32
+	UserID       int32
 }
 
 type GetLastListensFromReleasePaginatedRow struct {
-	TrackID      int32
-	ListenedAt   time.Time
-	Client       *string
-	UserID       int32
-	TrackTitle   string
-	ReleaseID    int32
-	ReleaseImage *uuid.UUID
-	ReleaseTitle string
-	Artists      []byte
+	TrackID                 int32
+	ListenedAt              time.Time
+	Client                  *string
+	UserID                  int32
+	DurationMs              pgtype.Int4
+	MediaPlayer             pgtype.Text
+	SubmissionClientVersion pgtype.Text
+	Tags                    []string
+	OriginUrl               pgtype.Text
+	Overlap                 bool
+	TrackTitle              string
+	ReleaseID               int32
+	ReleaseImage            *uuid.UUID
+	ReleaseTitle            string
+	Artists                 []byte
 }
 
 func (q *Queries) GetLastListensFromReleasePaginated(ctx context.Context, arg GetLastListensFromReleasePaginatedParams) ([]GetLastListensFromReleasePaginatedRow, error) {
@@ -375,6 +481,7 @@ func (q *Queries) GetLastListensFromReleasePaginated(ctx context.Context, arg Ge
 		arg.Limit,
 		arg.Offset,
 		arg.ReleaseID,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -388,6 +495,12 @@ func (q *Queries) GetLastListensFromReleasePaginated(ctx context.Context, arg Ge
 			&i.ListenedAt,
 			&i.Client,
 			&i.UserID,
+			&i.DurationMs,
+			&i.MediaPlayer,
+			&i.SubmissionClientVersion,
+			&i.Tags,
+			&i.OriginUrl,
+			&i.Overlap,
 			&i.TrackTitle,
 			&i.ReleaseID,
 			&i.ReleaseImage,
@@ -406,7 +519,7 @@ func (q *Queries) GetLastListensFromReleasePaginated(ctx context.Context, arg Ge
 
 const getLastListensFromTrackPaginated = `-- name: GetLastListensFromTrackPaginated :many
 SELECT 
-  l.track_id, l.listened_at, l.client, l.user_id,
+  l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap,
   t.title AS track_title,
   t.release_id AS release_id,
   r.image AS release_image,
@@ -417,6 +530,7 @@ JOIN tracks_with_title t ON l.track_id = t.id
 JOIN releases_with_title r ON t.release_id = r.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND t.id = $5
+  AND l.user_id = $6
 ORDER BY l.listened_at DESC
 LIMIT $3 OFFSET $4
 `
@@ -427,18 +541,25 @@ type GetLastListensFromTrackPaginatedParams struct {
 	Limit        int32
 	Offset       int32
 	ID           int32
+	UserID       int32
 }
 
 type GetLastListensFromTrackPaginatedRow struct {
-	TrackID      int32
-	ListenedAt   time.Time
-	Client       *string
-	UserID       int32
-	TrackTitle   string
-	ReleaseID    int32
-	ReleaseImage *uuid.UUID
-	ReleaseTitle string
-	Artists      []byte
+	TrackID                 int32
+	ListenedAt              time.Time
+	Client                  *string
+	UserID                  int32
+	DurationMs              pgtype.Int4
+	MediaPlayer             pgtype.Text
+	SubmissionClientVersion pgtype.Text
+	Tags                    []string
+	OriginUrl               pgtype.Text
+	Overlap                 bool
+	TrackTitle              string
+	ReleaseID               int32
+	ReleaseImage            *uuid.UUID
+	ReleaseTitle            string
+	Artists                 []byte
 }
 
 func (q *Queries) GetLastListensFromTrackPaginated(ctx context.Context, arg GetLastListensFromTrackPaginatedParams) ([]GetLastListensFromTrackPaginatedRow, error) {
@@ -448,6 +569,7 @@ func (q *Queries) GetLastListensFromTrackPaginated(ctx context.Context, arg GetL
 		arg.Limit,
 		arg.Offset,
 		arg.ID,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -461,6 +583,12 @@ func (q *Queries) GetLastListensFromTrackPaginated(ctx context.Context, arg GetL
 			&i.ListenedAt,
 			&i.Client,
 			&i.UserID,
+			&i.DurationMs,
+			&i.MediaPlayer,
+			&i.SubmissionClientVersion,
+			&i.Tags,
+			&i.OriginUrl,
+			&i.Overlap,
 			&i.TrackTitle,
 			&i.ReleaseID,
 			&i.ReleaseImage,
@@ -479,7 +607,7 @@ func (q *Queries) GetLastListensFromTrackPaginated(ctx context.Context, arg GetL
 
 const getLastListensPaginated = `-- name: GetLastListensPaginated :many
 SELECT 
-  l.track_id, l.listened_at, l.client, l.user_id,
+  l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap,
   t.title AS track_title,
   t.release_id AS release_id,
   r.image AS release_image,
@@ -489,6 +617,7 @@ FROM listens l
 JOIN tracks_with_title t ON l.track_id = t.id
 JOIN releases_with_title r ON t.release_id = r.id
 WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $5
 ORDER BY l.listened_at DESC
 LIMIT $3 OFFSET $4
 `
@@ -498,18 +627,25 @@ type GetLastListensPaginatedParams struct {
 	ListenedAt_2 time.Time
 	Limit        int32
 	Offset       int32
+	UserID       int32
 }
 
 type GetLastListensPaginatedRow struct {
-	TrackID      int32
-	ListenedAt   time.Time
-	Client       *string
-	UserID       int32
-	TrackTitle   string
-	ReleaseID    int32
-	ReleaseImage *uuid.UUID
-	ReleaseTitle string
-	Artists      []byte
+	TrackID                 int32
+	ListenedAt              time.Time
+	Client                  *string
+	UserID                  int32
+	DurationMs              pgtype.Int4
+	MediaPlayer             pgtype.Text
+	SubmissionClientVersion pgtype.Text
+	Tags                    []string
+	OriginUrl               pgtype.Text
+	Overlap                 bool
+	TrackTitle              string
+	ReleaseID               int32
+	ReleaseImage            *uuid.UUID
+	ReleaseTitle            string
+	Artists                 []byte
 }
 
 func (q *Queries) GetLastListensPaginated(ctx context.Context, arg GetLastListensPaginatedParams) ([]GetLastListensPaginatedRow, error) {
@@ -518,6 +654,7 @@ func (q *Queries) GetLastListensPaginated(ctx context.Context, arg GetLastListen
 		arg.ListenedAt_2,
 		arg.Limit,
 		arg.Offset,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -531,6 +668,12 @@ func (q *Queries) GetLastListensPaginated(ctx context.Context, arg GetLastListen
 			&i.ListenedAt,
 			&i.Client,
 			&i.UserID,
+			&i.DurationMs,
+			&i.MediaPlayer,
+			&i.SubmissionClientVersion,
+			&i.Tags,
+			&i.OriginUrl,
+			&i.Overlap,
 			&i.TrackTitle,
 			&i.ReleaseID,
 			&i.ReleaseImage,
@@ -547,11 +690,43 @@ func (q *Queries) GetLastListensPaginated(ctx context.Context, arg GetLastListen
 	return items, nil
 }
 
+const getListenBefore = `-- name: GetListenBefore :one
+SELECT l.listened_at, t.duration
+FROM listens l
+JOIN tracks t ON l.track_id = t.id
+WHERE l.user_id = $1
+  AND l.listened_at < $2
+ORDER BY l.listened_at DESC
+LIMIT 1
+`
+
+type GetListenBeforeParams struct {
+	UserID     int32
+	ListenedAt time.Time
+}
+
+type GetListenBeforeRow struct {
+	ListenedAt time.Time
+	Duration   int32
+}
+
+func (q *Queries) GetListenBefore(ctx context.Context, arg GetListenBeforeParams) (GetListenBeforeRow, error) {
+	row := q.db.QueryRow(ctx, getListenBefore, arg.UserID, arg.ListenedAt)
+	var i GetListenBeforeRow
+	err := row.Scan(&i.ListenedAt, &i.Duration)
+	return i, err
+}
+
 const getListensExportPage = `-- name: GetListensExportPage :many
 SELECT
     l.listened_at,
     l.user_id,
     l.client,
+    l.duration_ms,
+    l.media_player,
+    l.submission_client_version,
+    l.tags,
+    l.origin_url,
 
     -- Track info
     t.id AS track_id,
@@ -623,20 +798,25 @@ type GetListensExportPageParams struct {
 }
 
 type GetListensExportPageRow struct {
-	ListenedAt         time.Time
-	UserID             int32
-	Client             *string
-	TrackID            int32
-	TrackMbid          *uuid.UUID
-	TrackDuration      int32
-	TrackAliases       []byte
-	ReleaseID          int32
-	ReleaseMbid        *uuid.UUID
-	ReleaseImage       *uuid.UUID
-	ReleaseImageSource pgtype.Text
-	VariousArtists     bool
-	ReleaseAliases     []byte
-	Artists            []byte
+	ListenedAt              time.Time
+	UserID                  int32
+	Client                  *string
+	DurationMs              pgtype.Int4
+	MediaPlayer             pgtype.Text
+	SubmissionClientVersion pgtype.Text
+	Tags                    []string
+	OriginUrl               pgtype.Text
+	TrackID                 int32
+	TrackMbid               *uuid.UUID
+	TrackDuration           int32
+	TrackAliases            []byte
+	ReleaseID               int32
+	ReleaseMbid             *uuid.UUID
+	ReleaseImage            *uuid.UUID
+	ReleaseImageSource      pgtype.Text
+	VariousArtists          bool
+	ReleaseAliases          []byte
+	Artists                 []byte
 }
 
 func (q *Queries) GetListensExportPage(ctx context.Context, arg GetListensExportPageParams) ([]GetListensExportPageRow, error) {
@@ -657,6 +837,11 @@ func (q *Queries) GetListensExportPage(ctx context.Context, arg GetListensExport
 			&i.ListenedAt,
 			&i.UserID,
 			&i.Client,
+			&i.DurationMs,
+			&i.MediaPlayer,
+			&i.SubmissionClientVersion,
+			&i.Tags,
+			&i.OriginUrl,
 			&i.TrackID,
 			&i.TrackMbid,
 			&i.TrackDuration,
@@ -679,17 +864,76 @@ func (q *Queries) GetListensExportPage(ctx context.Context, arg GetListensExport
 	return items, nil
 }
 
+const getTrackListenCountsForReassociation = `-- name: GetTrackListenCountsForReassociation :many
+SELECT l.track_id, COUNT(*) AS listen_count
+FROM listens l
+WHERE l.user_id = $1
+  AND l.listened_at BETWEEN $2::timestamptz AND $3::timestamptz
+  AND ($4::text = '' OR l.client = $4::text)
+  AND ($5::int = 0 OR EXISTS (
+    SELECT 1 FROM artist_tracks at
+    WHERE at.track_id = l.track_id AND at.artist_id = $5::int
+  ))
+GROUP BY l.track_id
+ORDER BY l.track_id
+`
+
+type GetTrackListenCountsForReassociationParams struct {
+	UserID      int32
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Client      string
+	ArtistID    int32
+}
+
+type GetTrackListenCountsForReassociationRow struct {
+	TrackID     int32
+	ListenCount int64
+}
+
+func (q *Queries) GetTrackListenCountsForReassociation(ctx context.Context, arg GetTrackListenCountsForReassociationParams) ([]GetTrackListenCountsForReassociationRow, error) {
+	rows, err := q.db.Query(ctx, getTrackListenCountsForReassociation,
+		arg.UserID,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.Client,
+		arg.ArtistID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrackListenCountsForReassociationRow
+	for rows.Next() {
+		var i GetTrackListenCountsForReassociationRow
+		if err := rows.Scan(&i.TrackID, &i.ListenCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const insertListen = `-- name: InsertListen :exec
-INSERT INTO listens (track_id, listened_at, user_id, client)
-VALUES ($1, $2, $3, $4)
+INSERT INTO listens (track_id, listened_at, user_id, client, duration_ms, media_player, submission_client_version, tags, origin_url, overlap)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 ON CONFLICT DO NOTHING
 `
 
 type InsertListenParams struct {
-	TrackID    int32
-	ListenedAt time.Time
-	UserID     int32
-	Client     *string
+	TrackID                 int32
+	ListenedAt              time.Time
+	UserID                  int32
+	Client                  *string
+	DurationMs              pgtype.Int4
+	MediaPlayer             pgtype.Text
+	SubmissionClientVersion pgtype.Text
+	Tags                    []string
+	OriginUrl               pgtype.Text
+	Overlap                 bool
 }
 
 func (q *Queries) InsertListen(ctx context.Context, arg InsertListenParams) error {
@@ -698,6 +942,12 @@ func (q *Queries) InsertListen(ctx context.Context, arg InsertListenParams) erro
 		arg.ListenedAt,
 		arg.UserID,
 		arg.Client,
+		arg.DurationMs,
+		arg.MediaPlayer,
+		arg.SubmissionClientVersion,
+		arg.Tags,
+		arg.OriginUrl,
+		arg.Overlap,
 	)
 	return err
 }
@@ -714,6 +964,7 @@ bucketed_listens AS (
   LEFT JOIN listens l
     ON l.listened_at >= b.bucket_start
     AND l.listened_at < b.bucket_start + $3::interval
+    AND l.user_id = $4
   GROUP BY b.bucket_start
   ORDER BY b.bucket_start
 )
@@ -724,6 +975,7 @@ type ListenActivityParams struct {
 	Column1 time.Time
 	Column2 time.Time
 	Column3 pgtype.Interval
+	UserID  int32
 }
 
 type ListenActivityRow struct {
@@ -732,7 +984,12 @@ type ListenActivityRow struct {
 }
 
 func (q *Queries) ListenActivity(ctx context.Context, arg ListenActivityParams) ([]ListenActivityRow, error) {
-	rows, err := q.db.Query(ctx, listenActivity, arg.Column1, arg.Column2, arg.Column3)
+	rows, err := q.db.Query(ctx, listenActivity,
+		arg.Column1,
+		arg.Column2,
+		arg.Column3,
+		arg.UserID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -756,10 +1013,11 @@ WITH buckets AS (
   SELECT generate_series($1::timestamptz, $2::timestamptz, $3::interval) AS bucket_start
 ),
 filtered_listens AS (
-  SELECT l.track_id, l.listened_at, l.client, l.user_id
+  SELECT l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap
   FROM listens l
   JOIN artist_tracks t ON l.track_id = t.track_id
   WHERE t.artist_id = $4
+    AND l.user_id = $5
 ),
 bucketed_listens AS (
   SELECT
@@ -780,6 +1038,7 @@ type ListenActivityForArtistParams struct {
 	Column2  time.Time
 	Column3  pgtype.Interval
 	ArtistID int32
+	UserID   int32
 }
 
 type ListenActivityForArtistRow struct {
@@ -793,6 +1052,7 @@ func (q *Queries) ListenActivityForArtist(ctx context.Context, arg ListenActivit
 		arg.Column2,
 		arg.Column3,
 		arg.ArtistID,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -817,10 +1077,11 @@ WITH buckets AS (
   SELECT generate_series($1::timestamptz, $2::timestamptz, $3::interval) AS bucket_start
 ),
 filtered_listens AS (
-  SELECT l.track_id, l.listened_at, l.client, l.user_id
+  SELECT l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap
   FROM listens l
   JOIN tracks t ON l.track_id = t.id
   WHERE t.release_id = $4
+    AND l.user_id = $5
 ),
 bucketed_listens AS (
   SELECT
@@ -841,6 +1102,7 @@ type ListenActivityForReleaseParams struct {
 	Column2   time.Time
 	Column3   pgtype.Interval
 	ReleaseID int32
+	UserID    int32
 }
 
 type ListenActivityForReleaseRow struct {
@@ -854,6 +1116,7 @@ func (q *Queries) ListenActivityForRelease(ctx context.Context, arg ListenActivi
 		arg.Column2,
 		arg.Column3,
 		arg.ReleaseID,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -878,10 +1141,11 @@ WITH buckets AS (
   SELECT generate_series($1::timestamptz, $2::timestamptz, $3::interval) AS bucket_start
 ),
 filtered_listens AS (
-  SELECT l.track_id, l.listened_at, l.client, l.user_id
+  SELECT l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap
   FROM listens l
   JOIN tracks t ON l.track_id = t.id
   WHERE t.id = $4
+    AND l.user_id = $5
 ),
 bucketed_listens AS (
   SELECT
@@ -902,6 +1166,7 @@ type ListenActivityForTrackParams struct {
 	Column2 time.Time
 	Column3 pgtype.Interval
 	ID      int32
+	UserID  int32
 }
 
 type ListenActivityForTrackRow struct {
@@ -915,6 +1180,7 @@ func (q *Queries) ListenActivityForTrack(ctx context.Context, arg ListenActivity
 		arg.Column2,
 		arg.Column3,
 		arg.ID,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -934,6 +1200,73 @@ func (q *Queries) ListenActivityForTrack(ctx context.Context, arg ListenActivity
 	return items, nil
 }
 
+const listenOfTrackExistsInRange = `-- name: ListenOfTrackExistsInRange :one
+SELECT EXISTS (
+  SELECT 1 FROM listens
+  WHERE user_id = $1::int
+    AND track_id = $2::int
+    AND listened_at BETWEEN $3::timestamptz AND $4::timestamptz
+    AND listened_at <> $5::timestamptz
+)
+`
+
+type ListenOfTrackExistsInRangeParams struct {
+	UserID      int32
+	TrackID     int32
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	ListenedAt  time.Time
+}
+
+func (q *Queries) ListenOfTrackExistsInRange(ctx context.Context, arg ListenOfTrackExistsInRangeParams) (bool, error) {
+	row := q.db.QueryRow(ctx, listenOfTrackExistsInRange,
+		arg.UserID,
+		arg.TrackID,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.ListenedAt,
+	)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const updateTrackIdForFilteredListens = `-- name: UpdateTrackIdForFilteredListens :execrows
+UPDATE listens l SET track_id = $1::int
+WHERE l.track_id = $2::int
+  AND l.user_id = $3
+  AND l.listened_at BETWEEN $4::timestamptz AND $5::timestamptz
+  AND ($6::text = '' OR l.client = $6::text)
+  AND NOT EXISTS (
+    SELECT 1 FROM listens d
+    WHERE d.user_id = l.user_id AND d.track_id = $1::int AND d.listened_at = l.listened_at
+  )
+`
+
+type UpdateTrackIdForFilteredListensParams struct {
+	NewTrackID  int32
+	TrackID     int32
+	UserID      int32
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Client      string
+}
+
+func (q *Queries) UpdateTrackIdForFilteredListens(ctx context.Context, arg UpdateTrackIdForFilteredListensParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateTrackIdForFilteredListens,
+		arg.NewTrackID,
+		arg.TrackID,
+		arg.UserID,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.Client,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateTrackIdForListens = `-- name: UpdateTrackIdForListens :exec
 UPDATE listens SET track_id = $2
 WHERE track_id = $1
@@ -107,11 +107,159 @@ type ArtistsWithName struct {
 	Name          string
 }
 
+type AuditLog struct {
+	ID         int64
+	Action     string
+	UserID     pgtype.Int4
+	Inputs     []byte
+	Snapshot   []byte
+	AfterState []byte
+	CreatedAt  time.Time
+	UndoneAt   pgtype.Timestamptz
+}
+
+type CsvImportProfile struct {
+	ID              int32
+	UserID          int32
+	Name            string
+	Delimiter       string
+	ArtistColumn    string
+	TitleColumn     string
+	AlbumColumn     string
+	TimestampColumn string
+	TimestampFormat string
+	Timezone        string
+	DurationColumn  string
+	DurationUnit    string
+	MbidColumn      string
+	FilterColumn    string
+	FilterValue     string
+	PlayedColumn    string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+type EnrichmentAttempt struct {
+	Task        string
+	EntityID    int32
+	AttemptedAt time.Time
+}
+
+type EnrichmentProgress struct {
+	Task       string
+	LastID     int32
+	Processed  int32
+	Enriched   int32
+	StartedAt  time.Time
+	FinishedAt pgtype.Timestamptz
+	UpdatedAt  time.Time
+}
+
+type ImportJob struct {
+	ID         int64
+	UserID     int32
+	Format     string
+	Filename   string
+	Options    []byte
+	Status     string
+	Size       int64
+	BytesRead  int64
+	ItemOffset int32
+	Imported   int32
+	Skipped    int32
+	Failed     int32
+	Errors     []string
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  pgtype.Timestamptz
+	FinishedAt pgtype.Timestamptz
+	UpdatedAt  time.Time
+}
+
 type Listen struct {
+	TrackID                 int32
+	ListenedAt              time.Time
+	Client                  *string
+	UserID                  int32
+	DurationMs              pgtype.Int4
+	MediaPlayer             pgtype.Text
+	SubmissionClientVersion pgtype.Text
+	Tags                    []string
+	OriginUrl               pgtype.Text
+	Overlap                 bool
+}
+
+type ListenMatch struct {
+	UserID           int32
+	TrackID          int32
+	ListenedAt       time.Time
+	ArtistMethod     string
+	ArtistConfidence float64
+	AlbumMethod      string
+	AlbumConfidence  float64
+	TrackMethod      string
+	TrackConfidence  float64
+	Confidence       float64
+	Reviewed         bool
+	CreatedAt        time.Time
+}
+
+type ListenPolicy struct {
+	UserID                 int32
+	MinPlayedFraction      float64
+	MinPlayedSeconds       int32
+	DuplicateWindowSeconds int32
+	FlagOverlaps           bool
+	UpdatedAt              time.Time
+}
+
+type ListenSubmission struct {
+	ID            int64
+	UserID        int32
+	Payload       []byte
+	PartitionKey  string
+	Status        string
+	Attempts      int32
+	LastError     pgtype.Text
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type MbzCache struct {
+	Kind          string
+	MusicBrainzID uuid.UUID
+	Body          []byte
+	FetchedAt     time.Time
+	ExpiresAt     time.Time
+}
+
+type MbzSearchAttempt struct {
 	TrackID    int32
-	ListenedAt time.Time
-	Client     *string
+	Score      float32
+	SearchedAt time.Time
+}
+
+type MergeSuggestion struct {
+	ID        int64
+	Kind      string
+	FromID    int32
+	ToID      int32
+	Score     float64
+	Reasons   []string
+	Dismissed bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type RejectedListen struct {
+	ID         int64
 	UserID     int32
+	TrackID    int32
+	ListenedAt time.Time
+	Reason     string
+	Payload    []byte
+	CreatedAt  time.Time
 }
 
 type Release struct {
@@ -150,6 +298,20 @@ type ReleasesWithTitle struct {
 	Title                string
 }
 
+type RewriteRule struct {
+	ID          int32
+	UserID      int32
+	Position    int32
+	Enabled     bool
+	Field       string
+	Pattern     string
+	Action      string
+	Target      pgtype.Text
+	Replacement string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
 type Session struct {
 	ID         uuid.UUID
 	UserID     int32
@@ -34,15 +34,17 @@ const countTopTracks = `-- name: CountTopTracks :one
 SELECT COUNT(DISTINCT l.track_id) AS total_count
 FROM listens l
 WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $3
 `
 
 type CountTopTracksParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
+	UserID       int32
 }
 
 func (q *Queries) CountTopTracks(ctx context.Context, arg CountTopTracksParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countTopTracks, arg.ListenedAt, arg.ListenedAt_2)
+	row := q.db.QueryRow(ctx, countTopTracks, arg.ListenedAt, arg.ListenedAt_2, arg.UserID)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -54,16 +56,23 @@ FROM listens l
 JOIN artist_tracks at ON l.track_id = at.track_id
 WHERE l.listened_at BETWEEN $1 AND $2
 AND at.artist_id = $3
+AND l.user_id = $4
 `
 
 type CountTopTracksByArtistParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
 	ArtistID     int32
+	UserID       int32
 }
 
 func (q *Queries) CountTopTracksByArtist(ctx context.Context, arg CountTopTracksByArtistParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countTopTracksByArtist, arg.ListenedAt, arg.ListenedAt_2, arg.ArtistID)
+	row := q.db.QueryRow(ctx, countTopTracksByArtist,
+		arg.ListenedAt,
+		arg.ListenedAt_2,
+		arg.ArtistID,
+		arg.UserID,
+	)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -75,16 +84,23 @@ FROM listens l
 JOIN tracks t ON l.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
 AND t.release_id = $3
+AND l.user_id = $4
 `
 
 type CountTopTracksByReleaseParams struct {
 	ListenedAt   time.Time
 	ListenedAt_2 time.Time
 	ReleaseID    int32
+	UserID       int32
 }
 
 func (q *Queries) CountTopTracksByRelease(ctx context.Context, arg CountTopTracksByReleaseParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countTopTracksByRelease, arg.ListenedAt, arg.ListenedAt_2, arg.ReleaseID)
+	row := q.db.QueryRow(ctx, countTopTracksByRelease,
+		arg.ListenedAt,
+		arg.ListenedAt_2,
+		arg.ReleaseID,
+		arg.UserID,
+	)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -173,6 +189,7 @@ JOIN releases r ON t.release_id = r.id
 JOIN artist_tracks at ON at.track_id = t.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND at.artist_id = $5
+  AND l.user_id = $6
 GROUP BY t.id, t.title, t.musicbrainz_id, t.release_id, r.image, t.popularity, t.spotify_id, t.danceability, t.energy, t.key, t.loudness, t.mode, t.speechiness, t.acousticness, t.instrumentalness, t.liveness, t.valence, t.tempo
 ORDER BY listen_count DESC, t.id
 LIMIT $3 OFFSET $4
@@ -184,6 +201,7 @@ type GetTopTracksByArtistPaginatedParams struct {
 	Limit        int32
 	Offset       int32
 	ArtistID     int32
+	UserID       int32
 }
 
 type GetTopTracksByArtistPaginatedRow struct {
@@ -216,6 +234,7 @@ func (q *Queries) GetTopTracksByArtistPaginated(ctx context.Context, arg GetTopT
 		arg.Limit,
 		arg.Offset,
 		arg.ArtistID,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -283,6 +302,7 @@ JOIN tracks_with_title t ON l.track_id = t.id
 JOIN releases r ON t.release_id = r.id
 WHERE l.listened_at BETWEEN $1 AND $2
   AND t.release_id = $5
+  AND l.user_id = $6
 GROUP BY t.id, t.title, t.musicbrainz_id, t.release_id, r.image, t.popularity, t.spotify_id, t.danceability, t.energy, t.key, t.loudness, t.mode, t.speechiness, t.acousticness, t.instrumentalness, t.liveness, t.valence, t.tempo
 ORDER BY listen_count DESC, t.id
 LIMIT $3 OFFSET $4
@@ -294,6 +314,7 @@ type GetTopTracksInReleasePaginatedParams struct {
 	Limit        int32
 	Offset       int32
 	ReleaseID    int32
+	UserID       int32
 }
 
 type GetTopTracksInReleasePaginatedRow struct {
@@ -326,6 +347,7 @@ func (q *Queries) GetTopTracksInReleasePaginated(ctx context.Context, arg GetTop
 		arg.Limit,
 		arg.Offset,
 		arg.ReleaseID,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
@@ -392,6 +414,7 @@ FROM listens l
 JOIN tracks_with_title t ON l.track_id = t.id
 JOIN releases r ON t.release_id = r.id
 WHERE l.listened_at BETWEEN $1 AND $2
+  AND l.user_id = $5
 GROUP BY t.id, t.title, t.musicbrainz_id, t.release_id, r.image, t.popularity, t.spotify_id, t.danceability, t.energy, t.key, t.loudness, t.mode, t.speechiness, t.acousticness, t.instrumentalness, t.liveness, t.valence, t.tempo
 ORDER BY listen_count DESC, t.id
 LIMIT $3 OFFSET $4
@@ -402,6 +425,7 @@ type GetTopTracksPaginatedParams struct {
 	ListenedAt_2 time.Time
 	Limit        int32
 	Offset       int32
+	UserID       int32
 }
 
 type GetTopTracksPaginatedRow struct {
@@ -433,6 +457,7 @@ func (q *Queries) GetTopTracksPaginated(ctx context.Context, arg GetTopTracksPag
 		arg.ListenedAt_2,
 		arg.Limit,
 		arg.Offset,
+		arg.UserID,
 	)
 	if err != nil {
 		return nil, err
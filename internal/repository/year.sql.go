@@ -124,12 +124,13 @@ SELECT
     c.gap_days
 FROM ranked c
 JOIN artists_with_name awn ON awn.id = c.artist_id
-WHERE r = 1
+WHERE r = 1 AND c.user_id = $3::int
 `
 
 type GetArtistWithLongestGapInYearParams struct {
 	Year           int32
 	FirstDayOfYear pgtype.Date
+	UserID         int32
 }
 
 type GetArtistWithLongestGapInYearRow struct {
@@ -142,7 +143,7 @@ type GetArtistWithLongestGapInYearRow struct {
 }
 
 func (q *Queries) GetArtistWithLongestGapInYear(ctx context.Context, arg GetArtistWithLongestGapInYearParams) (GetArtistWithLongestGapInYearRow, error) {
-	row := q.db.QueryRow(ctx, getArtistWithLongestGapInYear, arg.Year, arg.FirstDayOfYear)
+	row := q.db.QueryRow(ctx, getArtistWithLongestGapInYear, arg.Year, arg.FirstDayOfYear, arg.UserID)
 	var i GetArtistWithLongestGapInYearRow
 	err := row.Scan(
 		&i.UserID,
@@ -163,14 +164,15 @@ WITH first_artist_plays_in_year AS (
         MIN(l.listened_at) AS first_listen
     FROM listens l
     JOIN artist_tracks at ON at.track_id = l.track_id
-    WHERE EXTRACT(YEAR FROM l.listened_at) = 2024
+    WHERE l.user_id = $1::int
+      AND EXTRACT(YEAR FROM l.listened_at) = $2::int
       AND NOT EXISTS (
           SELECT 1
           FROM listens l2
           JOIN artist_tracks at2 ON at2.track_id = l2.track_id
           WHERE l2.user_id = l.user_id
             AND at2.artist_id = at.artist_id
-            AND l2.listened_at < DATE '2024-01-01'
+            AND l2.listened_at < $3::date
       )
     GROUP BY l.user_id, at.artist_id
 ) 
@@ -183,10 +185,16 @@ FROM first_artist_plays_in_year f
 JOIN listens l ON l.user_id = f.user_id
 JOIN artist_tracks at ON at.track_id = l.track_id JOIN artists_with_name a ON at.artist_id = a.id
 WHERE at.artist_id = f.artist_id
-  AND EXTRACT(YEAR FROM l.listened_at) = 2024
+  AND EXTRACT(YEAR FROM l.listened_at) = $2::int
 GROUP BY f.user_id, f.artist_id, f.first_listen, a.name HAVING COUNT(*) = 1
 `
 
+type GetArtistsWithOnlyOnePlayInYearParams struct {
+	UserID         int32
+	Year           int32
+	FirstDayOfYear pgtype.Date
+}
+
 type GetArtistsWithOnlyOnePlayInYearRow struct {
 	UserID           int32
 	ArtistID         int32
@@ -195,8 +203,8 @@ type GetArtistsWithOnlyOnePlayInYearRow struct {
 	TotalPlaysInYear int64
 }
 
-func (q *Queries) GetArtistsWithOnlyOnePlayInYear(ctx context.Context) ([]GetArtistsWithOnlyOnePlayInYearRow, error) {
-	rows, err := q.db.Query(ctx, getArtistsWithOnlyOnePlayInYear)
+func (q *Queries) GetArtistsWithOnlyOnePlayInYear(ctx context.Context, arg GetArtistsWithOnlyOnePlayInYearParams) ([]GetArtistsWithOnlyOnePlayInYearRow, error) {
+	rows, err := q.db.Query(ctx, getArtistsWithOnlyOnePlayInYear, arg.UserID, arg.Year, arg.FirstDayOfYear)
 	if err != nil {
 		return nil, err
 	}
@@ -223,39 +231,57 @@ func (q *Queries) GetArtistsWithOnlyOnePlayInYear(ctx context.Context) ([]GetArt
 
 const getFirstListenInYear = `-- name: GetFirstListenInYear :one
 SELECT 
-    l.track_id, l.listened_at, l.client, l.user_id, 
+    l.track_id, l.listened_at, l.client, l.user_id, l.duration_ms, l.media_player, l.submission_client_version, l.tags, l.origin_url, l.overlap, 
     t.id, t.musicbrainz_id, t.duration, t.release_id, t.popularity, t.spotify_id, t.title, 
     get_artists_for_track(t.id) as artists 
 FROM listens l 
 LEFT JOIN tracks_with_title t ON l.track_id = t.id 
-WHERE EXTRACT(YEAR FROM l.listened_at) = 2025 
+WHERE l.user_id = $1::int
+    AND EXTRACT(YEAR FROM l.listened_at) = $2::int
 ORDER BY l.listened_at ASC 
 LIMIT 1
 `
 
-type GetFirstListenInYearRow struct {
-	TrackID       int32
-	ListenedAt    time.Time
-	Client        *string
-	UserID        int32
-	ID            pgtype.Int4
-	MusicBrainzID *uuid.UUID
-	Duration      pgtype.Int4
-	ReleaseID     pgtype.Int4
-	Popularity    pgtype.Int4
-	SpotifyID     pgtype.Text
-	Title         pgtype.Text
-	Artists       []byte
+type GetFirstListenInYearParams struct {
+	UserID int32
+	Year   int32
 }
 
-func (q *Queries) GetFirstListenInYear(ctx context.Context) (GetFirstListenInYearRow, error) {
-	row := q.db.QueryRow(ctx, getFirstListenInYear)
+type GetFirstListenInYearRow struct {
+	TrackID                 int32
+	ListenedAt              time.Time
+	Client                  *string
+	UserID                  int32
+	DurationMs              pgtype.Int4
+	MediaPlayer             pgtype.Text
+	SubmissionClientVersion pgtype.Text
+	Tags                    []string
+	OriginUrl               pgtype.Text
+	Overlap                 bool
+	ID                      pgtype.Int4
+	MusicBrainzID           *uuid.UUID
+	Duration                pgtype.Int4
+	ReleaseID               pgtype.Int4
+	Popularity              pgtype.Int4
+	SpotifyID               pgtype.Text
+	Title                   pgtype.Text
+	Artists                 []byte
+}
+
+func (q *Queries) GetFirstListenInYear(ctx context.Context, arg GetFirstListenInYearParams) (GetFirstListenInYearRow, error) {
+	row := q.db.QueryRow(ctx, getFirstListenInYear, arg.UserID, arg.Year)
 	var i GetFirstListenInYearRow
 	err := row.Scan(
 		&i.TrackID,
 		&i.ListenedAt,
 		&i.Client,
 		&i.UserID,
+		&i.DurationMs,
+		&i.MediaPlayer,
+		&i.SubmissionClientVersion,
+		&i.Tags,
+		&i.OriginUrl,
+		&i.Overlap,
 		&i.ID,
 		&i.MusicBrainzID,
 		&i.Duration,
@@ -499,7 +525,8 @@ WITH monthly_plays AS (
         l.track_id,
         EXTRACT(MONTH FROM l.listened_at) AS month
     FROM listens l
-    WHERE EXTRACT(YEAR FROM l.listened_at) = $1::int
+    WHERE l.user_id = $1::int
+      AND EXTRACT(YEAR FROM l.listened_at) = $2::int
     GROUP BY l.track_id, EXTRACT(MONTH FROM l.listened_at)
 ),
 monthly_counts AS (
@@ -517,13 +544,18 @@ JOIN tracks_with_title t ON t.id = mc.track_id
 WHERE mc.months_played = 12
 `
 
+type GetTracksPlayedAtLeastOncePerMonthInYearParams struct {
+	UserID int32
+	Year   int32
+}
+
 type GetTracksPlayedAtLeastOncePerMonthInYearRow struct {
 	TrackID int32
 	Title   string
 }
 
-func (q *Queries) GetTracksPlayedAtLeastOncePerMonthInYear(ctx context.Context, userID int32) ([]GetTracksPlayedAtLeastOncePerMonthInYearRow, error) {
-	rows, err := q.db.Query(ctx, getTracksPlayedAtLeastOncePerMonthInYear, userID)
+func (q *Queries) GetTracksPlayedAtLeastOncePerMonthInYear(ctx context.Context, arg GetTracksPlayedAtLeastOncePerMonthInYearParams) ([]GetTracksPlayedAtLeastOncePerMonthInYearRow, error) {
+	rows, err := q.db.Query(ctx, getTracksPlayedAtLeastOncePerMonthInYear, arg.UserID, arg.Year)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,150 @@
+// Package rewrite applies user defined rules that clean up submitted listens,
+// such as stripping "(Official Video)" from titles or " - Topic" from artist
+// names, before they are associated with artists, albums and tracks.
+package rewrite
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+)
+
+// Listen holds the fields of a submitted listen that rules can match on and
+// change.
+type Listen struct {
+	Artist      string   `json:"artist"`
+	ArtistNames []string `json:"artist_names,omitempty"`
+	Title       string   `json:"title"`
+	Album       string   `json:"album"`
+	Client      string   `json:"client"`
+}
+
+type Result struct {
+	Listen Listen `json:"listen"`
+	// true when a drop rule matched; the listen should not be saved
+	Dropped bool `json:"dropped"`
+	// ids of the rules that matched, in the order they were applied
+	Applied []int32 `json:"applied"`
+}
+
+// Validate reports whether the rule can be applied.
+func Validate(rule models.RewriteRule) error {
+	if !validField(rule.Field) {
+		return fmt.Errorf("invalid field '%s'", rule.Field)
+	}
+	if rule.Pattern == "" {
+		return errors.New("pattern is required")
+	}
+	if _, err := regexp.Compile(rule.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	if rule.Target != "" && !validField(rule.Target) {
+		return fmt.Errorf("invalid target '%s'", rule.Target)
+	}
+	switch rule.Action {
+	case models.RewriteActionReplace, models.RewriteActionDrop, models.RewriteActionRemap:
+	case models.RewriteActionSplit:
+		if rule.Field != models.RewriteFieldArtist {
+			return errors.New("only the artist field can be split")
+		}
+	default:
+		return fmt.Errorf("invalid action '%s'", rule.Action)
+	}
+	return nil
+}
+
+func validField(f models.RewriteField) bool {
+	switch f {
+	case models.RewriteFieldArtist, models.RewriteFieldTitle, models.RewriteFieldAlbum, models.RewriteFieldClient:
+		return true
+	}
+	return false
+}
+
+// Apply runs the rules over the listen in order. Each rule sees the listen as
+// changed by the rules before it, and applying stops at the first drop rule
+// that matches. Disabled and invalid rules are skipped.
+func Apply(rules []*models.RewriteRule, listen Listen) Result {
+	result := Result{Listen: listen, Applied: []int32{}}
+	l := &result.Listen
+	l.ArtistNames = append([]string(nil), listen.ArtistNames...)
+
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		value := l.get(rule.Field)
+		match := re.FindStringSubmatchIndex(value)
+		if match == nil {
+			continue
+		}
+		result.Applied = append(result.Applied, rule.ID)
+
+		switch rule.Action {
+		case models.RewriteActionReplace:
+			if rule.Field != models.RewriteFieldArtist {
+				l.set(rule.Field, strings.TrimSpace(re.ReplaceAllString(value, rule.Replacement)))
+				continue
+			}
+			// the same cleanup applies to each artist name sent by the client
+			l.Artist = strings.TrimSpace(re.ReplaceAllString(value, rule.Replacement))
+			for i, name := range l.ArtistNames {
+				l.ArtistNames[i] = strings.TrimSpace(re.ReplaceAllString(name, rule.Replacement))
+			}
+		case models.RewriteActionSplit:
+			var names []string
+			for _, name := range re.Split(value, -1) {
+				if name = strings.TrimSpace(name); name != "" {
+					names = append(names, name)
+				}
+			}
+			l.ArtistNames = names
+		case models.RewriteActionDrop:
+			result.Dropped = true
+			return result
+		case models.RewriteActionRemap:
+			target := rule.Target
+			if target == "" {
+				target = rule.Field
+			}
+			l.set(target, string(re.ExpandString(nil, rule.Replacement, value, match)))
+		}
+	}
+	return result
+}
+
+func (l *Listen) get(f models.RewriteField) string {
+	switch f {
+	case models.RewriteFieldArtist:
+		return l.Artist
+	case models.RewriteFieldTitle:
+		return l.Title
+	case models.RewriteFieldAlbum:
+		return l.Album
+	case models.RewriteFieldClient:
+		return l.Client
+	}
+	return ""
+}
+
+func (l *Listen) set(f models.RewriteField, value string) {
+	switch f {
+	case models.RewriteFieldArtist:
+		l.Artist = value
+		// names sent by the client no longer describe the new artist
+		l.ArtistNames = nil
+	case models.RewriteFieldTitle:
+		l.Title = value
+	case models.RewriteFieldAlbum:
+		l.Album = value
+	case models.RewriteFieldClient:
+		l.Client = value
+	}
+}
@@ -0,0 +1,113 @@
+package rewrite_test
+
+import (
+	"testing"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/models"
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/rewrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rule(id int32, field models.RewriteField, pattern string, action models.RewriteAction, replacement string) *models.RewriteRule {
+	return &models.RewriteRule{
+		ID:          id,
+		Enabled:     true,
+		Field:       field,
+		Pattern:     pattern,
+		Action:      action,
+		Replacement: replacement,
+	}
+}
+
+func TestApplyReplace(t *testing.T) {
+	rules := []*models.RewriteRule{
+		rule(1, models.RewriteFieldArtist, ` - Topic$`, models.RewriteActionReplace, ""),
+		rule(2, models.RewriteFieldTitle, `(?i)\s*\((official (music )?video|lyrics)\)`, models.RewriteActionReplace, ""),
+		rule(3, models.RewriteFieldAlbum, `(?i)\s*-\s*remastered \d{4}$`, models.RewriteActionReplace, ""),
+	}
+	result := rewrite.Apply(rules, rewrite.Listen{
+		Artist:      "ヨルシカ - Topic",
+		ArtistNames: []string{"ヨルシカ - Topic"},
+		Title:       "春泥棒 (Official Video)",
+		Album:       "盗作 - Remastered 2011",
+	})
+	assert.False(t, result.Dropped)
+	assert.Equal(t, []int32{1, 2, 3}, result.Applied)
+	assert.Equal(t, "ヨルシカ", result.Listen.Artist)
+	assert.Equal(t, []string{"ヨルシカ"}, result.Listen.ArtistNames)
+	assert.Equal(t, "春泥棒", result.Listen.Title)
+	assert.Equal(t, "盗作", result.Listen.Album)
+}
+
+func TestApplySplit(t *testing.T) {
+	rules := []*models.RewriteRule{
+		rule(1, models.RewriteFieldArtist, ` x `, models.RewriteActionSplit, ""),
+	}
+	result := rewrite.Apply(rules, rewrite.Listen{Artist: "Rat Tally x Madeline Kenney", Title: "In My Car"})
+	assert.Equal(t, "Rat Tally x Madeline Kenney", result.Listen.Artist)
+	assert.Equal(t, []string{"Rat Tally", "Madeline Kenney"}, result.Listen.ArtistNames)
+
+	// no match, no change
+	result = rewrite.Apply(rules, rewrite.Listen{Artist: "Rat Tally", Title: "In My Car"})
+	assert.Empty(t, result.Applied)
+	assert.Nil(t, result.Listen.ArtistNames)
+}
+
+func TestApplyDrop(t *testing.T) {
+	rules := []*models.RewriteRule{
+		rule(1, models.RewriteFieldClient, `^podcast-app$`, models.RewriteActionDrop, ""),
+		rule(2, models.RewriteFieldTitle, `.*`, models.RewriteActionRemap, "never applied"),
+	}
+	result := rewrite.Apply(rules, rewrite.Listen{Artist: "Host", Title: "Episode 1", Client: "podcast-app"})
+	assert.True(t, result.Dropped)
+	assert.Equal(t, []int32{1}, result.Applied)
+	assert.Equal(t, "Episode 1", result.Listen.Title)
+}
+
+func TestApplyRemap(t *testing.T) {
+	target := rule(1, models.RewriteFieldTitle, `^(.+) \(feat\. (.+)\)$`, models.RewriteActionRemap, "$1")
+	artist := rule(2, models.RewriteFieldArtist, `^The Beatles$`, models.RewriteActionRemap, "Beatles, The")
+	album := rule(3, models.RewriteFieldClient, `^tape-deck$`, models.RewriteActionRemap, "Mixtape")
+	album.Target = models.RewriteFieldAlbum
+
+	result := rewrite.Apply([]*models.RewriteRule{target, artist, album}, rewrite.Listen{
+		Artist:      "The Beatles",
+		ArtistNames: []string{"The Beatles"},
+		Title:       "Song (feat. Someone)",
+		Client:      "tape-deck",
+	})
+	assert.Equal(t, "Song", result.Listen.Title)
+	assert.Equal(t, "Beatles, The", result.Listen.Artist)
+	assert.Nil(t, result.Listen.ArtistNames)
+	assert.Equal(t, "Mixtape", result.Listen.Album)
+	assert.Equal(t, "tape-deck", result.Listen.Client)
+}
+
+func TestApplySkipsDisabledRules(t *testing.T) {
+	r := rule(1, models.RewriteFieldTitle, `.*`, models.RewriteActionDrop, "")
+	r.Enabled = false
+	in := rewrite.Listen{Artist: "Artist", ArtistNames: []string{"Artist"}, Title: "Title"}
+	result := rewrite.Apply([]*models.RewriteRule{r}, in)
+	assert.False(t, result.Dropped)
+	assert.Equal(t, in, result.Listen)
+
+	// the input listen is never modified
+	rules := []*models.RewriteRule{rule(2, models.RewriteFieldArtist, `Artist`, models.RewriteActionReplace, "Other")}
+	result = rewrite.Apply(rules, in)
+	assert.Equal(t, []string{"Other"}, result.Listen.ArtistNames)
+	assert.Equal(t, []string{"Artist"}, in.ArtistNames)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, rewrite.Validate(*rule(0, models.RewriteFieldArtist, ` & `, models.RewriteActionSplit, "")))
+	assert.Error(t, rewrite.Validate(*rule(0, models.RewriteFieldTitle, ` & `, models.RewriteActionSplit, "")))
+	assert.Error(t, rewrite.Validate(*rule(0, models.RewriteFieldTitle, `(`, models.RewriteActionReplace, "")))
+	assert.Error(t, rewrite.Validate(*rule(0, models.RewriteFieldTitle, ``, models.RewriteActionReplace, "")))
+	assert.Error(t, rewrite.Validate(*rule(0, "genre", `x`, models.RewriteActionReplace, "")))
+	assert.Error(t, rewrite.Validate(*rule(0, models.RewriteFieldTitle, `x`, "rename", "")))
+
+	r := rule(0, models.RewriteFieldClient, `x`, models.RewriteActionRemap, "y")
+	r.Target = "genre"
+	assert.Error(t, rewrite.Validate(*r))
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/SaturnX-Dev/Beat-Scrobble/internal/mbz"
@@ -335,3 +336,32 @@ func FlattenAliases(aliases []models.Alias) []string {
 	}
 	return ret
 }
+
+// A Waker wakes up every goroutine waiting on it at once, for background
+// workers that otherwise wait for their next interval. The zero value is ready
+// to use.
+type Waker struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// Wake closes the channel returned by C.
+func (w *Waker) Wake() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ch != nil {
+		close(w.ch)
+		w.ch = nil
+	}
+}
+
+// C returns a channel that is closed by the next call to Wake. Get it before
+// looking for work, so that a wake up in between is not missed.
+func (w *Waker) C() <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ch == nil {
+		w.ch = make(chan struct{})
+	}
+	return w.ch
+}
@@ -47,3 +47,30 @@ func TestRemoveInBoth(t *testing.T) {
 		assert.EqualValues(t, expected[i+2], r)
 	}
 }
+
+func TestWaker(t *testing.T) {
+	var w utils.Waker
+	// waking with nobody waiting does nothing
+	w.Wake()
+
+	c1 := w.C()
+	c2 := w.C()
+	select {
+	case <-c1:
+		t.Fatal("expected channel to be open before Wake")
+	default:
+	}
+	w.Wake()
+	for _, c := range []<-chan struct{}{c1, c2} {
+		select {
+		case <-c:
+		default:
+			t.Fatal("expected every channel to be closed by Wake")
+		}
+	}
+	select {
+	case <-w.C():
+		t.Fatal("expected a new channel after Wake")
+	default:
+	}
+}
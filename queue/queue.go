@@ -1,56 +1,200 @@
+// package queue sends HTTP requests to rate limited APIs. Requests wait in one
+// of two lanes, interactive requests always going before background ones, and
+// are sent no faster than the rate limit allows. Responses telling the client
+// to slow down are retried with backoff, honoring Retry-After.
 package queue
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/SaturnX-Dev/Beat-Scrobble/internal/logger"
 	"golang.org/x/time/rate"
 )
 
-// RequestResult holds the result of a queued request.
-type RequestResult struct {
-	Body []byte
-	Err  error
+// Priority is the lane a request waits in.
+type Priority int
+
+const (
+	// PriorityInteractive is for requests someone is waiting on, like
+	// scrobbles and edits. It is the default.
+	PriorityInteractive Priority = iota
+	// PriorityBackground is for bulk work like imports and enrichment, which
+	// only gets requests in when no interactive ones are waiting.
+	PriorityBackground
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose requests are queued with the priority.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
 }
 
-// RequestFunc is a function that performs an HTTP request using the provided client,
-// and sends its result to the given result channel.
-type RequestFunc func(client *http.Client, done chan<- RequestResult)
+// PriorityFromContext returns the priority requests with the context are
+// queued with.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityInteractive
+}
+
+var (
+	// ErrQueueFull is returned when the lane of a request is full, instead of
+	// waiting for room
+	ErrQueueFull = errors.New("request queue is full")
+	// ErrShutdown is returned for requests queued on or still waiting in a
+	// queue that was shut down
+	ErrShutdown = errors.New("request queue was shut down")
+)
+
+// Response is the response to a queued request, its body already read.
+type Response struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+type Options struct {
+	// Name identifies the queue in logs
+	Name string
+	// requests per second, and burst capacity
+	RPS   int
+	Burst int
+	// how many requests each lane holds before rejecting new ones
+	Capacity int
+	// how many times a request is retried after a 429, 502, 503 or 504
+	// response or a network error
+	MaxRetries int
+	// the first retry waits this long, doubling with every retry, unless the
+	// response says how long to wait in Retry-After
+	RetryBackoff time.Duration
+	// responses asking to wait longer than this are not retried
+	MaxRetryWait time.Duration
+	Timeout      time.Duration
+}
+
+const (
+	defaultCapacity     = 100
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = time.Second
+	defaultMaxRetryWait = time.Minute
+	defaultTimeout      = 10 * time.Second
+)
+
+type job struct {
+	ctx      context.Context
+	req      *http.Request
+	queuedAt time.Time
+	done     chan result
+}
+
+type result struct {
+	resp *Response
+	err  error
+}
 
 type RequestQueue struct {
+	opts    Options
 	client  *http.Client
 	limiter *rate.Limiter
-	queue   chan func(*http.Client) // now this is a wrapped closure
+	lanes   [2]chan *job
 	wg      sync.WaitGroup
 	ctx     context.Context
 	cancel  context.CancelFunc
+
+	// no request is sent before this time, set when a response asks to wait
+	pauseMu    sync.Mutex
+	pauseUntil time.Time
+
+	stats counters
 }
 
 // NewRequestQueue creates a new rate-limited request queue.
 // `rps` = requests per second, `burst` = burst capacity
 func NewRequestQueue(rps int, burst int) *RequestQueue {
+	return New(Options{RPS: rps, Burst: burst})
+}
+
+// New creates a request queue with the options, using defaults for those
+// left zero.
+func New(opts Options) *RequestQueue {
+	if opts.Capacity <= 0 {
+		opts.Capacity = defaultCapacity
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	} else if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	if opts.MaxRetryWait <= 0 {
+		opts.MaxRetryWait = defaultMaxRetryWait
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	q := &RequestQueue{
-		client:  &http.Client{Timeout: 10 * time.Second},
-		limiter: rate.NewLimiter(rate.Every(time.Second/time.Duration(rps)), burst),
-		queue:   make(chan func(*http.Client), 100), // accepts wrapped closures
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.Timeout},
+		limiter: rate.NewLimiter(rate.Every(time.Second/time.Duration(opts.RPS)), opts.Burst),
 		ctx:     ctx,
 		cancel:  cancel,
 	}
+	for i := range q.lanes {
+		q.lanes[i] = make(chan *job, opts.Capacity)
+	}
 	q.start()
 	return q
 }
 
-// Enqueue adds a new request to the queue and returns a result channel.
-func (q *RequestQueue) Enqueue(job RequestFunc) <-chan RequestResult {
-	resultChan := make(chan RequestResult, 1)
-	q.queue <- func(client *http.Client) {
-		job(client, resultChan)
+// Do queues the request with the priority of ctx and waits for its response.
+// Returns ErrQueueFull right away when its lane is full, and the error of ctx
+// when it is done before the response arrives. Responses with any status are
+// returned once retries are used up; only failing to get one is an error.
+// Requests with a body are only retried when it can be read again with
+// GetBody, as it is for bodies given to http.NewRequest as a bytes.Buffer,
+// bytes.Reader or strings.Reader.
+func (q *RequestQueue) Do(ctx context.Context, req *http.Request) (*Response, error) {
+	if q.ctx.Err() != nil {
+		return nil, ErrShutdown
+	}
+	p := PriorityFromContext(ctx)
+	if p != PriorityBackground {
+		p = PriorityInteractive
+	}
+	j := &job{
+		ctx:      ctx,
+		req:      req.WithContext(ctx),
+		queuedAt: time.Now(),
+		done:     make(chan result, 1),
+	}
+	select {
+	case q.lanes[p] <- j:
+	default:
+		q.stats.rejected.Add(1)
+		return nil, fmt.Errorf("%s: %w", q.opts.Name, ErrQueueFull)
+	}
+
+	select {
+	case r := <-j.done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-q.ctx.Done():
+		return nil, ErrShutdown
 	}
-	return resultChan
 }
 
 // start begins the worker loop.
@@ -59,23 +203,220 @@ func (q *RequestQueue) start() {
 	go func() {
 		defer q.wg.Done()
 		for {
-			select {
-			case <-q.ctx.Done():
+			j := q.next()
+			if j == nil {
+				q.drain()
 				return
-			case job := <-q.queue:
-				if err := q.limiter.Wait(q.ctx); err != nil {
-					log.Println("[queue] limiter wait failed:", err)
-					continue
+			}
+			if j.ctx.Err() != nil {
+				q.stats.canceled.Add(1)
+				continue
+			}
+			if err := q.waitTurn(j.ctx); err != nil {
+				if q.ctx.Err() != nil {
+					j.done <- result{err: ErrShutdown}
+					q.drain()
+					return
 				}
-				go job(q.client)
+				q.stats.canceled.Add(1)
+				continue
 			}
+			q.stats.waited(time.Since(j.queuedAt))
+			q.stats.inFlight.Add(1)
+			go q.run(j)
 		}
 	}()
 }
 
-// Shutdown stops the queue and waits for the worker to finish.
+// next returns the next job, interactive ones first, or nil once the queue
+// is shut down.
+func (q *RequestQueue) next() *job {
+	select {
+	case j := <-q.lanes[PriorityInteractive]:
+		return j
+	default:
+	}
+	select {
+	case <-q.ctx.Done():
+		return nil
+	case j := <-q.lanes[PriorityInteractive]:
+		return j
+	case j := <-q.lanes[PriorityBackground]:
+		return j
+	}
+}
+
+// drain fails the jobs still waiting after shutdown.
+func (q *RequestQueue) drain() {
+	for _, lane := range q.lanes {
+		for len(lane) > 0 {
+			j := <-lane
+			j.done <- result{err: ErrShutdown}
+		}
+	}
+}
+
+// waitTurn waits until the queue is no longer paused and the rate limit
+// allows another request.
+func (q *RequestQueue) waitTurn(ctx context.Context) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	unregister := context.AfterFunc(q.ctx, stop)
+	defer unregister()
+
+	q.pauseMu.Lock()
+	pause := time.Until(q.pauseUntil)
+	q.pauseMu.Unlock()
+	if pause > 0 {
+		t := time.NewTimer(pause)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return q.limiter.Wait(ctx)
+}
+
+// pause keeps requests from being sent for d.
+func (q *RequestQueue) pause(d time.Duration) {
+	q.pauseMu.Lock()
+	if until := time.Now().Add(d); until.After(q.pauseUntil) {
+		q.pauseUntil = until
+	}
+	q.pauseMu.Unlock()
+}
+
+func (q *RequestQueue) run(j *job) {
+	defer q.stats.inFlight.Add(-1)
+	l := logger.FromContext(j.ctx)
+	for attempt := 0; ; attempt++ {
+		resp, err := q.send(j.req)
+		wait, retry := q.retryAfter(resp, err, attempt)
+		var next *http.Request
+		if retry {
+			next, retry = rewind(j.req)
+		}
+		if !retry || j.ctx.Err() != nil {
+			q.stats.finished(time.Since(j.queuedAt), err)
+			j.done <- result{resp: resp, err: err}
+			return
+		}
+		j.req = next
+		q.stats.retried.Add(1)
+		if err != nil {
+			l.Debug().Err(err).Msgf("%s: Request failed; retrying in %v", q.opts.Name, wait)
+		} else {
+			l.Debug().Msgf("%s: Received %s; retrying in %v", q.opts.Name, resp.Status, wait)
+			// the whole API is asking to slow down, not just this request
+			q.pause(wait)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-j.ctx.Done():
+		case <-q.ctx.Done():
+		case <-t.C:
+		}
+		t.Stop()
+		if err := q.waitTurn(j.ctx); err != nil {
+			if q.ctx.Err() != nil {
+				err = ErrShutdown
+			}
+			q.stats.finished(time.Since(j.queuedAt), err)
+			j.done <- result{err: err}
+			return
+		}
+	}
+}
+
+func (q *RequestQueue) send(req *http.Request) (*Response, error) {
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		Body:       body,
+	}, nil
+}
+
+// rewind returns a copy of req to send again, with its body read anew. Requests
+// with a body that cannot be read again, without GetBody, are not retried.
+func rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, true
+}
+
+// retryAfter returns how long to wait before retrying, and whether to retry
+// at all, after the attempt.
+func (q *RequestQueue) retryAfter(resp *Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= q.opts.MaxRetries {
+		return 0, false
+	}
+	backoff := q.opts.RetryBackoff << attempt
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		return min(backoff, q.opts.MaxRetryWait), true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	default:
+		return 0, false
+	}
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if d > q.opts.MaxRetryWait {
+			return 0, false
+		}
+		return d, true
+	}
+	return min(backoff, q.opts.MaxRetryWait), true
+}
+
+// parseRetryAfter parses a Retry-After header, either a number of seconds or
+// an HTTP date.
+func parseRetryAfter(s string, now time.Time) (time.Duration, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(s); err == nil {
+		return max(0, time.Duration(secs)*time.Second), true
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		return max(0, t.Sub(now)), true
+	}
+	return 0, false
+}
+
+// Stats returns how many requests are waiting and in flight, what happened
+// to those done, and how long they took.
+func (q *RequestQueue) Stats() Stats {
+	return q.stats.snapshot(len(q.lanes[PriorityInteractive]), len(q.lanes[PriorityBackground]))
+}
+
+// Shutdown stops the queue and waits for the worker to finish. Requests
+// still waiting fail with ErrShutdown.
 func (q *RequestQueue) Shutdown() {
 	q.cancel()
 	q.wg.Wait()
-	close(q.queue)
 }
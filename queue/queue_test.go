@@ -0,0 +1,242 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SaturnX-Dev/Beat-Scrobble/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, url string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func TestDo_Retry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	q := queue.New(queue.Options{RPS: 100, Burst: 100, RetryBackoff: time.Millisecond})
+	defer q.Shutdown()
+
+	resp, err := q.Do(context.Background(), get(t, srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", string(resp.Body))
+	assert.EqualValues(t, 2, calls.Load())
+
+	stats := q.Stats()
+	assert.EqualValues(t, 1, stats.Retried)
+	assert.EqualValues(t, 1, stats.Completed)
+	assert.EqualValues(t, 0, stats.InFlight)
+}
+
+func TestDo_RetryWithBody(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	q := queue.New(queue.Options{RPS: 100, Burst: 100, RetryBackoff: time.Millisecond})
+	defer q.Shutdown()
+
+	// the body is sent again on the retry
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp, err := q.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+
+	// a body that cannot be read again is not retried
+	calls.Store(0)
+	bodies = nil
+	req, err = http.NewRequest(http.MethodPost, srv.URL, io.NopCloser(strings.NewReader("payload")))
+	require.NoError(t, err)
+	resp, err = q.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, []string{"payload"}, bodies)
+}
+
+func TestDo_NoRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/wait":
+			// longer than MaxRetryWait
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	q := queue.New(queue.Options{RPS: 100, Burst: 100, RetryBackoff: time.Millisecond, MaxRetryWait: time.Second})
+	defer q.Shutdown()
+
+	resp, err := q.Do(context.Background(), get(t, srv.URL+"/wait"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	resp, err = q.Do(context.Background(), get(t, srv.URL+"/missing"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	assert.EqualValues(t, 2, calls.Load())
+	assert.EqualValues(t, 0, q.Stats().Retried)
+}
+
+func TestDo_RetriesExhausted(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	q := queue.New(queue.Options{RPS: 100, Burst: 100, MaxRetries: 2, RetryBackoff: time.Millisecond})
+	defer q.Shutdown()
+
+	resp, err := q.Do(context.Background(), get(t, srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.EqualValues(t, 3, calls.Load())
+	assert.EqualValues(t, 2, q.Stats().Retried)
+}
+
+func TestDo_Priority(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	// one request every 200ms, so that the later ones wait in the lanes
+	q := queue.New(queue.Options{RPS: 5, Burst: 1})
+	defer q.Shutdown()
+
+	background := queue.WithPriority(context.Background(), queue.PriorityBackground)
+	_, err := q.Do(context.Background(), get(t, srv.URL+"/first"))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	do := func(ctx context.Context, path string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := q.Do(ctx, get(t, srv.URL+path))
+			assert.NoError(t, err)
+		}()
+		time.Sleep(20 * time.Millisecond)
+	}
+	// taken by the worker right away, waiting for the rate limit
+	do(background, "/background1")
+	do(background, "/background2")
+	do(background, "/background3")
+	do(context.Background(), "/interactive")
+	wg.Wait()
+
+	assert.Equal(t, []string{"/first", "/background1", "/interactive", "/background2", "/background3"}, order)
+}
+
+func TestDo_Full(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	q := queue.New(queue.Options{RPS: 1, Burst: 1, Capacity: 1})
+	_, err := q.Do(context.Background(), get(t, srv.URL))
+	require.NoError(t, err)
+
+	// the worker holds at most one while waiting for the rate limit, and the
+	// lane holds one more
+	errs := make(chan error, 3)
+	for range 3 {
+		go func() {
+			_, err := q.Do(context.Background(), get(t, srv.URL))
+			errs <- err
+		}()
+	}
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, queue.ErrQueueFull)
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expected a request to be rejected")
+	}
+	assert.EqualValues(t, 1, q.Stats().Rejected)
+
+	q.Shutdown()
+	for range 2 {
+		if err := <-errs; !errors.Is(err, queue.ErrShutdown) {
+			// one may have been sent before the shutdown
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestDo_Cancel(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	q := queue.New(queue.Options{RPS: 1, Burst: 1})
+	defer q.Shutdown()
+	_, err := q.Do(context.Background(), get(t, srv.URL))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = q.Do(ctx, get(t, srv.URL))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// the canceled request is never sent
+	time.Sleep(100 * time.Millisecond)
+	assert.EqualValues(t, 1, calls.Load())
+	assert.EqualValues(t, 1, q.Stats().Canceled)
+}
+
+func TestDo_Shutdown(t *testing.T) {
+	q := queue.NewRequestQueue(1, 1)
+	q.Shutdown()
+	_, err := q.Do(context.Background(), get(t, "http://localhost"))
+	assert.ErrorIs(t, err, queue.ErrShutdown)
+}
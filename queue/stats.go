@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes the requests of a queue since it was created. Durations
+// are in milliseconds.
+type Stats struct {
+	// requests waiting in each lane
+	Interactive int   `json:"interactive"`
+	Background  int   `json:"background"`
+	InFlight    int   `json:"in_flight"`
+	Completed   int64 `json:"completed"`
+	Failed      int64 `json:"failed"`
+	Rejected    int64 `json:"rejected"`
+	Canceled    int64 `json:"canceled"`
+	Retried     int64 `json:"retried"`
+	// average time from being queued to being sent, and to being done
+	AvgWaitMs    float64 `json:"avg_wait_ms"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
+type counters struct {
+	inFlight atomic.Int64
+	rejected atomic.Int64
+	canceled atomic.Int64
+	retried  atomic.Int64
+
+	mu         sync.Mutex
+	sent       int64
+	totalWait  time.Duration
+	completed  int64
+	failed     int64
+	total      time.Duration
+	maxLatency time.Duration
+}
+
+func (c *counters) waited(d time.Duration) {
+	c.mu.Lock()
+	c.sent++
+	c.totalWait += d
+	c.mu.Unlock()
+}
+
+func (c *counters) finished(latency time.Duration, err error) {
+	c.mu.Lock()
+	if err != nil {
+		c.failed++
+	} else {
+		c.completed++
+	}
+	c.total += latency
+	c.maxLatency = max(c.maxLatency, latency)
+	c.mu.Unlock()
+}
+
+func (c *counters) snapshot(interactive, background int) Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := Stats{
+		Interactive:  interactive,
+		Background:   background,
+		InFlight:     int(c.inFlight.Load()),
+		Completed:    c.completed,
+		Failed:       c.failed,
+		Rejected:     c.rejected.Load(),
+		Canceled:     c.canceled.Load(),
+		Retried:      c.retried.Load(),
+		MaxLatencyMs: ms(c.maxLatency),
+	}
+	if c.sent > 0 {
+		s.AvgWaitMs = ms(c.totalWait) / float64(c.sent)
+	}
+	if done := c.completed + c.failed; done > 0 {
+		s.AvgLatencyMs = ms(c.total) / float64(done)
+	}
+	return s
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}